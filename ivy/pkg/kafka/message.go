@@ -220,7 +220,7 @@ type ExecutionCompletedMessage struct {
 	TenantID    string         `json:"tenant_id"`
 	SourceKey   string         `json:"source_key"`
 	ExecutionID string         `json:"execution_id"`
-	Status      string         `json:"status"` // "success", "partial", "failed"
+	Status      string         `json:"status"` // "success", "partial", "failed", "cancelled"
 	Timestamp   time.Time      `json:"timestamp"`
 	Stats       ExecutionStats `json:"stats,omitempty"`
 }
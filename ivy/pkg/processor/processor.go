@@ -730,9 +730,10 @@ func (p *Processor) handleExecutionCompleted(ctx context.Context, msg *kafka.Inc
 
 	log.Info("Received execution.completed event")
 
-	// Only process successful or partial executions for deletion
-	if evt.Status == "failed" {
-		log.Debug("Skipping deletion for failed execution")
+	// Only process successful or partial executions for deletion; a failed or cancelled
+	// execution did not see every entity
+	if evt.Status == "failed" || evt.Status == "cancelled" {
+		log.Debug("Skipping deletion for unsuccessful execution")
 		return nil
 	}
 
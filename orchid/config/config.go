@@ -70,6 +70,26 @@ type Config struct {
 	// Kafka topic for API errors (responses that are not accepted per step policy)
 	KafkaErrorTopic string `env:"KAFKA_ERROR_TOPIC" env-default:"api-errors"`
 
+	// Response archive settings
+	// Where published API responses are archived for replay: filesystem or s3 (empty disables the archive)
+	ArchiveBackend string `env:"ARCHIVE_BACKEND" env-default:""`
+	// Root directory of the filesystem archive
+	ArchivePath string `env:"ARCHIVE_PATH" env-default:"/var/lib/orchid/archive"`
+	// Responses written to one compressed archive object
+	ArchiveSegmentSize int `env:"ARCHIVE_SEGMENT_SIZE" env-default:"500"`
+	// S3-compatible endpoint of the s3 archive (e.g. https://s3.us-east-1.amazonaws.com, http://minio:9000)
+	ArchiveS3Endpoint string `env:"ARCHIVE_S3_ENDPOINT" env-default:""`
+	// Bucket of the s3 archive
+	ArchiveS3Bucket string `env:"ARCHIVE_S3_BUCKET" env-default:""`
+	// Signing region of the s3 archive
+	ArchiveS3Region string `env:"ARCHIVE_S3_REGION" env-default:"us-east-1"`
+	// Access key of the s3 archive
+	ArchiveS3AccessKey string `env:"ARCHIVE_S3_ACCESS_KEY" env-default:""`
+	// Secret key of the s3 archive
+	ArchiveS3SecretKey string `env:"ARCHIVE_S3_SECRET_KEY" env-default:""`
+	// Address the bucket in the path instead of the host name (MinIO and most self-hosted stores)
+	ArchiveS3PathStyle bool `env:"ARCHIVE_S3_PATH_STYLE" env-default:"true"`
+
 	// Execution settings
 	// Maximum execution time for a plan
 	MaxExecutionTime time.Duration `env:"MAX_EXECUTION_TIME" env-default:"5m"`
@@ -85,13 +105,55 @@ type Config struct {
 	SchedulerEnabled bool `env:"SCHEDULER_ENABLED" env-default:"true"`
 
 	// Redis Streams settings
-	// Job queue stream name
+	// Job queue key prefix (one stream per lane and tenant)
 	RedisStreamsJobQueue string `env:"REDIS_STREAMS_JOB_QUEUE" env-default:"orchid:jobs"`
 	// Consumer group name
 	RedisStreamsConsumerGroup string `env:"REDIS_STREAMS_CONSUMER_GROUP" env-default:"orchid-workers"`
 	// Consumer name (defaults to hostname if empty)
 	RedisStreamsConsumerName string `env:"REDIS_STREAMS_CONSUMER_NAME" env-default:""`
 
+	// Job queue fairness settings
+	// Jobs a tenant may have queued or running at once (0 = unlimited)
+	QueueMaxInFlightPerTenant int `env:"QUEUE_MAX_IN_FLIGHT_PER_TENANT" env-default:"100"`
+	// Per-tenant overrides of the in-flight cap (tenant_id:limit,...)
+	QueueTenantMaxInFlight map[string]int `env:"QUEUE_TENANT_MAX_IN_FLIGHT" env-default:""`
+	// Scheduling weight of tenants without an override
+	QueueDefaultTenantWeight int `env:"QUEUE_DEFAULT_TENANT_WEIGHT" env-default:"1"`
+	// Per-tenant scheduling weights (tenant_id:weight,...)
+	QueueTenantWeights map[string]int `env:"QUEUE_TENANT_WEIGHTS" env-default:""`
+	// How long a job that never completes counts against its tenant's cap
+	QueueInFlightTTL time.Duration `env:"QUEUE_IN_FLIGHT_TTL" env-default:"1h"`
+
+	// Overlap guard settings
+	// TTL of the plan/config lease held while an execution runs (renewed at a third of it)
+	QueueLeaseTTL time.Duration `env:"QUEUE_LEASE_TTL" env-default:"30s"`
+	// How long a job waits for the running execution of its plan/config before it is requeued
+	QueueOverlapWait time.Duration `env:"QUEUE_OVERLAP_WAIT" env-default:"5s"`
+
+	// DLQ automatic retry settings
+	// Reasons whose DLQ entries are re-enqueued automatically (empty disables automatic retries)
+	DLQAutoRetryReasons []string `env:"DLQ_AUTO_RETRY_REASONS" env-default:"timeout,auth_error"`
+	// Time an entry spends in the DLQ before its first automatic retry (doubles with every retry of the same job)
+	DLQAutoRetryCooldown time.Duration `env:"DLQ_AUTO_RETRY_COOLDOWN" env-default:"5m"`
+	// Automatic retries per job before its entry stays in the DLQ
+	DLQAutoRetryMax int `env:"DLQ_AUTO_RETRY_MAX" env-default:"3"`
+	// How often DLQ entries are checked for automatic retries
+	DLQAutoRetryInterval time.Duration `env:"DLQ_AUTO_RETRY_INTERVAL" env-default:"1m"`
+
+	// Notification webhook settings
+	// How often queued webhook deliveries are checked for due attempts
+	NotificationDispatchInterval time.Duration `env:"NOTIFICATION_DISPATCH_INTERVAL" env-default:"10s"`
+	// Webhook deliveries sent at once
+	NotificationConcurrency int `env:"NOTIFICATION_CONCURRENCY" env-default:"10"`
+	// Timeout of a webhook request
+	NotificationTimeout time.Duration `env:"NOTIFICATION_TIMEOUT" env-default:"10s"`
+	// Attempts before a webhook delivery fails
+	NotificationMaxAttempts int `env:"NOTIFICATION_MAX_ATTEMPTS" env-default:"8"`
+	// Wait before the second attempt of a delivery (doubles with every further attempt)
+	NotificationRetryBackoff time.Duration `env:"NOTIFICATION_RETRY_BACKOFF" env-default:"30s"`
+	// Longest wait between attempts of a delivery
+	NotificationMaxBackoff time.Duration `env:"NOTIFICATION_MAX_BACKOFF" env-default:"1h"`
+
 	// Tracing settings
 	// Enable OTLP tracing export (set to true to send traces to collector)
 	OTLPEnabled bool `env:"OTLP_ENABLED" env-default:"false"`
@@ -102,6 +164,12 @@ type Config struct {
 	// Disable TLS for OTLP (for local development)
 	OTLPInsecure bool `env:"OTLP_INSECURE" env-default:"true"`
 
+	// Secrets settings
+	// Key provider used to envelope-encrypt secret config values (local)
+	SecretsKeyProvider string `env:"SECRETS_KEY_PROVIDER" env-default:"local"`
+	// Key files for the local key provider (comma-separated; the first wraps new secrets, all can decrypt)
+	SecretsKeyFiles []string `env:"SECRETS_KEY_FILES" env-default:""`
+
 	// Auth Enabled - when false, allows X-Tenant-ID and X-User-ID headers for testing
 	AuthEnabled bool `env:"AUTH_ENABLED" env-default:"false"`
 }
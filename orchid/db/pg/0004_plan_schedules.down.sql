@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_plan_statistics_next_run_at;
+
+ALTER TABLE plan_statistics DROP COLUMN IF EXISTS next_run_at;
+
+ALTER TABLE plans DROP COLUMN IF EXISTS blackout_windows;
+ALTER TABLE plans DROP COLUMN IF EXISTS schedule_timezone;
+ALTER TABLE plans DROP COLUMN IF EXISTS schedule_cron;
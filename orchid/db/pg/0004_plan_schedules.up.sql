@@ -0,0 +1,11 @@
+-- Cron-based scheduling for plans
+-- schedule_cron takes precedence over wait_seconds when set
+ALTER TABLE plans ADD COLUMN IF NOT EXISTS schedule_cron TEXT;
+ALTER TABLE plans ADD COLUMN IF NOT EXISTS schedule_timezone TEXT;
+ALTER TABLE plans ADD COLUMN IF NOT EXISTS blackout_windows JSONB NOT NULL DEFAULT '[]';
+
+-- next_run_at is the next cron fire time for a plan/config pair, advanced by the scheduler
+-- independently of when executions finish so slow runs do not shift the schedule
+ALTER TABLE plan_statistics ADD COLUMN IF NOT EXISTS next_run_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_plan_statistics_next_run_at ON plan_statistics(next_run_at);
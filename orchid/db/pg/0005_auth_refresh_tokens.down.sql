@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS auth_refresh_tokens;
+
+ALTER TABLE auth_flows DROP COLUMN IF EXISTS refresh_definition;
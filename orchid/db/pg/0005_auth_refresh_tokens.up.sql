@@ -0,0 +1,24 @@
+-- Refresh-token grant step for auth flows (a models.Step encoded as JSON)
+-- The stored refresh token is available to the step as auth.refresh_token
+ALTER TABLE auth_flows ADD COLUMN IF NOT EXISTS refresh_definition JSONB NOT NULL DEFAULT 'null';
+
+-- Auth refresh tokens table
+-- Stores the latest refresh token per auth flow/config so it outlives the cached access token
+-- and survives provider-side rotation
+CREATE TABLE IF NOT EXISTS auth_refresh_tokens (
+    tenant_id UUID NOT NULL,
+    auth_flow_id UUID NOT NULL,
+    config_id UUID NOT NULL,
+    refresh_token TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, auth_flow_id, config_id)
+);
+
+SELECT create_distributed_table('auth_refresh_tokens', 'tenant_id', colocate_with => 'integrations');
+
+DO $$
+BEGIN
+    EXECUTE 'ALTER TABLE auth_refresh_tokens ADD CONSTRAINT auth_refresh_tokens_auth_flow_id_fkey FOREIGN KEY (tenant_id, auth_flow_id) REFERENCES auth_flows(tenant_id, id) ON DELETE CASCADE';
+    EXECUTE 'ALTER TABLE auth_refresh_tokens ADD CONSTRAINT auth_refresh_tokens_config_id_fkey FOREIGN KEY (tenant_id, config_id) REFERENCES configs(tenant_id, id) ON DELETE CASCADE';
+END $$;
@@ -0,0 +1 @@
+ALTER TABLE auth_flows DROP COLUMN IF EXISTS reauth_on;
@@ -0,0 +1,2 @@
+-- Status codes (e.g. [401, 403]) that invalidate the cached token, re-run the auth flow once and retry the request
+ALTER TABLE auth_flows ADD COLUMN IF NOT EXISTS reauth_on JSONB NOT NULL DEFAULT '[]';
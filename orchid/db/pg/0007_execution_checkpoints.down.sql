@@ -0,0 +1 @@
+ALTER TABLE plan_executions DROP COLUMN IF EXISTS checkpoint;
@@ -0,0 +1,3 @@
+-- Loop state (iteration count, prev response, plan context, fanout progress) saved after every page,
+-- so a failed or aborted execution can be resumed by a new execution linked via parent_execution_id
+ALTER TABLE plan_executions ADD COLUMN IF NOT EXISTS checkpoint JSONB NOT NULL DEFAULT '{}';
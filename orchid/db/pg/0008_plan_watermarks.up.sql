@@ -0,0 +1,22 @@
+-- Plan watermarks table
+-- Stores the committed high-water mark of incremental (delta) syncs per plan/config.
+-- A watermark only advances when an execution completes successfully.
+CREATE TABLE IF NOT EXISTS plan_watermarks (
+    tenant_id UUID NOT NULL,
+    plan_key TEXT NOT NULL,
+    config_id UUID NOT NULL,
+    value TEXT NOT NULL,
+    previous_value TEXT, -- Value before the last change, kept for rewinds
+    execution_id UUID, -- Execution that committed the value (NULL when set through the API)
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, plan_key, config_id)
+);
+
+SELECT create_distributed_table('plan_watermarks', 'tenant_id', colocate_with => 'integrations');
+
+DO $$
+BEGIN
+    EXECUTE 'ALTER TABLE plan_watermarks ADD CONSTRAINT plan_watermarks_plan_key_fkey FOREIGN KEY (tenant_id, plan_key) REFERENCES plans(tenant_id, key) ON DELETE CASCADE';
+    EXECUTE 'ALTER TABLE plan_watermarks ADD CONSTRAINT plan_watermarks_config_id_fkey FOREIGN KEY (tenant_id, config_id) REFERENCES configs(tenant_id, id) ON DELETE CASCADE';
+END $$;
@@ -0,0 +1,2 @@
+-- What happens to a job of a plan/config that already has an execution running: skip, queue or cancel_previous
+ALTER TABLE plans ADD COLUMN IF NOT EXISTS overlap_policy TEXT NOT NULL DEFAULT 'queue';
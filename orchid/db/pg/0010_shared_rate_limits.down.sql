@@ -0,0 +1,2 @@
+ALTER TABLE configs DROP COLUMN IF EXISTS rate_limits;
+ALTER TABLE integrations DROP COLUMN IF EXISTS rate_limits;
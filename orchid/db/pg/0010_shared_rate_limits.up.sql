@@ -0,0 +1,3 @@
+-- Rate limits shared by every plan and auth flow of an integration, or of a config (its credentials)
+ALTER TABLE integrations ADD COLUMN IF NOT EXISTS rate_limits JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE configs ADD COLUMN IF NOT EXISTS rate_limits JSONB NOT NULL DEFAULT '[]';
@@ -0,0 +1,2 @@
+-- Optional request signer (AWS SigV4, HMAC or JWT) of an auth flow
+ALTER TABLE auth_flows ADD COLUMN IF NOT EXISTS signer JSONB NOT NULL DEFAULT 'null';
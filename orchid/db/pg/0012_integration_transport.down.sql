@@ -0,0 +1 @@
+ALTER TABLE integrations DROP COLUMN IF EXISTS transport;
@@ -0,0 +1,2 @@
+-- TLS and proxy settings of the dedicated transport every config of an integration gets (templates over config values)
+ALTER TABLE integrations ADD COLUMN IF NOT EXISTS transport JSONB NOT NULL DEFAULT 'null';
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS notification_deliveries;
+DROP TABLE IF EXISTS notification_subscriptions;
+
+ALTER TABLE plan_statistics DROP COLUMN IF EXISTS consecutive_failures;
@@ -0,0 +1,53 @@
+-- Failed executions in a row of a plan/config, reset by a successful execution
+-- Drives the thresholds of notification subscriptions
+ALTER TABLE plan_statistics ADD COLUMN IF NOT EXISTS consecutive_failures INTEGER NOT NULL DEFAULT 0;
+
+-- Notification subscriptions table
+-- Webhooks notified of execution outcomes, optionally scoped to a plan and/or config
+CREATE TABLE IF NOT EXISTS notification_subscriptions (
+    id UUID DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    event VARCHAR(100) NOT NULL, -- execution.failed, execution.auth_error, execution.recovered
+    plan_key TEXT,
+    config_id UUID,
+    threshold INTEGER NOT NULL DEFAULT 1,
+    url TEXT NOT NULL,
+    secret JSONB NOT NULL, -- Envelope-encrypted signing secret
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, id)
+);
+
+-- Notification deliveries table
+-- Delivery log of the events sent to subscriptions, and the queue of their retries
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    id UUID DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL,
+    subscription_id UUID NOT NULL,
+    event VARCHAR(100) NOT NULL,
+    execution_id UUID,
+    payload JSONB NOT NULL,
+    status VARCHAR(50) NOT NULL DEFAULT 'pending', -- pending, delivered, failed
+    attempts INTEGER NOT NULL DEFAULT 0,
+    response_status INTEGER,
+    error_message TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, id)
+);
+
+SELECT create_distributed_table('notification_subscriptions', 'tenant_id', colocate_with => 'integrations');
+SELECT create_distributed_table('notification_deliveries', 'tenant_id', colocate_with => 'integrations');
+
+DO $$
+BEGIN
+    EXECUTE 'ALTER TABLE notification_deliveries ADD CONSTRAINT notification_deliveries_subscription_id_fkey FOREIGN KEY (tenant_id, subscription_id) REFERENCES notification_subscriptions(tenant_id, id) ON DELETE CASCADE';
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_notification_subscriptions_tenant_event ON notification_subscriptions(tenant_id, event);
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_tenant_subscription ON notification_deliveries(tenant_id, subscription_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_due ON notification_deliveries(status, next_attempt_at);
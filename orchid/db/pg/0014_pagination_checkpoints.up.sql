@@ -0,0 +1,26 @@
+-- Pagination checkpoints table
+-- Stores the position of a paginated step while pages remain, so an interrupted execution,
+-- or one stopped by max_pages, resumes from the next page. Kept apart from plan_contexts,
+-- which only receives the set_context values of successful executions.
+CREATE TABLE IF NOT EXISTS plan_pagination_checkpoints (
+    tenant_id UUID NOT NULL,
+    plan_key TEXT NOT NULL,
+    config_id UUID NOT NULL,
+    step_key TEXT NOT NULL, -- Step ID (or step path) within the plan
+    state JSONB NOT NULL, -- Cursor, offset, page or sanitized next URL
+    execution_id UUID, -- Execution that stored the position
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, plan_key, config_id, step_key)
+);
+
+SELECT create_distributed_table('plan_pagination_checkpoints', 'tenant_id', colocate_with => 'integrations');
+
+DO $$
+BEGIN
+    EXECUTE 'ALTER TABLE plan_pagination_checkpoints ADD CONSTRAINT plan_pagination_checkpoints_plan_key_fkey FOREIGN KEY (tenant_id, plan_key) REFERENCES plans(tenant_id, key) ON DELETE CASCADE';
+    EXECUTE 'ALTER TABLE plan_pagination_checkpoints ADD CONSTRAINT plan_pagination_checkpoints_config_id_fkey FOREIGN KEY (tenant_id, config_id) REFERENCES configs(tenant_id, id) ON DELETE CASCADE';
+END $$;
+
+-- Pagination positions used to be kept in the plan context, including raw next-page URLs
+UPDATE plan_contexts SET context_data = context_data - '_pagination' WHERE context_data ? '_pagination';
@@ -0,0 +1,4 @@
+-- Encrypted tokens cannot be decrypted in SQL, so they are dropped and their flows authenticate again
+DELETE FROM auth_refresh_tokens WHERE jsonb_typeof(refresh_token) <> 'string';
+
+ALTER TABLE auth_refresh_tokens ALTER COLUMN refresh_token TYPE TEXT USING refresh_token #>> '{}';
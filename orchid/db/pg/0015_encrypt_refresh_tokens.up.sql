@@ -0,0 +1,3 @@
+-- Refresh tokens are stored as encrypted envelopes (JSON objects).
+-- Existing plaintext tokens become JSON strings and are encrypted the next time they are used.
+ALTER TABLE auth_refresh_tokens ALTER COLUMN refresh_token TYPE JSONB USING to_jsonb(refresh_token);
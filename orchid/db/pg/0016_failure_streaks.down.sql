@@ -0,0 +1,2 @@
+ALTER TABLE plan_statistics DROP COLUMN IF EXISTS consecutive_auth_failures;
+ALTER TABLE plan_statistics DROP COLUMN IF EXISTS last_streak;
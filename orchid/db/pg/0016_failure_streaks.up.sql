@@ -0,0 +1,7 @@
+-- Failure streak before the last recorded execution, written by the same locked upsert that
+-- updates consecutive_failures so concurrent executions each read the streak they changed
+ALTER TABLE plan_statistics ADD COLUMN IF NOT EXISTS last_streak INTEGER NOT NULL DEFAULT 0;
+
+-- Auth flow failures in a row, reset by a success or any other failure
+-- Drives the thresholds of execution.auth_error subscriptions
+ALTER TABLE plan_statistics ADD COLUMN IF NOT EXISTS consecutive_auth_failures INTEGER NOT NULL DEFAULT 0;
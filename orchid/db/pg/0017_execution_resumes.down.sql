@@ -0,0 +1,2 @@
+ALTER TABLE plan_executions DROP COLUMN IF EXISTS resumed_at;
+ALTER TABLE plan_executions DROP COLUMN IF EXISTS resume;
@@ -0,0 +1,5 @@
+-- Set on executions that resume their parent_execution_id, so they are not listed as sub-executions
+ALTER TABLE plan_executions ADD COLUMN IF NOT EXISTS resume BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Set by a conditional update when a resume of the execution is queued, so it is resumed at most once
+ALTER TABLE plan_executions ADD COLUMN IF NOT EXISTS resumed_at TIMESTAMPTZ;
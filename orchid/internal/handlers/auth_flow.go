@@ -6,6 +6,7 @@ import (
 
 	"github.com/Ramsey-B/orchid/pkg/models"
 	"github.com/Ramsey-B/orchid/pkg/repositories"
+	"github.com/Ramsey-B/orchid/pkg/signing"
 	"github.com/Ramsey-B/stem/pkg/database"
 )
 
@@ -23,29 +24,63 @@ func NewAuthFlowHandler(repo repositories.AuthFlowRepo) *AuthFlowHandler {
 type CreateAuthFlowRequest struct {
 	IntegrationID string         `json:"integration_id" validate:"required"`
 	Name          string         `json:"name" validate:"required"`
-	PlanDefinition map[string]any `json:"plan_definition" validate:"required"` // This is a models.Step encoded as JSON
+	PlanDefinition map[string]any `json:"plan_definition"` // This is a models.Step encoded as JSON (optional for flows that only sign requests)
+	// RefreshDefinition is an optional models.Step used to exchange auth.refresh_token for a new token
+	RefreshDefinition map[string]any `json:"refresh_definition,omitempty"`
 
-	TokenPath     string  `json:"token_path" validate:"required"`
-	HeaderName    string  `json:"header_name" validate:"required"`
+	TokenPath     string  `json:"token_path"`
+	HeaderName    string  `json:"header_name"`
 	HeaderFormat  *string `json:"header_format,omitempty"`
 	RefreshPath   *string `json:"refresh_path,omitempty"`
 	ExpiresInPath *string `json:"expires_in_path,omitempty"`
 	TTLSeconds    *int    `json:"ttl_seconds,omitempty"`
 	SkewSeconds   *int    `json:"skew_seconds,omitempty"`
+	ReauthOn      []int   `json:"reauth_on,omitempty"` // Statuses that invalidate the token and re-run the flow
+
+	Signer *models.SignerConfig `json:"signer,omitempty"` // Optional per-request signature (AWS SigV4, HMAC or JWT)
 }
 
 // UpdateAuthFlowRequest represents the update auth flow request body
 type UpdateAuthFlowRequest struct {
 	Name          string         `json:"name" validate:"required"`
-	PlanDefinition map[string]any `json:"plan_definition" validate:"required"` // This is a models.Step encoded as JSON
+	PlanDefinition map[string]any `json:"plan_definition"` // This is a models.Step encoded as JSON (optional for flows that only sign requests)
+	// RefreshDefinition is an optional models.Step used to exchange auth.refresh_token for a new token
+	RefreshDefinition map[string]any `json:"refresh_definition,omitempty"`
 
-	TokenPath     string  `json:"token_path" validate:"required"`
-	HeaderName    string  `json:"header_name" validate:"required"`
+	TokenPath     string  `json:"token_path"`
+	HeaderName    string  `json:"header_name"`
 	HeaderFormat  *string `json:"header_format,omitempty"`
 	RefreshPath   *string `json:"refresh_path,omitempty"`
 	ExpiresInPath *string `json:"expires_in_path,omitempty"`
 	TTLSeconds    *int    `json:"ttl_seconds,omitempty"`
 	SkewSeconds   *int    `json:"skew_seconds,omitempty"`
+	ReauthOn      []int   `json:"reauth_on,omitempty"` // Statuses that invalidate the token and re-run the flow
+
+	Signer *models.SignerConfig `json:"signer,omitempty"` // Optional per-request signature (AWS SigV4, HMAC or JWT)
+}
+
+// validateAuthFlowRequest checks the token step and signer of an auth flow.
+// Flows whose signer signs the plan's requests may have no token step at all.
+func validateAuthFlowRequest(planDefinition map[string]any, tokenPath, headerName string, signer *models.SignerConfig) error {
+	if err := signing.Validate(signer); err != nil {
+		return BadRequest(err.Error())
+	}
+	if planDefinition == nil {
+		if signer.AppliesTo(models.SignerApplyToRequests) {
+			return nil
+		}
+		if signer != nil {
+			return BadRequest("plan_definition is required for a token_request signer")
+		}
+		return BadRequest("plan_definition is required")
+	}
+	if tokenPath == "" {
+		return BadRequest("token_path is required")
+	}
+	if headerName == "" {
+		return BadRequest("header_name is required")
+	}
+	return nil
 }
 
 // RegisterRoutes registers auth flow routes
@@ -80,14 +115,8 @@ func (h *AuthFlowHandler) Create(c echo.Context) error {
 	if req.Name == "" {
 		return BadRequest("name is required")
 	}
-	if req.PlanDefinition == nil {
-		return BadRequest("plan_definition is required")
-	}
-	if req.TokenPath == "" {
-		return BadRequest("token_path is required")
-	}
-	if req.HeaderName == "" {
-		return BadRequest("header_name is required")
+	if err := validateAuthFlowRequest(req.PlanDefinition, req.TokenPath, req.HeaderName, req.Signer); err != nil {
+		return err
 	}
 
 	authFlow := &models.AuthFlow{
@@ -96,6 +125,7 @@ func (h *AuthFlowHandler) Create(c echo.Context) error {
 		IntegrationID: integrationID,
 		Name:          req.Name,
 		PlanDefinition: database.JSONB[map[string]any]{Data: req.PlanDefinition},
+		RefreshDefinition: database.JSONB[map[string]any]{Data: req.RefreshDefinition},
 		TokenPath:     req.TokenPath,
 		HeaderName:    req.HeaderName,
 		HeaderFormat:  req.HeaderFormat,
@@ -103,6 +133,8 @@ func (h *AuthFlowHandler) Create(c echo.Context) error {
 		ExpiresInPath: req.ExpiresInPath,
 		TTLSeconds:    req.TTLSeconds,
 		SkewSeconds:   req.SkewSeconds,
+		ReauthOn:      database.JSONB[[]int]{Data: req.ReauthOn},
+		Signer:        database.JSONB[*models.SignerConfig]{Data: req.Signer},
 	}
 
 	if err := h.repo.Create(ctx, authFlow); err != nil {
@@ -173,18 +205,13 @@ func (h *AuthFlowHandler) Update(c echo.Context) error {
 	if req.Name == "" {
 		return BadRequest("name is required")
 	}
-	if req.PlanDefinition == nil {
-		return BadRequest("plan_definition is required")
-	}
-	if req.TokenPath == "" {
-		return BadRequest("token_path is required")
-	}
-	if req.HeaderName == "" {
-		return BadRequest("header_name is required")
+	if err := validateAuthFlowRequest(req.PlanDefinition, req.TokenPath, req.HeaderName, req.Signer); err != nil {
+		return err
 	}
 
 	existing.Name = req.Name
 	existing.PlanDefinition = database.JSONB[map[string]any]{Data: req.PlanDefinition}
+	existing.RefreshDefinition = database.JSONB[map[string]any]{Data: req.RefreshDefinition}
 	existing.TokenPath = req.TokenPath
 	existing.HeaderName = req.HeaderName
 	existing.HeaderFormat = req.HeaderFormat
@@ -192,6 +219,8 @@ func (h *AuthFlowHandler) Update(c echo.Context) error {
 	existing.ExpiresInPath = req.ExpiresInPath
 	existing.TTLSeconds = req.TTLSeconds
 	existing.SkewSeconds = req.SkewSeconds
+	existing.ReauthOn = database.JSONB[[]int]{Data: req.ReauthOn}
+	existing.Signer = database.JSONB[*models.SignerConfig]{Data: req.Signer}
 
 	if err := h.repo.Update(ctx, existing); err != nil {
 		return err
@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/Gobusters/ectoerror/httperror"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 
+	"github.com/Ramsey-B/orchid/pkg/redis"
 	appctx "github.com/Ramsey-B/stem/pkg/context"
 )
 
@@ -65,3 +67,11 @@ func BadRequest(message string) error {
 func Unauthorized(message string) error {
 	return httperror.NewHTTPError(http.StatusUnauthorized, message)
 }
+
+// QueueError converts an error publishing a job: a tenant at its cap gets 429 Too Many Requests
+func QueueError(err error) error {
+	if errors.Is(err, redis.ErrTenantAtCapacity) {
+		return httperror.NewHTTPError(http.StatusTooManyRequests, err.Error())
+	}
+	return httperror.WrapError(500, err)
+}
@@ -1,37 +1,53 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/Gobusters/ectoerror/httperror"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 
 	"github.com/Ramsey-B/stem/pkg/database"
+	"github.com/Ramsey-B/orchid/pkg/jsonschema"
 	"github.com/Ramsey-B/orchid/pkg/models"
 	"github.com/Ramsey-B/orchid/pkg/repositories"
+	"github.com/Ramsey-B/orchid/pkg/secrets"
 )
 
 // ConfigHandler handles config-related API requests
 type ConfigHandler struct {
-	repo repositories.ConfigRepo
+	repo            repositories.ConfigRepo
+	integrationRepo repositories.IntegrationRepo
+	cipher          *secrets.Cipher
 }
 
-// NewConfigHandler creates a new config handler
-func NewConfigHandler(repo repositories.ConfigRepo) *ConfigHandler {
-	return &ConfigHandler{repo: repo}
+// NewConfigHandler creates a new config handler.
+// Fields marked "secret" in the integration config schema are encrypted with cipher before they are stored.
+func NewConfigHandler(repo repositories.ConfigRepo, integrationRepo repositories.IntegrationRepo, cipher *secrets.Cipher) *ConfigHandler {
+	return &ConfigHandler{
+		repo:            repo,
+		integrationRepo: integrationRepo,
+		cipher:          cipher,
+	}
 }
 
 // CreateConfigRequest is the request body for creating a config
 type CreateConfigRequest struct {
-	IntegrationID  uuid.UUID      `json:"integration_id" validate:"required"`
-	Name           string         `json:"name" validate:"required"`
-	Values         map[string]any `json:"values" validate:"required"`
-	Enabled        bool           `json:"enabled"`
+	IntegrationID  uuid.UUID                `json:"integration_id" validate:"required"`
+	Name           string                   `json:"name" validate:"required"`
+	Values         map[string]any           `json:"values" validate:"required"`
+	Enabled        bool                     `json:"enabled"`
+	RateLimits     []models.RateLimitConfig `json:"rate_limits,omitempty"` // Shared by every plan and auth flow using the config
 }
 
 // UpdateConfigRequest is the request body for updating a config
 type UpdateConfigRequest struct {
-	Name    string         `json:"name"`
-	Values  map[string]any `json:"values"`
-	Enabled *bool          `json:"enabled"`
+	Name       string                    `json:"name"`
+	Values     map[string]any            `json:"values"`
+	Enabled    *bool                     `json:"enabled"`
+	RateLimits *[]models.RateLimitConfig `json:"rate_limits,omitempty"` // Replaces the shared rate limits; [] removes them
 }
 
 // RegisterRoutes registers the config routes
@@ -69,21 +85,44 @@ func (h *ConfigHandler) Create(c echo.Context) error {
 	if req.Values == nil {
 		return BadRequest("values is required")
 	}
+	if secrets.ContainsEncrypted(req.Values) {
+		return BadRequest("values must not contain " + secrets.EnvelopeKey + " objects")
+	}
+	if err := validateRateLimits(req.RateLimits); err != nil {
+		return err
+	}
+
+	configSchema, err := h.configSchema(ctx, req.IntegrationID)
+	if err != nil {
+		return err
+	}
+
+	values, err := validateConfigValues(configSchema, req.Values)
+	if err != nil {
+		return err
+	}
+
+	secretPaths := secrets.SecretPaths(configSchema)
+	values, err = encryptConfigValues(ctx, h.cipher, values, secretPaths)
+	if err != nil {
+		return err
+	}
 
 	config := &models.Config{
 		ID:             uuid.New(),
 		TenantID:       tenantID,
 		IntegrationID:  req.IntegrationID,
 		Name:           req.Name,
-		Values:         database.JSONB[map[string]any]{Data: req.Values},
+		Values:         database.JSONB[map[string]any]{Data: values},
 		Enabled:        req.Enabled,
+		RateLimits:     rateLimitsJSONB(req.RateLimits),
 	}
 
 	if err := h.repo.Create(ctx, config); err != nil {
 		return err
 	}
 
-	return CreatedResponse(c, config)
+	return CreatedResponse(c, redactConfig(config, secretPaths))
 }
 
 // List handles GET /configs
@@ -106,7 +145,18 @@ func (h *ConfigHandler) List(c echo.Context) error {
 		return err
 	}
 
-	return SuccessResponse(c, configs)
+	configSchema, err := h.configSchema(ctx, integrationID)
+	if err != nil {
+		return err
+	}
+
+	secretPaths := secrets.SecretPaths(configSchema)
+	redacted := make([]*models.Config, 0, len(configs))
+	for i := range configs {
+		redacted = append(redacted, redactConfig(&configs[i], secretPaths))
+	}
+
+	return SuccessResponse(c, redacted)
 }
 
 // Get handles GET /configs/:id
@@ -123,7 +173,12 @@ func (h *ConfigHandler) Get(c echo.Context) error {
 		return err
 	}
 
-	return SuccessResponse(c, config)
+	configSchema, err := h.configSchema(ctx, config.IntegrationID)
+	if err != nil {
+		return err
+	}
+
+	return SuccessResponse(c, redactConfig(config, secrets.SecretPaths(configSchema)))
 }
 
 // Update handles PUT /configs/:id
@@ -145,21 +200,46 @@ func (h *ConfigHandler) Update(c echo.Context) error {
 		return BadRequest("invalid request body")
 	}
 
+	configSchema, err := h.configSchema(ctx, existing.IntegrationID)
+	if err != nil {
+		return err
+	}
+	secretPaths := secrets.SecretPaths(configSchema)
+
 	if req.Name != "" {
 		existing.Name = req.Name
 	}
+	if secrets.ContainsEncrypted(req.Values) {
+		return BadRequest("values must not contain " + secrets.EnvelopeKey + " objects")
+	}
 	if req.Values != nil {
-		existing.Values = database.JSONB[map[string]any]{Data: req.Values}
+		// Secret fields sent back redacted keep their stored value
+		values := secrets.RestoreRedacted(req.Values, existing.Values.Data, secretPaths)
+		values, err = validateConfigValues(configSchema, values)
+		if err != nil {
+			return err
+		}
+		values, err = encryptConfigValues(ctx, h.cipher, values, secretPaths)
+		if err != nil {
+			return err
+		}
+		existing.Values = database.JSONB[map[string]any]{Data: values}
 	}
 	if req.Enabled != nil {
 		existing.Enabled = *req.Enabled
 	}
+	if req.RateLimits != nil {
+		if err := validateRateLimits(*req.RateLimits); err != nil {
+			return err
+		}
+		existing.RateLimits = rateLimitsJSONB(*req.RateLimits)
+	}
 
 	if err := h.repo.Update(ctx, existing); err != nil {
 		return err
 	}
 
-	return SuccessResponse(c, existing)
+	return SuccessResponse(c, redactConfig(existing, secretPaths))
 }
 
 // Delete handles DELETE /configs/:id
@@ -209,3 +289,75 @@ func (h *ConfigHandler) Disable(c echo.Context) error {
 
 	return SuccessResponse(c, map[string]bool{"enabled": false})
 }
+
+// configSchema returns the integration's config schema ({"name": ..., "schema": {...}}), or nil when it has none
+func (h *ConfigHandler) configSchema(ctx context.Context, integrationID uuid.UUID) (map[string]any, error) {
+	integration, err := h.integrationRepo.GetByID(ctx, integrationID)
+	if err != nil {
+		return nil, err
+	}
+	return integration.ConfigSchema.Data, nil
+}
+
+// compileConfigSchema compiles the JSON schema held by an integration config schema.
+// It returns nil when the integration has no schema.
+func compileConfigSchema(configSchema map[string]any) (*jsonschema.Schema, error) {
+	schema, ok := configSchema["schema"].(map[string]any)
+	if !ok || len(schema) == 0 {
+		return nil, nil
+	}
+	return jsonschema.Compile(schema)
+}
+
+// applyConfigSchema applies schema defaults to config values and validates them against the integration's config schema.
+// Invalid values are reported as a *jsonschema.ValidationError. Encrypted secrets are accepted as-is;
+// they were validated before they were encrypted.
+func applyConfigSchema(configSchema map[string]any, values map[string]any) (map[string]any, error) {
+	schema, err := compileConfigSchema(configSchema)
+	if err != nil {
+		return nil, BadRequest("integration config_schema is invalid: " + err.Error())
+	}
+	if schema == nil {
+		return values, nil
+	}
+
+	values = schema.ApplyDefaults(values)
+	if err := schema.ValidateWithOptions(values, jsonschema.Options{Opaque: secrets.IsEncrypted}); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// validateConfigValues is applyConfigSchema with validation failures returned as a 400 listing the failing fields
+func validateConfigValues(configSchema map[string]any, values map[string]any) (map[string]any, error) {
+	values, err := applyConfigSchema(configSchema, values)
+	var validationErr *jsonschema.ValidationError
+	if errors.As(err, &validationErr) {
+		return nil, httperror.NewHTTPError(http.StatusBadRequest, "values do not match the integration config schema").
+			AddMetaValue("errors", validationErr.Errors)
+	}
+	return values, err
+}
+
+// encryptConfigValues envelope-encrypts the secret fields of config values before they are stored
+func encryptConfigValues(ctx context.Context, cipher *secrets.Cipher, values map[string]any, secretPaths []secrets.Path) (map[string]any, error) {
+	if len(secretPaths) == 0 {
+		return values, nil
+	}
+	if cipher == nil {
+		return nil, httperror.NewHTTPError(http.StatusInternalServerError, "secret storage is not configured")
+	}
+
+	encrypted, err := cipher.EncryptFields(ctx, values, secretPaths)
+	if err != nil {
+		return nil, httperror.NewHTTPErrorf(http.StatusInternalServerError, "failed to encrypt config values: %v", err)
+	}
+	return encrypted, nil
+}
+
+// redactConfig returns a copy of the config with secret values replaced for API responses
+func redactConfig(config *models.Config, secretPaths []secrets.Path) *models.Config {
+	redacted := *config
+	redacted.Values = database.JSONB[map[string]any]{Data: secrets.Redact(config.Values.Data, secretPaths)}
+	return &redacted
+}
@@ -1,13 +1,19 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/Gobusters/ectologger"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 
 	appctx "github.com/Ramsey-B/stem/pkg/context"
+	"github.com/Ramsey-B/orchid/pkg/metrics"
+	"github.com/Ramsey-B/orchid/pkg/models"
 	"github.com/Ramsey-B/orchid/pkg/redis"
 	"github.com/Ramsey-B/orchid/pkg/repositories"
 )
@@ -15,26 +21,31 @@ import (
 // DLQHandler handles dead letter queue API requests
 type DLQHandler struct {
 	dlq      *redis.DeadLetterQueue
-	streams  *redis.Streams
-	jobQueue string
+	jobQueue *redis.JobQueue
 	logger   ectologger.Logger
 }
 
 // NewDLQHandler creates a new DLQ handler
 func NewDLQHandler(
 	dlq *redis.DeadLetterQueue,
-	streams *redis.Streams,
-	jobQueue string,
+	jobQueue *redis.JobQueue,
 	logger ectologger.Logger,
 ) *DLQHandler {
 	return &DLQHandler{
 		dlq:      dlq,
-		streams:  streams,
 		jobQueue: jobQueue,
 		logger:   logger,
 	}
 }
 
+const (
+	// DefaultDLQBulkLimit is the number of entries a bulk retry or delete processes by default
+	DefaultDLQBulkLimit = 500
+
+	// MaxDLQBulkLimit caps the entries a single bulk retry or delete processes
+	MaxDLQBulkLimit = 1000
+)
+
 // DLQListResponse represents the response for listing DLQ entries
 type DLQListResponse struct {
 	Entries []redis.DLQEntry `json:"entries"`
@@ -42,8 +53,29 @@ type DLQListResponse struct {
 	Total   int64            `json:"total"`
 }
 
+// DLQBulkRequest selects the DLQ entries of a bulk retry or delete
+type DLQBulkRequest struct {
+	IDs      []string                `json:"ids,omitempty"` // Message IDs
+	PlanKey  string                  `json:"plan_key,omitempty"`
+	ConfigID string                  `json:"config_id,omitempty"`
+	Reason   models.DeadLetterReason `json:"reason,omitempty"`
+	Since    *time.Time              `json:"since,omitempty"` // Entries added to the DLQ at or after since
+	Until    *time.Time              `json:"until,omitempty"` // Entries added to the DLQ at or before until
+	Limit    int                     `json:"limit,omitempty"` // Entries processed, newest first (default 500, at most 1000)
+	DryRun   bool                    `json:"dry_run,omitempty"`
+}
+
+// DLQBulkResponse is the outcome of a bulk retry or delete
+type DLQBulkResponse struct {
+	Matched   int               `json:"matched"`
+	Processed int               `json:"processed"`        // Entries retried or deleted (0 on a dry run)
+	Remaining int               `json:"remaining"`        // Matched entries beyond the limit, left for another request
+	Failed    map[string]string `json:"failed,omitempty"` // Errors by message ID
+	DryRun    bool              `json:"dry_run"`
+}
+
 // List returns dead letter queue entries
-// GET /api/v1/dlq
+// GET /api/v1/dlq?plan_key=&config_id=&reason=&since=&until=&count=
 func (h *DLQHandler) List(c echo.Context) error {
 	ctx := c.Request().Context()
 
@@ -56,17 +88,14 @@ func (h *DLQHandler) List(c echo.Context) error {
 		}
 	}
 
-	// Check if filtering by tenant
-	tenantID := appctx.GetTenantID(ctx)
-	var entries []redis.DLQEntry
-	var err error
-
-	if tenantID != "" {
-		entries, err = h.dlq.ListByTenant(ctx, tenantID, count)
-	} else {
-		entries, err = h.dlq.List(ctx, count)
+	filter, err := parseDLQFilter(c)
+	if err != nil {
+		return err
 	}
 
+	// Filtered by tenant when there is one
+	filter.TenantID = appctx.GetTenantID(ctx)
+	entries, err := h.dlq.Find(ctx, filter, int(count))
 	if err != nil {
 		h.logger.WithContext(ctx).WithError(err).Error("Failed to list DLQ entries")
 		return err
@@ -107,10 +136,23 @@ func (h *DLQHandler) Retry(c echo.Context) error {
 	ctx := c.Request().Context()
 	messageID := c.Param("id")
 
-	if err := h.dlq.Retry(ctx, messageID, h.streams, h.jobQueue); err != nil {
+	entry, err := h.dlq.Get(ctx, messageID)
+	if err != nil {
+		h.logger.WithContext(ctx).WithError(err).Error("Failed to get DLQ entry")
+		return err
+	}
+	if entry == nil {
+		return repositories.NotFound("DLQ entry %s not found", messageID)
+	}
+
+	if err := h.dlq.RetryEntry(ctx, entry, h.jobQueue, false); err != nil {
 		h.logger.WithContext(ctx).WithError(err).Error("Failed to retry DLQ entry")
+		if errors.Is(err, redis.ErrTenantAtCapacity) {
+			return QueueError(err)
+		}
 		return err
 	}
+	metrics.RecordDLQRetry(string(entry.Reason), "manual")
 
 	return c.JSON(http.StatusOK, map[string]string{
 		"status":  "retried",
@@ -132,6 +174,148 @@ func (h *DLQHandler) Delete(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+// BulkRetry re-enqueues the DLQ entries matching the request up to its limit, or only counts them on a dry run
+// POST /api/v1/dlq/retry
+func (h *DLQHandler) BulkRetry(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	entries, matched, req, err := h.bulkEntries(c)
+	if err != nil {
+		return err
+	}
+
+	resp := DLQBulkResponse{Matched: matched, Remaining: matched - len(entries), DryRun: req.DryRun}
+	if req.DryRun {
+		return c.JSON(http.StatusOK, resp)
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+		if err := h.dlq.RetryEntry(ctx, entry, h.jobQueue, false); err != nil {
+			if resp.Failed == nil {
+				resp.Failed = make(map[string]string)
+			}
+			resp.Failed[entry.MessageID] = err.Error()
+			continue
+		}
+		metrics.RecordDLQRetry(string(entry.Reason), "manual")
+		resp.Processed++
+	}
+
+	h.logger.WithContext(ctx).Infof("Retried %d of %d DLQ entries (%d remaining)", resp.Processed, resp.Matched, resp.Remaining)
+	return c.JSON(http.StatusOK, resp)
+}
+
+// BulkDelete removes the DLQ entries matching the request up to its limit, or only counts them on a dry run
+// POST /api/v1/dlq/delete
+func (h *DLQHandler) BulkDelete(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	entries, matched, req, err := h.bulkEntries(c)
+	if err != nil {
+		return err
+	}
+
+	resp := DLQBulkResponse{Matched: matched, Remaining: matched - len(entries), DryRun: req.DryRun}
+	if req.DryRun {
+		return c.JSON(http.StatusOK, resp)
+	}
+
+	for _, entry := range entries {
+		if err := h.dlq.Delete(ctx, entry.MessageID); err != nil {
+			if resp.Failed == nil {
+				resp.Failed = make(map[string]string)
+			}
+			resp.Failed[entry.MessageID] = err.Error()
+			continue
+		}
+		resp.Processed++
+	}
+
+	h.logger.WithContext(ctx).Infof("Deleted %d of %d DLQ entries (%d remaining)", resp.Processed, resp.Matched, resp.Remaining)
+	return c.JSON(http.StatusOK, resp)
+}
+
+// bulkEntries binds a bulk request and finds the entries it selects, up to its limit, and the number of entries matching it.
+// A bulk request needs at least one criterion, so an empty body never selects the whole DLQ.
+func (h *DLQHandler) bulkEntries(c echo.Context) ([]redis.DLQEntry, int, *DLQBulkRequest, error) {
+	ctx := c.Request().Context()
+
+	var req DLQBulkRequest
+	if err := c.Bind(&req); err != nil {
+		return nil, 0, nil, BadRequest("invalid request body")
+	}
+	if req.Limit < 0 || req.Limit > MaxDLQBulkLimit {
+		return nil, 0, nil, BadRequest(fmt.Sprintf("limit must be between 1 and %d", MaxDLQBulkLimit))
+	}
+	if req.Limit == 0 {
+		req.Limit = DefaultDLQBulkLimit
+	}
+
+	filter := redis.DLQFilter{
+		IDs:      req.IDs,
+		PlanKey:  req.PlanKey,
+		ConfigID: req.ConfigID,
+		Reason:   req.Reason,
+	}
+	if req.Since != nil {
+		filter.Since = *req.Since
+	}
+	if req.Until != nil {
+		filter.Until = *req.Until
+	}
+	if err := validateDLQFilter(filter); err != nil {
+		return nil, 0, nil, err
+	}
+	if len(filter.IDs) == 0 && filter.PlanKey == "" && filter.ConfigID == "" && filter.Reason == "" &&
+		filter.Since.IsZero() && filter.Until.IsZero() {
+		return nil, 0, nil, BadRequest("at least one of ids, plan_key, config_id, reason, since or until is required")
+	}
+
+	filter.TenantID = appctx.GetTenantID(ctx)
+	entries, matched, err := h.dlq.FindN(ctx, filter, req.Limit)
+	if err != nil {
+		h.logger.WithContext(ctx).WithError(err).Error("Failed to find DLQ entries")
+		return nil, 0, nil, err
+	}
+	return entries, matched, &req, nil
+}
+
+// parseDLQFilter parses the DLQ filter query params
+func parseDLQFilter(c echo.Context) (redis.DLQFilter, error) {
+	filter := redis.DLQFilter{
+		PlanKey:  c.QueryParam("plan_key"),
+		ConfigID: c.QueryParam("config_id"),
+		Reason:   models.DeadLetterReason(c.QueryParam("reason")),
+	}
+	for param, t := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if value := c.QueryParam(param); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return filter, BadRequest("invalid " + param + ": must be an RFC 3339 timestamp")
+			}
+			*t = parsed
+		}
+	}
+	return filter, validateDLQFilter(filter)
+}
+
+// validateDLQFilter checks the config ID, reason and time range of a DLQ filter
+func validateDLQFilter(filter redis.DLQFilter) error {
+	if filter.ConfigID != "" {
+		if _, err := uuid.Parse(filter.ConfigID); err != nil {
+			return BadRequest("invalid config_id")
+		}
+	}
+	if filter.Reason != "" && !filter.Reason.Valid() {
+		return BadRequest("invalid reason")
+	}
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && filter.Until.Before(filter.Since) {
+		return BadRequest("until must not be before since")
+	}
+	return nil
+}
+
 // Stats returns DLQ statistics
 // GET /api/v1/dlq/stats
 func (h *DLQHandler) Stats(c echo.Context) error {
@@ -153,6 +337,8 @@ func (h *DLQHandler) RegisterRoutes(g *echo.Group) {
 	dlq := g.Group("/dlq")
 	dlq.GET("", h.List)
 	dlq.GET("/stats", h.Stats)
+	dlq.POST("/retry", h.BulkRetry)
+	dlq.POST("/delete", h.BulkDelete)
 	dlq.GET("/:id", h.Get)
 	dlq.POST("/:id/retry", h.Retry)
 	dlq.DELETE("/:id", h.Delete)
@@ -1,31 +1,54 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+
+	"github.com/Gobusters/ectoerror/httperror"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 
+	"github.com/Ramsey-B/orchid/pkg/httpclient"
+	"github.com/Ramsey-B/orchid/pkg/jsonschema"
 	"github.com/Ramsey-B/orchid/pkg/models"
 	"github.com/Ramsey-B/orchid/pkg/repositories"
+	"github.com/Ramsey-B/orchid/pkg/secrets"
 	"github.com/Ramsey-B/stem/pkg/database"
 )
 
 // IntegrationHandler handles integration-related API requests
 type IntegrationHandler struct {
-	repo repositories.IntegrationRepo
+	repo       repositories.IntegrationRepo
+	configRepo repositories.ConfigRepo
+	cipher     *secrets.Cipher
 }
 
-// NewIntegrationHandler creates a new integration handler
-func NewIntegrationHandler(repo repositories.IntegrationRepo) *IntegrationHandler {
+// NewIntegrationHandler creates a new integration handler.
+// Config values rewritten for a new config schema have their secret fields encrypted with cipher.
+func NewIntegrationHandler(repo repositories.IntegrationRepo, configRepo repositories.ConfigRepo, cipher *secrets.Cipher) *IntegrationHandler {
 	return &IntegrationHandler{
-		repo: repo,
+		repo:       repo,
+		configRepo: configRepo,
+		cipher:     cipher,
 	}
 }
 
+// InvalidConfig describes an existing config that does not satisfy a new config schema
+type InvalidConfig struct {
+	ConfigID uuid.UUID               `json:"config_id"`
+	Name     string                  `json:"name"`
+	Errors   []jsonschema.FieldError `json:"errors"`
+}
+
 // CreateIntegrationRequest is the request body for creating an integration
 type CreateIntegrationRequest struct {
-	Name        string  `json:"name" validate:"required"`
-	Description *string `json:"description,omitempty"`
+	Name         string                           `json:"name" validate:"required"`
+	Description  *string                          `json:"description,omitempty"`
 	ConfigSchema *CreateConfigSchemaInlineRequest `json:"config_schema,omitempty"`
+	RateLimits   []models.RateLimitConfig         `json:"rate_limits,omitempty"` // Shared by every plan and auth flow of the integration
+	Transport    *models.TransportConfig          `json:"transport,omitempty"`   // TLS and proxy settings of each config's dedicated transport
 }
 
 type CreateConfigSchemaInlineRequest struct {
@@ -35,9 +58,11 @@ type CreateConfigSchemaInlineRequest struct {
 
 // UpdateIntegrationRequest is the request body for updating an integration
 type UpdateIntegrationRequest struct {
-	Name        *string `json:"name,omitempty"`
-	Description *string `json:"description,omitempty"`
+	Name         *string                          `json:"name,omitempty"`
+	Description  *string                          `json:"description,omitempty"`
 	ConfigSchema *CreateConfigSchemaInlineRequest `json:"config_schema,omitempty"`
+	RateLimits   *[]models.RateLimitConfig        `json:"rate_limits,omitempty"` // Replaces the shared rate limits; [] removes them
+	Transport    *models.TransportConfig          `json:"transport,omitempty"`   // Replaces the transport settings; {} removes them
 }
 
 // RegisterRoutes registers the integration routes
@@ -67,12 +92,20 @@ func (h *IntegrationHandler) Create(c echo.Context) error {
 	if req.Name == "" {
 		return BadRequest("name is required")
 	}
+	if err := validateRateLimits(req.RateLimits); err != nil {
+		return err
+	}
+	if err := httpclient.ValidateTransport(req.Transport); err != nil {
+		return BadRequest(err.Error())
+	}
 
 	integration := &models.Integration{
 		ID:          uuid.New(),
 		TenantID:    tenantID,
 		Name:        req.Name,
 		Description: req.Description,
+		RateLimits:  rateLimitsJSONB(req.RateLimits),
+		Transport:   transportJSONB(req.Transport),
 	}
 
 	if req.ConfigSchema != nil {
@@ -82,6 +115,9 @@ func (h *IntegrationHandler) Create(c echo.Context) error {
 		if req.ConfigSchema.Schema == nil {
 			return BadRequest("config_schema.schema is required")
 		}
+		if _, err := jsonschema.Compile(req.ConfigSchema.Schema); err != nil {
+			return BadRequest("config_schema.schema is invalid: " + err.Error())
+		}
 		integration.ConfigSchema = database.JSONB[map[string]any]{Data: map[string]any{
 			"name":   req.ConfigSchema.Name,
 			"schema": req.ConfigSchema.Schema,
@@ -151,6 +187,18 @@ func (h *IntegrationHandler) Update(c echo.Context) error {
 	if req.Description != nil {
 		existing.Description = req.Description
 	}
+	if req.RateLimits != nil {
+		if err := validateRateLimits(*req.RateLimits); err != nil {
+			return err
+		}
+		existing.RateLimits = rateLimitsJSONB(*req.RateLimits)
+	}
+	if req.Transport != nil {
+		if err := httpclient.ValidateTransport(req.Transport); err != nil {
+			return BadRequest(err.Error())
+		}
+		existing.Transport = transportJSONB(req.Transport)
+	}
 
 	if req.ConfigSchema != nil {
 		if req.ConfigSchema.Name == "" {
@@ -159,13 +207,30 @@ func (h *IntegrationHandler) Update(c echo.Context) error {
 		if req.ConfigSchema.Schema == nil {
 			return BadRequest("config_schema.schema is required")
 		}
+		if _, err := jsonschema.Compile(req.ConfigSchema.Schema); err != nil {
+			return BadRequest("config_schema.schema is invalid: " + err.Error())
+		}
 		existing.ConfigSchema = database.JSONB[map[string]any]{Data: map[string]any{
 			"name":   req.ConfigSchema.Name,
 			"schema": req.ConfigSchema.Schema,
 		}}
 	}
 
-	if err := h.repo.Update(ctx, existing); err != nil {
+	// Existing configs must still satisfy the new schema
+	var revalidated []models.Config
+	if req.ConfigSchema != nil {
+		revalidated, err = h.revalidateConfigs(ctx, existing)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Configs rewritten for the new schema are stored together with it
+	if len(revalidated) > 0 {
+		if err := h.repo.UpdateWithConfigs(ctx, existing, revalidated); err != nil {
+			return err
+		}
+	} else if err := h.repo.Update(ctx, existing); err != nil {
 		return err
 	}
 
@@ -187,3 +252,50 @@ func (h *IntegrationHandler) Delete(c echo.Context) error {
 
 	return NoContentResponse(c)
 }
+
+// transportJSONB stores transport settings, with an empty object stored as none
+func transportJSONB(transport *models.TransportConfig) database.JSONB[*models.TransportConfig] {
+	if transport != nil && *transport == (models.TransportConfig{}) {
+		transport = nil
+	}
+	return database.JSONB[*models.TransportConfig]{Data: transport}
+}
+
+// revalidateConfigs validates every config of the integration against its (new) config schema.
+// It returns the configs whose values changed because new defaults were applied or fields became secret,
+// with their secret fields encrypted, or an error listing the configs that no longer validate.
+func (h *IntegrationHandler) revalidateConfigs(ctx context.Context, integration *models.Integration) ([]models.Config, error) {
+	configs, err := h.configRepo.ListByIntegration(ctx, integration.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	secretPaths := secrets.SecretPaths(integration.ConfigSchema.Data)
+	var changed []models.Config
+	var invalid []InvalidConfig
+	for _, config := range configs {
+		values, err := applyConfigSchema(integration.ConfigSchema.Data, config.Values.Data)
+		var validationErr *jsonschema.ValidationError
+		if errors.As(err, &validationErr) {
+			invalid = append(invalid, InvalidConfig{ConfigID: config.ID, Name: config.Name, Errors: validationErr.Errors})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		values, err = encryptConfigValues(ctx, h.cipher, values, secretPaths)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(values, config.Values.Data) {
+			config.Values = database.JSONB[map[string]any]{Data: values}
+			changed = append(changed, config)
+		}
+	}
+
+	if len(invalid) > 0 {
+		return nil, httperror.NewHTTPErrorf(http.StatusBadRequest, "config_schema would invalidate %d existing config(s)", len(invalid)).
+			AddMetaValue("configs", invalid)
+	}
+	return changed, nil
+}
@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+
+	"github.com/Gobusters/ectoerror/httperror"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+	"github.com/Ramsey-B/orchid/pkg/notify"
+	"github.com/Ramsey-B/orchid/pkg/repositories"
+	"github.com/Ramsey-B/orchid/pkg/secrets"
+)
+
+const (
+	// defaultDeliveryLimit is the number of deliveries listed when no limit is given
+	defaultDeliveryLimit = 50
+
+	// maxDeliveryLimit caps the number of deliveries listed at once
+	maxDeliveryLimit = 500
+)
+
+// NotificationHandler handles notification subscription API requests
+type NotificationHandler struct {
+	subscriptions repositories.NotificationSubscriptionRepo
+	deliveries    repositories.NotificationDeliveryRepo
+	cipher        *secrets.Cipher
+}
+
+// NewNotificationHandler creates a new notification handler.
+// Webhook signing secrets are encrypted with cipher before they are stored.
+func NewNotificationHandler(subscriptions repositories.NotificationSubscriptionRepo, deliveries repositories.NotificationDeliveryRepo, cipher *secrets.Cipher) *NotificationHandler {
+	return &NotificationHandler{
+		subscriptions: subscriptions,
+		deliveries:    deliveries,
+		cipher:        cipher,
+	}
+}
+
+// CreateNotificationSubscriptionRequest is the request body for creating a notification subscription
+type CreateNotificationSubscriptionRequest struct {
+	Name      string                   `json:"name" validate:"required"`
+	Event     models.NotificationEvent `json:"event" validate:"required"`
+	PlanKey   *string                  `json:"plan_key,omitempty"`  // Only executions of this plan
+	ConfigID  *uuid.UUID               `json:"config_id,omitempty"` // Only executions with this config
+	Threshold *int                     `json:"threshold,omitempty"` // Failures in a row that fire the subscription (default 1)
+	URL       string                   `json:"url" validate:"required"`
+	Secret    string                   `json:"secret,omitempty"` // Generated when empty
+	Enabled   *bool                    `json:"enabled,omitempty"`
+}
+
+// UpdateNotificationSubscriptionRequest is the request body for updating a notification subscription
+type UpdateNotificationSubscriptionRequest struct {
+	Name      string                   `json:"name"`
+	Event     models.NotificationEvent `json:"event"`
+	PlanKey   *string                  `json:"plan_key"`  // "" removes the filter
+	ConfigID  *uuid.UUID               `json:"config_id"` // Replaces the filter; use clear_config_id to remove it
+	Threshold *int                     `json:"threshold"`
+	URL       string                   `json:"url"`
+	Secret    string                   `json:"secret,omitempty"` // Rotates the signing secret
+	Enabled   *bool                    `json:"enabled"`
+
+	ClearConfigID bool `json:"clear_config_id,omitempty"`
+}
+
+// NotificationSubscriptionWithSecret is a subscription returned with its signing secret, only when it is set
+type NotificationSubscriptionWithSecret struct {
+	*models.NotificationSubscription
+	Secret string `json:"secret"`
+}
+
+// RegisterRoutes registers the notification subscription routes
+func (h *NotificationHandler) RegisterRoutes(g *echo.Group) {
+	subscriptions := g.Group("/notification-subscriptions")
+	subscriptions.POST("", h.Create)
+	subscriptions.GET("", h.List)
+	subscriptions.GET("/:id", h.Get)
+	subscriptions.PUT("/:id", h.Update)
+	subscriptions.DELETE("/:id", h.Delete)
+	subscriptions.GET("/:id/deliveries", h.ListDeliveries)
+	subscriptions.POST("/:id/deliveries/:delivery_id/redeliver", h.Redeliver)
+}
+
+// Create handles POST /notification-subscriptions
+func (h *NotificationHandler) Create(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	tenantID, err := GetTenantID(c)
+	if err != nil {
+		return err
+	}
+
+	var req CreateNotificationSubscriptionRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequest("invalid request body")
+	}
+
+	subscription := &models.NotificationSubscription{
+		ID:        uuid.New(),
+		TenantID:  tenantID,
+		Name:      req.Name,
+		Event:     req.Event,
+		PlanKey:   emptyToNil(req.PlanKey),
+		ConfigID:  req.ConfigID,
+		Threshold: 1,
+		URL:       req.URL,
+		Enabled:   true,
+	}
+	if req.Threshold != nil {
+		subscription.Threshold = *req.Threshold
+	}
+	if req.Enabled != nil {
+		subscription.Enabled = *req.Enabled
+	}
+	if err := notify.ValidateSubscription(subscription); err != nil {
+		return BadRequest(err.Error())
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = generateSecret(); err != nil {
+			return err
+		}
+	}
+	if subscription.Secret.Data, err = h.encryptSecret(ctx, secret); err != nil {
+		return err
+	}
+
+	if err := h.subscriptions.Create(ctx, subscription); err != nil {
+		return err
+	}
+
+	return CreatedResponse(c, NotificationSubscriptionWithSecret{NotificationSubscription: subscription, Secret: secret})
+}
+
+// List handles GET /notification-subscriptions
+func (h *NotificationHandler) List(c echo.Context) error {
+	subscriptions, err := h.subscriptions.List(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return SuccessResponse(c, subscriptions)
+}
+
+// Get handles GET /notification-subscriptions/:id
+func (h *NotificationHandler) Get(c echo.Context) error {
+	id, err := ParseUUID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	subscription, err := h.subscriptions.GetByID(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return SuccessResponse(c, subscription)
+}
+
+// Update handles PUT /notification-subscriptions/:id
+func (h *NotificationHandler) Update(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := ParseUUID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	subscription, err := h.subscriptions.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	var req UpdateNotificationSubscriptionRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequest("invalid request body")
+	}
+
+	// Apply updates
+	if req.Name != "" {
+		subscription.Name = req.Name
+	}
+	if req.Event != "" {
+		subscription.Event = req.Event
+	}
+	if req.PlanKey != nil {
+		subscription.PlanKey = emptyToNil(req.PlanKey)
+	}
+	if req.ConfigID != nil {
+		subscription.ConfigID = req.ConfigID
+	}
+	if req.ClearConfigID {
+		subscription.ConfigID = nil
+	}
+	if req.Threshold != nil {
+		subscription.Threshold = *req.Threshold
+	}
+	if req.URL != "" {
+		subscription.URL = req.URL
+	}
+	if req.Enabled != nil {
+		subscription.Enabled = *req.Enabled
+	}
+	if err := notify.ValidateSubscription(subscription); err != nil {
+		return BadRequest(err.Error())
+	}
+
+	// The existing secret is kept unless a new one is given
+	if req.Secret != "" {
+		if subscription.Secret.Data, err = h.encryptSecret(ctx, req.Secret); err != nil {
+			return err
+		}
+	}
+
+	if err := h.subscriptions.Update(ctx, subscription); err != nil {
+		return err
+	}
+
+	if req.Secret != "" {
+		return SuccessResponse(c, NotificationSubscriptionWithSecret{NotificationSubscription: subscription, Secret: req.Secret})
+	}
+	return SuccessResponse(c, subscription)
+}
+
+// Delete handles DELETE /notification-subscriptions/:id
+func (h *NotificationHandler) Delete(c echo.Context) error {
+	id, err := ParseUUID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.subscriptions.Delete(c.Request().Context(), id); err != nil {
+		return err
+	}
+
+	return NoContentResponse(c)
+}
+
+// ListDeliveries handles GET /notification-subscriptions/:id/deliveries?status=&limit=
+func (h *NotificationHandler) ListDeliveries(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := ParseUUID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	status := models.NotificationDeliveryStatus(c.QueryParam("status"))
+	switch status {
+	case "", models.NotificationDeliveryPending, models.NotificationDeliveryDelivered, models.NotificationDeliveryFailed:
+	default:
+		return BadRequest("status must be pending, delivered or failed")
+	}
+
+	limit := defaultDeliveryLimit
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			return BadRequest("limit must be a positive integer")
+		}
+		limit = min(parsed, maxDeliveryLimit)
+	}
+
+	// 404 for an unknown subscription rather than an empty log
+	if _, err := h.subscriptions.GetByID(ctx, id); err != nil {
+		return err
+	}
+
+	deliveries, err := h.deliveries.ListBySubscription(ctx, id, status, limit)
+	if err != nil {
+		return err
+	}
+
+	return SuccessResponse(c, deliveries)
+}
+
+// Redeliver handles POST /notification-subscriptions/:id/deliveries/:delivery_id/redeliver
+func (h *NotificationHandler) Redeliver(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := ParseUUID(c, "id")
+	if err != nil {
+		return err
+	}
+	deliveryID, err := ParseUUID(c, "delivery_id")
+	if err != nil {
+		return err
+	}
+
+	delivery, err := h.deliveries.GetByID(ctx, deliveryID)
+	if err != nil {
+		return err
+	}
+	if delivery.SubscriptionID != id {
+		return httperror.NewHTTPErrorf(http.StatusNotFound, "notification delivery %s does not exist", deliveryID)
+	}
+
+	if err := h.deliveries.Redeliver(ctx, deliveryID); err != nil {
+		return err
+	}
+
+	delivery, err = h.deliveries.GetByID(ctx, deliveryID)
+	if err != nil {
+		return err
+	}
+
+	return SuccessResponse(c, delivery)
+}
+
+// encryptSecret encrypts a webhook signing secret for storage
+func (h *NotificationHandler) encryptSecret(ctx context.Context, secret string) (map[string]any, error) {
+	if h.cipher == nil {
+		return nil, httperror.NewHTTPError(http.StatusInternalServerError, "secret storage is not configured")
+	}
+
+	encrypted, err := h.cipher.EncryptValue(ctx, secret)
+	if err != nil {
+		return nil, httperror.NewHTTPErrorf(http.StatusInternalServerError, "failed to encrypt subscription secret: %v", err)
+	}
+	return encrypted, nil
+}
+
+// generateSecret returns a random webhook signing secret
+func generateSecret() (string, error) {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		return "", httperror.NewHTTPErrorf(http.StatusInternalServerError, "failed to generate subscription secret: %v", err)
+	}
+	return hex.EncodeToString(data), nil
+}
+
+// emptyToNil returns nil for a nil or empty string
+func emptyToNil(s *string) *string {
+	if s == nil || *s == "" {
+		return nil
+	}
+	return s
+}
@@ -1,6 +1,10 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/Gobusters/ectoerror/httperror"
@@ -8,59 +12,81 @@ import (
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 
+	"github.com/Ramsey-B/orchid/pkg/archive"
+	"github.com/Ramsey-B/orchid/pkg/execution"
 	"github.com/Ramsey-B/orchid/pkg/models"
 	"github.com/Ramsey-B/orchid/pkg/queue"
 	"github.com/Ramsey-B/orchid/pkg/redis"
 	"github.com/Ramsey-B/orchid/pkg/repositories"
+	"github.com/Ramsey-B/orchid/pkg/scheduler"
 	appctx "github.com/Ramsey-B/stem/pkg/context"
 	"github.com/Ramsey-B/stem/pkg/database"
 	"github.com/Ramsey-B/stem/pkg/tracing"
 )
 
+// PlanPreviewer runs plans without publishing or persisting anything
+type PlanPreviewer interface {
+	Preview(ctx context.Context, input execution.PlanExecutionInput) (*execution.PreviewResult, error)
+}
+
 // PlanHandler handles plan API endpoints
 type PlanHandler struct {
-	repo     repositories.PlanRepo
-	streams  *redis.Streams
-	jobQueue string
-	logger   ectologger.Logger
+	repo           repositories.PlanRepo
+	watermarkRepo  repositories.PlanWatermarkRepo
+	statisticsRepo repositories.PlanStatisticsRepo
+	jobQueue       *redis.JobQueue
+	previewer      PlanPreviewer
+	logger         ectologger.Logger
 }
 
 // NewPlanHandler creates a new plan handler
 func NewPlanHandler(
 	repo repositories.PlanRepo,
-	streams *redis.Streams,
-	jobQueue string,
+	watermarkRepo repositories.PlanWatermarkRepo,
+	statisticsRepo repositories.PlanStatisticsRepo,
+	jobQueue *redis.JobQueue,
+	previewer PlanPreviewer,
 	logger ectologger.Logger,
 ) *PlanHandler {
 	return &PlanHandler{
-		repo:     repo,
-		streams:  streams,
-		jobQueue: jobQueue,
-		logger:   logger,
+		repo:           repo,
+		watermarkRepo:  watermarkRepo,
+		statisticsRepo: statisticsRepo,
+		jobQueue:       jobQueue,
+		previewer:      previewer,
+		logger:         logger,
 	}
 }
 
 // CreatePlanRequest represents the create plan request body
 type CreatePlanRequest struct {
-	IntegrationID  string         `json:"integration_id" validate:"required"`
-	Key            string         `json:"key" validate:"required"`
-	Name           string         `json:"name" validate:"required"`
-	Description    *string        `json:"description,omitempty"`
-	PlanDefinition map[string]any `json:"plan_definition" validate:"required"`
-	Enabled        *bool          `json:"enabled,omitempty"`
-	WaitSeconds    *int           `json:"wait_seconds,omitempty"`
-	RepeatCount    *int           `json:"repeat_count,omitempty"`
+	IntegrationID    string                  `json:"integration_id" validate:"required"`
+	Key              string                  `json:"key" validate:"required"`
+	Name             string                  `json:"name" validate:"required"`
+	Description      *string                 `json:"description,omitempty"`
+	PlanDefinition   map[string]any          `json:"plan_definition" validate:"required"`
+	Enabled          *bool                   `json:"enabled,omitempty"`
+	WaitSeconds      *int                    `json:"wait_seconds,omitempty"`
+	RepeatCount      *int                    `json:"repeat_count,omitempty"`
+	ScheduleCron     *string                 `json:"schedule_cron,omitempty"`
+	ScheduleTimezone *string                 `json:"schedule_timezone,omitempty"`
+	BlackoutWindows  []models.BlackoutWindow `json:"blackout_windows,omitempty"`
+	OverlapPolicy    models.OverlapPolicy    `json:"overlap_policy,omitempty"` // skip, queue or cancel_previous (default queue)
 }
 
 // UpdatePlanRequest represents the update plan request body
 type UpdatePlanRequest struct {
-	Key            string         `json:"key" validate:"required"`
-	Name           string         `json:"name" validate:"required"`
-	Description    *string        `json:"description,omitempty"`
-	PlanDefinition map[string]any `json:"plan_definition" validate:"required"`
-	Enabled        *bool          `json:"enabled,omitempty"`
-	WaitSeconds    *int           `json:"wait_seconds,omitempty"`
-	RepeatCount    *int           `json:"repeat_count,omitempty"`
+	Key              string                  `json:"key" validate:"required"`
+	Name             string                  `json:"name" validate:"required"`
+	Description      *string                 `json:"description,omitempty"`
+	PlanDefinition   map[string]any          `json:"plan_definition" validate:"required"`
+	Enabled          *bool                   `json:"enabled,omitempty"`
+	WaitSeconds      *int                    `json:"wait_seconds,omitempty"`
+	RepeatCount      *int                    `json:"repeat_count,omitempty"`
+	ScheduleCron     *string                 `json:"schedule_cron,omitempty"`
+	ScheduleTimezone *string                 `json:"schedule_timezone,omitempty"`
+	BlackoutWindows  []models.BlackoutWindow `json:"blackout_windows,omitempty"`
+	OverlapPolicy    models.OverlapPolicy    `json:"overlap_policy,omitempty"` // skip, queue or cancel_previous (default queue)
 }
 
 // TriggerPlanRequest represents the trigger plan request body
@@ -69,6 +95,19 @@ type TriggerPlanRequest struct {
 	ContextOverride map[string]any `json:"context_override,omitempty"`
 }
 
+// PreviewPlanRequest represents the preview plan request body
+type PreviewPlanRequest struct {
+	ConfigID        string         `json:"config_id" validate:"required"`
+	ContextOverride map[string]any `json:"context_override,omitempty"`
+	MaxLoops        int            `json:"max_loops,omitempty"`        // Default 3, at most 50
+	MaxFanoutItems  int            `json:"max_fanout_items,omitempty"` // Default 5, at most 100
+}
+
+// SetWatermarkRequest represents the set watermark request body
+type SetWatermarkRequest struct {
+	Value string `json:"value" validate:"required"`
+}
+
 // SetEnabledRequest represents the set enabled request body
 type SetEnabledRequest struct {
 	Enabled bool `json:"enabled"`
@@ -83,6 +122,11 @@ func (h *PlanHandler) Register(g *echo.Group) {
 	g.DELETE("/:key", h.Delete)
 	g.PATCH("/:key/enabled", h.SetEnabled)
 	g.POST("/:key/trigger", h.Trigger)
+	g.POST("/:key/preview", h.Preview)
+	g.GET("/:key/watermarks", h.ListWatermarks)
+	g.GET("/:key/watermarks/:config_id", h.GetWatermark)
+	g.PUT("/:key/watermarks/:config_id", h.SetWatermark)
+	g.DELETE("/:key/watermarks/:config_id", h.ResetWatermark)
 }
 
 // List returns all plans for the current tenant
@@ -138,16 +182,32 @@ func (h *PlanHandler) Create(c echo.Context) error {
 		return BadRequest("invalid integration_id")
 	}
 
+	if err := validateSchedule(req.ScheduleCron, req.ScheduleTimezone, req.BlackoutWindows); err != nil {
+		return err
+	}
+
+	if err := validateOverlapPolicy(req.OverlapPolicy); err != nil {
+		return err
+	}
+
+	if err := validatePlanDefinition(req.PlanDefinition); err != nil {
+		return err
+	}
+
 	plan := &models.Plan{
-		IntegrationID: integrationID,
-		Key:           req.Key,
-		Name:          req.Name,
-		Description:   req.Description,
-		Enabled:       false,
-		WaitSeconds:   req.WaitSeconds,
-		RepeatCount:   req.RepeatCount,
+		IntegrationID:    integrationID,
+		Key:              req.Key,
+		Name:             req.Name,
+		Description:      req.Description,
+		Enabled:          false,
+		WaitSeconds:      req.WaitSeconds,
+		RepeatCount:      req.RepeatCount,
+		ScheduleCron:     req.ScheduleCron,
+		ScheduleTimezone: req.ScheduleTimezone,
+		OverlapPolicy:    req.OverlapPolicy,
 	}
 	plan.PlanDefinition.Data = req.PlanDefinition
+	plan.BlackoutWindows.Data = req.BlackoutWindows
 
 	if req.Enabled != nil {
 		plan.Enabled = *req.Enabled
@@ -201,17 +261,39 @@ func (h *PlanHandler) Update(c echo.Context) error {
 		return httperror.NewHTTPError(http.StatusBadRequest, "name is required")
 	}
 
+	if err := validateSchedule(req.ScheduleCron, req.ScheduleTimezone, req.BlackoutWindows); err != nil {
+		return err
+	}
+
+	if err := validateOverlapPolicy(req.OverlapPolicy); err != nil {
+		return err
+	}
+
+	if err := validatePlanDefinition(req.PlanDefinition); err != nil {
+		return err
+	}
+
 	plan, err := h.repo.GetByKey(ctx, key)
 	if err != nil {
 		return err
 	}
 
+	// The next fire time was computed from the old schedule
+	scheduleChanged := stringValue(plan.ScheduleCron) != stringValue(req.ScheduleCron) ||
+		stringValue(plan.ScheduleTimezone) != stringValue(req.ScheduleTimezone)
+
 	plan.Key = req.Key
 	plan.Name = req.Name
 	plan.Description = req.Description
 	plan.PlanDefinition = database.JSONB[map[string]any]{Data: req.PlanDefinition}
 	plan.WaitSeconds = req.WaitSeconds
 	plan.RepeatCount = req.RepeatCount
+	plan.ScheduleCron = req.ScheduleCron
+	plan.ScheduleTimezone = req.ScheduleTimezone
+	plan.BlackoutWindows = database.JSONB[[]models.BlackoutWindow]{Data: req.BlackoutWindows}
+	if req.OverlapPolicy != "" {
+		plan.OverlapPolicy = req.OverlapPolicy
+	}
 	if req.Enabled != nil {
 		plan.Enabled = *req.Enabled
 	}
@@ -219,6 +301,11 @@ func (h *PlanHandler) Update(c echo.Context) error {
 	if err := h.repo.Update(ctx, plan); err != nil {
 		return err
 	}
+	if scheduleChanged {
+		if err := h.statisticsRepo.ClearNextRun(ctx, key); err != nil {
+			return err
+		}
+	}
 	return SuccessResponse(c, plan)
 }
 
@@ -310,10 +397,10 @@ func (h *PlanHandler) Trigger(c echo.Context) error {
 		ContextOverride: req.ContextOverride,
 	}
 
-	messageID, err := queue.PublishPlanExecution(ctx, h.streams, h.jobQueue, job)
+	messageID, err := queue.PublishPlanExecution(ctx, h.jobQueue, redis.LaneManual, job)
 	if err != nil {
 		h.logger.WithContext(ctx).WithError(err).Error("Failed to publish plan execution job")
-		return httperror.WrapError(500, err)
+		return QueueError(err)
 	}
 
 	h.logger.WithContext(ctx).Infof("Triggered plan %s with config %s (message_id=%s)", planKey, configID, messageID)
@@ -326,17 +413,351 @@ func (h *PlanHandler) Trigger(c echo.Context) error {
 	})
 }
 
+// Preview runs a plan against a config without publishing to Kafka or persisting context and statistics
+func (h *PlanHandler) Preview(c echo.Context) error {
+	ctx, span := tracing.StartSpan(c.Request().Context(), "PlanHandler.Preview")
+	defer span.End()
+	c.SetRequest(c.Request().WithContext(ctx))
+
+	if h.previewer == nil {
+		return httperror.NewHTTPError(http.StatusNotImplemented, "plan preview is not configured")
+	}
+
+	planKey := c.Param("key")
+	if planKey == "" {
+		return httperror.NewHTTPError(http.StatusBadRequest, "plan key is required")
+	}
+
+	var req PreviewPlanRequest
+	if err := c.Bind(&req); err != nil {
+		return httperror.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	if req.ConfigID == "" {
+		return httperror.NewHTTPError(http.StatusBadRequest, "config_id is required")
+	}
+
+	configID, err := uuid.Parse(req.ConfigID)
+	if err != nil {
+		return httperror.NewHTTPError(http.StatusBadRequest, "invalid config_id")
+	}
+
+	if req.MaxLoops < 0 || req.MaxFanoutItems < 0 {
+		return httperror.NewHTTPError(http.StatusBadRequest, "max_loops and max_fanout_items must not be negative")
+	}
+	if req.MaxLoops > execution.MaxPreviewLoops {
+		return httperror.NewHTTPErrorf(http.StatusBadRequest, "max_loops must not exceed %d", execution.MaxPreviewLoops)
+	}
+	if req.MaxFanoutItems > execution.MaxPreviewFanoutItems {
+		return httperror.NewHTTPErrorf(http.StatusBadRequest, "max_fanout_items must not exceed %d", execution.MaxPreviewFanoutItems)
+	}
+
+	tenantID, err := GetTenantID(c)
+	if err != nil {
+		return err
+	}
+
+	plan, err := h.repo.GetByKey(ctx, planKey)
+	if err != nil {
+		return err
+	}
+
+	result, err := h.previewer.Preview(ctx, execution.PlanExecutionInput{
+		PlanKey:         plan.Key,
+		Integration:     plan.Integration,
+		ConfigID:        configID,
+		TenantID:        tenantID,
+		ContextOverride: req.ContextOverride,
+		Preview:         execution.NewPreview(req.MaxLoops, req.MaxFanoutItems),
+	})
+	if err != nil {
+		if errors.Is(err, execution.ErrPlanNotFound) || errors.Is(err, execution.ErrConfigNotFound) {
+			return httperror.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		h.logger.WithContext(ctx).WithError(err).Error("Failed to preview plan")
+		return err
+	}
+
+	h.logger.WithContext(ctx).Infof("Previewed plan %s with config %s: status=%s api_calls=%d",
+		planKey, configID, result.Status, result.TotalAPICalls)
+
+	return SuccessResponse(c, result)
+}
+
+// ListWatermarks returns the committed watermarks of every config of a plan
+func (h *PlanHandler) ListWatermarks(c echo.Context) error {
+	ctx, span := tracing.StartSpan(c.Request().Context(), "PlanHandler.ListWatermarks")
+	defer span.End()
+	c.SetRequest(c.Request().WithContext(ctx))
+
+	planKey := c.Param("key")
+	if planKey == "" {
+		return httperror.NewHTTPError(http.StatusBadRequest, "plan key is required")
+	}
+
+	watermarks, err := h.watermarkRepo.ListByPlan(ctx, planKey)
+	if err != nil {
+		h.logger.WithContext(ctx).WithError(err).Error("Failed to list watermarks")
+		return err
+	}
+
+	return SuccessResponse(c, watermarks)
+}
+
+// GetWatermark returns the committed watermark of a plan/config combination
+func (h *PlanHandler) GetWatermark(c echo.Context) error {
+	ctx, span := tracing.StartSpan(c.Request().Context(), "PlanHandler.GetWatermark")
+	defer span.End()
+	c.SetRequest(c.Request().WithContext(ctx))
+
+	planKey := c.Param("key")
+	if planKey == "" {
+		return httperror.NewHTTPError(http.StatusBadRequest, "plan key is required")
+	}
+
+	configID, err := ParseUUID(c, "config_id")
+	if err != nil {
+		return err
+	}
+
+	watermark, err := h.watermarkRepo.GetByPlanAndConfig(ctx, planKey, configID)
+	if err != nil {
+		return err
+	}
+
+	return SuccessResponse(c, watermark)
+}
+
+// SetWatermark rewinds (or advances) the watermark of a plan/config combination, e.g. for a backfill
+func (h *PlanHandler) SetWatermark(c echo.Context) error {
+	ctx, span := tracing.StartSpan(c.Request().Context(), "PlanHandler.SetWatermark")
+	defer span.End()
+	c.SetRequest(c.Request().WithContext(ctx))
+
+	planKey := c.Param("key")
+	if planKey == "" {
+		return httperror.NewHTTPError(http.StatusBadRequest, "plan key is required")
+	}
+
+	configID, err := ParseUUID(c, "config_id")
+	if err != nil {
+		return err
+	}
+
+	var req SetWatermarkRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequest("invalid request body")
+	}
+
+	plan, err := h.repo.GetByKey(ctx, planKey)
+	if err != nil {
+		return err
+	}
+	planDef, err := decodePlanDefinition(plan.PlanDefinition.Data)
+	if err != nil {
+		return err
+	}
+	if planDef.Watermark == nil {
+		return BadRequest(fmt.Sprintf("plan %s does not declare a watermark", planKey))
+	}
+	if err := execution.ValidateWatermarkValue(planDef.Watermark, req.Value); err != nil {
+		return BadRequest("invalid value: " + err.Error())
+	}
+
+	watermark := &models.PlanWatermark{
+		PlanKey:  planKey,
+		ConfigID: configID,
+		Value:    req.Value,
+	}
+	if err := h.watermarkRepo.Upsert(ctx, watermark); err != nil {
+		h.logger.WithContext(ctx).WithError(err).Error("Failed to set watermark")
+		return err
+	}
+
+	h.logger.WithContext(ctx).Infof("Set watermark of plan %s config %s to %s", planKey, configID, req.Value)
+	return SuccessResponse(c, watermark)
+}
+
+// ResetWatermark removes the watermark of a plan/config combination,
+// so the next execution syncs from the plan's initial watermark
+func (h *PlanHandler) ResetWatermark(c echo.Context) error {
+	ctx, span := tracing.StartSpan(c.Request().Context(), "PlanHandler.ResetWatermark")
+	defer span.End()
+	c.SetRequest(c.Request().WithContext(ctx))
+
+	planKey := c.Param("key")
+	if planKey == "" {
+		return httperror.NewHTTPError(http.StatusBadRequest, "plan key is required")
+	}
+
+	configID, err := ParseUUID(c, "config_id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.watermarkRepo.Delete(ctx, planKey, configID); err != nil {
+		return err
+	}
+
+	h.logger.WithContext(ctx).Infof("Reset watermark of plan %s config %s", planKey, configID)
+	return NoContentResponse(c)
+}
+
+// validateSchedule checks the cron expression, timezone and blackout windows of a plan
+func validateSchedule(cron, timezone *string, windows []models.BlackoutWindow) error {
+	tz := ""
+	if timezone != nil {
+		tz = *timezone
+	}
+	location, err := scheduler.LoadLocation(tz)
+	if err != nil {
+		return BadRequest(err.Error())
+	}
+
+	if cron != nil {
+		if _, err := scheduler.ParseCron(*cron, location); err != nil {
+			return BadRequest("invalid schedule_cron: " + err.Error())
+		}
+	}
+
+	if err := scheduler.ValidateBlackoutWindows(windows); err != nil {
+		return BadRequest(err.Error())
+	}
+	return nil
+}
+
+// validateOverlapPolicy checks the overlap policy of a plan; empty keeps the current or default policy
+func validateOverlapPolicy(policy models.OverlapPolicy) error {
+	if policy != "" && !policy.Valid() {
+		return BadRequest("invalid overlap_policy: must be skip, queue or cancel_previous")
+	}
+	return nil
+}
+
+// validatePlanDefinition checks the steps, pagination, streaming and watermark of a plan definition.
+// Only top-level steps are paginated or streamed; sub-steps run once per page or item.
+func validatePlanDefinition(definition map[string]any) error {
+	planDef, err := decodePlanDefinition(definition)
+	if err != nil {
+		return err
+	}
+
+	if err := execution.ValidateSteps(planDef); err != nil {
+		return BadRequest("invalid steps: " + err.Error())
+	}
+	for i, step := range planDef.EntrySteps() {
+		path := "step"
+		if len(planDef.Steps) > 0 {
+			path = fmt.Sprintf("steps[%d]", i)
+		}
+		if err := execution.ValidatePagination(step.Pagination); err != nil {
+			return BadRequest("invalid " + path + ".pagination: " + err.Error())
+		}
+		if nested := subStepPagination(step.SubSteps, path); nested != "" {
+			return BadRequest(nested + ".pagination is not supported: only top-level steps can be paginated")
+		}
+		if err := execution.ValidateStream(&step); err != nil {
+			return BadRequest("invalid " + path + ".stream: " + err.Error())
+		}
+		if nested := subStepStream(step.SubSteps, path); nested != "" {
+			return BadRequest(nested + ".stream is not supported: only top-level steps can be streamed")
+		}
+		if err := validateStepRequest(&step, path); err != nil {
+			return err
+		}
+	}
+	if err := execution.ValidateWatermark(planDef.Watermark); err != nil {
+		return BadRequest("invalid watermark: " + err.Error())
+	}
+	return nil
+}
+
+// decodePlanDefinition decodes a plan_definition into its typed form
+func decodePlanDefinition(definition map[string]any) (*models.PlanDefinition, error) {
+	data, err := json.Marshal(definition)
+	if err != nil {
+		return nil, BadRequest("invalid plan_definition")
+	}
+	var planDef models.PlanDefinition
+	if err := json.Unmarshal(data, &planDef); err != nil {
+		return nil, BadRequest("invalid plan_definition: " + err.Error())
+	}
+	return &planDef, nil
+}
+
+// subStepPagination returns the path of the first sub-step that declares pagination
+func subStepPagination(steps []models.Step, parent string) string {
+	for i := range steps {
+		path := fmt.Sprintf("%s.sub_steps[%d]", parent, i)
+		if steps[i].Pagination != nil {
+			return path
+		}
+		if nested := subStepPagination(steps[i].SubSteps, path); nested != "" {
+			return nested
+		}
+	}
+	return ""
+}
+
+// subStepStream returns the path of the first sub-step that declares a stream
+func subStepStream(steps []models.Step, parent string) string {
+	for i := range steps {
+		path := fmt.Sprintf("%s.sub_steps[%d]", parent, i)
+		if steps[i].Stream != nil {
+			return path
+		}
+		if nested := subStepStream(steps[i].SubSteps, path); nested != "" {
+			return nested
+		}
+	}
+	return ""
+}
+
+// validateStepRequest checks the request body and parse options of a step and its sub-steps
+func validateStepRequest(step *models.Step, path string) error {
+	if err := execution.ValidateBody(step); err != nil {
+		return BadRequest("invalid " + path + ".body: " + err.Error())
+	}
+	if err := execution.ValidateParse(step.Parse); err != nil {
+		return BadRequest("invalid " + path + ".parse: " + err.Error())
+	}
+	for i := range step.SubSteps {
+		if err := validateStepRequest(&step.SubSteps[i], fmt.Sprintf("%s.sub_steps[%d]", path, i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // ExecutionHandler handles plan execution API endpoints
 type ExecutionHandler struct {
-	repo   repositories.PlanExecutionRepo
-	logger ectologger.Logger
+	repo      repositories.PlanExecutionRepo
+	planRepo  repositories.PlanRepo
+	jobQueue  *redis.JobQueue
+	canceller *redis.ExecutionCanceller
+	archive   *archive.Archive          // Optional: nil when the response archive is disabled
+	publisher archive.ResponsePublisher // Where replayed responses are published
+	logger    ectologger.Logger
 }
 
 // NewExecutionHandler creates a new execution handler
-func NewExecutionHandler(repo repositories.PlanExecutionRepo, logger ectologger.Logger) *ExecutionHandler {
+func NewExecutionHandler(
+	repo repositories.PlanExecutionRepo,
+	planRepo repositories.PlanRepo,
+	jobQueue *redis.JobQueue,
+	canceller *redis.ExecutionCanceller,
+	archive *archive.Archive,
+	publisher archive.ResponsePublisher,
+	logger ectologger.Logger,
+) *ExecutionHandler {
 	return &ExecutionHandler{
-		repo:   repo,
-		logger: logger,
+		repo:      repo,
+		planRepo:  planRepo,
+		jobQueue:  jobQueue,
+		canceller: canceller,
+		archive:   archive,
+		publisher: publisher,
+		logger:    logger,
 	}
 }
 
@@ -345,6 +766,9 @@ func (h *ExecutionHandler) Register(g *echo.Group) {
 	g.GET("", h.List)
 	g.GET("/:id", h.GetByID)
 	g.GET("/:id/children", h.ListChildren)
+	g.POST("/:id/resume", h.Resume)
+	g.POST("/:id/cancel", h.Cancel)
+	g.POST("/:id/replay", h.Replay)
 }
 
 // List returns plan executions
@@ -416,6 +840,71 @@ func (h *ExecutionHandler) ListChildren(c echo.Context) error {
 	return SuccessResponse(c, children)
 }
 
+// Resume queues a new execution that continues a failed or aborted execution from its checkpoint
+func (h *ExecutionHandler) Resume(c echo.Context) error {
+	ctx, span := tracing.StartSpan(c.Request().Context(), "ExecutionHandler.Resume")
+	defer span.End()
+	c.SetRequest(c.Request().WithContext(ctx))
+
+	id, err := ParseUUID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	exec, err := h.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !exec.Resumable() {
+		return httperror.NewHTTPErrorf(http.StatusConflict, "execution %s cannot be resumed: only failed or aborted executions with a checkpoint can", id).
+			AddMetaValue("status", string(exec.Status))
+	}
+
+	plan, err := h.planRepo.GetByKey(ctx, exec.PlanKey)
+	if err != nil {
+		return err
+	}
+
+	// Claim the resume before queueing it, so concurrent requests resume the execution once
+	claimed, err := h.repo.ClaimResume(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return httperror.NewHTTPErrorf(http.StatusConflict, "execution %s cannot be resumed: it was already resumed", id)
+	}
+
+	job := queue.PlanExecutionJob{
+		TenantID:          exec.TenantID.String(),
+		Integration:       plan.Integration,
+		PlanKey:           exec.PlanKey,
+		ConfigID:          exec.ConfigID.String(),
+		ParentExecutionID: id.String(),
+		Resume:            true,
+	}
+
+	messageID, err := queue.PublishPlanExecution(ctx, h.jobQueue, redis.LaneRetry, job)
+	if err != nil {
+		h.logger.WithContext(ctx).WithError(err).Error("Failed to publish resume job")
+		if releaseErr := h.repo.ReleaseResume(context.WithoutCancel(ctx), id); releaseErr != nil {
+			h.logger.WithContext(ctx).WithError(releaseErr).Errorf("Failed to release resume of execution %s", id)
+		}
+		return QueueError(err)
+	}
+
+	h.logger.WithContext(ctx).Infof("Resuming execution %s after %d pages (message_id=%s)", id, exec.Checkpoint.Data.LoopCount, messageID)
+
+	return SuccessResponse(c, map[string]any{
+		"message_id":          messageID,
+		"parent_execution_id": id.String(),
+		"plan_key":            exec.PlanKey,
+		"config_id":           exec.ConfigID.String(),
+		"loop_count":          exec.Checkpoint.Data.LoopCount,
+		"status":              "queued",
+	})
+}
+
 // StatisticsHandler handles plan statistics API endpoints
 type StatisticsHandler struct {
 	repo   repositories.PlanStatisticsRepo
@@ -479,3 +968,104 @@ func (h *StatisticsHandler) GetByPlanAndConfig(c echo.Context) error {
 
 	return SuccessResponse(c, stats)
 }
+
+// Cancel signals the processor running an execution to stop it.
+// The execution completes asynchronously with the cancelled status.
+func (h *ExecutionHandler) Cancel(c echo.Context) error {
+	ctx, span := tracing.StartSpan(c.Request().Context(), "ExecutionHandler.Cancel")
+	defer span.End()
+	c.SetRequest(c.Request().WithContext(ctx))
+
+	id, err := ParseUUID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	exec, err := h.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !exec.Active() {
+		return httperror.NewHTTPErrorf(http.StatusConflict, "execution %s cannot be cancelled: it already completed", id).
+			AddMetaValue("status", string(exec.Status))
+	}
+
+	receivers, err := h.canceller.Cancel(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).WithError(err).Error("Failed to publish cancellation")
+		return httperror.WrapError(500, err)
+	}
+
+	h.logger.WithContext(ctx).Infof("Requested cancellation of execution %s (processors=%d)", id, receivers)
+
+	// Without a processor listening, nothing runs the execution: cancel its record directly
+	if receivers == 0 {
+		cancelled, err := h.repo.CancelActive(ctx, id, "execution cancelled")
+		if err != nil {
+			return err
+		}
+		if !cancelled {
+			return httperror.NewHTTPErrorf(http.StatusConflict, "execution %s cannot be cancelled: it already completed", id)
+		}
+		return SuccessResponse(c, map[string]string{
+			"execution_id": id.String(),
+			"status":       string(models.ExecutionStatusCancelled),
+		})
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]string{
+		"execution_id": id.String(),
+		"status":       "cancelling",
+	})
+}
+
+// Replay re-publishes the archived API responses of a completed execution to the response topic,
+// so downstream consumers can reprocess them without calling the upstream API again
+func (h *ExecutionHandler) Replay(c echo.Context) error {
+	ctx, span := tracing.StartSpan(c.Request().Context(), "ExecutionHandler.Replay")
+	defer span.End()
+	c.SetRequest(c.Request().WithContext(ctx))
+
+	if h.archive == nil || h.publisher == nil {
+		return httperror.NewHTTPError(http.StatusNotImplemented, "the response archive is not enabled")
+	}
+
+	id, err := ParseUUID(c, "id")
+	if err != nil {
+		return err
+	}
+
+	exec, err := h.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if exec.Active() {
+		return httperror.NewHTTPErrorf(http.StatusConflict, "execution %s cannot be replayed until it completes", id).
+			AddMetaValue("status", string(exec.Status))
+	}
+
+	replayed, err := h.archive.Replay(ctx, exec.TenantID.String(), exec.PlanKey, id.String(), h.publisher)
+	if err != nil {
+		h.logger.WithContext(ctx).WithError(err).Errorf("Failed to replay execution %s after %d responses", id, replayed)
+		return httperror.WrapError(500, err).AddMetaValue("replayed", fmt.Sprint(replayed))
+	}
+	if replayed == 0 {
+		return repositories.NotFound("no archived responses for execution %s", id)
+	}
+
+	return SuccessResponse(c, map[string]any{
+		"execution_id": id.String(),
+		"plan_key":     exec.PlanKey,
+		"replayed":     replayed,
+	})
+}
+
+// stringValue dereferences an optional string
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Gobusters/ectoerror/httperror"
+	"github.com/Gobusters/ectologger"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+	"github.com/Ramsey-B/orchid/pkg/ratelimit"
+	"github.com/Ramsey-B/orchid/pkg/redis"
+	"github.com/Ramsey-B/orchid/pkg/repositories"
+	"github.com/Ramsey-B/stem/pkg/database"
+	"github.com/Ramsey-B/stem/pkg/tracing"
+)
+
+// MaxRateLimitOverrideTTL bounds how long a bucket override lasts
+const MaxRateLimitOverrideTTL = 24 * time.Hour
+
+// RateLimitHandler handles rate limit bucket API requests
+type RateLimitHandler struct {
+	manager *ratelimit.Manager
+	logger  ectologger.Logger
+}
+
+// NewRateLimitHandler creates a new rate limit handler
+func NewRateLimitHandler(manager *ratelimit.Manager, logger ectologger.Logger) *RateLimitHandler {
+	return &RateLimitHandler{
+		manager: manager,
+		logger:  logger,
+	}
+}
+
+// OverrideRateLimitRequest represents the override rate limit bucket request body
+type OverrideRateLimitRequest struct {
+	Requests      int `json:"requests,omitempty"`       // Max requests per window
+	WindowSecs    int `json:"window_secs,omitempty"`    // Window size in seconds
+	MaxConcurrent int `json:"max_concurrent,omitempty"` // Max in-flight requests
+	TTLSeconds    int `json:"ttl_seconds"`              // How long the override lasts
+}
+
+// RegisterRoutes registers the rate limit routes
+func (h *RateLimitHandler) RegisterRoutes(g *echo.Group) {
+	rl := g.Group("/rate-limits")
+	rl.GET("", h.List)
+	rl.GET("/:id", h.Get)
+	rl.POST("/:id/reset", h.Reset)
+	rl.PUT("/:id/override", h.Override)
+	rl.DELETE("/:id/override", h.ClearOverride)
+}
+
+// List returns the rate limit buckets of the tenant
+// GET /api/v1/rate-limits?integration_id=
+func (h *RateLimitHandler) List(c echo.Context) error {
+	ctx, span := tracing.StartSpan(c.Request().Context(), "RateLimitHandler.List")
+	defer span.End()
+	c.SetRequest(c.Request().WithContext(ctx))
+
+	tenantID, err := GetTenantID(c)
+	if err != nil {
+		return err
+	}
+
+	var integrationID *uuid.UUID
+	if param := c.QueryParam("integration_id"); param != "" {
+		id, err := uuid.Parse(param)
+		if err != nil {
+			return BadRequest("invalid integration_id")
+		}
+		integrationID = &id
+	}
+
+	buckets, err := h.manager.Buckets(ctx, tenantID, integrationID)
+	if err != nil {
+		h.logger.WithContext(ctx).WithError(err).Error("Failed to list rate limit buckets")
+		return httperror.WrapError(500, err)
+	}
+
+	return SuccessResponse(c, map[string]any{
+		"buckets": buckets,
+		"count":   len(buckets),
+	})
+}
+
+// Get returns a rate limit bucket
+// GET /api/v1/rate-limits/:id
+func (h *RateLimitHandler) Get(c echo.Context) error {
+	ctx, span := tracing.StartSpan(c.Request().Context(), "RateLimitHandler.Get")
+	defer span.End()
+	c.SetRequest(c.Request().WithContext(ctx))
+
+	tenantID, err := GetTenantID(c)
+	if err != nil {
+		return err
+	}
+
+	bucket, err := h.manager.Bucket(ctx, tenantID, c.Param("id"))
+	if err != nil {
+		return h.bucketError(c, err)
+	}
+	return SuccessResponse(c, bucket)
+}
+
+// Reset empties the window of a rate limit bucket, lifts its dynamic block and forgets its learned rate
+// POST /api/v1/rate-limits/:id/reset
+func (h *RateLimitHandler) Reset(c echo.Context) error {
+	ctx, span := tracing.StartSpan(c.Request().Context(), "RateLimitHandler.Reset")
+	defer span.End()
+	c.SetRequest(c.Request().WithContext(ctx))
+
+	tenantID, err := GetTenantID(c)
+	if err != nil {
+		return err
+	}
+
+	id := c.Param("id")
+	if err := h.manager.ResetBucket(ctx, tenantID, id); err != nil {
+		return h.bucketError(c, err)
+	}
+
+	bucket, err := h.manager.Bucket(ctx, tenantID, id)
+	if err != nil {
+		return h.bucketError(c, err)
+	}
+	return SuccessResponse(c, bucket)
+}
+
+// Override temporarily replaces the limits of a rate limit bucket
+// PUT /api/v1/rate-limits/:id/override
+func (h *RateLimitHandler) Override(c echo.Context) error {
+	ctx, span := tracing.StartSpan(c.Request().Context(), "RateLimitHandler.Override")
+	defer span.End()
+	c.SetRequest(c.Request().WithContext(ctx))
+
+	tenantID, err := GetTenantID(c)
+	if err != nil {
+		return err
+	}
+
+	var req OverrideRateLimitRequest
+	if err := c.Bind(&req); err != nil {
+		return BadRequest("invalid request body")
+	}
+	if req.Requests < 0 || req.WindowSecs < 0 || req.MaxConcurrent < 0 {
+		return BadRequest("requests, window_secs and max_concurrent must not be negative")
+	}
+	if req.Requests == 0 && req.WindowSecs == 0 && req.MaxConcurrent == 0 {
+		return BadRequest("at least one of requests, window_secs or max_concurrent is required")
+	}
+	ttl := time.Duration(req.TTLSeconds) * time.Second
+	if ttl <= 0 || ttl > MaxRateLimitOverrideTTL {
+		return BadRequest("ttl_seconds must be between 1 and 86400")
+	}
+
+	bucket, err := h.manager.OverrideBucket(ctx, tenantID, c.Param("id"), redis.RateLimitOverride{
+		Requests:      req.Requests,
+		WindowSecs:    req.WindowSecs,
+		MaxConcurrent: req.MaxConcurrent,
+	}, ttl)
+	if err != nil {
+		return h.bucketError(c, err)
+	}
+	return SuccessResponse(c, bucket)
+}
+
+// ClearOverride restores the configured limits of a rate limit bucket
+// DELETE /api/v1/rate-limits/:id/override
+func (h *RateLimitHandler) ClearOverride(c echo.Context) error {
+	ctx, span := tracing.StartSpan(c.Request().Context(), "RateLimitHandler.ClearOverride")
+	defer span.End()
+	c.SetRequest(c.Request().WithContext(ctx))
+
+	tenantID, err := GetTenantID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.manager.ClearOverride(ctx, tenantID, c.Param("id")); err != nil {
+		return h.bucketError(c, err)
+	}
+	return NoContentResponse(c)
+}
+
+// bucketError converts an error of a bucket operation
+func (h *RateLimitHandler) bucketError(c echo.Context, err error) error {
+	if errors.Is(err, ratelimit.ErrBucketNotFound) {
+		return repositories.NotFound("rate limit bucket %s not found", c.Param("id"))
+	}
+	h.logger.WithContext(c.Request().Context()).WithError(err).Error("Rate limit bucket operation failed")
+	return httperror.WrapError(500, err)
+}
+
+// validateRateLimits checks the shared rate limits of an integration or config
+func validateRateLimits(limits []models.RateLimitConfig) error {
+	if err := ratelimit.ValidateLimits(limits); err != nil {
+		return BadRequest(err.Error())
+	}
+	return nil
+}
+
+// rateLimitsJSONB stores rate limits, with none stored as an empty list
+func rateLimitsJSONB(limits []models.RateLimitConfig) database.JSONB[[]models.RateLimitConfig] {
+	if limits == nil {
+		limits = []models.RateLimitConfig{}
+	}
+	return database.JSONB[[]models.RateLimitConfig]{Data: limits}
+}
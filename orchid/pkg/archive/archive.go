@@ -0,0 +1,259 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Gobusters/ectologger"
+
+	"github.com/Ramsey-B/orchid/pkg/kafka"
+	"github.com/Ramsey-B/orchid/pkg/metrics"
+	"github.com/Ramsey-B/stem/pkg/tracing"
+)
+
+const (
+	// DefaultSegmentSize is how many responses are written to one archive object
+	DefaultSegmentSize = 500
+
+	// segmentIdleTimeout is how long an open segment waits for its execution to complete before it is written anyway
+	segmentIdleTimeout = 10 * time.Minute
+
+	// segmentExt is the extension of archive objects: gzip-compressed newline-delimited JSON
+	segmentExt = ".ndjson.gz"
+)
+
+// ResponsePublisher publishes API response messages (*kafka.Producer satisfies it)
+type ResponsePublisher interface {
+	Publish(ctx context.Context, msg *kafka.APIResponseMessage) error
+}
+
+// Archive keeps the API responses an execution published so they can be replayed later.
+// Responses are buffered per execution and written as compressed segments under
+// <tenant>/<plan>/<execution>/ when a segment fills up or the execution completes.
+type Archive struct {
+	store       Store
+	segmentSize int
+	logger      ectologger.Logger
+
+	mu   sync.Mutex
+	open map[string]*segment // Segments being filled, by execution prefix
+	seq  atomic.Uint64       // Tie-breaker for segments started at the same instant
+}
+
+// segment is a batch of responses of an execution not yet written to the store
+type segment struct {
+	prefix    string
+	startedAt time.Time
+	updatedAt time.Time
+	messages  []*kafka.APIResponseMessage
+}
+
+// New creates an archive writing to store
+func New(store Store, segmentSize int, logger ectologger.Logger) *Archive {
+	if segmentSize <= 0 {
+		segmentSize = DefaultSegmentSize
+	}
+	return &Archive{
+		store:       store,
+		segmentSize: segmentSize,
+		logger:      logger,
+		open:        make(map[string]*segment),
+	}
+}
+
+// ExecutionPrefix returns the key prefix of the archived responses of an execution
+func ExecutionPrefix(tenantID, planKey, executionID string) string {
+	return url.PathEscape(tenantID) + "/" + url.PathEscape(planKey) + "/" + url.PathEscape(executionID) + "/"
+}
+
+// Append adds a response to the open segment of its execution, writing the segment once it is full
+func (a *Archive) Append(ctx context.Context, msg *kafka.APIResponseMessage) error {
+	now := time.Now()
+	prefix := ExecutionPrefix(msg.TenantID, msg.PlanKey, msg.ExecutionID)
+
+	a.mu.Lock()
+	seg, ok := a.open[prefix]
+	if !ok {
+		seg = &segment{prefix: prefix, startedAt: now}
+		a.open[prefix] = seg
+	}
+	seg.messages = append(seg.messages, msg)
+	seg.updatedAt = now
+
+	var ready []*segment
+	if len(seg.messages) >= a.segmentSize {
+		ready = append(ready, seg)
+		delete(a.open, prefix)
+	}
+	// Executions that never completed (e.g. their process died) must not hold their responses forever
+	for key, idle := range a.open {
+		if now.Sub(idle.updatedAt) > segmentIdleTimeout {
+			ready = append(ready, idle)
+			delete(a.open, key)
+		}
+	}
+	a.mu.Unlock()
+
+	return a.write(ctx, ready...)
+}
+
+// Flush writes the open segment of an execution
+func (a *Archive) Flush(ctx context.Context, tenantID, planKey, executionID string) error {
+	prefix := ExecutionPrefix(tenantID, planKey, executionID)
+
+	a.mu.Lock()
+	seg, ok := a.open[prefix]
+	delete(a.open, prefix)
+	a.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	return a.write(ctx, seg)
+}
+
+// Close writes every open segment
+func (a *Archive) Close(ctx context.Context) error {
+	a.mu.Lock()
+	ready := make([]*segment, 0, len(a.open))
+	for _, seg := range a.open {
+		ready = append(ready, seg)
+	}
+	a.open = make(map[string]*segment)
+	a.mu.Unlock()
+
+	return a.write(ctx, ready...)
+}
+
+// write stores segments, continuing past failures and returning the first
+func (a *Archive) write(ctx context.Context, segments ...*segment) error {
+	var firstErr error
+	for _, seg := range segments {
+		if err := a.writeSegment(ctx, seg); err != nil {
+			metrics.RecordArchiveSegment("failed")
+			a.logger.WithContext(ctx).WithError(err).Errorf("Failed to archive %d responses under %s", len(seg.messages), seg.prefix)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		metrics.RecordArchiveSegment("success")
+	}
+	return firstErr
+}
+
+func (a *Archive) writeSegment(ctx context.Context, seg *segment) error {
+	ctx, span := tracing.StartSpan(ctx, "Archive.writeSegment")
+	defer span.End()
+
+	data, err := encodeSegment(seg.messages)
+	if err != nil {
+		return err
+	}
+
+	// Keys sort by the time the segment started, so a resumed execution's segments follow the earlier ones
+	key := fmt.Sprintf("%s%020d-%06d%s", seg.prefix, seg.startedAt.UnixNano(), a.seq.Add(1)%1000000, segmentExt)
+	return a.store.Put(ctx, key, data)
+}
+
+// Messages returns the archived responses of an execution in the order they were published
+func (a *Archive) Messages(ctx context.Context, tenantID, planKey, executionID string) ([]*kafka.APIResponseMessage, error) {
+	var messages []*kafka.APIResponseMessage
+	err := a.each(ctx, tenantID, planKey, executionID, func(msg *kafka.APIResponseMessage) error {
+		messages = append(messages, msg)
+		return nil
+	})
+	return messages, err
+}
+
+// Replay re-publishes the archived responses of an execution, returning how many were published
+func (a *Archive) Replay(ctx context.Context, tenantID, planKey, executionID string, pub ResponsePublisher) (int, error) {
+	ctx, span := tracing.StartSpan(ctx, "Archive.Replay")
+	defer span.End()
+
+	replayed := 0
+	err := a.each(ctx, tenantID, planKey, executionID, func(msg *kafka.APIResponseMessage) error {
+		msg.Replayed = true
+		if err := pub.Publish(ctx, msg); err != nil {
+			return fmt.Errorf("failed to replay response %d: %w", replayed+1, err)
+		}
+		replayed++
+		return nil
+	})
+
+	a.logger.WithContext(ctx).Infof("Replayed %d archived responses of execution %s", replayed, executionID)
+	return replayed, err
+}
+
+// each calls fn for every archived response of an execution, one segment at a time
+func (a *Archive) each(ctx context.Context, tenantID, planKey, executionID string, fn func(msg *kafka.APIResponseMessage) error) error {
+	keys, err := a.store.List(ctx, ExecutionPrefix(tenantID, planKey, executionID))
+	if err != nil {
+		return fmt.Errorf("failed to list archived responses: %w", err)
+	}
+
+	for _, key := range keys {
+		if !strings.HasSuffix(key, segmentExt) {
+			continue
+		}
+		data, err := a.store.Get(ctx, key)
+		if err != nil {
+			return fmt.Errorf("failed to read archived responses %s: %w", key, err)
+		}
+		messages, err := decodeSegment(data)
+		if err != nil {
+			return fmt.Errorf("failed to decode archived responses %s: %w", key, err)
+		}
+		for _, msg := range messages {
+			if err := fn(msg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// encodeSegment writes responses as gzip-compressed newline-delimited JSON
+func encodeSegment(messages []*kafka.APIResponseMessage) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(zw)
+	for _, msg := range messages {
+		if err := enc.Encode(msg); err != nil {
+			return nil, fmt.Errorf("failed to encode archived response: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeSegment reads the responses of an archive object
+func decodeSegment(data []byte) ([]*kafka.APIResponseMessage, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var messages []*kafka.APIResponseMessage
+	dec := json.NewDecoder(bufio.NewReader(zr))
+	for dec.More() {
+		var msg kafka.APIResponseMessage
+		if err := dec.Decode(&msg); err != nil {
+			return nil, err
+		}
+		messages = append(messages, &msg)
+	}
+	return messages, nil
+}
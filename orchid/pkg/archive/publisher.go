@@ -0,0 +1,45 @@
+package archive
+
+import (
+	"context"
+
+	"github.com/Ramsey-B/orchid/pkg/kafka"
+)
+
+// Publisher receives the messages an execution emits (the shape of execution.Publisher)
+type Publisher interface {
+	Publish(ctx context.Context, msg *kafka.APIResponseMessage) error
+	PublishError(ctx context.Context, msg *kafka.APIResponseMessage) error
+	PublishExecutionEvent(ctx context.Context, evt *kafka.ExecutionEventMessage) error
+}
+
+// archivingPublisher archives the responses next published successfully
+type archivingPublisher struct {
+	Publisher
+	archive *Archive
+}
+
+// Wrap returns a publisher that archives the responses next publishes,
+// writing an execution's remaining responses when it completes.
+// Archiving is best-effort: failures are logged and never fail the publish.
+func (a *Archive) Wrap(next Publisher) Publisher {
+	return &archivingPublisher{Publisher: next, archive: a}
+}
+
+// Publish publishes a response, then archives it
+func (p *archivingPublisher) Publish(ctx context.Context, msg *kafka.APIResponseMessage) error {
+	if err := p.Publisher.Publish(ctx, msg); err != nil {
+		return err
+	}
+	_ = p.archive.Append(ctx, msg)
+	return nil
+}
+
+// PublishExecutionEvent publishes a lifecycle event, writing the archive of a completed execution
+func (p *archivingPublisher) PublishExecutionEvent(ctx context.Context, evt *kafka.ExecutionEventMessage) error {
+	err := p.Publisher.PublishExecutionEvent(ctx, evt)
+	if evt.Type == "execution.completed" {
+		_ = p.archive.Flush(ctx, evt.TenantID, evt.PlanKey, evt.ExecutionID)
+	}
+	return err
+}
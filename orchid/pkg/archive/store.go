@@ -0,0 +1,148 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// BackendFilesystem stores archived responses under a local directory
+	BackendFilesystem = "filesystem"
+	// BackendS3 stores archived responses in an S3-compatible bucket
+	BackendS3 = "s3"
+)
+
+// ErrNotFound is returned when an archived object does not exist
+var ErrNotFound = errors.New("archived object not found")
+
+// Store persists archive objects by key. Keys are slash-separated paths.
+type Store interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	// List returns the keys under a prefix in lexical order
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// StoreConfig selects and configures the store of the archive
+type StoreConfig struct {
+	Backend string // filesystem or s3 (empty disables the archive)
+	Path    string // Root directory of the filesystem backend
+	S3      S3Config
+}
+
+// NewStore creates the store of a config, or nil when the archive is disabled
+func NewStore(cfg StoreConfig) (Store, error) {
+	switch cfg.Backend {
+	case "":
+		return nil, nil
+	case BackendFilesystem:
+		return NewFileStore(cfg.Path)
+	case BackendS3:
+		return NewS3Store(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown archive backend %q", cfg.Backend)
+	}
+}
+
+// FileStore stores archive objects as files under a root directory
+type FileStore struct {
+	root string
+}
+
+// NewFileStore creates a file store, creating its root directory if needed
+func NewFileStore(root string) (*FileStore, error) {
+	if root == "" {
+		return nil, errors.New("archive path is required")
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	return &FileStore{root: filepath.Clean(root)}, nil
+}
+
+// Put writes an object, replacing it atomically if it exists
+func (s *FileStore) Put(ctx context.Context, key string, data []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get reads an object
+func (s *FileStore) Get(ctx context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return data, err
+}
+
+// List returns the keys of the objects under a prefix
+func (s *FileStore) List(ctx context.Context, prefix string) ([]string, error) {
+	dir, err := s.path(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".tmp-") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.root, path)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, filepath.ToSlash(rel))
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// path returns the file of a key, refusing keys that escape the root
+func (s *FileStore) path(key string) (string, error) {
+	path := filepath.Join(s.root, filepath.FromSlash(key))
+	if path != s.root && !strings.HasPrefix(path, s.root+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid archive key %q", key)
+	}
+	return path, nil
+}
@@ -15,9 +15,15 @@ import (
 
 	"github.com/Ramsey-B/orchid/pkg/execution"
 	"github.com/Ramsey-B/orchid/pkg/expressions"
+	"github.com/Ramsey-B/orchid/pkg/httpclient"
+	"github.com/Ramsey-B/orchid/pkg/metrics"
 	"github.com/Ramsey-B/orchid/pkg/models"
+	"github.com/Ramsey-B/orchid/pkg/ratelimit"
 	"github.com/Ramsey-B/orchid/pkg/redis"
 	"github.com/Ramsey-B/orchid/pkg/repositories"
+	"github.com/Ramsey-B/orchid/pkg/secrets"
+	"github.com/Ramsey-B/orchid/pkg/signing"
+	"github.com/Ramsey-B/stem/pkg/database"
 	"github.com/Ramsey-B/stem/pkg/tracing"
 )
 
@@ -47,6 +53,9 @@ const (
 
 	// CacheKeyPrefix is the prefix for auth token cache keys
 	CacheKeyPrefix = "auth:token:"
+
+	// PreviewCacheKeyPrefix is the prefix for the tokens plan previews obtain, kept apart from the executions' tokens
+	PreviewCacheKeyPrefix = "auth:preview-token:"
 )
 
 // CachedToken represents a cached authentication token
@@ -81,27 +90,43 @@ func (t *CachedToken) ToAuthContext() *execution.AuthContext {
 
 // Manager handles authentication token management
 type Manager struct {
-	authFlowRepo repositories.AuthFlowRepo
-	redisClient  *redis.Client
-	stepExecutor *execution.StepExecutor
-	evaluator    *expressions.Evaluator
-	logger       ectologger.Logger
+	authFlowRepo     repositories.AuthFlowRepo
+	integrationRepo  repositories.IntegrationRepo
+	refreshTokenRepo repositories.AuthRefreshTokenRepo
+	redisClient      tokenCache
+	stepExecutor     *execution.StepExecutor
+	evaluator        *expressions.Evaluator
+	cipher           *secrets.Cipher
+	logger           ectologger.Logger
+}
+
+// tokenCache caches the obtained tokens (a *redis.Client)
+type tokenCache interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	Del(ctx context.Context, keys ...string) error
 }
 
 // NewManager creates a new auth manager
 func NewManager(
 	authFlowRepo repositories.AuthFlowRepo,
+	integrationRepo repositories.IntegrationRepo,
+	refreshTokenRepo repositories.AuthRefreshTokenRepo,
 	redisClient *redis.Client,
 	stepExecutor *execution.StepExecutor,
 	evaluator *expressions.Evaluator,
+	cipher *secrets.Cipher,
 	logger ectologger.Logger,
 ) *Manager {
 	return &Manager{
-		authFlowRepo: authFlowRepo,
-		redisClient:  redisClient,
-		stepExecutor: stepExecutor,
-		evaluator:    evaluator,
-		logger:       logger,
+		authFlowRepo:     authFlowRepo,
+		integrationRepo:  integrationRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		redisClient:      redisClient,
+		stepExecutor:     stepExecutor,
+		evaluator:        evaluator,
+		cipher:           cipher,
+		logger:           logger,
 	}
 }
 
@@ -112,6 +137,8 @@ func (m *Manager) GetAuthContext(
 	tenantID uuid.UUID,
 	configID uuid.UUID,
 	config map[string]any,
+	rateLimits []models.RateLimitConfig,
+	transport *httpclient.TransportSettings,
 ) (*execution.AuthContext, error) {
 	ctx, span := tracing.StartSpan(ctx, "AuthManager.GetAuthContext")
 	defer span.End()
@@ -125,8 +152,54 @@ func (m *Manager) GetAuthContext(
 		return nil, fmt.Errorf("failed to load auth flow: %w", err)
 	}
 
+	// Secret config fields are only decrypted here and in the plan executor.
+	// Values that are already plaintext pass through unchanged.
+	config, plaintexts, err := m.cipher.DecryptValues(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt config values: %w", err)
+	}
+
+	// The plan executor passes values it already decrypted, so the secrets to mask come from the schema
+	integration, err := m.integrationRepo.GetByID(ctx, authFlow.IntegrationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load integration: %w", err)
+	}
+	secretValues := secrets.SecretValues(config, secrets.SecretPaths(integration.ConfigSchema.Data))
+	plaintexts = append(plaintexts, secretValues...)
+
+	// Auth requests count against the integration and config level rate limits the plans share
+	opts := &execution.ExecuteOptions{
+		TenantID:      tenantID,
+		IntegrationID: authFlow.IntegrationID,
+		ConfigID:      configID,
+		Credential:    ratelimit.CredentialKey(tenantID.String(), authFlowID.String(), secretValues), // The same buckets as the plans using the flow
+		RateLimits:    rateLimits,
+		MaxRateWait:   60 * time.Second,
+		Masker:        secrets.NewMasker(plaintexts...),
+		Transport:     transport, // Token endpoints behind mTLS or a proxy use the config's transport too
+	}
+
+	// The signer signs either the plan's requests or the flow's own token requests
+	var requestSigner signing.Signer
+	if signerConfig := authFlow.Signer.Data; signerConfig != nil {
+		signer, err := signing.New(signerConfig, config, m.evaluator)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request signer: %w", err)
+		}
+		if signerConfig.AppliesTo(models.SignerApplyToTokenRequest) {
+			opts.Signer = signer
+		} else {
+			requestSigner = signer
+		}
+	}
+
+	// Flows that only sign requests (e.g. AWS SigV4) have no token to obtain
+	if len(authFlow.PlanDefinition.Data) == 0 && requestSigner != nil {
+		return &execution.AuthContext{ReauthOn: authFlow.ReauthOn.Data, Signer: requestSigner}, nil
+	}
+
 	// Try to get cached token
-	cacheKey := m.cacheKey(tenantID, authFlowID, configID)
+	cacheKey := m.cacheKey(ctx, tenantID, authFlowID, configID)
 	cachedToken, err := m.getCachedToken(ctx, cacheKey)
 	if err == nil {
 		// Backwards/forwards-compat: ensure cached token has the expected auth header populated.
@@ -172,26 +245,63 @@ func (m *Manager) GetAuthContext(
 					m.logger.WithContext(ctx).WithError(cacheErr).Warn("Failed to update cached auth token headers")
 				}
 			}
-			return cachedToken.ToAuthContext(), nil
+			return m.toAuthContext(authFlow, cachedToken, requestSigner), nil
 		}
 
 		m.logger.WithContext(ctx).Debugf("Cached token expired, refreshing for flow %s", authFlowID)
 	}
 
+	// Prefer the refresh-token grant over full re-authentication when the flow defines one.
+	// Providers such as Salesforce and Microsoft Graph heavily rate-limit password/client-credential grants.
+	if len(authFlow.RefreshDefinition.Data) > 0 {
+		previousRefreshToken := ""
+		if cachedToken != nil {
+			previousRefreshToken = cachedToken.RefreshToken
+		}
+		// A preview exchanging the persisted refresh token could rotate it away from the executions
+		if previousRefreshToken == "" && !execution.IsPreview(ctx) {
+			previousRefreshToken = m.getStoredRefreshToken(ctx, authFlowID, configID)
+		}
+
+		if previousRefreshToken != "" {
+			m.logger.WithContext(ctx).Infof("Exchanging refresh token for auth flow %s", authFlowID)
+			newToken, err := m.executeRefreshFlow(ctx, authFlow, config, previousRefreshToken, opts)
+			if err == nil {
+				metrics.RecordAuthTokenRefresh(tenantID.String(), "refreshed")
+				m.storeToken(ctx, cacheKey, authFlow, configID, newToken, previousRefreshToken)
+				return m.toAuthContext(authFlow, newToken, requestSigner), nil
+			}
+
+			metrics.RecordAuthTokenRefresh(tenantID.String(), "refresh_failed")
+			m.logger.WithContext(ctx).WithError(err).Warnf("Refresh token grant failed for auth flow %s, falling back to full authentication", authFlowID)
+
+			// A rejected refresh token (e.g. invalid_grant) is never exchanged again; transport errors keep it
+			if errors.Is(err, ErrTokenExtractionFailed) {
+				m.deleteRefreshToken(ctx, authFlowID, configID)
+			}
+		}
+	}
+
 	// Execute auth flow to get new token
 	m.logger.WithContext(ctx).Infof("Executing auth flow %s to obtain token", authFlowID)
-	newToken, err := m.executeAuthFlow(ctx, authFlow, config)
+	newToken, err := m.executeAuthFlow(ctx, authFlow, config, opts)
 	if err != nil {
+		metrics.RecordAuthTokenRefresh(tenantID.String(), "failed")
 		return nil, fmt.Errorf("auth flow execution failed: %w", err)
 	}
+	metrics.RecordAuthTokenRefresh(tenantID.String(), "authenticated")
 
-	// Cache the token
-	ttl := m.calculateTTL(authFlow, newToken)
-	if err := m.cacheToken(ctx, cacheKey, newToken, ttl); err != nil {
-		m.logger.WithContext(ctx).WithError(err).Warn("Failed to cache auth token")
-	}
+	m.storeToken(ctx, cacheKey, authFlow, configID, newToken, "")
 
-	return newToken.ToAuthContext(), nil
+	return m.toAuthContext(authFlow, newToken, requestSigner), nil
+}
+
+// toAuthContext converts a token to an AuthContext carrying the auth flow's reauth_on statuses and request signer
+func (m *Manager) toAuthContext(authFlow *models.AuthFlow, token *CachedToken, signer signing.Signer) *execution.AuthContext {
+	authCtx := token.ToAuthContext()
+	authCtx.ReauthOn = authFlow.ReauthOn.Data
+	authCtx.Signer = signer
+	return authCtx
 }
 
 // InvalidateToken removes a cached token
@@ -199,12 +309,12 @@ func (m *Manager) InvalidateToken(ctx context.Context, tenantID, authFlowID, con
 	ctx, span := tracing.StartSpan(ctx, "AuthManager.InvalidateToken")
 	defer span.End()
 
-	cacheKey := m.cacheKey(tenantID, authFlowID, configID)
+	cacheKey := m.cacheKey(ctx, tenantID, authFlowID, configID)
 	return m.redisClient.Del(ctx, cacheKey)
 }
 
 // executeAuthFlow executes an auth flow to obtain a token
-func (m *Manager) executeAuthFlow(ctx context.Context, authFlow *models.AuthFlow, config map[string]any) (*CachedToken, error) {
+func (m *Manager) executeAuthFlow(ctx context.Context, authFlow *models.AuthFlow, config map[string]any, opts *execution.ExecuteOptions) (*CachedToken, error) {
 	ctx, span := tracing.StartSpan(ctx, "AuthManager.executeAuthFlow")
 	defer span.End()
 
@@ -213,7 +323,47 @@ func (m *Manager) executeAuthFlow(ctx context.Context, authFlow *models.AuthFlow
 		return nil, errors.New("auth flow plan definition is empty")
 	}
 
-	stepData, err := json.Marshal(authFlow.PlanDefinition.Data)
+	step, err := parseAuthStep(authFlow.PlanDefinition.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build execution context with config
+	execCtx := execution.NewExecutionContext().WithConfig(config)
+
+	return m.runAuthStep(ctx, authFlow, step, execCtx, opts)
+}
+
+// executeRefreshFlow exchanges a refresh token for a new access token using the auth flow's refresh step.
+// The refresh token is exposed to the step as auth.refresh_token.
+func (m *Manager) executeRefreshFlow(ctx context.Context, authFlow *models.AuthFlow, config map[string]any, refreshToken string, opts *execution.ExecuteOptions) (*CachedToken, error) {
+	ctx, span := tracing.StartSpan(ctx, "AuthManager.executeRefreshFlow")
+	defer span.End()
+
+	step, err := parseAuthStep(authFlow.RefreshDefinition.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	execCtx := execution.NewExecutionContext().
+		WithConfig(config).
+		WithAuth(&execution.AuthContext{RefreshToken: refreshToken})
+
+	token, err := m.runAuthStep(ctx, authFlow, step, execCtx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Providers that do not rotate refresh tokens omit them from the refresh response
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	return token, nil
+}
+
+// parseAuthStep decodes an auth flow step definition
+func parseAuthStep(definition map[string]any) (*models.Step, error) {
+	stepData, err := json.Marshal(definition)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal auth flow definition: %w", err)
 	}
@@ -222,12 +372,13 @@ func (m *Manager) executeAuthFlow(ctx context.Context, authFlow *models.AuthFlow
 	if err := json.Unmarshal(stepData, &step); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal auth flow step: %w", err)
 	}
+	return &step, nil
+}
 
-	// Build execution context with config
-	execCtx := execution.NewExecutionContext().WithConfig(config)
-
+// runAuthStep executes an auth or refresh step and extracts the token from its response
+func (m *Manager) runAuthStep(ctx context.Context, authFlow *models.AuthFlow, step *models.Step, execCtx *execution.ExecutionContext, opts *execution.ExecuteOptions) (*CachedToken, error) {
 	// Execute the auth step
-	result, err := m.stepExecutor.Execute(ctx, &step, execCtx)
+	result, err := m.stepExecutor.ExecuteWithOptions(ctx, step, execCtx, opts)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrAuthFlowExecutionFailed, err)
 	}
@@ -295,6 +446,87 @@ func (m *Manager) executeAuthFlow(ctx context.Context, authFlow *models.AuthFlow
 	return cachedToken, nil
 }
 
+// storeToken caches a token and persists its refresh token when the provider issued a new one
+func (m *Manager) storeToken(ctx context.Context, cacheKey string, authFlow *models.AuthFlow, configID uuid.UUID, token *CachedToken, previousRefreshToken string) {
+	ttl := m.calculateTTL(authFlow, token)
+	if err := m.cacheToken(ctx, cacheKey, token, ttl); err != nil {
+		m.logger.WithContext(ctx).WithError(err).Warn("Failed to cache auth token")
+	}
+
+	if m.refreshTokenRepo == nil || token.RefreshToken == "" || token.RefreshToken == previousRefreshToken {
+		return
+	}
+
+	// Preview tokens only live in the preview cache
+	if execution.IsPreview(ctx) {
+		return
+	}
+
+	// The cached entry expires with the access token, so the refresh token is persisted separately
+	m.persistRefreshToken(ctx, authFlow.ID, configID, token.RefreshToken)
+}
+
+// persistRefreshToken stores a refresh token encrypted; it is never stored in plaintext
+func (m *Manager) persistRefreshToken(ctx context.Context, authFlowID, configID uuid.UUID, refreshToken string) {
+	encrypted, err := m.cipher.EncryptValue(ctx, refreshToken)
+	if err != nil {
+		m.logger.WithContext(ctx).WithError(err).Warnf("Failed to encrypt refresh token for auth flow %s; it is not persisted", authFlowID)
+		return
+	}
+
+	if err := m.refreshTokenRepo.Upsert(ctx, &models.AuthRefreshToken{
+		AuthFlowID:   authFlowID,
+		ConfigID:     configID,
+		RefreshToken: database.JSONB[any]{Data: encrypted},
+	}); err != nil {
+		m.logger.WithContext(ctx).WithError(err).Warnf("Failed to persist refresh token for auth flow %s", authFlowID)
+	}
+}
+
+// deleteRefreshToken removes the persisted refresh token of an auth flow/config combination
+func (m *Manager) deleteRefreshToken(ctx context.Context, authFlowID, configID uuid.UUID) {
+	// Previews never exchange the persisted refresh token
+	if m.refreshTokenRepo == nil || execution.IsPreview(ctx) {
+		return
+	}
+
+	if err := m.refreshTokenRepo.Delete(ctx, authFlowID, configID); err != nil {
+		m.logger.WithContext(ctx).WithError(err).Warnf("Failed to delete refresh token for auth flow %s", authFlowID)
+	}
+}
+
+// getStoredRefreshToken loads and decrypts the persisted refresh token, returning "" if there is none
+func (m *Manager) getStoredRefreshToken(ctx context.Context, authFlowID, configID uuid.UUID) string {
+	if m.refreshTokenRepo == nil {
+		return ""
+	}
+
+	stored, err := m.refreshTokenRepo.Get(ctx, authFlowID, configID)
+	if err != nil {
+		if !httperror.IsHTTPError(err) || httperror.GetStatusCode(err) != http.StatusNotFound {
+			m.logger.WithContext(ctx).WithError(err).Warnf("Failed to load refresh token for auth flow %s", authFlowID)
+		}
+		return ""
+	}
+
+	switch value := stored.RefreshToken.Data.(type) {
+	case map[string]any:
+		plain, err := m.cipher.DecryptValue(ctx, value)
+		if err != nil {
+			m.logger.WithContext(ctx).WithError(err).Warnf("Failed to decrypt refresh token for auth flow %s", authFlowID)
+			return ""
+		}
+		refreshToken, _ := plain.(string)
+		return refreshToken
+	case string:
+		// Tokens persisted before they were encrypted are encrypted on first use
+		m.persistRefreshToken(ctx, authFlowID, configID, value)
+		return value
+	default:
+		return ""
+	}
+}
+
 // getCachedToken retrieves a token from Redis cache
 func (m *Manager) getCachedToken(ctx context.Context, key string) (*CachedToken, error) {
 	data, err := m.redisClient.Get(ctx, key)
@@ -344,8 +576,12 @@ func (m *Manager) calculateTTL(authFlow *models.AuthFlow, token *CachedToken) ti
 	return time.Duration(DefaultTTLSeconds) * time.Second
 }
 
-// cacheKey generates a cache key for auth tokens
-func (m *Manager) cacheKey(tenantID, authFlowID, configID uuid.UUID) string {
-	return fmt.Sprintf("%s%s:%s:%s", CacheKeyPrefix, tenantID, authFlowID, configID)
+// cacheKey generates a cache key for auth tokens (previews use their own keys)
+func (m *Manager) cacheKey(ctx context.Context, tenantID, authFlowID, configID uuid.UUID) string {
+	prefix := CacheKeyPrefix
+	if execution.IsPreview(ctx) {
+		prefix = PreviewCacheKeyPrefix
+	}
+	return fmt.Sprintf("%s%s:%s:%s", prefix, tenantID, authFlowID, configID)
 }
 
@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Gobusters/ectoerror/httperror"
+	"github.com/Gobusters/ectologger/zapadapter"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/Ramsey-B/orchid/pkg/execution"
+	"github.com/Ramsey-B/orchid/pkg/expressions"
+	"github.com/Ramsey-B/orchid/pkg/httpclient"
+	"github.com/Ramsey-B/orchid/pkg/models"
+	"github.com/Ramsey-B/orchid/pkg/repositories"
+	"github.com/Ramsey-B/orchid/pkg/secrets"
+	"github.com/Ramsey-B/stem/pkg/database"
+)
+
+// fakeCache keeps the cached tokens in memory
+type fakeCache struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func (f *fakeCache) Get(ctx context.Context, key string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	value, ok := f.values[key]
+	if !ok {
+		return "", ErrTokenNotFound
+	}
+	return value, nil
+}
+
+func (f *fakeCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.values[key] = value.(string)
+	return nil
+}
+
+func (f *fakeCache) Del(ctx context.Context, keys ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, key := range keys {
+		delete(f.values, key)
+	}
+	return nil
+}
+
+type fakeAuthFlows struct {
+	repositories.AuthFlowRepo
+	flow *models.AuthFlow
+}
+
+func (f *fakeAuthFlows) GetByID(ctx context.Context, id uuid.UUID) (*models.AuthFlow, error) {
+	return f.flow, nil
+}
+
+type fakeIntegrations struct {
+	repositories.IntegrationRepo
+}
+
+func (f *fakeIntegrations) GetByID(ctx context.Context, id uuid.UUID) (*models.Integration, error) {
+	return &models.Integration{ID: id}, nil
+}
+
+// fakeRefreshTokens holds the persisted refresh token of a single auth flow/config
+type fakeRefreshTokens struct {
+	token   *models.AuthRefreshToken
+	deleted bool
+}
+
+func (f *fakeRefreshTokens) Upsert(ctx context.Context, token *models.AuthRefreshToken) error {
+	f.token = token
+	return nil
+}
+
+func (f *fakeRefreshTokens) Get(ctx context.Context, authFlowID, configID uuid.UUID) (*models.AuthRefreshToken, error) {
+	if f.token == nil {
+		return nil, httperror.NewHTTPError(http.StatusNotFound, "auth refresh token not found")
+	}
+	return f.token, nil
+}
+
+func (f *fakeRefreshTokens) Delete(ctx context.Context, authFlowID, configID uuid.UUID) error {
+	f.token = nil
+	f.deleted = true
+	return nil
+}
+
+func TestManager_GetAuthContext_RefreshTokenGrant(t *testing.T) {
+	tests := []struct {
+		name        string
+		cached      string // Refresh token of the expired cached token, none without
+		stored      string // Persisted refresh token
+		accepted    string // Refresh token the provider accepts
+		rotated     string // Refresh token the provider issues on refresh
+		wantToken   string
+		wantGrants  []string
+		wantStored  string
+		wantDeleted bool
+	}{
+		{name: "refresh succeeds", cached: "r1", stored: "r1", accepted: "r1",
+			wantToken: "refreshed", wantGrants: []string{"refresh_token"}, wantStored: "r1"},
+		{name: "rejected refresh runs the full flow", cached: "r1", stored: "r1",
+			wantToken: "full", wantGrants: []string{"refresh_token", "password"}, wantStored: "r-full", wantDeleted: true},
+		{name: "rotated refresh token is persisted", cached: "r1", stored: "r1", accepted: "r1", rotated: "r2",
+			wantToken: "refreshed", wantGrants: []string{"refresh_token"}, wantStored: "r2"},
+		{name: "persisted refresh token used after a cache miss", stored: "r1", accepted: "r1",
+			wantToken: "refreshed", wantGrants: []string{"refresh_token"}, wantStored: "r1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			var grants []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				query := r.URL.Query()
+				grants = append(grants, query.Get("grant_type"))
+				body := map[string]any{"access_token": "full", "refresh_token": "r-full"}
+				if query.Get("grant_type") == "refresh_token" {
+					if query.Get("refresh_token") != tt.accepted {
+						w.WriteHeader(http.StatusBadRequest)
+						_ = json.NewEncoder(w).Encode(map[string]any{"error": "invalid_grant"})
+						return
+					}
+					body = map[string]any{"access_token": "refreshed"}
+					if tt.rotated != "" {
+						body["refresh_token"] = tt.rotated
+					}
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(body)
+			}))
+			defer server.Close()
+
+			zapLogger, _ := zap.NewDevelopment()
+			logger := zapadapter.NewZapEctoLogger(zapLogger, nil)
+			provider, err := secrets.NewLocalKeyProviderFromKeys(bytes.Repeat([]byte{1}, 32))
+			require.NoError(t, err)
+			evaluator := expressions.NewEvaluator()
+
+			headerFormat, refreshPath := "Bearer {token}", "response.body.refresh_token"
+			flow := &models.AuthFlow{
+				ID:                uuid.New(),
+				IntegrationID:     uuid.New(),
+				PlanDefinition:    database.JSONB[map[string]any]{Data: map[string]any{"url": server.URL + "/token?grant_type=password"}},
+				RefreshDefinition: database.JSONB[map[string]any]{Data: map[string]any{"url": server.URL + "/token?grant_type=refresh_token&refresh_token={{ auth.refresh_token }}"}},
+				TokenPath:         "response.body.access_token",
+				HeaderName:        "Authorization",
+				HeaderFormat:      &headerFormat,
+				RefreshPath:       &refreshPath,
+			}
+			refreshTokens := &fakeRefreshTokens{}
+			cache := &fakeCache{values: map[string]string{}}
+			m := &Manager{
+				authFlowRepo:     &fakeAuthFlows{flow: flow},
+				integrationRepo:  &fakeIntegrations{},
+				refreshTokenRepo: refreshTokens,
+				redisClient:      cache,
+				stepExecutor:     execution.NewStepExecutor(httpclient.NewClient(httpclient.DefaultConfig(), logger), evaluator, nil, logger),
+				evaluator:        evaluator,
+				cipher:           secrets.NewCipher(provider),
+				logger:           logger,
+			}
+
+			tenantID, configID := uuid.New(), uuid.New()
+			m.persistRefreshToken(ctx, flow.ID, configID, tt.stored)
+			if tt.cached != "" {
+				expired := &CachedToken{Token: "expired", RefreshToken: tt.cached, ExpiresAt: time.Now().Add(-time.Minute).Unix()}
+				require.NoError(t, m.cacheToken(ctx, m.cacheKey(ctx, tenantID, flow.ID, configID), expired, time.Hour))
+			}
+
+			authCtx, err := m.GetAuthContext(ctx, flow.ID, tenantID, configID, map[string]any{}, nil, nil)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantToken, authCtx.Token)
+			require.Equal(t, "Bearer "+tt.wantToken, authCtx.Headers["Authorization"])
+			require.Equal(t, tt.wantGrants, grants)
+			require.Equal(t, tt.wantDeleted, refreshTokens.deleted)
+			require.Equal(t, tt.wantStored, m.getStoredRefreshToken(ctx, flow.ID, configID))
+
+			cached, err := m.getCachedToken(ctx, m.cacheKey(ctx, tenantID, flow.ID, configID))
+			require.NoError(t, err)
+			require.Equal(t, tt.wantToken, cached.Token)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package execution
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+)
+
+// ValidateBody checks that the body of a step suits its body_type
+func ValidateBody(step *models.Step) error {
+	_, isString := step.Body.(string)
+	_, isObject := step.Body.(map[string]any)
+
+	switch step.BodyType {
+	case "", models.BodyTypeJSON:
+	case models.BodyTypeForm:
+		if step.Body != nil && !isString && !isObject {
+			return errors.New("a form body must be an object or an encoded string")
+		}
+	case models.BodyTypeMultipart:
+		if step.Body != nil && !isObject {
+			return errors.New("a multipart body must be an object")
+		}
+	case models.BodyTypeRaw:
+		if step.Body != nil && !isString {
+			return errors.New("a raw body must be a string")
+		}
+	case models.BodyTypeGraphQL:
+		query := step.Body
+		if isObject {
+			query = step.Body.(map[string]any)["query"]
+		}
+		if q, ok := query.(string); !ok || q == "" {
+			return errors.New("a graphql body must be a query string or an object with a query")
+		}
+	default:
+		return fmt.Errorf("unknown body_type %q (want json, form, multipart, raw or graphql)", step.BodyType)
+	}
+
+	if step.Pagination != nil && step.Pagination.CursorVariable != "" && step.BodyType != models.BodyTypeGraphQL {
+		return errors.New("pagination cursor_variable requires the graphql body_type")
+	}
+	return nil
+}
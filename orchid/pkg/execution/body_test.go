@@ -0,0 +1,184 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ramsey-B/orchid/pkg/expressions"
+	"github.com/Ramsey-B/orchid/pkg/httpclient"
+	"github.com/Ramsey-B/orchid/pkg/models"
+)
+
+// capturedRequest is what a test server received
+type capturedRequest struct {
+	contentType string
+	body        []byte
+	form        map[string][]string
+	files       map[string]string
+}
+
+// runBodyStep executes a step against a server that records the request and answers with response
+func runBodyStep(t *testing.T, step *models.Step, execCtx *ExecutionContext, response string) (*StepResult, *capturedRequest, error) {
+	captured := &capturedRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		captured.contentType = r.Header.Get("Content-Type")
+		switch step.BodyType {
+		case models.BodyTypeForm:
+			require.NoError(t, r.ParseForm())
+			captured.form = r.PostForm
+		case models.BodyTypeMultipart:
+			require.NoError(t, r.ParseMultipartForm(1<<20))
+			captured.form = r.MultipartForm.Value
+			captured.files = map[string]string{}
+			for name, headers := range r.MultipartForm.File {
+				f, err := headers[0].Open()
+				require.NoError(t, err)
+				data, _ := io.ReadAll(f)
+				captured.files[name] = headers[0].Filename + ":" + headers[0].Header.Get("Content-Type") + ":" + string(data)
+			}
+		default:
+			captured.body, _ = io.ReadAll(r.Body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(response))
+	}))
+	defer server.Close()
+
+	step.URL = server.URL
+	step.Method = http.MethodPost
+	if execCtx == nil {
+		execCtx = NewExecutionContext()
+	}
+	result, err := newTestExecutor().stepExecutor.Execute(context.Background(), step, execCtx)
+	return result, captured, err
+}
+
+func TestBody_FormEncodesNestedValues(t *testing.T) {
+	execCtx := NewExecutionContext().WithConfig(map[string]any{"client_id": "abc"})
+	_, captured, err := runBodyStep(t, &models.Step{
+		BodyType: models.BodyTypeForm,
+		Body: map[string]any{
+			"grant_type": "client_credentials",
+			"client_id":  "{{ config.client_id }}",
+			"scope":      []any{"read", "write"},
+			"metadata":   map[string]any{"source": "orchid"},
+		},
+	}, execCtx, `{}`)
+	require.NoError(t, err)
+
+	require.Equal(t, "application/x-www-form-urlencoded", captured.contentType)
+	require.Equal(t, []string{"abc"}, captured.form["client_id"])
+	require.Equal(t, []string{"read", "write"}, captured.form["scope"])
+	require.Equal(t, []string{"orchid"}, captured.form["metadata[source]"])
+}
+
+func TestBody_MultipartWithFile(t *testing.T) {
+	_, captured, err := runBodyStep(t, &models.Step{
+		BodyType: models.BodyTypeMultipart,
+		Headers:  map[string]string{"Content-Type": "multipart/form-data"},
+		Body: map[string]any{
+			"purpose": "import",
+			"file": map[string]any{
+				"filename":     "users.csv",
+				"content":      "aWQKMQo=",
+				"encoding":     "base64",
+				"content_type": "text/csv",
+			},
+		},
+	}, nil, `{}`)
+	require.NoError(t, err)
+
+	require.Contains(t, captured.contentType, "boundary=")
+	require.Equal(t, []string{"import"}, captured.form["purpose"])
+	require.Equal(t, "users.csv:text/csv:id\n1\n", captured.files["file"])
+}
+
+func TestBody_RawIsSentAsIs(t *testing.T) {
+	_, captured, err := runBodyStep(t, &models.Step{
+		BodyType: models.BodyTypeRaw,
+		Headers:  map[string]string{"Content-Type": "text/plain"},
+		Body:     "hello {{ 'world' }}",
+	}, nil, `{}`)
+	require.NoError(t, err)
+
+	require.Equal(t, "text/plain", captured.contentType)
+	require.Equal(t, "hello world", string(captured.body))
+}
+
+func TestBody_GraphQLKeepsQueryAndVariableTypes(t *testing.T) {
+	execCtx := NewExecutionContext().WithConfig(map[string]any{"page_size": float64(50)})
+	_, captured, err := runBodyStep(t, &models.Step{
+		BodyType: models.BodyTypeGraphQL,
+		Body: map[string]any{
+			"query":          "query Users($first: Int!) {users(first: $first) {nodes {id}}}",
+			"variables":      map[string]any{"first": "{{ config.page_size }}"},
+			"operation_name": "Users",
+		},
+	}, execCtx, `{"data":{"users":{"nodes":[]}}}`)
+	require.NoError(t, err)
+
+	var request map[string]any
+	require.NoError(t, json.Unmarshal(captured.body, &request))
+	require.Equal(t, "application/json", captured.contentType)
+	require.Equal(t, "query Users($first: Int!) {users(first: $first) {nodes {id}}}", request["query"])
+	require.Equal(t, map[string]any{"first": float64(50)}, request["variables"])
+	require.Equal(t, "Users", request["operationName"])
+}
+
+func TestBody_GraphQLErrorsFailTheStep(t *testing.T) {
+	result, _, err := runBodyStep(t, &models.Step{
+		BodyType: models.BodyTypeGraphQL,
+		Body:     "{ viewer { id } }",
+	}, nil, `{"data":null,"errors":[{"message":"Field 'viewer' is not allowed"}]}`)
+
+	var gqlErr *httpclient.GraphQLError
+	require.ErrorAs(t, err, &gqlErr)
+	require.Equal(t, []string{"Field 'viewer' is not allowed"}, gqlErr.Messages)
+	require.Equal(t, err, result.Error)
+}
+
+func TestPaginator_GraphQLCursorVariable(t *testing.T) {
+	step := &models.Step{
+		BodyType: models.BodyTypeGraphQL,
+		Body:     map[string]any{"query": "query($after: String) {users(after: $after) {pageInfo {endCursor hasNextPage}}}"},
+	}
+	pager := newPaginator(&models.Pagination{
+		Type:           models.PaginationCursor,
+		CursorVariable: "after",
+		CursorPath:     "response.body.data.users.pageInfo.endCursor",
+		HasMorePath:    "response.body.data.users.pageInfo.hasNextPage",
+	}, expressions.NewEvaluator(), nil)
+
+	result, data := pageResult("", nil, map[string]any{"data": map[string]any{"users": map[string]any{
+		"pageInfo": map[string]any{"endCursor": "c1", "hasNextPage": true},
+	}}})
+	outcome, err := pager.advance(result, data)
+	require.NoError(t, err)
+	require.Equal(t, pageNext, outcome)
+
+	next := pager.apply(step)
+	require.Empty(t, next.Params)
+	require.Equal(t, map[string]any{"after": "c1"}, next.Body.(map[string]any)["variables"])
+	// The step's own body is not modified
+	require.NotContains(t, step.Body.(map[string]any), "variables")
+}
+
+func TestValidateBody(t *testing.T) {
+	require.NoError(t, ValidateBody(&models.Step{Body: map[string]any{"a": 1}}))
+	require.NoError(t, ValidateBody(&models.Step{BodyType: models.BodyTypeForm, Body: "a=1"}))
+	require.NoError(t, ValidateBody(&models.Step{BodyType: models.BodyTypeGraphQL, Body: "{ viewer { id } }",
+		Pagination: &models.Pagination{Type: models.PaginationCursor, CursorVariable: "after", CursorPath: "x"}}))
+
+	require.Error(t, ValidateBody(&models.Step{BodyType: "xml"}))
+	require.Error(t, ValidateBody(&models.Step{BodyType: models.BodyTypeRaw, Body: map[string]any{}}))
+	require.Error(t, ValidateBody(&models.Step{BodyType: models.BodyTypeMultipart, Body: "a=1"}))
+	require.Error(t, ValidateBody(&models.Step{BodyType: models.BodyTypeGraphQL, Body: map[string]any{"variables": map[string]any{}}}))
+	require.Error(t, ValidateBody(&models.Step{Body: map[string]any{},
+		Pagination: &models.Pagination{Type: models.PaginationCursor, CursorVariable: "after", CursorPath: "x"}}))
+}
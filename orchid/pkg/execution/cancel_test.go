@@ -0,0 +1,65 @@
+package execution
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+)
+
+func TestPlanExecutor_CancelStopsRunningSteps(t *testing.T) {
+	requested := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(requested)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	executor := newTestExecutor()
+
+	executionID := uuid.New()
+	require.False(t, executor.Cancel(executionID))
+
+	runCtx, cancelRun := context.WithCancelCause(context.Background())
+	defer cancelRun(nil)
+	executor.running.Store(executionID, cancelRun)
+	defer executor.running.Delete(executionID)
+
+	go func() {
+		<-requested
+		require.True(t, executor.Cancel(executionID))
+	}()
+
+	execCtx := NewExecutionContext()
+	execCtx.WithMeta(&ExecutionMeta{StepPath: "root"})
+	steps := []models.Step{{ID: "users", URL: server.URL + "/users"}}
+	_, err := executor.executeSteps(runCtx, steps, execCtx, 1, 1, PlanExecutionInput{}, &PlanExecutionOutput{}, nil)
+	require.Error(t, err)
+	require.ErrorIs(t, context.Cause(runCtx), ErrExecutionCancelled)
+}
+
+func TestStepExecutor_CancelInterruptsRetryDelay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	stepExecutor := newTestExecutor().stepExecutor
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	execCtx := NewExecutionContext()
+	step := &models.Step{ID: "users", URL: server.URL + "/users", Retry: &models.RetryConfig{MaxRetries: 3}}
+	start := time.Now()
+	_, err := stepExecutor.Execute(ctx, step, execCtx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), 5*time.Second)
+}
@@ -0,0 +1,186 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Gobusters/ectologger"
+	"github.com/google/uuid"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+	"github.com/Ramsey-B/orchid/pkg/repositories"
+)
+
+// FanoutCheckpointInterval is the number of completed fanout items between checkpoints of a page in flight
+const FanoutCheckpointInterval = 25
+
+// checkpointer saves the loop state of an execution after every page,
+// so a failed or aborted execution can be resumed where it stopped.
+// A nil checkpointer (previews) saves nothing.
+type checkpointer struct {
+	repo        repositories.PlanExecutionRepo
+	logger      ectologger.Logger
+	executionID uuid.UUID
+	state       models.ExecutionCheckpoint
+	unsaved     int // Fanout items completed since the last save
+}
+
+// newCheckpointer creates a checkpointer starting from state (the resumed execution's checkpoint when resuming)
+func newCheckpointer(repo repositories.PlanExecutionRepo, logger ectologger.Logger, executionID uuid.UUID, state models.ExecutionCheckpoint) *checkpointer {
+	return &checkpointer{repo: repo, logger: logger, executionID: executionID, state: state}
+}
+
+// loopCount returns the number of pages completed before this execution
+func (c *checkpointer) loopCount() int {
+	if c == nil {
+		return 0
+	}
+	return c.state.LoopCount
+}
+
+// pagination returns the pagination position of the execution being resumed (nil when there is none)
+func (c *checkpointer) pagination() map[string]any {
+	if c == nil {
+		return nil
+	}
+	return c.state.Pagination
+}
+
+// pageCompleted checkpoints the state the next page starts from
+func (c *checkpointer) pageCompleted(ctx context.Context, loopCount int, result *StepResult, execCtx *ExecutionContext, pagination map[string]any, watermark string) {
+	if c == nil {
+		return
+	}
+	c.state.LoopCount = loopCount
+	c.state.Pagination = pagination
+	c.state.Watermark = watermark
+	c.state.Prev = nil
+	if result != nil && result.Response != nil {
+		c.state.Prev = &models.CheckpointResponse{
+			StatusCode: result.Response.StatusCode,
+			Headers:    result.Response.Headers,
+			Body:       result.Response.BodyJSON,
+		}
+	}
+	// Later pages mutate the context in place, so the checkpoint keeps a copy
+	c.state.Context = copyContext(execCtx.Context)
+	c.state.Fanout = nil
+	c.save(ctx)
+}
+
+// fanoutProgress skips the items of the current page a previous execution completed
+// and checkpoints newly completed items every FanoutCheckpointInterval items
+func (c *checkpointer) fanoutProgress(ctx context.Context) *FanoutProgress {
+	if c == nil {
+		return nil
+	}
+	return &FanoutProgress{
+		Completed: func(total int) map[int]bool {
+			if c.state.Fanout == nil || c.state.Fanout.Total != total {
+				// A page that now returns a different number of items is fanned out from scratch
+				if c.state.Fanout != nil {
+					c.logger.WithContext(ctx).Warnf("Discarding fanout checkpoint: page has %d items, checkpoint has %d",
+						total, c.state.Fanout.Total)
+				}
+				c.state.Fanout = &models.FanoutCheckpoint{Total: total, Items: make(map[int]models.FanoutItemCheckpoint)}
+				return nil
+			}
+			completed := make(map[int]bool, len(c.state.Fanout.Items))
+			for index := range c.state.Fanout.Items {
+				completed[index] = true
+			}
+			return completed
+		},
+		OnItem: func(index int, result *StepResult) {
+			if result == nil || result.Context == nil {
+				return
+			}
+			item := models.FanoutItemCheckpoint{Payload: buildEnrichedFanoutPayload(result.Context)}
+			if result.Context.Context != nil {
+				// Items that abort the page are executed again on resume
+				if v, ok := result.Context.Context["fanout_policy_abort"].(bool); ok && v {
+					return
+				}
+				if v, ok := result.Context.Context["fanout_policy_error"].(bool); ok && v {
+					item.PolicyError = true
+				}
+			}
+			c.state.Fanout.Items[index] = item
+			c.unsaved++
+			if c.unsaved >= FanoutCheckpointInterval {
+				c.save(ctx)
+			}
+		},
+	}
+}
+
+// completedItem returns a fanout item of the current page completed by a previous execution
+func (c *checkpointer) completedItem(index int) (models.FanoutItemCheckpoint, bool) {
+	if c == nil || c.state.Fanout == nil {
+		return models.FanoutItemCheckpoint{}, false
+	}
+	item, ok := c.state.Fanout.Items[index]
+	return item, ok
+}
+
+// flush saves fanout progress that has not been saved yet
+func (c *checkpointer) flush(ctx context.Context) {
+	if c == nil || c.unsaved == 0 {
+		return
+	}
+	c.save(ctx)
+}
+
+// save stores the checkpoint on the execution record (best-effort).
+// It also runs after a timeout, so the write is not tied to the execution deadline.
+func (c *checkpointer) save(ctx context.Context) {
+	if c == nil {
+		return
+	}
+	c.unsaved = 0
+	if err := c.repo.SaveCheckpoint(context.WithoutCancel(ctx), c.executionID, c.state); err != nil {
+		c.logger.WithContext(ctx).WithError(err).Warnf("Failed to save checkpoint of execution %s", c.executionID)
+	}
+}
+
+// loadCheckpoint returns the checkpoint of the execution a resumed execution continues
+func (e *PlanExecutor) loadCheckpoint(ctx context.Context, input PlanExecutionInput) (models.ExecutionCheckpoint, error) {
+	if input.ParentExecutionID == nil {
+		return models.ExecutionCheckpoint{}, fmt.Errorf("%w: no parent execution", ErrExecutionNotResumable)
+	}
+
+	parent, err := e.executionRepo.GetByID(ctx, *input.ParentExecutionID)
+	if err != nil {
+		if isNotFound(err) {
+			return models.ExecutionCheckpoint{}, fmt.Errorf("%w: execution %s not found", ErrExecutionNotResumable, *input.ParentExecutionID)
+		}
+		return models.ExecutionCheckpoint{}, fmt.Errorf("failed to load parent execution: %w", err)
+	}
+	if parent.PlanKey != input.PlanKey || parent.ConfigID != input.ConfigID {
+		return models.ExecutionCheckpoint{}, fmt.Errorf("%w: execution %s belongs to another plan or config", ErrExecutionNotResumable, parent.ID)
+	}
+	if parent.Status != models.ExecutionStatusFailed && parent.Status != models.ExecutionStatusAborted {
+		return models.ExecutionCheckpoint{}, fmt.Errorf("%w: execution %s is %s", ErrExecutionNotResumable, parent.ID, parent.Status)
+	}
+	if !parent.Resumable() {
+		return models.ExecutionCheckpoint{}, fmt.Errorf("%w: execution %s has no checkpoint", ErrExecutionNotResumable, parent.ID)
+	}
+
+	e.logger.WithContext(ctx).Infof("Resuming execution %s after %d completed pages", parent.ID, parent.Checkpoint.Data.LoopCount)
+	return parent.Checkpoint.Data, nil
+}
+
+// copyContext deep-copies a plan context through JSON
+func copyContext(values map[string]any) map[string]any {
+	if values == nil {
+		return nil
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil
+	}
+	var clone map[string]any
+	_ = json.Unmarshal(data, &clone)
+	return clone
+}
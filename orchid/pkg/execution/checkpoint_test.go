@@ -0,0 +1,77 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ramsey-B/orchid/pkg/httpclient"
+	"github.com/Ramsey-B/orchid/pkg/models"
+	"github.com/Ramsey-B/orchid/pkg/repositories"
+)
+
+type checkpointRepo struct {
+	repositories.PlanExecutionRepo
+	saved []models.ExecutionCheckpoint
+}
+
+func (r *checkpointRepo) SaveCheckpoint(_ context.Context, _ uuid.UUID, checkpoint models.ExecutionCheckpoint) error {
+	data, _ := json.Marshal(checkpoint)
+	var stored models.ExecutionCheckpoint
+	_ = json.Unmarshal(data, &stored)
+	r.saved = append(r.saved, stored)
+	return nil
+}
+
+func TestCheckpointer_ResumesFanoutItems(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"path": r.URL.Path})
+	}))
+	defer server.Close()
+
+	executor := newTestExecutor()
+
+	step := &models.Step{
+		IterateOver: "context.ids",
+		SubSteps:    []models.Step{{ID: "detail", URL: server.URL + "/items/{{item}}"}},
+	}
+	execCtx := NewExecutionContext()
+	execCtx.Context["ids"] = []any{"a", "b", "c"}
+
+	// A page completed and the next one was interrupted after its first item
+	repo := &checkpointRepo{}
+	checkpoints := newCheckpointer(repo, executor.logger, uuid.New(), models.ExecutionCheckpoint{})
+	checkpoints.pageCompleted(context.Background(), 1, &StepResult{Response: &httpclient.Response{StatusCode: 200, BodyJSON: map[string]any{"page": 1}}}, execCtx, nil, "")
+	checkpoints.state.Fanout = &models.FanoutCheckpoint{Total: 3, Items: map[int]models.FanoutItemCheckpoint{
+		0: {Payload: map[string]any{"user": "a", "detail": "cached"}},
+	}}
+
+	result, err := executor.fanoutExecutor.ExecuteWithProgress(context.Background(), step, execCtx, 0, nil, checkpoints.fanoutProgress(context.Background()))
+	require.NoError(t, err)
+	checkpoints.flush(context.Background())
+
+	require.Equal(t, 1, result.SkippedItems)
+	require.Equal(t, int32(2), calls.Load())
+	require.Nil(t, result.Results[0])
+
+	done, ok := checkpoints.completedItem(0)
+	require.True(t, ok)
+	require.Equal(t, "cached", done.Payload["detail"])
+
+	last := repo.saved[len(repo.saved)-1]
+	require.Equal(t, 1, last.LoopCount)
+	require.Equal(t, 200, last.Prev.StatusCode)
+	require.Equal(t, []any{"a", "b", "c"}, last.Context["ids"])
+	require.Len(t, last.Fanout.Items, 3)
+	require.Equal(t, map[string]any{"path": "/items/c"}, last.Fanout.Items[2].Payload["detail"])
+	require.False(t, last.IsZero())
+}
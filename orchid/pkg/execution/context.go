@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/Ramsey-B/orchid/pkg/httpclient"
+	"github.com/Ramsey-B/orchid/pkg/signing"
 )
 
 const (
@@ -29,6 +30,9 @@ type ExecutionContext struct {
 	// Persistent context (stored between executions)
 	Context map[string]any `json:"context,omitempty"`
 
+	// Final responses of completed top-level steps, by step ID
+	Steps map[string]*ResponseContext `json:"steps,omitempty"`
+
 	// Config values for the current config
 	Config map[string]any `json:"config,omitempty"`
 
@@ -58,7 +62,9 @@ type AuthContext struct {
 	TokenType    string            `json:"token_type,omitempty"`
 	ExpiresAt    int64             `json:"expires_at,omitempty"`
 	RefreshToken string            `json:"refresh_token,omitempty"`
-	Headers      map[string]string `json:"headers,omitempty"` // Pre-formatted auth headers
+	Headers      map[string]string `json:"headers,omitempty"`   // Pre-formatted auth headers
+	ReauthOn     []int             `json:"reauth_on,omitempty"` // Statuses that trigger re-authentication (from the auth flow)
+	Signer       signing.Signer    `json:"-"`                   // Signs every request of the plan (from the auth flow), passed on in ExecuteOptions
 }
 
 // ExecutionMeta holds execution metadata
@@ -71,6 +77,7 @@ type ExecutionMeta struct {
 	LoopCount    int    `json:"loop_count,omitempty"`
 	RetryCount   int    `json:"retry_count,omitempty"`
 	NestingLevel int    `json:"nesting_level,omitempty"`
+	Watermark    string `json:"watermark,omitempty"` // Committed watermark the execution syncs from
 }
 
 // NewExecutionContext creates a new execution context
@@ -102,6 +109,19 @@ func (c *ExecutionContext) WithParent(parent *ResponseContext) *ExecutionContext
 	return c
 }
 
+// WithStepOutput records the response of a top-level step under its ID
+func (c *ExecutionContext) WithStepOutput(id string, resp *httpclient.Response) *ExecutionContext {
+	if c.Steps == nil {
+		c.Steps = make(map[string]*ResponseContext)
+	}
+	c.Steps[id] = &ResponseContext{
+		StatusCode: resp.StatusCode,
+		Headers:    resp.Headers,
+		Body:       resp.BodyJSON,
+	}
+	return c
+}
+
 // WithConfig sets the config values
 func (c *ExecutionContext) WithConfig(config map[string]any) *ExecutionContext {
 	c.Config = config
@@ -197,6 +217,18 @@ func (c *ExecutionContext) ToMap() map[string]any {
 		result["context"] = c.Context
 	}
 
+	if c.Steps != nil {
+		steps := make(map[string]any, len(c.Steps))
+		for id, step := range c.Steps {
+			steps[id] = map[string]any{
+				"status_code": step.StatusCode,
+				"headers":     toAnyMap(step.Headers),
+				"body":        step.Body,
+			}
+		}
+		result["steps"] = steps
+	}
+
 	if c.Config != nil {
 		result["config"] = c.Config
 	}
@@ -226,6 +258,7 @@ func (c *ExecutionContext) ToMap() map[string]any {
 			"loop_count":    c.Meta.LoopCount,
 			"retry_count":   c.Meta.RetryCount,
 			"nesting_level": c.Meta.NestingLevel,
+			"watermark":     c.Meta.Watermark,
 		}
 	}
 
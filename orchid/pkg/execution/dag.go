@@ -0,0 +1,237 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+)
+
+// ValidateSteps checks the top-level steps of a DAG plan: unique IDs, known dependencies and no cycles
+func ValidateSteps(planDef *models.PlanDefinition) error {
+	if len(planDef.Steps) == 0 {
+		if len(planDef.Step.DependsOn) > 0 {
+			return errors.New("step.depends_on is only supported on the steps of a DAG plan")
+		}
+		return nil
+	}
+	if planDef.Step.URL != "" {
+		return errors.New("a plan defines either step or steps, not both")
+	}
+	if planDef.MaxParallelSteps < 0 {
+		return errors.New("max_parallel_steps must not be negative")
+	}
+	if planDef.Watermark != nil {
+		return errors.New("watermarks are only supported on single-step plans")
+	}
+
+	index := make(map[string]int, len(planDef.Steps))
+	for i, step := range planDef.Steps {
+		if step.ID == "" {
+			return fmt.Errorf("steps[%d] requires an id", i)
+		}
+		if _, dup := index[step.ID]; dup {
+			return fmt.Errorf("duplicate step id %q", step.ID)
+		}
+		index[step.ID] = i
+	}
+
+	authFlowID := planDef.AuthFlowID()
+	for _, step := range planDef.Steps {
+		if step.AuthFlowID != "" && step.AuthFlowID != authFlowID {
+			return fmt.Errorf("step %q uses auth flow %s, but the plan already uses %s", step.ID, step.AuthFlowID, authFlowID)
+		}
+		for _, dep := range step.DependsOn {
+			if dep == step.ID {
+				return fmt.Errorf("step %q depends on itself", step.ID)
+			}
+			if _, ok := index[dep]; !ok {
+				return fmt.Errorf("step %q depends on unknown step %q", step.ID, dep)
+			}
+		}
+	}
+
+	if _, err := stepOrder(planDef.Steps); err != nil {
+		return err
+	}
+	return nil
+}
+
+// stepOrder returns the steps in dependency order, failing when they form a cycle
+func stepOrder(steps []models.Step) ([]int, error) {
+	index := make(map[string]int, len(steps))
+	for i, step := range steps {
+		index[step.ID] = i
+	}
+
+	pending := make([]int, len(steps))
+	dependents := make([][]int, len(steps))
+	for i, step := range steps {
+		for _, dep := range step.DependsOn {
+			pending[i]++
+			dependents[index[dep]] = append(dependents[index[dep]], i)
+		}
+	}
+
+	order := make([]int, 0, len(steps))
+	for i := range steps {
+		if pending[i] == 0 {
+			order = append(order, i)
+		}
+	}
+	for next := 0; next < len(order); next++ {
+		for _, d := range dependents[order[next]] {
+			pending[d]--
+			if pending[d] == 0 {
+				order = append(order, d)
+			}
+		}
+	}
+
+	if len(order) < len(steps) {
+		var cycle []string
+		for i, n := range pending {
+			if n > 0 {
+				cycle = append(cycle, steps[i].ID)
+			}
+		}
+		sort.Strings(cycle)
+		return nil, fmt.Errorf("steps form a dependency cycle: %s", strings.Join(cycle, ", "))
+	}
+	return order, nil
+}
+
+// stepOutcome is the result of a DAG step
+type stepOutcome struct {
+	index    int
+	apiCalls int
+	stepCtx  *ExecutionContext
+	before   map[string]any // Plan context the step started from
+	err      error
+}
+
+// executeSteps runs the top-level steps of a DAG plan. Each step starts once its dependencies completed,
+// with at most maxParallel steps in flight. Steps run on their own copy of the execution context;
+// their set_context writes and their output (steps.<id>) are merged back when they complete.
+// The first failing step cancels the others.
+func (e *PlanExecutor) executeSteps(
+	ctx context.Context,
+	steps []models.Step,
+	execCtx *ExecutionContext,
+	maxLoops int,
+	maxParallel int,
+	input PlanExecutionInput,
+	output *PlanExecutionOutput,
+	execOpts *ExecuteOptions,
+) (int, error) {
+	if _, err := stepOrder(steps); err != nil {
+		return 0, err
+	}
+	if maxParallel <= 0 {
+		maxParallel = models.DefaultMaxParallelSteps
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	index := make(map[string]int, len(steps))
+	for i, step := range steps {
+		index[step.ID] = i
+	}
+	pending := make([]int, len(steps))
+	dependents := make([][]int, len(steps))
+	ready := make([]int, 0, len(steps))
+	for i, step := range steps {
+		pending[i] = len(step.DependsOn)
+		for _, dep := range step.DependsOn {
+			dependents[index[dep]] = append(dependents[index[dep]], i)
+		}
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	outcomes := make(chan stepOutcome, len(steps))
+	running := 0
+	totalAPICalls := 0
+	var firstErr error
+
+	for {
+		for firstErr == nil && running < maxParallel && len(ready) > 0 {
+			i := ready[0]
+			ready = ready[1:]
+			running++
+
+			// Each step gets its own copy, so concurrent steps don't share loop state
+			stepCtx := execCtx.Clone()
+			if stepCtx.Context == nil {
+				stepCtx.Context = make(map[string]any)
+			}
+			if stepCtx.Meta != nil {
+				stepCtx.Meta.StepPath = steps[i].ID
+			}
+			before := copyContext(execCtx.Context)
+
+			go func(i int, stepCtx *ExecutionContext, before map[string]any) {
+				step := steps[i]
+				e.logger.WithContext(ctx).Debugf("Starting DAG step %s", step.ID)
+				apiCalls, err := e.executeStepWithLoop(ctx, &step, stepCtx, maxLoops, input, output, execOpts, nil, nil)
+				outcomes <- stepOutcome{index: i, apiCalls: apiCalls, stepCtx: stepCtx, before: before, err: err}
+			}(i, stepCtx, before)
+		}
+		if running == 0 {
+			break
+		}
+
+		res := <-outcomes
+		running--
+		totalAPICalls += res.apiCalls
+		step := steps[res.index]
+
+		if res.err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("step %s: %w", step.ID, res.err)
+				cancel()
+			}
+			continue
+		}
+		e.logger.WithContext(ctx).Debugf("Completed DAG step %s", step.ID)
+
+		// Merge the context keys the step wrote and publish its output
+		mergeContext(execCtx.Context, res.before, res.stepCtx.Context)
+		if out, ok := res.stepCtx.Steps[step.ID]; ok {
+			if execCtx.Steps == nil {
+				execCtx.Steps = make(map[string]*ResponseContext)
+			}
+			execCtx.Steps[step.ID] = out
+		}
+
+		for _, d := range dependents[res.index] {
+			pending[d]--
+			if pending[d] == 0 {
+				ready = append(ready, d)
+			}
+		}
+	}
+
+	return totalAPICalls, firstErr
+}
+
+// mergeContext applies the changes a step made to its copy of the plan context (before -> after) to dst
+func mergeContext(dst, before, after map[string]any) {
+	for k, v := range after {
+		if old, ok := before[k]; ok && reflect.DeepEqual(old, v) {
+			continue
+		}
+		dst[k] = v
+	}
+	for k := range before {
+		if _, ok := after[k]; !ok {
+			delete(dst, k)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+)
+
+func TestValidateSteps(t *testing.T) {
+	plan := func(steps ...models.Step) *models.PlanDefinition {
+		return &models.PlanDefinition{Steps: steps}
+	}
+
+	require.NoError(t, ValidateSteps(plan(
+		models.Step{ID: "users", URL: "/users"},
+		models.Step{ID: "groups", URL: "/groups"},
+		models.Step{ID: "memberships", URL: "/memberships", DependsOn: []string{"users", "groups"}},
+	)))
+	require.ErrorContains(t, ValidateSteps(plan(
+		models.Step{ID: "users", URL: "/users"},
+		models.Step{ID: "users", URL: "/groups"},
+	)), "duplicate step id")
+	require.ErrorContains(t, ValidateSteps(plan(
+		models.Step{ID: "users", URL: "/users", DependsOn: []string{"groups"}},
+	)), "unknown step")
+	require.ErrorContains(t, ValidateSteps(plan(
+		models.Step{ID: "a", URL: "/a", DependsOn: []string{"c"}},
+		models.Step{ID: "b", URL: "/b", DependsOn: []string{"a"}},
+		models.Step{ID: "c", URL: "/c", DependsOn: []string{"b"}},
+		models.Step{ID: "d", URL: "/d"},
+	)), "dependency cycle: a, b, c")
+	require.Error(t, ValidateSteps(&models.PlanDefinition{Step: models.Step{URL: "/users", DependsOn: []string{"groups"}}}))
+}
+
+func TestExecuteSteps_RunsDependenciesFirst(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": r.URL.Path[1:]})
+	}))
+	defer server.Close()
+
+	executor := newTestExecutor()
+
+	steps := []models.Step{
+		{
+			ID:         "memberships",
+			URL:        server.URL + "/{{steps.users.body.id}}-{{steps.groups.body.id}}",
+			DependsOn:  []string{"users", "groups"},
+			SetContext: map[string]string{"membership": "response.body.id"},
+		},
+		{ID: "users", URL: server.URL + "/users", SetContext: map[string]string{"users": "response.body.id"}},
+		{ID: "groups", URL: server.URL + "/groups", SetContext: map[string]string{"groups": "response.body.id"}},
+	}
+
+	execCtx := NewExecutionContext()
+	execCtx.WithMeta(&ExecutionMeta{StepPath: "root"})
+	output := &PlanExecutionOutput{}
+	apiCalls, err := executor.executeSteps(context.Background(), steps, execCtx, 1, 2, PlanExecutionInput{}, output, nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, apiCalls)
+
+	require.Len(t, paths, 3)
+	require.ElementsMatch(t, []string{"/users", "/groups"}, paths[:2])
+	require.Equal(t, "/users-groups", paths[2])
+
+	// Context writes of every step are merged into the plan context
+	require.Equal(t, "users", execCtx.Context["users"])
+	require.Equal(t, "groups", execCtx.Context["groups"])
+	require.Equal(t, "users-groups", execCtx.Context["membership"])
+	require.Equal(t, map[string]any{"id": "users-groups"}, execCtx.Steps["memberships"].Body)
+}
+
+func TestMergeContext_AppliesOnlyTheStepsChanges(t *testing.T) {
+	dst := map[string]any{"shared": 1, "other": "a", "removed": true}
+	before := map[string]any{"shared": 1, "removed": true}
+	after := map[string]any{"shared": 2, "added": "b"}
+
+	mergeContext(dst, before, after)
+	require.Equal(t, map[string]any{"shared": 2, "other": "a", "added": "b"}, dst)
+}
@@ -2,7 +2,10 @@ package execution
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"strconv"
 	"time"
 
@@ -11,8 +14,11 @@ import (
 
 	"github.com/Ramsey-B/orchid/pkg/expressions"
 	"github.com/Ramsey-B/orchid/pkg/httpclient"
+	"github.com/Ramsey-B/orchid/pkg/metrics"
 	"github.com/Ramsey-B/orchid/pkg/models"
 	"github.com/Ramsey-B/orchid/pkg/ratelimit"
+	"github.com/Ramsey-B/orchid/pkg/secrets"
+	"github.com/Ramsey-B/orchid/pkg/signing"
 )
 
 // StepResult holds the result of a step execution
@@ -30,6 +36,10 @@ type StepResult struct {
 	ExecutionTime time.Duration
 	RateLimited   bool          // True if request was rate limited
 	WaitedFor     time.Duration // Time spent waiting for rate limit
+	Streamed      int           // Records decoded from a streamed response body
+	Trace         *StepTrace    // Set during plan previews
+
+	rawURL string // Unmasked request URL, for resolving relative next-page links
 }
 
 // ExecuteOptions provides optional configuration for step execution
@@ -37,8 +47,46 @@ type ExecuteOptions struct {
 	TenantID      uuid.UUID
 	IntegrationID uuid.UUID
 	ConfigID      uuid.UUID
+	Credential    string // ratelimit.CredentialKey of the config
 	RateLimits    []models.RateLimitConfig
-	MaxRateWait   time.Duration // Max time to wait for rate limit (default: 60s)
+	MaxRateWait   time.Duration                 // Max time to wait for rate limit (default: 60s)
+	Reauth        *Reauthenticator              // Re-runs the auth flow on reauth_on statuses (nil when the plan has no auth flow)
+	Masker        *secrets.Masker               // Masks decrypted secret config values in request URLs and logs
+	Preview       *Preview                      // Records step traces and caps loops/fanout (nil outside a preview)
+	Signer        signing.Signer                // Signs every request after it is built (nil when the auth flow has no request signer)
+	Transport     *httpclient.TransportSettings // TLS and proxy settings of the config's dedicated transport (nil uses the shared one)
+}
+
+// mask hides decrypted secrets in s (no-op without a masker)
+func (o *ExecuteOptions) mask(s string) string {
+	if o == nil {
+		return s
+	}
+	return o.Masker.Mask(s)
+}
+
+// masker returns the secret masker (nil without options)
+func (o *ExecuteOptions) masker() *secrets.Masker {
+	if o == nil {
+		return nil
+	}
+	return o.Masker
+}
+
+// signer returns the request signer (nil without options)
+func (o *ExecuteOptions) signer() signing.Signer {
+	if o == nil {
+		return nil
+	}
+	return o.Signer
+}
+
+// preview returns the active preview (nil outside a preview)
+func (o *ExecuteOptions) preview() *Preview {
+	if o == nil {
+		return nil
+	}
+	return o.Preview
 }
 
 // StepExecutor executes individual steps
@@ -75,6 +123,32 @@ func (e *StepExecutor) Execute(ctx context.Context, step *models.Step, execCtx *
 
 // ExecuteWithOptions executes a step with additional options (rate limiting, etc.)
 func (e *StepExecutor) ExecuteWithOptions(ctx context.Context, step *models.Step, execCtx *ExecutionContext, opts *ExecuteOptions) (*StepResult, error) {
+	return e.executeTraced(ctx, step, execCtx, opts, nil)
+}
+
+// ExecuteStream executes a step whose successful response body is decoded incrementally (step.Stream),
+// passing every batch of records to emit as it is read. Other responses are handled like ExecuteWithOptions.
+func (e *StepExecutor) ExecuteStream(ctx context.Context, step *models.Step, execCtx *ExecutionContext, opts *ExecuteOptions, emit StreamFunc) (*StepResult, error) {
+	if step.Stream == nil {
+		return nil, errors.New("step has no stream configuration")
+	}
+	return e.executeTraced(ctx, step, execCtx, opts, emit)
+}
+
+// executeTraced runs execute within the step's preview trace
+func (e *StepExecutor) executeTraced(ctx context.Context, step *models.Step, execCtx *ExecutionContext, opts *ExecuteOptions, emit StreamFunc) (*StepResult, error) {
+	trace := opts.preview().startStep(ctx, step, execCtx)
+	result, err := e.execute(ctx, step, execCtx, opts, trace, emit)
+	trace.finish(err)
+	if result != nil {
+		result.Trace = trace
+	}
+	return result, err
+}
+
+// execute runs the step's request with retries, recording each attempt on trace (nil outside a preview).
+// With emit, a successful response body is streamed to it instead of being buffered.
+func (e *StepExecutor) execute(ctx context.Context, step *models.Step, execCtx *ExecutionContext, opts *ExecuteOptions, trace *StepTrace, emit StreamFunc) (*StepResult, error) {
 	// Apply defaults
 	step = e.applyDefaults(step)
 
@@ -90,11 +164,32 @@ func (e *StepExecutor) ExecuteWithOptions(ctx context.Context, step *models.Step
 		maxRetries = step.Retry.MaxRetries
 	}
 
+	reauthStatuses := e.reauthStatuses(step, execCtx)
+	reauthenticated := false
+
+	// Configs with TLS or proxy settings send their requests through a dedicated transport
+	client := e.client
+	if opts != nil && opts.Transport != nil {
+		var err error
+		client, err = e.client.ForConfig(opts.ConfigID, opts.Transport)
+		if err != nil {
+			result := &StepResult{Context: execCtx, Error: err}
+			return result, err
+		}
+	}
+
 	var lastResult *StepResult
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		start := time.Now()
 		result := &StepResult{Context: execCtx, RetryCount: attempt}
 
+		// Pick up a token refreshed by another request of this execution
+		if opts != nil && opts.Reauth != nil && execCtx.Auth != nil {
+			if current := opts.Reauth.Current(); current != nil && current.Token != execCtx.Auth.Token {
+				execCtx.WithAuth(current)
+			}
+		}
+
 		// Build the HTTP request first (need URL for rate limiting)
 		data := execCtx.ToMap()
 		req, err := e.requestBuilder.BuildRequest(ctx, step, data)
@@ -102,8 +197,16 @@ func (e *StepExecutor) ExecuteWithOptions(ctx context.Context, step *models.Step
 			result.Error = fmt.Errorf("failed to build request: %w", err)
 			return result, result.Error
 		}
-		result.RequestURL = req.URL.String()
+		if signer := opts.signer(); signer != nil {
+			if err := signer.Sign(req); err != nil {
+				result.Error = fmt.Errorf("failed to sign request: %w", err)
+				return result, result.Error
+			}
+		}
+		result.rawURL = req.URL.String()
+		result.RequestURL = opts.mask(result.rawURL)
 		result.RequestMethod = req.Method
+		trace.recordRequest(req, result.RequestURL, opts.masker())
 
 		// Check and wait for rate limit (also acquires any concurrency slots)
 		var release releaseFunc
@@ -113,6 +216,7 @@ func (e *StepExecutor) ExecuteWithOptions(ctx context.Context, step *models.Step
 				TenantID:      opts.TenantID,
 				IntegrationID: opts.IntegrationID,
 				ConfigID:      opts.ConfigID,
+				Credential:    opts.Credential,
 				URL:           req.URL.String(),
 				RateLimits:    opts.RateLimits,
 			}
@@ -134,20 +238,45 @@ func (e *StepExecutor) ExecuteWithOptions(ctx context.Context, step *models.Step
 			}
 		}
 
-		e.logger.WithContext(ctx).Debugf("Executing step: %s %s", req.Method, req.URL.String())
+		e.logger.WithContext(ctx).Debugf("Executing step: %s %s", req.Method, result.RequestURL)
 
-		resp, err := e.client.Do(ctx, req)
+		// The client logs request URLs with the decrypted secrets hidden
+		reqCtx := httpclient.WithMasker(ctx, opts.masker())
+		var resp *httpclient.Response
+		if emit != nil {
+			resp, err = client.DoStream(reqCtx, req, e.streamRecords(step, execCtx, opts, result, emit))
+		} else {
+			resp, err = client.Do(reqCtx, req)
+		}
 		if release != nil {
 			// Release concurrency slot as soon as the request returns.
 			release()
 		}
+		// Records of a failed stream may already have been emitted, so it is not retried
+		var streamErr *httpclient.StreamError
+		if errors.As(err, &streamErr) {
+			result.Response = resp
+			result.ExecutionTime = time.Since(start)
+			result.Error = fmt.Errorf("request failed after %d records: %w", result.Streamed, err)
+			return result, result.Error
+		}
 		if err != nil {
+			// Transport errors embed the request URL
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				urlErr.URL = opts.mask(urlErr.URL)
+			}
 			result.Error = fmt.Errorf("request failed: %w", err)
 			// Network errors: retry if retries are configured
 			if attempt < maxRetries {
 				delay := CalculateBackoff(step.Retry, attempt+1)
 				e.logger.WithContext(ctx).Warnf("Request error, retrying in %v (attempt %d/%d): %v", delay, attempt+1, maxRetries, err)
-				time.Sleep(delay)
+				select {
+				case <-ctx.Done():
+					result.Error = ctx.Err()
+					return result, result.Error
+				case <-time.After(delay):
+				}
 				lastResult = result
 				continue
 			}
@@ -155,12 +284,35 @@ func (e *StepExecutor) ExecuteWithOptions(ctx context.Context, step *models.Step
 		}
 
 		// Parse response
-		if err := httpclient.ParseResponse(resp); err != nil {
+		if err := httpclient.ParseResponseWith(resp, step.Parse); err != nil {
 			e.logger.WithContext(ctx).WithError(err).Warn("Failed to parse response body")
 		}
 
 		result.Response = resp
 		result.ExecutionTime = time.Since(start)
+		trace.recordResponse(resp, result.ExecutionTime, opts.masker())
+
+		// Token rejected upstream (e.g. revoked before expiry): re-authenticate once and retry transparently
+		if !reauthenticated && opts != nil && opts.Reauth != nil && containsStatus(reauthStatuses, resp.StatusCode) {
+			reauthenticated = true
+			e.logger.WithContext(ctx).Warnf("Received %d from %s, re-authenticating", resp.StatusCode, result.RequestURL)
+
+			authCtx, ran, err := opts.Reauth.Reauthenticate(ctx, execCtx.Auth)
+			if err != nil {
+				metrics.RecordAuthReauthentication(opts.TenantID.String(), "failed")
+				result.Error = fmt.Errorf("%w: re-authentication failed: %w", ErrAuthFlowFailed, err)
+				return result, result.Error
+			}
+			if ran {
+				metrics.RecordAuthReauthentication(opts.TenantID.String(), "success")
+			} else {
+				metrics.RecordAuthReauthentication(opts.TenantID.String(), "reused")
+			}
+
+			execCtx.WithAuth(authCtx)
+			attempt-- // does not count against the retry budget
+			continue
+		}
 
 		// Update dynamic rate limits from response headers
 		if opts != nil && len(opts.RateLimits) > 0 && e.rateLimiter != nil {
@@ -171,7 +323,7 @@ func (e *StepExecutor) ExecuteWithOptions(ctx context.Context, step *models.Step
 		if resp.StatusCode == 429 {
 			result.RateLimited = true
 			result.ShouldRetry = true
-			e.logger.WithContext(ctx).Warnf("Received 429 Too Many Requests from %s", req.URL.String())
+			e.logger.WithContext(ctx).Warnf("Received 429 Too Many Requests from %s", result.RequestURL)
 		}
 
 		// Update context with response for condition evaluation
@@ -179,19 +331,19 @@ func (e *StepExecutor) ExecuteWithOptions(ctx context.Context, step *models.Step
 		data = execCtx.ToMap()
 
 		// Evaluate conditions
-		if err := e.evaluateConditions(ctx, step, data, result); err != nil {
+		if err := e.evaluateConditions(ctx, step, data, result, trace); err != nil {
 			result.Error = err
 			return result, err
 		}
 
 		// Process set_context
-		if err := e.processSetContext(ctx, step, data, execCtx); err != nil {
+		if err := e.processSetContext(ctx, step, data, execCtx, trace); err != nil {
 			result.Error = err
 			return result, err
 		}
 
-		// Retry logic
-		if result.ShouldRetry && attempt < maxRetries {
+		// Retry logic (a streamed body was already emitted, so it is never fetched again)
+		if result.ShouldRetry && attempt < maxRetries && result.Streamed == 0 {
 			// If 429 and Retry-After header is present, honor it and also block the rate limiter bucket.
 			if resp.StatusCode == 429 {
 				if ra, ok := resp.Headers["Retry-After"]; ok && ra != "" {
@@ -203,13 +355,19 @@ func (e *StepExecutor) ExecuteWithOptions(ctx context.Context, step *models.Step
 								TenantID:      opts.TenantID,
 								IntegrationID: opts.IntegrationID,
 								ConfigID:      opts.ConfigID,
+								Credential:    opts.Credential,
 								URL:           req.URL.String(),
 								RateLimits:    opts.RateLimits,
 							}
 							e.rateLimiter.UpdateFromResponse(ctx, checkReq, resp.Headers)
 						}
 						e.logger.WithContext(ctx).Warnf("Retry-After=%ds, retrying (attempt %d/%d)", secs, attempt+1, maxRetries)
-						time.Sleep(delay)
+						select {
+						case <-ctx.Done():
+							result.Error = ctx.Err()
+							return result, result.Error
+						case <-time.After(delay):
+						}
 						lastResult = result
 						continue
 					}
@@ -218,11 +376,24 @@ func (e *StepExecutor) ExecuteWithOptions(ctx context.Context, step *models.Step
 
 			delay := CalculateBackoff(step.Retry, attempt+1)
 			e.logger.WithContext(ctx).Warnf("Retrying in %v (attempt %d/%d)", delay, attempt+1, maxRetries)
-			time.Sleep(delay)
+			select {
+			case <-ctx.Done():
+				result.Error = ctx.Err()
+				return result, result.Error
+			case <-time.After(delay):
+			}
 			lastResult = result
 			continue
 		}
 
+		// GraphQL reports failures in an errors array, usually with a 200 status
+		if step.BodyType == models.BodyTypeGraphQL && !result.ShouldIgnore {
+			if err := httpclient.GraphQLErrors(resp); err != nil {
+				result.Error = err
+				return result, err
+			}
+		}
+
 		return result, nil
 	}
 
@@ -233,17 +404,90 @@ func (e *StepExecutor) ExecuteWithOptions(ctx context.Context, step *models.Step
 	return &StepResult{Context: execCtx}, nil
 }
 
-// updateRateLimitsFromResponse updates rate limits from response headers
+// StreamFunc receives a batch of records decoded from a streamed response body.
+// result carries the response status and headers; the body itself is never buffered.
+type StreamFunc func(result *StepResult, records []any) error
+
+// streamRecords decodes the records of a streamed body and passes them to emit in batches of step.Stream.BatchSize
+func (e *StepExecutor) streamRecords(step *models.Step, execCtx *ExecutionContext, opts *ExecuteOptions, result *StepResult, emit StreamFunc) httpclient.StreamFunc {
+	return func(resp *httpclient.Response, body io.Reader) error {
+		dec, err := httpclient.NewRecordDecoder(body, step.Stream.Format, step.Stream.Path)
+		if err != nil {
+			return err
+		}
+		result.Response = resp
+		result.ExecutionTime = resp.Duration
+
+		batchSize := step.Stream.BatchSize
+		if batchSize <= 0 {
+			batchSize = 1
+		}
+		limit := opts.preview().streamLimit()
+
+		batch := make([]any, 0, batchSize)
+		for {
+			// Previews only stream the first few records
+			if limit > 0 && result.Streamed >= limit {
+				if _, err := dec.Next(); err != io.EOF {
+					opts.preview().capStream(step, execCtx, limit)
+				}
+				break
+			}
+
+			record, err := dec.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("failed to decode record %d: %w", result.Streamed+1, err)
+			}
+			batch = append(batch, record)
+			result.Streamed++
+
+			if len(batch) == batchSize {
+				if err := emit(result, batch); err != nil {
+					return err
+				}
+				batch = make([]any, 0, batchSize)
+			}
+		}
+		if len(batch) > 0 {
+			return emit(result, batch)
+		}
+		return nil
+	}
+}
+
+// updateRateLimitsFromResponse updates rate limits from response headers and adapts learned rates to the status
 func (e *StepExecutor) updateRateLimitsFromResponse(ctx context.Context, url string, opts *ExecuteOptions, resp *httpclient.Response) {
 	checkReq := ratelimit.CheckRequest{
 		TenantID:      opts.TenantID,
 		IntegrationID: opts.IntegrationID,
 		ConfigID:      opts.ConfigID,
+		Credential:    opts.Credential,
 		URL:           url,
 		RateLimits:    opts.RateLimits,
 	}
 
 	e.rateLimiter.UpdateFromResponse(ctx, checkReq, resp.Headers)
+
+	// A preview's few requests must not move the rates real executions learned
+	if IsPreview(ctx) {
+		return
+	}
+	e.rateLimiter.Observe(ctx, checkReq, resp.StatusCode)
+}
+
+// reauthStatuses returns the statuses that trigger re-authentication.
+// The step's reauth_on takes precedence over the auth flow's.
+func (e *StepExecutor) reauthStatuses(step *models.Step, execCtx *ExecutionContext) []int {
+	if len(step.ReauthOn) > 0 {
+		return step.ReauthOn
+	}
+	if execCtx.Auth != nil {
+		return execCtx.Auth.ReauthOn
+	}
+	return nil
 }
 
 // applyDefaults applies default values to a step
@@ -254,7 +498,9 @@ func (e *StepExecutor) applyDefaults(step *models.Step) *models.Step {
 	if s.Method == "" {
 		s.Method = "GET"
 	}
-	if s.TimeoutSeconds <= 0 {
+	if s.TimeoutSeconds <= 0 && s.Stream != nil {
+		s.TimeoutSeconds = models.DefaultStreamTimeoutSeconds
+	} else if s.TimeoutSeconds <= 0 {
 		s.TimeoutSeconds = 30
 	}
 	if s.Concurrency <= 0 {
@@ -282,10 +528,11 @@ func (e *StepExecutor) applyDefaults(step *models.Step) *models.Step {
 }
 
 // evaluateConditions evaluates all step conditions
-func (e *StepExecutor) evaluateConditions(ctx context.Context, step *models.Step, data map[string]any, result *StepResult) error {
+func (e *StepExecutor) evaluateConditions(ctx context.Context, step *models.Step, data map[string]any, result *StepResult, trace *StepTrace) error {
 	// Check abort_when
 	if step.AbortWhen != "" {
 		abort, err := e.evaluateBoolCondition(ctx, step.AbortWhen, data)
+		trace.recordCondition("abort_when", step.AbortWhen, abort, err)
 		if err != nil {
 			return fmt.Errorf("failed to evaluate abort_when: %w", err)
 		}
@@ -299,6 +546,7 @@ func (e *StepExecutor) evaluateConditions(ctx context.Context, step *models.Step
 	// Check break_when (for while loops)
 	if step.BreakWhen != "" {
 		breakLoop, err := e.evaluateBoolCondition(ctx, step.BreakWhen, data)
+		trace.recordCondition("break_when", step.BreakWhen, breakLoop, err)
 		if err != nil {
 			return fmt.Errorf("failed to evaluate break_when: %w", err)
 		}
@@ -311,6 +559,7 @@ func (e *StepExecutor) evaluateConditions(ctx context.Context, step *models.Step
 	// Check retry_when
 	if step.RetryWhen != "" {
 		retry, err := e.evaluateBoolCondition(ctx, step.RetryWhen, data)
+		trace.recordCondition("retry_when", step.RetryWhen, retry, err)
 		if err != nil {
 			return fmt.Errorf("failed to evaluate retry_when: %w", err)
 		}
@@ -323,6 +572,7 @@ func (e *StepExecutor) evaluateConditions(ctx context.Context, step *models.Step
 	// Check ignore_when
 	if step.IgnoreWhen != "" {
 		ignore, err := e.evaluateBoolCondition(ctx, step.IgnoreWhen, data)
+		trace.recordCondition("ignore_when", step.IgnoreWhen, ignore, err)
 		if err != nil {
 			return fmt.Errorf("failed to evaluate ignore_when: %w", err)
 		}
@@ -354,7 +604,7 @@ func (e *StepExecutor) EvaluateWhile(ctx context.Context, step *models.Step, dat
 }
 
 // processSetContext processes set_context expressions
-func (e *StepExecutor) processSetContext(ctx context.Context, step *models.Step, data map[string]any, execCtx *ExecutionContext) error {
+func (e *StepExecutor) processSetContext(ctx context.Context, step *models.Step, data map[string]any, execCtx *ExecutionContext, trace *StepTrace) error {
 	if step.SetContext == nil {
 		return nil
 	}
@@ -369,7 +619,9 @@ func (e *StepExecutor) processSetContext(ctx context.Context, step *models.Step,
 		if err := execCtx.SetContextValue(key, value); err != nil {
 			e.logger.WithContext(ctx).WithError(err).Warnf("Failed to set context[%s]", key)
 			// Continue, don't fail the step
+			continue
 		}
+		trace.recordSetContext(key, value)
 	}
 
 	return nil
@@ -23,11 +23,21 @@ type FanoutResult struct {
 	Results        []*StepResult
 	Errors         []error
 	TotalItems     int
+	SkippedItems   int // Items completed by an earlier execution and skipped on resume
 	SuccessCount   int
 	FailureCount   int
 	AbortTriggered bool
 }
 
+// FanoutProgress lets a resumed execution skip items that already completed
+// and observe items as they complete, so it can checkpoint them
+type FanoutProgress struct {
+	// Completed returns the indexes of items that already completed, given the number of items
+	Completed func(total int) map[int]bool
+	// OnItem is called for every item that completes successfully
+	OnItem func(index int, result *StepResult)
+}
+
 // FanoutExecutor handles sub-step fanout execution
 type FanoutExecutor struct {
 	stepExecutor *StepExecutor
@@ -71,6 +81,19 @@ func (f *FanoutExecutor) ExecuteWithOptions(
 	execCtx *ExecutionContext,
 	currentNesting int,
 	execOpts *ExecuteOptions,
+) (*FanoutResult, error) {
+	return f.ExecuteWithProgress(ctx, step, execCtx, currentNesting, execOpts, nil)
+}
+
+// ExecuteWithProgress executes sub-steps, skipping and reporting items through progress.
+// Nested fanouts run without progress.
+func (f *FanoutExecutor) ExecuteWithProgress(
+	ctx context.Context,
+	step *models.Step,
+	execCtx *ExecutionContext,
+	currentNesting int,
+	execOpts *ExecuteOptions,
+	progress *FanoutProgress,
 ) (*FanoutResult, error) {
 	// Check nesting depth
 	if currentNesting >= f.maxNesting {
@@ -88,6 +111,35 @@ func (f *FanoutExecutor) ExecuteWithOptions(
 		return nil, fmt.Errorf("failed to evaluate iterate_over: %w", err)
 	}
 
+	return f.executeItems(ctx, step, execCtx, items, currentNesting, execOpts, progress)
+}
+
+// ExecuteItems executes sub-steps for each of the given items instead of evaluating iterate_over.
+// Streamed steps fan out over each batch of records as it is read.
+func (f *FanoutExecutor) ExecuteItems(
+	ctx context.Context,
+	step *models.Step,
+	execCtx *ExecutionContext,
+	items []any,
+	currentNesting int,
+	execOpts *ExecuteOptions,
+) (*FanoutResult, error) {
+	if currentNesting >= f.maxNesting {
+		return nil, fmt.Errorf("maximum nesting depth exceeded: %d (max %d)", currentNesting, f.maxNesting)
+	}
+	return f.executeItems(ctx, step, execCtx, items, currentNesting, execOpts, nil)
+}
+
+// executeItems runs the sub-steps of step for every item, skipping and reporting items through progress
+func (f *FanoutExecutor) executeItems(
+	ctx context.Context,
+	step *models.Step,
+	execCtx *ExecutionContext,
+	items []any,
+	currentNesting int,
+	execOpts *ExecuteOptions,
+	progress *FanoutProgress,
+) (*FanoutResult, error) {
 	if len(items) == 0 {
 		f.logger.WithContext(ctx).Debug("No items to iterate over")
 		return &FanoutResult{
@@ -98,26 +150,50 @@ func (f *FanoutExecutor) ExecuteWithOptions(
 		}, nil
 	}
 
+	// Previews only fan out over the first few items
+	items = execOpts.preview().capFanout(step, execCtx, items)
+	itemTraces := execOpts.preview().fanoutItems(ctx, execCtx, len(items))
+
 	result := &FanoutResult{
 		Results:    make([]*StepResult, len(items)),
 		Errors:     make([]error, 0),
 		TotalItems: len(items),
 	}
 
+	// Items an earlier execution completed are not executed again
+	var completed map[int]bool
+	if progress != nil && progress.Completed != nil {
+		completed = progress.Completed(len(items))
+	}
+	pending := make([]int, 0, len(items))
+	for i := range items {
+		if completed[i] {
+			result.SkippedItems++
+			continue
+		}
+		pending = append(pending, i)
+	}
+	if result.SkippedItems > 0 {
+		f.logger.WithContext(ctx).Infof("Resuming fanout: skipping %d completed items", result.SkippedItems)
+	}
+	if len(pending) == 0 {
+		return result, nil
+	}
+
 	// Determine concurrency
 	concurrency := step.Concurrency
 	if concurrency <= 0 {
 		concurrency = DefaultConcurrency
 	}
-	if concurrency > len(items) {
-		concurrency = len(items)
+	if concurrency > len(pending) {
+		concurrency = len(pending)
 	}
 
-	f.logger.WithContext(ctx).Infof("Executing fanout: %d items with concurrency %d", len(items), concurrency)
+	f.logger.WithContext(ctx).Infof("Executing fanout: %d items with concurrency %d", len(pending), concurrency)
 
 	// Create worker pool
-	itemChan := make(chan indexedItem, len(items))
-	resultChan := make(chan indexedResult, len(items))
+	itemChan := make(chan indexedItem, len(pending))
+	resultChan := make(chan indexedResult, len(pending))
 
 	// Start workers
 	var wg sync.WaitGroup
@@ -131,11 +207,15 @@ func (f *FanoutExecutor) ExecuteWithOptions(
 
 	// Send items to workers
 	go func() {
-		for i, item := range items {
+		for _, i := range pending {
+			next := indexedItem{index: i, item: items[i]}
+			if itemTraces != nil {
+				next.trace = itemTraces[i]
+			}
 			select {
 			case <-workerCtx.Done():
 				return
-			case itemChan <- indexedItem{index: i, item: item}:
+			case itemChan <- next:
 			}
 		}
 		close(itemChan)
@@ -156,6 +236,9 @@ func (f *FanoutExecutor) ExecuteWithOptions(
 			result.FailureCount++
 		} else {
 			result.SuccessCount++
+			if progress != nil && progress.OnItem != nil && (res.result == nil || !res.result.ShouldAbort) {
+				progress.OnItem(res.index, res.result)
+			}
 		}
 
 		// Check for abort
@@ -173,6 +256,7 @@ func (f *FanoutExecutor) ExecuteWithOptions(
 type indexedItem struct {
 	index int
 	item  any
+	trace *StepTrace // Preview trace node for the item (nil outside a preview)
 }
 
 type indexedResult struct {
@@ -211,6 +295,9 @@ func (f *FanoutExecutor) worker(
 			itemCtx.Meta.StepPath = fmt.Sprintf("%s.fanout[%d]", itemCtx.Meta.StepPath, item.index)
 		}
 
+		// Sub-step traces attach under the item's node during previews
+		stepCtx := withTraceParent(ctx, item.trace)
+
 		// Execute sub-steps sequentially for this item
 		var lastResult *StepResult
 		var lastErr error
@@ -218,7 +305,7 @@ func (f *FanoutExecutor) worker(
 		for subIdx, subStep := range step.SubSteps {
 			// Check for nested fanout
 			if subStep.IterateOver != "" && len(subStep.SubSteps) > 0 {
-				fanoutResult, err := f.ExecuteWithOptions(ctx, &subStep, itemCtx, nestingLevel+1, execOpts)
+				fanoutResult, err := f.ExecuteWithOptions(stepCtx, &subStep, itemCtx, nestingLevel+1, execOpts)
 				if err != nil {
 					lastErr = err
 					break
@@ -231,7 +318,7 @@ func (f *FanoutExecutor) worker(
 			}
 
 			// Execute regular step with rate limiting
-			result, err := f.stepExecutor.ExecuteWithOptions(ctx, &subStep, itemCtx, execOpts)
+			result, err := f.stepExecutor.ExecuteWithOptions(stepCtx, &subStep, itemCtx, execOpts)
 			if err != nil {
 				lastErr = err
 				lastResult = result
@@ -0,0 +1,432 @@
+package execution
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Ramsey-B/orchid/pkg/expressions"
+	"github.com/Ramsey-B/orchid/pkg/models"
+	"github.com/Ramsey-B/orchid/pkg/secrets"
+)
+
+var (
+	// linkPattern splits an RFC 5988 Link header into <uri> and its parameters
+	linkPattern = regexp.MustCompile(`<([^>]*)>([^<]*)`)
+	// linkRelPattern extracts the rel parameter of a link
+	linkRelPattern = regexp.MustCompile(`(?i)\brel\s*=\s*"?([^";,]*)"?`)
+)
+
+// ValidatePagination checks that a pagination block has what its strategy needs
+func ValidatePagination(p *models.Pagination) error {
+	if p == nil {
+		return nil
+	}
+	if p.PageSize < 0 || p.MaxPages < 0 {
+		return errors.New("pagination page_size and max_pages must not be negative")
+	}
+	switch p.Type {
+	case models.PaginationCursor:
+		if (p.CursorParam == "" && p.CursorVariable == "") || p.CursorPath == "" {
+			return errors.New("cursor pagination requires cursor_param (or cursor_variable) and cursor_path")
+		}
+		if p.CursorParam != "" && p.CursorVariable != "" {
+			return errors.New("cursor pagination takes cursor_param or cursor_variable, not both")
+		}
+	case models.PaginationOffset, models.PaginationPage:
+		if p.Type == models.PaginationOffset && p.PageSize == 0 && p.ItemsPath == "" {
+			return errors.New("offset pagination requires page_size or items_path")
+		}
+		if p.StartPage != nil && *p.StartPage < 0 {
+			return errors.New("pagination start_page must not be negative")
+		}
+		// Offsets and page numbers never run out on their own
+		if p.ItemsPath == "" && p.HasMorePath == "" && (p.TotalPath == "" || p.PageSize == 0) {
+			return fmt.Errorf("%s pagination requires items_path, has_more_path or total_path with page_size to detect the last page", p.Type)
+		}
+	case models.PaginationLinkHeader:
+	case models.PaginationNextURL:
+		if p.NextURLPath == "" {
+			return errors.New("next_url pagination requires next_url_path")
+		}
+	default:
+		return fmt.Errorf("unknown pagination type %q (want cursor, offset, page, link_header or next_url)", p.Type)
+	}
+	return nil
+}
+
+// paginationKey identifies a step's checkpoint in the plan context
+func paginationKey(step *models.Step, execCtx *ExecutionContext) string {
+	if step.ID != "" {
+		return step.ID
+	}
+	if execCtx.Meta != nil && execCtx.Meta.StepPath != "" {
+		return execCtx.Meta.StepPath
+	}
+	return "root"
+}
+
+// pageState is the position of a paginated step, checkpointed after every page
+type pageState struct {
+	Cursor  string `json:"cursor,omitempty"`
+	Offset  int    `json:"offset,omitempty"`
+	Page    int    `json:"page,omitempty"`
+	NextURL string `json:"next_url,omitempty"`
+
+	// Query params of the step stripped from a checkpointed next URL, rendered again from the step on resume
+	RestoreParams []string `json:"restore_params,omitempty"`
+}
+
+// empty reports whether the state holds no position
+func (s pageState) empty() bool {
+	return s.Cursor == "" && s.Offset == 0 && s.Page == 0 && s.NextURL == ""
+}
+
+// pageOutcome is what the paginator decided after a page
+type pageOutcome int
+
+const (
+	pageNext     pageOutcome = iota // Another page follows
+	pageDone                        // The API has no more pages
+	pageMaxPages                    // The max_pages guard stopped pagination
+)
+
+// paginator drives a step's pagination strategy
+type paginator struct {
+	cfg       models.Pagination
+	evaluator *expressions.Evaluator
+	state     pageState
+	fetched   int
+	resumed   bool
+}
+
+// newPaginator creates a paginator, resuming from a checkpoint when one is stored
+func newPaginator(cfg *models.Pagination, evaluator *expressions.Evaluator, checkpoint any) *paginator {
+	p := &paginator{cfg: *cfg, evaluator: evaluator}
+	if p.cfg.OffsetParam == "" {
+		p.cfg.OffsetParam = "offset"
+	}
+	if p.cfg.LimitParam == "" {
+		p.cfg.LimitParam = "limit"
+	}
+	if p.cfg.PageParam == "" {
+		p.cfg.PageParam = "page"
+	}
+	if p.cfg.MaxPages <= 0 {
+		p.cfg.MaxPages = models.DefaultMaxPages
+	}
+
+	p.state.Page = 1
+	if p.cfg.StartPage != nil {
+		p.state.Page = *p.cfg.StartPage
+	}
+
+	if checkpoint != nil {
+		if data, err := json.Marshal(checkpoint); err == nil {
+			var state pageState
+			if json.Unmarshal(data, &state) == nil && !state.empty() {
+				p.state = state
+				p.resumed = true
+			}
+		}
+	}
+	return p
+}
+
+// apply returns a copy of step that requests the current page
+func (p *paginator) apply(step *models.Step) *models.Step {
+	s := *step
+	if p.state.NextURL != "" {
+		// Next URLs already carry every query param, except the step's own ones stripped from a checkpoint
+		s.URL = p.state.NextURL
+		s.Params = nil
+		for _, key := range p.state.RestoreParams {
+			if value, ok := step.Params[key]; ok {
+				if s.Params == nil {
+					s.Params = make(map[string]string, len(p.state.RestoreParams))
+				}
+				s.Params[key] = value
+			}
+		}
+		return &s
+	}
+
+	params := make(map[string]string, len(step.Params)+2)
+	for k, v := range step.Params {
+		params[k] = v
+	}
+	switch p.cfg.Type {
+	case models.PaginationCursor:
+		if p.state.Cursor != "" && p.cfg.CursorVariable != "" {
+			s.Body = withVariable(step.Body, p.cfg.CursorVariable, p.state.Cursor)
+		} else if p.state.Cursor != "" {
+			params[p.cfg.CursorParam] = p.state.Cursor
+		}
+	case models.PaginationOffset:
+		params[p.cfg.OffsetParam] = strconv.Itoa(p.state.Offset)
+		if p.cfg.PageSize > 0 {
+			params[p.cfg.LimitParam] = strconv.Itoa(p.cfg.PageSize)
+		}
+	case models.PaginationPage:
+		params[p.cfg.PageParam] = strconv.Itoa(p.state.Page)
+		if p.cfg.SizeParam != "" && p.cfg.PageSize > 0 {
+			params[p.cfg.SizeParam] = strconv.Itoa(p.cfg.PageSize)
+		}
+	}
+	s.Params = params
+	return &s
+}
+
+// withVariable returns a copy of a GraphQL body with a variable set
+func withVariable(body any, name string, value any) map[string]any {
+	result := map[string]any{}
+	switch v := body.(type) {
+	case string:
+		result["query"] = v
+	case map[string]any:
+		for key, item := range v {
+			result[key] = item
+		}
+	}
+
+	variables := map[string]any{}
+	if existing, ok := result["variables"].(map[string]any); ok {
+		for key, item := range existing {
+			variables[key] = item
+		}
+	}
+	variables[name] = value
+	result["variables"] = variables
+	return result
+}
+
+// advance inspects a fetched page and moves to the next one
+func (p *paginator) advance(result *StepResult, data map[string]any) (pageOutcome, error) {
+	p.fetched++
+	if result == nil || result.Response == nil {
+		return pageDone, nil
+	}
+
+	if p.cfg.HasMorePath != "" {
+		more, err := p.evaluator.EvaluateBool(p.cfg.HasMorePath, data)
+		if err != nil {
+			return pageDone, fmt.Errorf("failed to evaluate has_more_path: %w", err)
+		}
+		if !more {
+			return pageDone, nil
+		}
+	}
+
+	itemCount := -1
+	if p.cfg.ItemsPath != "" {
+		items, err := p.evaluator.EvaluateSlice(p.cfg.ItemsPath, data)
+		if err != nil {
+			return pageDone, fmt.Errorf("failed to evaluate items_path: %w", err)
+		}
+		itemCount = len(items)
+		if itemCount == 0 || (p.cfg.PageSize > 0 && itemCount < p.cfg.PageSize) {
+			return pageDone, nil
+		}
+	}
+
+	next := p.state
+	switch p.cfg.Type {
+	case models.PaginationCursor:
+		cursor, err := p.evaluateString(p.cfg.CursorPath, data)
+		if err != nil {
+			return pageDone, fmt.Errorf("failed to evaluate cursor_path: %w", err)
+		}
+		// A repeated cursor would loop forever
+		if cursor == "" || cursor == p.state.Cursor {
+			return pageDone, nil
+		}
+		next.Cursor = cursor
+	case models.PaginationOffset:
+		step := itemCount
+		if step < 0 {
+			step = p.cfg.PageSize
+		}
+		next.Offset += step
+		if done, err := p.pastTotal(next.Offset, data); err != nil || done {
+			return pageDone, err
+		}
+	case models.PaginationPage:
+		next.Page++
+		if p.cfg.PageSize > 0 {
+			if done, err := p.pastTotal((next.Page-p.startPage())*p.cfg.PageSize, data); err != nil || done {
+				return pageDone, err
+			}
+		}
+	case models.PaginationLinkHeader:
+		link := nextLink(result.Response.Headers)
+		if link == "" {
+			return pageDone, nil
+		}
+		next.NextURL = resolveReference(result.rawURL, link)
+	case models.PaginationNextURL:
+		nextURL, err := p.evaluateString(p.cfg.NextURLPath, data)
+		if err != nil {
+			return pageDone, fmt.Errorf("failed to evaluate next_url_path: %w", err)
+		}
+		if nextURL == "" {
+			return pageDone, nil
+		}
+		next.NextURL = resolveReference(result.rawURL, nextURL)
+	}
+	if next.NextURL != "" && next.NextURL == p.state.NextURL {
+		return pageDone, nil
+	}
+	if next.NextURL != p.state.NextURL {
+		next.RestoreParams = nil
+	}
+
+	p.state = next
+	if p.fetched >= p.cfg.MaxPages {
+		return pageMaxPages, nil
+	}
+	return pageNext, nil
+}
+
+// checkpoint returns the position to store for step, or nil when it cannot be stored without a secret.
+// A next URL is stored without the step's own query params, which may carry credentials and are
+// rendered again on resume; any other known secret in it keeps the position from being stored.
+func (p *paginator) checkpoint(step *models.Step, masker *secrets.Masker) map[string]any {
+	state := p.state
+	if state.NextURL != "" && len(state.RestoreParams) == 0 {
+		nextURL, restore, ok := sanitizeNextURL(state.NextURL, step.Params, masker)
+		if !ok {
+			return nil
+		}
+		state.NextURL = nextURL
+		state.RestoreParams = restore
+	}
+
+	data, _ := json.Marshal(state)
+	var m map[string]any
+	_ = json.Unmarshal(data, &m)
+	return m
+}
+
+// sanitizeNextURL strips the query params a step renders itself from a next-page URL and reports
+// whether the rest is free of user info and known secrets
+func sanitizeNextURL(rawURL string, params map[string]string, masker *secrets.Masker) (string, []string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User != nil {
+		return "", nil, false
+	}
+
+	query := u.Query()
+	var restore []string
+	for key := range query {
+		if _, ok := params[key]; ok {
+			restore = append(restore, key)
+			query.Del(key)
+		}
+	}
+	sort.Strings(restore)
+	u.RawQuery = query.Encode()
+
+	sanitized := u.String()
+	if masker.Mask(sanitized) != sanitized {
+		return "", nil, false
+	}
+	return sanitized, restore, true
+}
+
+// startPage returns the configured first page number
+func (p *paginator) startPage() int {
+	if p.cfg.StartPage != nil {
+		return *p.cfg.StartPage
+	}
+	return 1
+}
+
+// pastTotal reports whether position has reached the total from total_path
+func (p *paginator) pastTotal(position int, data map[string]any) (bool, error) {
+	if p.cfg.TotalPath == "" {
+		return false, nil
+	}
+	value, err := p.evaluator.Evaluate(p.cfg.TotalPath, data)
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate total_path: %w", err)
+	}
+	total, ok := toFloat(value)
+	if !ok {
+		if s, isString := value.(string); isString {
+			parsed, parseErr := strconv.ParseFloat(s, 64)
+			total, ok = parsed, parseErr == nil
+		}
+	}
+	return ok && float64(position) >= total, nil
+}
+
+// evaluateString evaluates an expression to a string ("" for null)
+func (p *paginator) evaluateString(expr string, data map[string]any) (string, error) {
+	value, err := p.evaluator.Evaluate(expr, data)
+	if err != nil || value == nil {
+		return "", err
+	}
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return fmt.Sprintf("%v", v), nil
+	}
+}
+
+// toFloat converts a decoded JSON number to float64
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// nextLink extracts the rel="next" URL from a Link header
+func nextLink(headers map[string]string) string {
+	for name, value := range headers {
+		if !strings.EqualFold(name, "Link") {
+			continue
+		}
+		for _, link := range linkPattern.FindAllStringSubmatch(value, -1) {
+			rel := linkRelPattern.FindStringSubmatch(link[2])
+			if rel == nil {
+				continue
+			}
+			for _, relType := range strings.Fields(rel[1]) {
+				if strings.EqualFold(relType, "next") {
+					return link[1]
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// resolveReference resolves a possibly relative next-page URL against the current request URL
+func resolveReference(base, ref string) string {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return baseURL.ResolveReference(refURL).String()
+}
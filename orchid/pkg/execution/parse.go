@@ -0,0 +1,51 @@
+package execution
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/htmlindex"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+)
+
+// ValidateParse checks the parse options of a step
+func ValidateParse(cfg *models.ParseConfig) error {
+	if cfg == nil {
+		return nil
+	}
+
+	switch cfg.Format {
+	case "", models.ParseFormatJSON, models.ParseFormatNDJSON, models.ParseFormatXML, models.ParseFormatCSV,
+		models.ParseFormatTSV, models.ParseFormatText, models.ParseFormatBinary:
+	default:
+		return fmt.Errorf("unsupported format %q", cfg.Format)
+	}
+	switch cfg.Compression {
+	case "", models.CompressionNone, models.CompressionGzip, models.CompressionZip:
+	default:
+		return fmt.Errorf("unsupported compression %q", cfg.Compression)
+	}
+	if cfg.Delimiter != "" {
+		r, size := utf8.DecodeRuneInString(cfg.Delimiter)
+		if size != len(cfg.Delimiter) || r == utf8.RuneError || r == '"' || r == '\r' || r == '\n' {
+			return fmt.Errorf("delimiter must be a single character other than a quote or line break")
+		}
+	}
+	if cfg.Encoding != "" {
+		if _, err := htmlindex.Get(cfg.Encoding); err != nil {
+			return fmt.Errorf("unknown encoding %q", cfg.Encoding)
+		}
+	}
+	if cfg.ZipEntry != "" {
+		if _, err := path.Match(cfg.ZipEntry, ""); err != nil {
+			return fmt.Errorf("invalid zip_entry pattern %q", cfg.ZipEntry)
+		}
+	}
+	if cfg.SkipRows < 0 {
+		return errors.New("skip_rows must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package execution
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+)
+
+// runParseStep serves body with the given headers and returns the parsed response body of a step
+func runParseStep(t *testing.T, headers map[string]string, body []byte, parse *models.ParseConfig) any {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for name, value := range headers {
+			w.Header().Set(name, value)
+		}
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	result, err := newTestExecutor().stepExecutor.Execute(context.Background(), &models.Step{
+		URL:   server.URL + "/report",
+		Parse: parse,
+	}, NewExecutionContext())
+	require.NoError(t, err)
+	require.NoError(t, result.Error)
+	return result.Response.BodyJSON
+}
+
+func TestParse_CSVWithHeader(t *testing.T) {
+	body := runParseStep(t, map[string]string{"Content-Type": "text/csv; charset=utf-8"},
+		[]byte("\xEF\xBB\xBFid,name\n1,\"Smith, Jo\"\n2,Lee,extra\n"), nil)
+
+	require.Equal(t, []any{
+		map[string]any{"id": "1", "name": "Smith, Jo"},
+		map[string]any{"id": "2", "name": "Lee", "column_3": "extra"},
+	}, body)
+}
+
+func TestParse_ConfiguredTSVWithEncodingAndColumns(t *testing.T) {
+	// "Café" in windows-1252, after a report preamble and without a header row
+	noHeader := false
+	body := runParseStep(t, map[string]string{"Content-Type": "application/octet-stream"},
+		[]byte("Generated 2024-01-01\n1\tCaf\xe9\n"), &models.ParseConfig{
+			Format:   models.ParseFormatTSV,
+			Encoding: "windows-1252",
+			Header:   &noHeader,
+			Columns:  []string{"id", "name"},
+			SkipRows: 1,
+		})
+
+	require.Equal(t, []any{map[string]any{"id": "1", "name": "Café"}}, body)
+}
+
+func TestParse_NDJSON(t *testing.T) {
+	body := runParseStep(t, map[string]string{"Content-Type": "application/x-ndjson"},
+		[]byte("{\"id\":1}\n\n{\"id\":2}\n"), nil)
+
+	require.Equal(t, []any{map[string]any{"id": float64(1)}, map[string]any{"id": float64(2)}}, body)
+}
+
+func TestParse_GzipCSVFromFilename(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write([]byte("id\n1\n2\n"))
+	require.NoError(t, zw.Close())
+
+	body := runParseStep(t, map[string]string{
+		"Content-Type":        "application/octet-stream",
+		"Content-Disposition": `attachment; filename="export.csv.gz"`,
+	}, buf.Bytes(), nil)
+
+	require.Equal(t, []any{map[string]any{"id": "1"}, map[string]any{"id": "2"}}, body)
+}
+
+func TestParse_ZipEntriesAreConcatenated(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"users/a.csv": "id\n1\n",
+		"users/b.csv": "id\n2\n",
+		"README.txt":  "ignored",
+	} {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, _ = w.Write([]byte(content))
+	}
+	require.NoError(t, zw.Close())
+
+	body := runParseStep(t, map[string]string{"Content-Type": "application/zip"}, buf.Bytes(),
+		&models.ParseConfig{ZipEntry: "*.csv"})
+
+	require.ElementsMatch(t, []any{map[string]any{"id": "1"}, map[string]any{"id": "2"}}, body)
+}
+
+func TestParse_UnknownBinaryIsBase64Encoded(t *testing.T) {
+	body := runParseStep(t, map[string]string{"Content-Type": "application/octet-stream"}, []byte{0x1f, 0x8b, 0x00}, nil)
+
+	binary, ok := body.(map[string]any)
+	require.True(t, ok)
+	require.Equal(t, true, binary["_binary"])
+	require.Equal(t, 3, binary["_size"])
+}
+
+func TestValidateParse(t *testing.T) {
+	require.NoError(t, ValidateParse(nil))
+	require.NoError(t, ValidateParse(&models.ParseConfig{Format: models.ParseFormatCSV, Delimiter: ";", Encoding: "latin1"}))
+
+	require.Error(t, ValidateParse(&models.ParseConfig{Format: "yaml"}))
+	require.Error(t, ValidateParse(&models.ParseConfig{Compression: "brotli"}))
+	require.Error(t, ValidateParse(&models.ParseConfig{Delimiter: ";;"}))
+	require.Error(t, ValidateParse(&models.ParseConfig{Encoding: "klingon"}))
+	require.Error(t, ValidateParse(&models.ParseConfig{ZipEntry: "["}))
+	require.Error(t, ValidateParse(&models.ParseConfig{SkipRows: -1}))
+}
@@ -6,30 +6,49 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/Gobusters/ectoerror/httperror"
 	"github.com/Gobusters/ectologger"
 	"github.com/google/uuid"
 
+	"github.com/Ramsey-B/orchid/pkg/archive"
 	"github.com/Ramsey-B/orchid/pkg/expressions"
+	"github.com/Ramsey-B/orchid/pkg/httpclient"
 	"github.com/Ramsey-B/orchid/pkg/kafka"
 	"github.com/Ramsey-B/orchid/pkg/models"
+	"github.com/Ramsey-B/orchid/pkg/notify"
+	"github.com/Ramsey-B/orchid/pkg/ratelimit"
 	"github.com/Ramsey-B/orchid/pkg/repositories"
+	"github.com/Ramsey-B/orchid/pkg/secrets"
+	"github.com/Ramsey-B/orchid/pkg/signing"
+	"github.com/Ramsey-B/stem/pkg/database"
 	"github.com/Ramsey-B/stem/pkg/tracing"
 )
 
 // AuthManager interface for auth token management
 // Defined here to avoid circular imports
 type AuthManager interface {
-	GetAuthContext(ctx context.Context, authFlowID uuid.UUID, tenantID uuid.UUID, configID uuid.UUID, config map[string]any) (*AuthContext, error)
+	GetAuthContext(ctx context.Context, authFlowID uuid.UUID, tenantID uuid.UUID, configID uuid.UUID, config map[string]any, rateLimits []models.RateLimitConfig, transport *httpclient.TransportSettings) (*AuthContext, error)
 	InvalidateToken(ctx context.Context, tenantID, authFlowID, configID uuid.UUID) error
 }
 
+// Publisher receives the messages an execution emits.
+// *kafka.Producer publishes them; previews capture them in a *kafka.MemorySink.
+type Publisher interface {
+	Publish(ctx context.Context, msg *kafka.APIResponseMessage) error
+	PublishError(ctx context.Context, msg *kafka.APIResponseMessage) error
+	PublishExecutionEvent(ctx context.Context, evt *kafka.ExecutionEventMessage) error
+}
+
 var (
 	// ErrPlanNotFound is returned when a plan is not found
 	ErrPlanNotFound = errors.New("plan not found")
 
+	// ErrAuthFlowFailed is returned when the auth flow of a plan fails
+	ErrAuthFlowFailed = errors.New("auth flow failed")
+
 	// ErrConfigNotFound is returned when a config is not found
 	ErrConfigNotFound = errors.New("config not found")
 
@@ -44,6 +63,12 @@ var (
 
 	// ErrExecutionTimeout is returned when the execution times out
 	ErrExecutionTimeout = errors.New("execution timeout exceeded")
+
+	// ErrExecutionNotResumable is returned when resuming an execution that did not stop early or left no checkpoint
+	ErrExecutionNotResumable = errors.New("execution cannot be resumed")
+
+	// ErrExecutionCancelled is returned when an execution is cancelled through the API
+	ErrExecutionCancelled = errors.New("execution cancelled")
 )
 
 const (
@@ -82,11 +107,20 @@ type PlanExecutionInput struct {
 	ConfigID    uuid.UUID
 	TenantID    uuid.UUID
 
+	// Optional: ID of the execution record, generated when unset
+	ExecutionID uuid.UUID
+
 	// Optional: override stored context
 	ContextOverride map[string]any
 
 	// Optional: parent execution ID for sub-executions
 	ParentExecutionID *uuid.UUID
+
+	// Optional: continue from the checkpoint of the failed or aborted ParentExecutionID
+	Resume bool
+
+	// Set by PlanExecutor.Preview: captures traces and messages instead of publishing them
+	Preview *Preview
 }
 
 // PlanExecutionOutput holds the result of plan execution
@@ -100,62 +134,84 @@ type PlanExecutionOutput struct {
 	Error         error
 	ErrorType     *models.ErrorType
 	FinalContext  map[string]any
+	Watermark     string // Highest watermark seen (committed only when the execution succeeds)
 }
 
 // PlanExecutor orchestrates the execution of plans
 type PlanExecutor struct {
 	// Repositories
-	planRepo       repositories.PlanRepo
-	configRepo     repositories.ConfigRepo
-	authFlowRepo   repositories.AuthFlowRepo
-	contextRepo    repositories.PlanContextRepo
-	executionRepo  repositories.PlanExecutionRepo
-	statisticsRepo repositories.PlanStatisticsRepo
+	planRepo        repositories.PlanRepo
+	integrationRepo repositories.IntegrationRepo
+	configRepo      repositories.ConfigRepo
+	authFlowRepo    repositories.AuthFlowRepo
+	contextRepo     repositories.PlanContextRepo
+	executionRepo   repositories.PlanExecutionRepo
+	statisticsRepo  repositories.PlanStatisticsRepo
+	watermarkRepo   repositories.PlanWatermarkRepo
+	paginationRepo  repositories.PlanPaginationCheckpointRepo
 
 	// Execution components
 	stepExecutor   *StepExecutor
 	fanoutExecutor *FanoutExecutor
 	evaluator      *expressions.Evaluator
 	authManager    AuthManager
+	cipher         *secrets.Cipher
 
 	// External services
 	kafkaProducer *kafka.Producer
+	archive       *archive.Archive // Optional: keeps published responses for replay
+	notifier      *notify.Notifier // Optional: queues webhooks of the subscriptions an outcome fires
 
 	// Configuration
 	config PlanExecutorConfig
 	logger ectologger.Logger
+
+	// Cancel functions of the executions running on this executor, by execution ID
+	running sync.Map
 }
 
 // NewPlanExecutor creates a new plan executor
 func NewPlanExecutor(
 	planRepo repositories.PlanRepo,
+	integrationRepo repositories.IntegrationRepo,
 	configRepo repositories.ConfigRepo,
 	authFlowRepo repositories.AuthFlowRepo,
 	contextRepo repositories.PlanContextRepo,
 	executionRepo repositories.PlanExecutionRepo,
 	statisticsRepo repositories.PlanStatisticsRepo,
+	watermarkRepo repositories.PlanWatermarkRepo,
+	paginationRepo repositories.PlanPaginationCheckpointRepo,
 	stepExecutor *StepExecutor,
 	fanoutExecutor *FanoutExecutor,
 	evaluator *expressions.Evaluator,
 	authManager AuthManager,
+	cipher *secrets.Cipher,
 	kafkaProducer *kafka.Producer,
+	archive *archive.Archive,
+	notifier *notify.Notifier,
 	config PlanExecutorConfig,
 	logger ectologger.Logger,
 ) *PlanExecutor {
 	return &PlanExecutor{
-		planRepo:       planRepo,
-		configRepo:     configRepo,
-		authFlowRepo:   authFlowRepo,
-		contextRepo:    contextRepo,
-		executionRepo:  executionRepo,
-		statisticsRepo: statisticsRepo,
-		stepExecutor:   stepExecutor,
-		fanoutExecutor: fanoutExecutor,
-		evaluator:      evaluator,
-		authManager:    authManager,
-		kafkaProducer:  kafkaProducer,
-		config:         config,
-		logger:         logger,
+		planRepo:        planRepo,
+		integrationRepo: integrationRepo,
+		configRepo:      configRepo,
+		authFlowRepo:    authFlowRepo,
+		contextRepo:     contextRepo,
+		executionRepo:   executionRepo,
+		statisticsRepo:  statisticsRepo,
+		watermarkRepo:   watermarkRepo,
+		paginationRepo:  paginationRepo,
+		stepExecutor:    stepExecutor,
+		fanoutExecutor:  fanoutExecutor,
+		evaluator:       evaluator,
+		authManager:     authManager,
+		cipher:          cipher,
+		kafkaProducer:   kafkaProducer,
+		archive:         archive,
+		notifier:        notifier,
+		config:          config,
+		logger:          logger,
 	}
 }
 
@@ -166,14 +222,23 @@ func (e *PlanExecutor) Execute(ctx context.Context, input PlanExecutionInput) (*
 
 	startTime := time.Now()
 	output := &PlanExecutionOutput{
-		ExecutionID: uuid.New(),
+		ExecutionID: input.ExecutionID,
 		StartedAt:   startTime,
 		Status:      models.ExecutionStatusPending,
 	}
+	if output.ExecutionID == uuid.Nil {
+		output.ExecutionID = uuid.New()
+	}
 
 	e.logger.WithContext(ctx).Infof("Starting plan execution: plan=%s config=%s execution=%s",
 		input.PlanKey, input.ConfigID, output.ExecutionID)
 
+	// Register the execution before its record exists, so every cancellation of the record reaches it
+	runCtx, cancelRun := context.WithCancelCause(ctx)
+	defer cancelRun(nil)
+	e.running.Store(output.ExecutionID, cancelRun)
+	defer e.running.Delete(output.ExecutionID)
+
 	// Create execution record
 	execution := &models.PlanExecution{
 		ID:                output.ExecutionID,
@@ -181,6 +246,7 @@ func (e *PlanExecutor) Execute(ctx context.Context, input PlanExecutionInput) (*
 		PlanKey:           input.PlanKey,
 		ConfigID:          input.ConfigID,
 		ParentExecutionID: input.ParentExecutionID,
+		Resume:            input.Resume,
 		Status:            models.ExecutionStatusPending,
 	}
 
@@ -192,29 +258,21 @@ func (e *PlanExecutor) Execute(ctx context.Context, input PlanExecutionInput) (*
 	}
 
 	// Emit execution.started lifecycle event (best-effort).
-	if e.kafkaProducer != nil {
-		_ = e.kafkaProducer.PublishExecutionEvent(ctx, &kafka.ExecutionEventMessage{
-			Type:        "execution.started",
-			TenantID:    input.TenantID.String(),
-			Integration: input.Integration,
-			PlanKey:     input.PlanKey,
-			ConfigID:    input.ConfigID.String(),
-			ExecutionID: output.ExecutionID.String(),
-			Status:      "running",
-			Timestamp:   startTime.UTC(),
-		})
-	}
+	e.publishExecutionEvent(ctx, input, output.ExecutionID, "execution.started", "running", startTime)
 
 	// Set up execution timeout
-	execCtx := ctx
+	execCtx := runCtx
 	if e.config.MaxExecutionTime > 0 {
 		var cancel context.CancelFunc
-		execCtx, cancel = context.WithTimeout(ctx, e.config.MaxExecutionTime)
+		execCtx, cancel = context.WithTimeout(runCtx, e.config.MaxExecutionTime)
 		defer cancel()
 	}
 
 	// Execute the plan
 	err := e.executePlan(execCtx, input, output)
+	if err != nil && errors.Is(context.Cause(runCtx), ErrExecutionCancelled) {
+		err = ErrExecutionCancelled
+	}
 
 	// Complete the execution
 	output.CompletedAt = time.Now()
@@ -228,10 +286,13 @@ func (e *PlanExecutor) Execute(ctx context.Context, input PlanExecutionInput) (*
 		errorType := classifyError(err)
 		output.ErrorType = &errorType
 
-		// Check if it was an abort
+		// Check if it was an abort or a cancellation
 		if errors.Is(err, ErrExecutionAborted) {
 			output.Status = models.ExecutionStatusAborted
 		}
+		if errors.Is(err, ErrExecutionCancelled) {
+			output.Status = models.ExecutionStatusCancelled
+		}
 
 		errorMsg := err.Error()
 		if markErr := e.executionRepo.MarkCompleted(ctx, output.ExecutionID, output.Status, &errorMsg, output.ErrorType); markErr != nil {
@@ -244,10 +305,15 @@ func (e *PlanExecutor) Execute(ctx context.Context, input PlanExecutionInput) (*
 		}
 	}
 
-	// Record statistics
+	// Record statistics (a cancelled execution is neither a success nor a failure of the plan)
 	durationMs := int(output.Duration.Milliseconds())
-	if statsErr := e.statisticsRepo.RecordExecution(ctx, input.PlanKey, input.ConfigID, output.Status == models.ExecutionStatusSuccess, durationMs); statsErr != nil {
-		e.logger.WithContext(ctx).WithError(statsErr).Warn("Failed to record execution statistics")
+	if output.Status != models.ExecutionStatusCancelled {
+		streak, statsErr := e.statisticsRepo.RecordExecution(ctx, input.PlanKey, input.ConfigID, output.Status == models.ExecutionStatusSuccess, output.ErrorType, durationMs)
+		if statsErr != nil {
+			e.logger.WithContext(ctx).WithError(statsErr).Warn("Failed to record execution statistics")
+		} else {
+			e.notify(ctx, input, output, streak)
+		}
 	}
 
 	if output.TotalAPICalls > 0 {
@@ -260,21 +326,148 @@ func (e *PlanExecutor) Execute(ctx context.Context, input PlanExecutionInput) (*
 		output.ExecutionID, output.Status, output.Duration, output.TotalAPICalls)
 
 	// Emit execution.completed lifecycle event (best-effort).
+	e.publishExecutionEvent(ctx, input, output.ExecutionID, "execution.completed", string(output.Status), output.CompletedAt)
+
+	return output, err
+}
+
+// notify queues the webhooks of the notification subscriptions an execution outcome fires (best-effort)
+func (e *PlanExecutor) notify(ctx context.Context, input PlanExecutionInput, output *PlanExecutionOutput, streak models.FailureStreak) {
+	if e.notifier == nil {
+		return
+	}
+	outcome := notify.Outcome{
+		TenantID:         input.TenantID,
+		ExecutionID:      output.ExecutionID,
+		PlanKey:          input.PlanKey,
+		Integration:      input.Integration,
+		ConfigID:         input.ConfigID,
+		Status:           output.Status,
+		ErrorType:        output.ErrorType,
+		CompletedAt:      output.CompletedAt,
+		PreviousFailures: streak.PreviousFailures,
+		AuthFailures:     streak.AuthFailures,
+	}
+	if output.Error != nil {
+		outcome.Error = output.Error.Error()
+	}
+	if err := e.notifier.Notify(ctx, outcome); err != nil {
+		e.logger.WithContext(ctx).WithError(err).Warn("Failed to queue execution notifications")
+	}
+}
+
+// Cancel cancels an execution running on this executor: the context of its requests and fanout
+// workers is cancelled and the execution completes with the cancelled status.
+// It returns false when the execution does not run here.
+func (e *PlanExecutor) Cancel(executionID uuid.UUID) bool {
+	cancel, ok := e.running.Load(executionID)
+	if !ok {
+		return false
+	}
+	cancel.(context.CancelCauseFunc)(ErrExecutionCancelled)
+	return true
+}
+
+// OverlapPolicy returns the overlap policy of a plan
+func (e *PlanExecutor) OverlapPolicy(ctx context.Context, planKey string) (models.OverlapPolicy, error) {
+	plan, err := e.planRepo.GetByKey(ctx, planKey)
+	if err != nil {
+		return "", err
+	}
+	if !plan.OverlapPolicy.Valid() {
+		return models.DefaultOverlapPolicy, nil
+	}
+	return plan.OverlapPolicy, nil
+}
+
+// Preview runs a plan against a real config without side effects.
+// Requests are made as usual, but messages are captured in memory, loops and fanout are capped,
+// and no execution record, statistics or context is persisted.
+// An error is returned only when the plan or config does not exist; execution failures are reported in the result.
+func (e *PlanExecutor) Preview(ctx context.Context, input PlanExecutionInput) (*PreviewResult, error) {
+	ctx, span := tracing.StartSpan(ctx, "PlanExecutor.Preview")
+	defer span.End()
+
+	// Tokens, refresh tokens and learned rates of the real executions are left untouched
+	ctx = WithPreview(ctx)
+
+	if input.Preview == nil {
+		input.Preview = NewPreview(0, 0)
+	}
+
+	startTime := time.Now()
+	output := &PlanExecutionOutput{
+		ExecutionID: uuid.New(),
+		StartedAt:   startTime,
+		Status:      models.ExecutionStatusPending,
+	}
+
+	e.logger.WithContext(ctx).Infof("Starting plan preview: plan=%s config=%s execution=%s",
+		input.PlanKey, input.ConfigID, output.ExecutionID)
+
+	e.publishExecutionEvent(ctx, input, output.ExecutionID, "execution.started", "running", startTime)
+
+	execCtx := ctx
+	if e.config.MaxExecutionTime > 0 {
+		var cancel context.CancelFunc
+		execCtx, cancel = context.WithTimeout(ctx, e.config.MaxExecutionTime)
+		defer cancel()
+	}
+
+	err := e.executePlan(execCtx, input, output)
+	if errors.Is(err, ErrPlanNotFound) || errors.Is(err, ErrConfigNotFound) {
+		return nil, err
+	}
+
+	output.CompletedAt = time.Now()
+	output.Duration = output.CompletedAt.Sub(startTime)
+	output.Status = models.ExecutionStatusSuccess
+	if err != nil {
+		output.Error = err
+		output.Status = models.ExecutionStatusFailed
+		if errors.Is(err, ErrExecutionAborted) {
+			output.Status = models.ExecutionStatusAborted
+		}
+	}
+
+	e.publishExecutionEvent(ctx, input, output.ExecutionID, "execution.completed", string(output.Status), output.CompletedAt)
+
+	e.logger.WithContext(ctx).Infof("Plan preview completed: execution=%s status=%s duration=%s api_calls=%d",
+		output.ExecutionID, output.Status, output.Duration, output.TotalAPICalls)
+
+	return input.Preview.result(input, output), nil
+}
+
+// publisher returns where the execution's messages go (nil when there is nowhere to publish)
+func (e *PlanExecutor) publisher(input PlanExecutionInput) Publisher {
+	if input.Preview != nil {
+		return input.Preview.sink
+	}
 	if e.kafkaProducer != nil {
-		status := string(output.Status)
-		_ = e.kafkaProducer.PublishExecutionEvent(ctx, &kafka.ExecutionEventMessage{
-			Type:        "execution.completed",
-			TenantID:    input.TenantID.String(),
-			Integration: input.Integration,
-			PlanKey:     input.PlanKey,
-			ConfigID:    input.ConfigID.String(),
-			ExecutionID: output.ExecutionID.String(),
-			Status:      status,
-			Timestamp:   output.CompletedAt.UTC(),
-		})
+		if e.archive != nil {
+			return e.archive.Wrap(e.kafkaProducer)
+		}
+		return e.kafkaProducer
 	}
+	return nil
+}
 
-	return output, err
+// publishExecutionEvent emits a lifecycle event (best-effort)
+func (e *PlanExecutor) publishExecutionEvent(ctx context.Context, input PlanExecutionInput, executionID uuid.UUID, eventType, status string, timestamp time.Time) {
+	pub := e.publisher(input)
+	if pub == nil {
+		return
+	}
+	_ = pub.PublishExecutionEvent(ctx, &kafka.ExecutionEventMessage{
+		Type:        eventType,
+		TenantID:    input.TenantID.String(),
+		Integration: input.Integration,
+		PlanKey:     input.PlanKey,
+		ConfigID:    input.ConfigID.String(),
+		ExecutionID: executionID.String(),
+		Status:      status,
+		Timestamp:   timestamp.UTC(),
+	})
 }
 
 // executePlan performs the actual plan execution
@@ -282,9 +475,13 @@ func (e *PlanExecutor) executePlan(ctx context.Context, input PlanExecutionInput
 	ctx, span := tracing.StartSpan(ctx, "PlanExecutor.executePlan")
 	defer span.End()
 
-	// Mark execution as started
-	if err := e.executionRepo.MarkStarted(ctx, output.ExecutionID); err != nil {
-		return fmt.Errorf("failed to mark execution as started: %w", err)
+	preview := input.Preview
+
+	// Mark execution as started (previews have no execution record)
+	if preview == nil {
+		if err := e.executionRepo.MarkStarted(ctx, output.ExecutionID); err != nil {
+			return fmt.Errorf("failed to mark execution as started: %w", err)
+		}
 	}
 	output.Status = models.ExecutionStatusRunning
 
@@ -297,7 +494,8 @@ func (e *PlanExecutor) executePlan(ctx context.Context, input PlanExecutionInput
 		return fmt.Errorf("failed to load plan: %w", err)
 	}
 
-	if !plan.Enabled {
+	// Disabled plans can still be previewed while they are being authored
+	if !plan.Enabled && preview == nil {
 		return ErrPlanDisabled
 	}
 
@@ -310,6 +508,16 @@ func (e *PlanExecutor) executePlan(ctx context.Context, input PlanExecutionInput
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
+	// Load integration for the rate limits its plans share
+	integration, err := e.integrationRepo.GetByID(ctx, plan.IntegrationID)
+	if err != nil {
+		return fmt.Errorf("failed to load integration: %w", err)
+	}
+	sharedLimits := append(
+		ratelimit.WithLevel(models.RateLimitLevelIntegration, integration.RateLimits.Data),
+		ratelimit.WithLevel(models.RateLimitLevelConfig, config.RateLimits.Data)...,
+	)
+
 	// Parse plan definition
 	planDef, err := e.parsePlanDefinition(plan)
 	if err != nil {
@@ -322,9 +530,20 @@ func (e *PlanExecutor) executePlan(ctx context.Context, input PlanExecutionInput
 		return fmt.Errorf("failed to load context: %w", err)
 	}
 
+	// A resumed execution continues from the checkpoint the execution it resumes left behind
+	var checkpoint models.ExecutionCheckpoint
+	if input.Resume && preview == nil {
+		checkpoint, err = e.loadCheckpoint(ctx, input)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Build execution context
 	execCtx := NewExecutionContext()
-	if storedContext != nil {
+	if checkpoint.Context != nil {
+		execCtx.Context = checkpoint.Context
+	} else if storedContext != nil {
 		execCtx.Context = storedContext
 	}
 	if input.ContextOverride != nil {
@@ -332,10 +551,31 @@ func (e *PlanExecutor) executePlan(ctx context.Context, input PlanExecutionInput
 			execCtx.Context[k] = v
 		}
 	}
+	if checkpoint.Prev != nil {
+		execCtx.WithPrev(&ResponseContext{
+			StatusCode: checkpoint.Prev.StatusCode,
+			Headers:    checkpoint.Prev.Headers,
+			Body:       checkpoint.Prev.Body,
+		})
+	}
 
-	// Set config values
+	// Set config values, decrypting secret fields for the duration of the execution
+	var masker *secrets.Masker
+	var plaintexts []string
 	if config.Values.Data != nil {
-		execCtx.WithConfig(config.Values.Data)
+		values, decrypted, err := e.cipher.DecryptValues(ctx, config.Values.Data)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt config values: %w", err)
+		}
+		execCtx.WithConfig(values)
+		plaintexts = decrypted
+		masker = secrets.NewMasker(plaintexts...)
+	}
+
+	// Client certificates, CA bundles and proxies of the config get it a dedicated transport
+	transport, err := httpclient.ResolveTransport(integration.Transport.Data, execCtx.Config, e.evaluator)
+	if err != nil {
+		return fmt.Errorf("failed to resolve transport settings: %w", err)
 	}
 
 	// Set metadata
@@ -351,26 +591,53 @@ func (e *PlanExecutor) executePlan(ctx context.Context, input PlanExecutionInput
 		execCtx.Meta.PlanKey = planDef.Key
 	}
 
+	// Incremental syncs start from the committed watermark
+	var watermarks *watermarkTracker
+	if planDef.Watermark != nil {
+		committed, err := e.loadWatermark(ctx, input.PlanKey, input.ConfigID)
+		if err != nil {
+			return err
+		}
+		watermarks = newWatermarkTracker(planDef.Watermark, e.evaluator, committed)
+		watermarks.candidate = checkpoint.Watermark
+		execCtx.Meta.Watermark = watermarks.value
+	}
+
 	// Execute auth flow if specified
-	if planDef.Step.AuthFlowID != "" {
-		authFlowID, parseErr := uuid.Parse(planDef.Step.AuthFlowID)
+	var reauth *Reauthenticator
+	var signer signing.Signer
+	var authFlowKey string
+	if planAuthFlowID := planDef.AuthFlowID(); planAuthFlowID != "" {
+		authFlowID, parseErr := uuid.Parse(planAuthFlowID)
 		if parseErr != nil {
 			return fmt.Errorf("invalid auth_flow_id: %w", parseErr)
 		}
+		authFlowKey = authFlowID.String()
 
 		e.logger.WithContext(ctx).Debugf("Executing auth flow %s", authFlowID)
 
-		authCtx, authErr := e.authManager.GetAuthContext(ctx, authFlowID, input.TenantID, input.ConfigID, execCtx.Config)
+		authCtx, authErr := e.authManager.GetAuthContext(ctx, authFlowID, input.TenantID, input.ConfigID, execCtx.Config, sharedLimits, transport)
 		if authErr != nil {
-			return fmt.Errorf("auth flow failed: %w", authErr)
+			return fmt.Errorf("%w: %w", ErrAuthFlowFailed, authErr)
 		}
 
 		execCtx.WithAuth(authCtx)
+		signer = authCtx.Signer
 		e.logger.WithContext(ctx).Debug("Auth context obtained successfully")
+
+		reauth = NewReauthenticator(authCtx, func(ctx context.Context) (*AuthContext, error) {
+			if err := e.authManager.InvalidateToken(ctx, input.TenantID, authFlowID, input.ConfigID); err != nil {
+				e.logger.WithContext(ctx).WithError(err).Warnf("Failed to invalidate cached token for auth flow %s", authFlowID)
+			}
+			return e.authManager.GetAuthContext(ctx, authFlowID, input.TenantID, input.ConfigID, execCtx.Config, sharedLimits, transport)
+		})
 	}
 
 	// Apply plan definition limits
 	maxLoops := e.config.MaxLoops
+	if preview != nil && preview.MaxLoops < maxLoops {
+		maxLoops = preview.MaxLoops
+	}
 	if planDef.MaxExecutionSeconds > 0 {
 		// Use plan's timeout if it's more restrictive
 		planTimeout := time.Duration(planDef.MaxExecutionSeconds) * time.Second
@@ -387,19 +654,54 @@ func (e *PlanExecutor) executePlan(ctx context.Context, input PlanExecutionInput
 		maxNesting = planDef.MaxNestingDepth
 	}
 
+	// Configs authenticating with the same credentials share their rate limit buckets
+	secretValues := secrets.SecretValues(execCtx.Config, secrets.SecretPaths(integration.ConfigSchema.Data))
+	credential := ratelimit.CredentialKey(input.TenantID.String(), authFlowKey, secretValues)
+
+	// Previews return response bodies and the final context, which may echo any secret field or the token
+	if preview != nil {
+		previewSecrets := append(append([]string{}, plaintexts...), secretValues...)
+		if execCtx.Auth != nil {
+			previewSecrets = append(previewSecrets, execCtx.Auth.Token, execCtx.Auth.RefreshToken)
+		}
+		preview.masker = secrets.NewMasker(previewSecrets...)
+	}
+
 	// Build execution options with rate limits
 	execOpts := &ExecuteOptions{
 		TenantID:      input.TenantID,
 		IntegrationID: plan.IntegrationID,
 		ConfigID:      input.ConfigID,
-		RateLimits:    planDef.RateLimits,
+		Credential:    credential,
+		RateLimits:    append(planDef.RateLimits, sharedLimits...),
 		MaxRateWait:   60 * time.Second,
+		Reauth:        reauth,
+		Masker:        masker,
+		Preview:       preview,
+		Signer:        signer,
+		Transport:     transport,
+	}
+
+	// Loop state is checkpointed after every page of a single-step plan (previews have no execution record)
+	var checkpoints *checkpointer
+	if preview == nil && len(planDef.Steps) == 0 {
+		if checkpoint.Context == nil {
+			// A first page interrupted mid-fanout resumes from the context it started with
+			checkpoint.Context = copyContext(execCtx.Context)
+		}
+		checkpoints = newCheckpointer(e.executionRepo, e.logger, output.ExecutionID, checkpoint)
 	}
 
-	// Execute the main step (with optional while loop)
-	step := &planDef.Step
-	apiCalls, err := e.executeStepWithLoop(ctx, step, execCtx, maxLoops, input, output, execOpts)
+	// Execute the main step (with optional while loop), or the steps of a DAG plan
+	var apiCalls int
+	if len(planDef.Steps) > 0 {
+		apiCalls, err = e.executeSteps(ctx, planDef.Steps, execCtx, maxLoops, planDef.MaxParallelSteps, input, output, execOpts)
+	} else {
+		step := watermarks.apply(&planDef.Step)
+		apiCalls, err = e.executeStepWithLoop(ctx, step, execCtx, maxLoops, input, output, execOpts, checkpoints, watermarks)
+	}
 	output.TotalAPICalls = apiCalls
+	output.Watermark = watermarks.high()
 
 	if err != nil {
 		return err
@@ -407,10 +709,26 @@ func (e *PlanExecutor) executePlan(ctx context.Context, input PlanExecutionInput
 
 	// Save final context
 	output.FinalContext = execCtx.Context
+	if preview != nil {
+		return nil
+	}
 	if err := e.saveContext(ctx, input.PlanKey, input.ConfigID, execCtx.Context); err != nil {
 		e.logger.WithContext(ctx).WithError(err).Warn("Failed to save execution context")
 	}
 
+	// Every page was emitted, so the watermark can advance
+	if value, ok := watermarks.next(); ok {
+		watermark := &models.PlanWatermark{
+			PlanKey:     input.PlanKey,
+			ConfigID:    input.ConfigID,
+			Value:       value,
+			ExecutionID: &output.ExecutionID,
+		}
+		if err := e.watermarkRepo.Upsert(ctx, watermark); err != nil {
+			e.logger.WithContext(ctx).WithError(err).Warn("Failed to commit watermark; the next execution syncs from the previous one")
+		}
+	}
+
 	return nil
 }
 
@@ -423,12 +741,41 @@ func (e *PlanExecutor) executeStepWithLoop(
 	input PlanExecutionInput,
 	output *PlanExecutionOutput,
 	execOpts *ExecuteOptions,
+	checkpoints *checkpointer,
+	watermarks *watermarkTracker,
 ) (int, error) {
 	ctx, span := tracing.StartSpan(ctx, "PlanExecutor.executeStepWithLoop")
 	defer span.End()
 
-	loopCount := 0
+	// A resumed execution carries the checkpoint it resumes until it completes a page of its own
+	loopCount := checkpoints.loopCount()
 	totalAPICalls := 0
+	if input.Resume {
+		checkpoints.save(ctx)
+	}
+
+	// Paginated steps resume from the position an interrupted execution left behind.
+	// A resumed execution continues from the position of the execution it resumes rather than the latest one.
+	var pager *paginator
+	var pageState map[string]any
+	resumable := false
+	pageKey := paginationKey(step, execCtx)
+	if step.Pagination != nil {
+		state := checkpoints.pagination()
+		if state == nil && loopCount == 0 {
+			var err error
+			if state, err = e.loadPagination(ctx, input, pageKey); err != nil {
+				return 0, err
+			}
+		}
+		pager = newPaginator(step.Pagination, e.evaluator, state)
+		if pager.resumed {
+			// Pages synced before the checkpoint count toward the watermark
+			value, _ := state[watermarkCheckpointKey].(string)
+			watermarks.restore(value)
+			e.logger.WithContext(ctx).Infof("Resuming pagination of step %s from checkpoint", pageKey)
+		}
+	}
 
 	for {
 		// Check context cancellation
@@ -444,6 +791,11 @@ func (e *PlanExecutor) executeStepWithLoop(
 		// Check max loops
 		loopCount++
 		if loopCount > maxLoops {
+			if execOpts.Preview != nil {
+				execOpts.Preview.capLoops(step, execCtx)
+				resumable = true
+				break
+			}
 			return totalAPICalls, ErrMaxLoopsExceeded
 		}
 
@@ -453,16 +805,36 @@ func (e *PlanExecutor) executeStepWithLoop(
 		}
 
 		// Execute the step with rate limiting
-		result, err := e.stepExecutor.ExecuteWithOptions(ctx, step, execCtx, execOpts)
+		pageStep := step
+		if pager != nil {
+			pageStep = pager.apply(step)
+		}
+		var result *StepResult
+		var err error
+		if step.Stream != nil {
+			result, err = e.stepExecutor.ExecuteStream(ctx, pageStep, execCtx, execOpts,
+				e.streamEmitter(ctx, input, output, step, execCtx, execOpts, &totalAPICalls))
+		} else {
+			result, err = e.stepExecutor.ExecuteWithOptions(ctx, pageStep, execCtx, execOpts)
+		}
 		if err != nil {
 			return totalAPICalls, fmt.Errorf("step execution failed: %w", err)
 		}
+		if step.ID != "" && result.Response != nil {
+			execCtx.WithStepOutput(step.ID, result.Response)
+		}
 
 		totalAPICalls++
 
-		hasFanout := len(step.SubSteps) > 0 && step.IterateOver != ""
-		hasSubStepsNoFanout := len(step.SubSteps) > 0 && step.IterateOver == ""
-		hasIterateOnly := len(step.SubSteps) == 0 && step.IterateOver != ""
+		// Sub-step and fanout traces attach under this step's trace during previews
+		subCtx := withTraceParent(ctx, result.Trace)
+
+		// Records of a successful streamed response were emitted (and fanned out over) while it was read
+		streamed := step.Stream != nil && result.Response != nil && httpclient.IsSuccessStatus(result.Response.StatusCode)
+
+		hasFanout := step.Stream == nil && len(step.SubSteps) > 0 && step.IterateOver != ""
+		hasSubStepsNoFanout := step.Stream == nil && len(step.SubSteps) > 0 && step.IterateOver == ""
+		hasIterateOnly := step.Stream == nil && len(step.SubSteps) == 0 && step.IterateOver != ""
 
 		// Check for abort
 		if result.ShouldAbort {
@@ -477,20 +849,37 @@ func (e *PlanExecutor) executeStepWithLoop(
 
 		// Handle sub-steps with fanout
 		if hasFanout {
-			fanoutResult, fanoutErr := e.fanoutExecutor.ExecuteWithOptions(ctx, step, execCtx, 0, execOpts)
+			fanoutResult, fanoutErr := e.fanoutExecutor.ExecuteWithProgress(subCtx, step, execCtx, 0, execOpts, checkpoints.fanoutProgress(ctx))
+			checkpoints.flush(ctx)
 			if fanoutErr != nil {
 				return totalAPICalls, fmt.Errorf("fanout execution failed: %w", fanoutErr)
 			}
 
-			totalAPICalls += fanoutResult.TotalItems
+			// An interrupted fanout is not emitted; resuming emits the whole page
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				if ctxErr == context.DeadlineExceeded {
+					return totalAPICalls, ErrExecutionTimeout
+				}
+				return totalAPICalls, ctxErr
+			}
+
+			totalAPICalls += fanoutResult.TotalItems - fanoutResult.SkippedItems
 
 			// Standard emission: 1 Kafka message per step execution, response_body is ALWAYS an array.
 			// For fanout steps, response_body = []enriched items (each item has sub_step outputs appended as fields).
 			items := make([]any, 0, len(fanoutResult.Results))
 			forceError := false
 			forceAbort := false
-			for _, itemRes := range fanoutResult.Results {
-				if itemRes == nil || itemRes.Context == nil {
+			for i, itemRes := range fanoutResult.Results {
+				if itemRes == nil {
+					// Items completed before a resume are emitted from the checkpoint
+					if done, ok := checkpoints.completedItem(i); ok {
+						forceError = forceError || done.PolicyError
+						items = append(items, done.Payload)
+					}
+					continue
+				}
+				if itemRes.Context == nil {
 					continue
 				}
 				// If any sub-step tripped ignore_on/abort_on, route the whole page to error topic.
@@ -522,7 +911,7 @@ func (e *PlanExecutor) executeStepWithLoop(
 			forceError := false
 			forceAbort := false
 			for subIdx, subStep := range step.SubSteps {
-				subRes, subErr := e.stepExecutor.ExecuteWithOptions(ctx, &subStep, execCtx, execOpts)
+				subRes, subErr := e.stepExecutor.ExecuteWithOptions(subCtx, &subStep, execCtx, execOpts)
 				if subErr != nil {
 					return totalAPICalls, fmt.Errorf("sub_step execution failed: %w", subErr)
 				}
@@ -574,7 +963,7 @@ func (e *PlanExecutor) executeStepWithLoop(
 			if emitErr := e.emitStepBatchToKafka(ctx, input, output, step, result, items, false); emitErr != nil {
 				e.logger.WithContext(ctx).WithError(emitErr).Warn("Failed to emit response to Kafka")
 			}
-		} else {
+		} else if !streamed {
 			// Non-fanout: emit the main response as an array (object wrapped as [object], arrays passed through).
 			items := buildItemsFromResponse(result)
 			if emitErr := e.emitStepBatchToKafka(ctx, input, output, step, result, items, false); emitErr != nil {
@@ -587,9 +976,33 @@ func (e *PlanExecutor) executeStepWithLoop(
 			return totalAPICalls, ErrExecutionAborted
 		}
 
+		if err := watermarks.observe(execCtx.ToMap()); err != nil {
+			return totalAPICalls, err
+		}
+
+		// Pagination decides whether another page follows
+		if pager != nil {
+			outcome, pageErr := pager.advance(result, execCtx.ToMap())
+			if pageErr != nil {
+				return totalAPICalls, fmt.Errorf("pagination failed: %w", pageErr)
+			}
+			if outcome == pageDone {
+				e.logger.WithContext(ctx).Debug("Exiting pagination: no more pages")
+				break
+			}
+			pageState = e.checkpointPage(ctx, input, output, step, pageKey, pager, execOpts.masker(), watermarks.high())
+			if outcome == pageMaxPages {
+				e.logger.WithContext(ctx).Warnf("Pagination of step %s stopped after max_pages=%d; the next execution resumes from the checkpoint",
+					pageKey, pager.cfg.MaxPages)
+				resumable = true
+				break
+			}
+		}
+
 		// Check while condition for looping
 		if step.While != "" {
 			shouldContinue, whileErr := e.stepExecutor.EvaluateWhile(ctx, step, execCtx.ToMap())
+			result.Trace.recordCondition("while", step.While, shouldContinue, whileErr)
 			if whileErr != nil {
 				return totalAPICalls, fmt.Errorf("while condition evaluation failed: %w", whileErr)
 			}
@@ -598,24 +1011,105 @@ func (e *PlanExecutor) executeStepWithLoop(
 				e.logger.WithContext(ctx).Debug("Exiting while loop: condition is false")
 				break
 			}
-
-			// Store previous response for next iteration
-			if result.Response != nil {
-				execCtx.WithPrev(&ResponseContext{
-					StatusCode: result.Response.StatusCode,
-					Headers:    result.Response.Headers,
-					Body:       result.Response.BodyJSON,
-				})
-			}
-		} else {
-			// No while condition, execute only once
+		} else if pager == nil {
+			// No while condition or pagination, execute only once
 			break
 		}
+
+		// Store previous response for next iteration
+		if result.Response != nil {
+			execCtx.WithPrev(&ResponseContext{
+				StatusCode: result.Response.StatusCode,
+				Headers:    result.Response.Headers,
+				Body:       result.Response.BodyJSON,
+			})
+		}
+
+		// Checkpoint the state the next page starts from
+		checkpoints.pageCompleted(ctx, loopCount, result, execCtx, pageState, watermarks.high())
+	}
+
+	// A finished pagination starts from the first page next time; an unfinished one keeps
+	// the watermark until the execution that syncs its last page
+	if pager != nil && !resumable {
+		e.clearPagination(ctx, input, pageKey)
+	}
+	if resumable {
+		watermarks.hold()
 	}
 
 	return totalAPICalls, nil
 }
 
+// loadPagination returns the stored position of a paginated step (nil when there is none)
+func (e *PlanExecutor) loadPagination(ctx context.Context, input PlanExecutionInput, key string) (map[string]any, error) {
+	if e.paginationRepo == nil {
+		return nil, nil
+	}
+	checkpoint, err := e.paginationRepo.Get(ctx, input.PlanKey, input.ConfigID, key)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load pagination checkpoint: %w", err)
+	}
+	return checkpoint.State.Data, nil
+}
+
+// checkpointPage stores the next page of a paginated step, so an interrupted execution resumes
+// from that page instead of starting over. Only the position is stored, along with the highest
+// watermark seen so far, never the plan context; a position that cannot be stored without a secret
+// is dropped and pagination restarts next time. It returns the stored position (nil when there is none).
+func (e *PlanExecutor) checkpointPage(ctx context.Context, input PlanExecutionInput, output *PlanExecutionOutput, step *models.Step, key string, pager *paginator, masker *secrets.Masker, watermark string) map[string]any {
+	state := pager.checkpoint(step, masker)
+	if state == nil {
+		e.logger.WithContext(ctx).Warnf("Next page of step %s holds a secret and is not checkpointed", key)
+	} else if watermark != "" {
+		state[watermarkCheckpointKey] = watermark
+	}
+	if input.Preview != nil || e.paginationRepo == nil {
+		return state
+	}
+	if state == nil {
+		e.clearPagination(ctx, input, key)
+		return nil
+	}
+
+	checkpoint := &models.PlanPaginationCheckpoint{
+		PlanKey:     input.PlanKey,
+		ConfigID:    input.ConfigID,
+		StepKey:     key,
+		State:       database.JSONB[map[string]any]{Data: state},
+		ExecutionID: &output.ExecutionID,
+	}
+	if err := e.paginationRepo.Upsert(context.WithoutCancel(ctx), checkpoint); err != nil {
+		e.logger.WithContext(ctx).WithError(err).Warn("Failed to checkpoint pagination")
+	}
+	return state
+}
+
+// clearPagination removes the stored position of a paginated step (previews store none)
+func (e *PlanExecutor) clearPagination(ctx context.Context, input PlanExecutionInput, key string) {
+	if input.Preview != nil || e.paginationRepo == nil {
+		return
+	}
+	if err := e.paginationRepo.Delete(context.WithoutCancel(ctx), input.PlanKey, input.ConfigID, key); err != nil {
+		e.logger.WithContext(ctx).WithError(err).Warnf("Failed to clear pagination checkpoint of step %s", key)
+	}
+}
+
+// loadWatermark returns the committed watermark of a plan/config combination ("" when none was committed)
+func (e *PlanExecutor) loadWatermark(ctx context.Context, planKey string, configID uuid.UUID) (string, error) {
+	watermark, err := e.watermarkRepo.GetByPlanAndConfig(ctx, planKey, configID)
+	if err != nil {
+		if isNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to load watermark: %w", err)
+	}
+	return watermark.Value, nil
+}
+
 // parsePlanDefinition parses the plan definition from the plan model
 func (e *PlanExecutor) parsePlanDefinition(plan *models.Plan) (*models.PlanDefinition, error) {
 	if plan.PlanDefinition.Data == nil {
@@ -679,7 +1173,8 @@ func (e *PlanExecutor) emitStepBatchToKafka(
 	items []any,
 	forceError bool,
 ) error {
-	if e.kafkaProducer == nil || step == nil {
+	pub := e.publisher(input)
+	if pub == nil || step == nil {
 		return nil
 	}
 
@@ -729,10 +1224,12 @@ func (e *PlanExecutor) emitStepBatchToKafka(
 	// Status policy routing: abort_on and ignore_on go to error topic.
 	status := result.Response.StatusCode
 	if forceError || result.ShouldIgnore || containsStatus(step.AbortOn, status) || containsStatus(step.IgnoreOn, status) {
-		return e.kafkaProducer.PublishError(ctx, msg)
+		result.Trace.recordMessage(kafka.SinkMessage{Kind: kafka.SinkKindError, Response: msg})
+		return pub.PublishError(ctx, msg)
 	}
 
-	return e.kafkaProducer.Publish(ctx, msg)
+	result.Trace.recordMessage(kafka.SinkMessage{Kind: kafka.SinkKindResponse, Response: msg})
+	return pub.Publish(ctx, msg)
 }
 
 // buildEnrichedFanoutPayload merges the iterated item object with captured sub-step bodies.
@@ -800,11 +1297,15 @@ func classifyError(err error) models.ErrorType {
 		return models.ErrorTypePermanent
 	}
 
+	if errors.Is(err, ErrAuthFlowFailed) {
+		return models.ErrorTypeAuth
+	}
+
 	if errors.Is(err, ErrPlanNotFound) || errors.Is(err, ErrConfigNotFound) || errors.Is(err, ErrPlanDisabled) {
 		return models.ErrorTypePermanent
 	}
 
-	if errors.Is(err, ErrExecutionAborted) {
+	if errors.Is(err, ErrExecutionAborted) || errors.Is(err, ErrExecutionNotResumable) || errors.Is(err, ErrExecutionCancelled) {
 		return models.ErrorTypePermanent
 	}
 
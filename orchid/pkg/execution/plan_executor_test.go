@@ -0,0 +1,25 @@
+package execution
+
+import (
+	"github.com/Gobusters/ectologger/zapadapter"
+	"go.uber.org/zap"
+
+	"github.com/Ramsey-B/orchid/pkg/expressions"
+	"github.com/Ramsey-B/orchid/pkg/httpclient"
+)
+
+// newTestExecutor creates a plan executor without repositories, rate limiter or Kafka;
+// tests set the fields they need on it
+func newTestExecutor() *PlanExecutor {
+	zapLogger, _ := zap.NewDevelopment()
+	logger := zapadapter.NewZapEctoLogger(zapLogger, nil)
+	evaluator := expressions.NewEvaluator()
+	stepExecutor := NewStepExecutor(httpclient.NewClient(httpclient.DefaultConfig(), logger), evaluator, nil, logger)
+	return &PlanExecutor{
+		stepExecutor:   stepExecutor,
+		fanoutExecutor: NewFanoutExecutor(stepExecutor, evaluator, logger, 0),
+		evaluator:      evaluator,
+		config:         DefaultPlanExecutorConfig(),
+		logger:         logger,
+	}
+}
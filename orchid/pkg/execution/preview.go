@@ -0,0 +1,382 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Ramsey-B/orchid/pkg/httpclient"
+	"github.com/Ramsey-B/orchid/pkg/kafka"
+	"github.com/Ramsey-B/orchid/pkg/models"
+	"github.com/Ramsey-B/orchid/pkg/secrets"
+)
+
+const (
+	// DefaultPreviewMaxLoops is the default number of while loop iterations a preview runs
+	DefaultPreviewMaxLoops = 3
+
+	// DefaultPreviewMaxFanoutItems is the default number of items a preview fans out over
+	DefaultPreviewMaxFanoutItems = 5
+
+	// MaxPreviewLoops caps the while loop iterations a preview may request
+	MaxPreviewLoops = 50
+
+	// MaxPreviewFanoutItems caps the items a preview may request to fan out over
+	MaxPreviewFanoutItems = 100
+)
+
+const (
+	// TraceKindStep is a trace node for a single step execution
+	TraceKindStep = "step"
+
+	// TraceKindFanoutItem is a trace node grouping the sub-steps run for one fanout item
+	TraceKindFanoutItem = "fanout_item"
+)
+
+// Preview captures a dry-run of a plan execution.
+// Messages go to an in-memory sink instead of Kafka, and every step records a trace.
+type Preview struct {
+	MaxLoops       int
+	MaxFanoutItems int
+
+	mu        sync.Mutex
+	sink      *kafka.MemorySink
+	masker    *secrets.Masker // Set once the config's secrets are decrypted
+	steps     []*StepTrace
+	truncated []string
+}
+
+// NewPreview creates a preview with the given caps (defaults apply when <= 0, and they are clamped
+// to MaxPreviewLoops and MaxPreviewFanoutItems)
+func NewPreview(maxLoops, maxFanoutItems int) *Preview {
+	if maxLoops <= 0 {
+		maxLoops = DefaultPreviewMaxLoops
+	}
+	maxLoops = min(maxLoops, MaxPreviewLoops)
+	if maxFanoutItems <= 0 {
+		maxFanoutItems = DefaultPreviewMaxFanoutItems
+	}
+	maxFanoutItems = min(maxFanoutItems, MaxPreviewFanoutItems)
+	return &Preview{
+		MaxLoops:       maxLoops,
+		MaxFanoutItems: maxFanoutItems,
+		sink:           kafka.NewMemorySink(),
+	}
+}
+
+// StepTrace records what a step did during a preview
+type StepTrace struct {
+	Kind       string              `json:"kind"`
+	StepID     string              `json:"step_id,omitempty"`
+	StepPath   string              `json:"step_path"`
+	Loop       int                 `json:"loop,omitempty"`
+	ItemIndex  *int                `json:"item_index,omitempty"`
+	Attempts   int                 `json:"attempts,omitempty"`
+	Request    *RequestTrace       `json:"request,omitempty"`
+	Response   *ResponseTrace      `json:"response,omitempty"`
+	Conditions []ConditionTrace    `json:"conditions,omitempty"`
+	SetContext map[string]any      `json:"set_context,omitempty"`
+	Messages   []kafka.SinkMessage `json:"messages,omitempty"`
+	Error      string              `json:"error,omitempty"`
+	Children   []*StepTrace        `json:"children,omitempty"`
+
+	mu sync.Mutex
+}
+
+// RequestTrace is the rendered request of the last attempt, with secrets redacted
+type RequestTrace struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// ResponseTrace is the response of the last attempt
+type ResponseTrace struct {
+	StatusCode int               `json:"status_code"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       any               `json:"body,omitempty"`
+	DurationMs int64             `json:"duration_ms"`
+}
+
+// ConditionTrace is the result of an evaluated condition expression
+type ConditionTrace struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+	Result     bool   `json:"result"`
+	Error      string `json:"error,omitempty"`
+}
+
+// PreviewResult is the outcome of a plan preview
+type PreviewResult struct {
+	ExecutionID   string              `json:"execution_id"`
+	PlanKey       string              `json:"plan_key"`
+	ConfigID      string              `json:"config_id"`
+	Status        string              `json:"status"`
+	Error         string              `json:"error,omitempty"`
+	DurationMs    int64               `json:"duration_ms"`
+	TotalAPICalls int                 `json:"total_api_calls"`
+	Steps         []*StepTrace        `json:"steps"`
+	Messages      []kafka.SinkMessage `json:"messages"`
+	Context       map[string]any      `json:"context,omitempty"`
+	Truncated     []string            `json:"truncated,omitempty"`
+	Watermark     string              `json:"watermark,omitempty"` // Highest watermark the preview saw
+}
+
+// previewKey marks a context as running a preview
+type previewKey struct{}
+
+// WithPreview marks ctx as running a preview, so shared state (cached tokens,
+// persisted refresh tokens, learned rates) is neither read nor written
+func WithPreview(ctx context.Context) context.Context {
+	return context.WithValue(ctx, previewKey{}, true)
+}
+
+// IsPreview reports whether ctx runs a preview
+func IsPreview(ctx context.Context) bool {
+	preview, _ := ctx.Value(previewKey{}).(bool)
+	return preview
+}
+
+// traceParentKey carries the trace node new step traces attach to
+type traceParentKey struct{}
+
+// withTraceParent returns a context whose step traces attach under parent
+func withTraceParent(ctx context.Context, parent *StepTrace) context.Context {
+	if parent == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, traceParentKey{}, parent)
+}
+
+// attach adds a trace node under the parent carried by ctx, or at the root
+func (p *Preview) attach(ctx context.Context, node *StepTrace) {
+	if parent, ok := ctx.Value(traceParentKey{}).(*StepTrace); ok && parent != nil {
+		parent.mu.Lock()
+		parent.Children = append(parent.Children, node)
+		parent.mu.Unlock()
+		return
+	}
+	p.mu.Lock()
+	p.steps = append(p.steps, node)
+	p.mu.Unlock()
+}
+
+// startStep creates and attaches the trace node for a step execution (nil outside a preview)
+func (p *Preview) startStep(ctx context.Context, step *models.Step, execCtx *ExecutionContext) *StepTrace {
+	if p == nil {
+		return nil
+	}
+	node := &StepTrace{Kind: TraceKindStep, StepID: step.ID, StepPath: "root"}
+	if execCtx != nil && execCtx.Meta != nil {
+		if execCtx.Meta.StepPath != "" {
+			node.StepPath = execCtx.Meta.StepPath
+		}
+		node.Loop = execCtx.Meta.LoopCount
+	}
+	if execCtx != nil && execCtx.Item != nil {
+		index := execCtx.ItemIndex
+		node.ItemIndex = &index
+	}
+	p.attach(ctx, node)
+	return node
+}
+
+// fanoutItems creates one trace node per fanout item, in item order (nil outside a preview)
+func (p *Preview) fanoutItems(ctx context.Context, execCtx *ExecutionContext, count int) []*StepTrace {
+	if p == nil {
+		return nil
+	}
+	stepPath := "root"
+	if execCtx != nil && execCtx.Meta != nil && execCtx.Meta.StepPath != "" {
+		stepPath = execCtx.Meta.StepPath
+	}
+	nodes := make([]*StepTrace, count)
+	for i := range nodes {
+		index := i
+		nodes[i] = &StepTrace{
+			Kind:      TraceKindFanoutItem,
+			StepPath:  fmt.Sprintf("%s.fanout[%d]", stepPath, i),
+			ItemIndex: &index,
+		}
+		p.attach(ctx, nodes[i])
+	}
+	return nodes
+}
+
+// capFanout limits the items a preview fans out over
+func (p *Preview) capFanout(step *models.Step, execCtx *ExecutionContext, items []any) []any {
+	if p == nil || len(items) <= p.MaxFanoutItems {
+		return items
+	}
+	p.truncate("%s: iterate_over %q yielded %d items, previewed the first %d",
+		stepLabel(step, execCtx), step.IterateOver, len(items), p.MaxFanoutItems)
+	return items[:p.MaxFanoutItems]
+}
+
+// streamLimit returns how many records a streamed step reads (0, unlimited, outside a preview)
+func (p *Preview) streamLimit() int {
+	if p == nil {
+		return 0
+	}
+	return p.MaxFanoutItems
+}
+
+// capStream records that a streamed body was not read past the preview's item cap
+func (p *Preview) capStream(step *models.Step, execCtx *ExecutionContext, limit int) {
+	p.truncate("%s: streamed the first %d records", stepLabel(step, execCtx), limit)
+}
+
+// capLoops records that a while loop was stopped at the preview's loop cap
+func (p *Preview) capLoops(step *models.Step, execCtx *ExecutionContext) {
+	p.truncate("%s: while loop stopped after %d iterations", stepLabel(step, execCtx), p.MaxLoops)
+}
+
+// truncate records a note about work the preview skipped
+func (p *Preview) truncate(format string, args ...any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.truncated = append(p.truncated, fmt.Sprintf(format, args...))
+}
+
+// result builds the preview result from the execution output
+func (p *Preview) result(input PlanExecutionInput, output *PlanExecutionOutput) *PreviewResult {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	res := &PreviewResult{
+		ExecutionID:   output.ExecutionID.String(),
+		PlanKey:       input.PlanKey,
+		ConfigID:      input.ConfigID.String(),
+		Status:        string(output.Status),
+		DurationMs:    output.Duration.Milliseconds(),
+		TotalAPICalls: output.TotalAPICalls,
+		Steps:         p.steps,
+		Messages:      p.sink.Messages(),
+		Context:       maskMap(output.FinalContext, p.masker),
+		Truncated:     p.truncated,
+		Watermark:     output.Watermark,
+	}
+	if res.Steps == nil {
+		res.Steps = []*StepTrace{}
+	}
+	if res.Messages == nil {
+		res.Messages = []kafka.SinkMessage{}
+	}
+	if output.Error != nil {
+		res.Error = p.masker.Mask(output.Error.Error())
+	}
+	for _, step := range res.Steps {
+		step.mask(p.masker)
+	}
+	return res
+}
+
+// mask replaces secrets in the recorded response bodies and set_context writes of the trace tree
+func (t *StepTrace) mask(masker *secrets.Masker) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.Response != nil {
+		t.Response.Body = masker.MaskValue(t.Response.Body)
+	}
+	t.SetContext = maskMap(t.SetContext, masker)
+	for _, child := range t.Children {
+		child.mask(masker)
+	}
+}
+
+// maskMap returns a copy of values with secrets masked
+func maskMap(values map[string]any, masker *secrets.Masker) map[string]any {
+	masked, _ := masker.MaskValue(values).(map[string]any)
+	return masked
+}
+
+// recordRequest records the rendered request of an attempt
+func (t *StepTrace) recordRequest(req *http.Request, url string, masker *secrets.Masker) {
+	if t == nil {
+		return
+	}
+	t.Attempts++
+	headers := make(map[string]string, len(req.Header))
+	for name, values := range req.Header {
+		headers[name] = strings.Join(values, ", ")
+	}
+	t.Request = &RequestTrace{
+		Method:  req.Method,
+		URL:     url,
+		Headers: secrets.RedactHeaders(headers, masker),
+	}
+	// The trace reflects the last attempt only
+	t.Response = nil
+	t.Conditions = nil
+	t.SetContext = nil
+}
+
+// recordResponse records the response of an attempt
+func (t *StepTrace) recordResponse(resp *httpclient.Response, duration time.Duration, masker *secrets.Masker) {
+	if t == nil {
+		return
+	}
+	t.Response = &ResponseTrace{
+		StatusCode: resp.StatusCode,
+		Headers:    secrets.RedactHeaders(resp.Headers, masker),
+		Body:       resp.BodyJSON,
+		DurationMs: duration.Milliseconds(),
+	}
+	if t.Response.Body == nil && len(resp.Body) > 0 {
+		t.Response.Body = masker.Mask(string(resp.Body))
+	}
+}
+
+// recordCondition records an evaluated condition
+func (t *StepTrace) recordCondition(name, expr string, result bool, err error) {
+	if t == nil {
+		return
+	}
+	cond := ConditionTrace{Name: name, Expression: expr, Result: result}
+	if err != nil {
+		cond.Error = err.Error()
+	}
+	t.Conditions = append(t.Conditions, cond)
+}
+
+// recordSetContext records a set_context write
+func (t *StepTrace) recordSetContext(key string, value any) {
+	if t == nil {
+		return
+	}
+	if t.SetContext == nil {
+		t.SetContext = make(map[string]any)
+	}
+	t.SetContext[key] = value
+}
+
+// recordMessage records a message the step would have emitted
+func (t *StepTrace) recordMessage(msg kafka.SinkMessage) {
+	if t == nil {
+		return
+	}
+	t.Messages = append(t.Messages, msg)
+}
+
+// finish records the final error of the step
+func (t *StepTrace) finish(err error) {
+	if t == nil || err == nil {
+		return
+	}
+	t.Error = err.Error()
+}
+
+// stepLabel names a step in truncation notes
+func stepLabel(step *models.Step, execCtx *ExecutionContext) string {
+	label := "root"
+	if execCtx != nil && execCtx.Meta != nil && execCtx.Meta.StepPath != "" {
+		label = execCtx.Meta.StepPath
+	}
+	if step.ID != "" {
+		label += " (" + step.ID + ")"
+	}
+	return label
+}
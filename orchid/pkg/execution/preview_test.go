@@ -0,0 +1,120 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+	"github.com/Ramsey-B/orchid/pkg/secrets"
+)
+
+func TestPreview_RecordsStepTree(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/users" {
+			users := make([]any, 0, 4)
+			for i := 1; i <= 4; i++ {
+				users = append(users, map[string]any{"id": i})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"users": users, "next": nil})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"path": r.URL.Path})
+	}))
+	defer server.Close()
+
+	executor := newTestExecutor()
+
+	step := &models.Step{
+		ID:          "list_users",
+		URL:         server.URL + "/users?key={{config.api_key}}",
+		Headers:     map[string]string{"Authorization": "Bearer {{config.api_key}}"},
+		BreakWhen:   "response.body.next == null",
+		SetContext:  map[string]string{"user_count": "length(response.body.users)"},
+		IterateOver: "response.body.users",
+		SubSteps:    []models.Step{{ID: "user", URL: server.URL + "/users/{{item.id}}"}},
+	}
+
+	execCtx := NewExecutionContext().WithConfig(map[string]any{"api_key": "sk_live_secret"})
+	execCtx.WithMeta(&ExecutionMeta{StepPath: "root", LoopCount: 1})
+
+	preview := NewPreview(0, 2)
+	opts := &ExecuteOptions{Masker: secrets.NewMasker("sk_live_secret"), Preview: preview}
+
+	ctx := context.Background()
+	result, err := executor.stepExecutor.ExecuteWithOptions(ctx, step, execCtx, opts)
+	require.NoError(t, err)
+	fanoutResult, err := executor.fanoutExecutor.ExecuteWithOptions(withTraceParent(ctx, result.Trace), step, execCtx, 0, opts)
+	require.NoError(t, err)
+	require.Equal(t, 2, fanoutResult.TotalItems)
+
+	require.Len(t, preview.steps, 1)
+	root := preview.steps[0]
+	require.Equal(t, "list_users", root.StepID)
+	require.Equal(t, 1, root.Attempts)
+	require.NotContains(t, root.Request.URL, "sk_live_secret")
+	require.True(t, strings.HasSuffix(root.Request.URL, "key="+secrets.RedactedValue))
+	require.Equal(t, secrets.RedactedValue, root.Request.Headers["Authorization"])
+	require.Equal(t, http.StatusOK, root.Response.StatusCode)
+	require.Equal(t, []ConditionTrace{{Name: "break_when", Expression: step.BreakWhen, Result: true}}, root.Conditions)
+	require.Equal(t, map[string]any{"user_count": float64(4)}, root.SetContext)
+
+	require.Len(t, root.Children, 2)
+	for i, item := range root.Children {
+		require.Equal(t, TraceKindFanoutItem, item.Kind)
+		require.Equal(t, i, *item.ItemIndex)
+		require.Len(t, item.Children, 1)
+		require.Equal(t, "user", item.Children[0].StepID)
+		require.Equal(t, http.StatusOK, item.Children[0].Response.StatusCode)
+	}
+	require.Len(t, preview.truncated, 1)
+}
+
+func TestPreview_MasksBodiesAndContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		// The API echoes the credential back, e.g. in a "whoami" response
+		_ = json.NewEncoder(w).Encode(map[string]any{"key": r.URL.Query().Get("key"), "next": "page-2"})
+	}))
+	defer server.Close()
+
+	stepExecutor := newTestExecutor().stepExecutor
+
+	step := &models.Step{
+		ID:         "whoami",
+		URL:        server.URL + "/whoami?key={{config.api_key}}",
+		SetContext: map[string]string{"echoed": "response.body.key"},
+	}
+	execCtx := NewExecutionContext().WithConfig(map[string]any{"api_key": "sk_live_secret"})
+	execCtx.WithMeta(&ExecutionMeta{StepPath: "root"})
+
+	preview := NewPreview(0, 0)
+	preview.masker = secrets.NewMasker("sk_live_secret")
+	_, err := stepExecutor.ExecuteWithOptions(WithPreview(context.Background()), step, execCtx, &ExecuteOptions{Masker: preview.masker, Preview: preview})
+	require.NoError(t, err)
+
+	finalContext := map[string]any{"echoed": "sk_live_secret", "cursor": "page-2"}
+	result := preview.result(PlanExecutionInput{PlanKey: "whoami"}, &PlanExecutionOutput{FinalContext: finalContext})
+
+	require.Len(t, result.Steps, 1)
+	require.Equal(t, map[string]any{"key": secrets.RedactedValue, "next": "page-2"}, result.Steps[0].Response.Body)
+	require.Equal(t, map[string]any{"echoed": secrets.RedactedValue}, result.Steps[0].SetContext)
+	require.Equal(t, map[string]any{"echoed": secrets.RedactedValue, "cursor": "page-2"}, result.Context)
+	require.Equal(t, "sk_live_secret", finalContext["echoed"])
+}
+
+func TestNewPreview_Caps(t *testing.T) {
+	preview := NewPreview(0, 0)
+	require.Equal(t, DefaultPreviewMaxLoops, preview.MaxLoops)
+	require.Equal(t, DefaultPreviewMaxFanoutItems, preview.MaxFanoutItems)
+
+	preview = NewPreview(MaxPreviewLoops+1, MaxPreviewFanoutItems*10)
+	require.Equal(t, MaxPreviewLoops, preview.MaxLoops)
+	require.Equal(t, MaxPreviewFanoutItems, preview.MaxFanoutItems)
+}
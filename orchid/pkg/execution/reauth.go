@@ -0,0 +1,51 @@
+package execution
+
+import (
+	"context"
+	"sync"
+)
+
+// ReauthFunc invalidates the cached token and re-runs the auth flow
+type ReauthFunc func(ctx context.Context) (*AuthContext, error)
+
+// Reauthenticator re-runs an auth flow when an upstream API rejects the current token.
+// A single instance is shared by every request of a plan execution (including fanout),
+// so a burst of concurrent 401s results in one re-authentication rather than one per request.
+type Reauthenticator struct {
+	mu      sync.Mutex
+	current *AuthContext
+	reauth  ReauthFunc
+}
+
+// NewReauthenticator creates a reauthenticator seeded with the auth context the execution started with
+func NewReauthenticator(current *AuthContext, reauth ReauthFunc) *Reauthenticator {
+	return &Reauthenticator{
+		current: current,
+		reauth:  reauth,
+	}
+}
+
+// Current returns the most recent auth context
+func (r *Reauthenticator) Current() *AuthContext {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Reauthenticate replaces a rejected auth context.
+// If another request already replaced it, the newer context is returned without re-running the auth flow.
+func (r *Reauthenticator) Reauthenticate(ctx context.Context, rejected *AuthContext) (*AuthContext, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.current != nil && rejected != nil && r.current.Token != rejected.Token {
+		return r.current, false, nil
+	}
+
+	authCtx, err := r.reauth(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	r.current = authCtx
+	return authCtx, true, nil
+}
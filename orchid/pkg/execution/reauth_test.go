@@ -0,0 +1,108 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+)
+
+func TestReauthenticator_ConcurrentRejectionsReauthenticateOnce(t *testing.T) {
+	var calls int32
+	stale := &AuthContext{Token: "stale"}
+	reauth := NewReauthenticator(stale, func(ctx context.Context) (*AuthContext, error) {
+		atomic.AddInt32(&calls, 1)
+		return &AuthContext{Token: "fresh"}, nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			authCtx, _, err := reauth.Reauthenticate(context.Background(), stale)
+			require.NoError(t, err)
+			require.Equal(t, "fresh", authCtx.Token)
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	require.Equal(t, "fresh", reauth.Current().Token)
+}
+
+func TestPlanExecutor_ReauthenticatesOnceOn401(t *testing.T) {
+	tests := []struct {
+		name       string
+		accepted   string // Token the API accepts
+		reauthErr  error  // Returned by the auth flow
+		wantErr    error
+		wantType   models.ErrorType
+		wantTokens []string
+	}{
+		{name: "fresh token accepted", accepted: "Bearer fresh", wantTokens: []string{"Bearer stale", "Bearer fresh"}},
+		{name: "fresh token rejected too", wantErr: ErrExecutionAborted, wantType: models.ErrorTypePermanent,
+			wantTokens: []string{"Bearer stale", "Bearer fresh"}},
+		{name: "auth flow fails", reauthErr: errors.New("invalid_client"), wantErr: ErrAuthFlowFailed, wantType: models.ErrorTypeAuth,
+			wantTokens: []string{"Bearer stale"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var tokens []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				tokens = append(tokens, r.Header.Get("Authorization"))
+				mu.Unlock()
+				if r.Header.Get("Authorization") != tt.accepted {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"users": []}`))
+			}))
+			defer server.Close()
+
+			executor := newTestExecutor()
+
+			stale := &AuthContext{Token: "stale", ReauthOn: []int{http.StatusUnauthorized}}
+			var flows int32
+			reauth := NewReauthenticator(stale, func(ctx context.Context) (*AuthContext, error) {
+				atomic.AddInt32(&flows, 1)
+				if tt.reauthErr != nil {
+					return nil, tt.reauthErr
+				}
+				return &AuthContext{Token: "fresh", ReauthOn: stale.ReauthOn}, nil
+			})
+
+			execCtx := NewExecutionContext().WithAuth(stale)
+			execCtx.WithMeta(&ExecutionMeta{StepPath: "root"})
+			steps := []models.Step{{
+				ID:        "users",
+				URL:       server.URL + "/users",
+				Headers:   map[string]string{"Authorization": "Bearer {{auth.token}}"},
+				AbortWhen: "to_string(response.status_code) == '401'",
+			}}
+			_, err := executor.executeSteps(context.Background(), steps, execCtx, 1, 1, PlanExecutionInput{}, &PlanExecutionOutput{},
+				&ExecuteOptions{Reauth: reauth})
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Equal(t, tt.wantType, classifyError(err))
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, "fresh", reauth.Current().Token)
+			}
+			// The auth flow runs once and the request is retried at most once, with the new token
+			require.Equal(t, int32(1), atomic.LoadInt32(&flows))
+			require.Equal(t, tt.wantTokens, tokens)
+		})
+	}
+}
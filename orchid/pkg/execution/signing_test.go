@@ -0,0 +1,48 @@
+package execution
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ramsey-B/orchid/pkg/expressions"
+	"github.com/Ramsey-B/orchid/pkg/models"
+	"github.com/Ramsey-B/orchid/pkg/signing"
+)
+
+func TestExecute_SignsBuiltRequest(t *testing.T) {
+	var signature, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature = r.Header.Get("X-Signature")
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	execCtx := NewExecutionContext().WithConfig(map[string]any{"secret": "s3cret", "name": "orchid"})
+	signer, err := signing.New(&models.SignerConfig{
+		Type:         models.SignerHMAC,
+		Secret:       "{{ config.secret }}",
+		StringToSign: "{{ request.body }}",
+		Headers:      map[string]string{"X-Signature": "{{ signature }}"},
+	}, execCtx.Config, expressions.NewEvaluator())
+	require.NoError(t, err)
+
+	step := &models.Step{
+		Method: http.MethodPost,
+		URL:    server.URL,
+		Body:   map[string]any{"name": "{{ config.name }}"},
+	}
+	_, err = newTestExecutor().stepExecutor.ExecuteWithOptions(context.Background(), step, execCtx, &ExecuteOptions{Signer: signer})
+	require.NoError(t, err)
+
+	// The signature covers the rendered body, which is still sent in full
+	require.Equal(t, `{"name":"orchid"}`, body)
+	require.Equal(t, "6178f3af9545bf2165781211d1834e35210586d7a0ddd4d361196747fef961c6", signature)
+}
@@ -0,0 +1,91 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Ramsey-B/orchid/pkg/httpclient"
+	"github.com/Ramsey-B/orchid/pkg/models"
+)
+
+// ValidateStream checks the stream block of a step
+func ValidateStream(step *models.Step) error {
+	s := step.Stream
+	if s == nil {
+		return nil
+	}
+	if s.BatchSize < 0 {
+		return errors.New("stream batch_size must not be negative")
+	}
+	if _, err := httpclient.NewRecordDecoder(strings.NewReader(""), s.Format, s.Path); err != nil {
+		return fmt.Errorf("%w (want json_array, json_path or ndjson)", err)
+	}
+	if step.Parse != nil {
+		return errors.New("streamed steps decode their own format: parse is not supported")
+	}
+	if step.IterateOver != "" {
+		return errors.New("streamed steps fan out over their records: iterate_over is not supported")
+	}
+	// The body is not kept, so only the Link header can point to the next page
+	if step.Pagination != nil && step.Pagination.Type != models.PaginationLinkHeader {
+		return errors.New("streamed steps only support link_header pagination")
+	}
+	return nil
+}
+
+// streamEmitter emits each batch of records of a streamed step as it is read.
+// With sub_steps, the batch is fanned out over first and its enriched items are emitted,
+// adding the sub-step calls to apiCalls.
+func (e *PlanExecutor) streamEmitter(
+	ctx context.Context,
+	input PlanExecutionInput,
+	output *PlanExecutionOutput,
+	step *models.Step,
+	execCtx *ExecutionContext,
+	execOpts *ExecuteOptions,
+	apiCalls *int,
+) StreamFunc {
+	return func(result *StepResult, records []any) error {
+		if len(step.SubSteps) == 0 {
+			if err := e.emitStepBatchToKafka(ctx, input, output, step, result, records, false); err != nil {
+				e.logger.WithContext(ctx).WithError(err).Warn("Failed to emit streamed records to Kafka")
+			}
+			return nil
+		}
+
+		fanoutResult, err := e.fanoutExecutor.ExecuteItems(ctx, step, execCtx, records, 0, execOpts)
+		if err != nil {
+			return fmt.Errorf("fanout execution failed: %w", err)
+		}
+		*apiCalls += fanoutResult.TotalItems
+
+		items := make([]any, 0, len(fanoutResult.Results))
+		forceError := false
+		forceAbort := false
+		for _, itemRes := range fanoutResult.Results {
+			if itemRes == nil || itemRes.Context == nil {
+				continue
+			}
+			if itemRes.Context.Context != nil {
+				if v, ok := itemRes.Context.Context["fanout_policy_error"].(bool); ok && v {
+					forceError = true
+				}
+				if v, ok := itemRes.Context.Context["fanout_policy_abort"].(bool); ok && v {
+					forceAbort = true
+					forceError = true
+				}
+			}
+			items = append(items, buildEnrichedFanoutPayload(itemRes.Context))
+		}
+		if err := e.emitStepBatchToKafka(ctx, input, output, step, result, items, forceError); err != nil {
+			e.logger.WithContext(ctx).WithError(err).Warn("Failed to emit streamed records to Kafka")
+		}
+
+		if fanoutResult.AbortTriggered || forceAbort {
+			return ErrExecutionAborted
+		}
+		return nil
+	}
+}
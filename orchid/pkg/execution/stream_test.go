@@ -0,0 +1,109 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ramsey-B/orchid/pkg/httpclient"
+	"github.com/Ramsey-B/orchid/pkg/kafka"
+	"github.com/Ramsey-B/orchid/pkg/models"
+)
+
+// runStreamStep runs a single step and returns the bodies of the messages it emitted
+func runStreamStep(t *testing.T, executor *PlanExecutor, step *models.Step) (int, [][]map[string]any) {
+	preview := NewPreview(0, 100)
+	execCtx := NewExecutionContext()
+	execCtx.WithMeta(&ExecutionMeta{StepPath: "root"})
+
+	apiCalls, err := executor.executeStepWithLoop(context.Background(), step, execCtx, 1,
+		PlanExecutionInput{Preview: preview}, &PlanExecutionOutput{}, &ExecuteOptions{Preview: preview}, nil, nil)
+	require.NoError(t, err)
+
+	var bodies [][]map[string]any
+	for _, msg := range preview.sink.Messages() {
+		require.Equal(t, kafka.SinkKindResponse, msg.Kind)
+		var body []map[string]any
+		require.NoError(t, json.Unmarshal(msg.Response.ResponseBody, &body))
+		bodies = append(bodies, body)
+	}
+	return apiCalls, bodies
+}
+
+func TestStream_EmitsBatchesOfJSONPathArray(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"meta":{"cursor":"x","tags":["a"]},"data":{"total":5,"items":[`))
+		for i := 1; i <= 5; i++ {
+			if i > 1 {
+				_, _ = w.Write([]byte(","))
+			}
+			_, _ = fmt.Fprintf(w, `{"id":%d}`, i)
+		}
+		_, _ = w.Write([]byte(`]}}`))
+	}))
+	defer server.Close()
+
+	apiCalls, bodies := runStreamStep(t, newTestExecutor(), &models.Step{
+		URL:    server.URL + "/export",
+		Stream: &models.Stream{Format: models.StreamJSONPath, Path: "$.data.items", BatchSize: 2},
+	})
+
+	require.Equal(t, 1, apiCalls)
+	require.Len(t, bodies, 3)
+	require.Equal(t, []map[string]any{{"id": float64(1)}, {"id": float64(2)}}, bodies[0])
+	require.Equal(t, []map[string]any{{"id": float64(5)}}, bodies[2])
+}
+
+func TestStream_FansOutOverNDJSONRecords(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/export" {
+			_, _ = w.Write([]byte("{\"id\":\"a\"}\n{\"id\":\"b\"}\n\n{\"id\":\"c\"}\n"))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"path": r.URL.Path})
+	}))
+	defer server.Close()
+
+	apiCalls, bodies := runStreamStep(t, newTestExecutor(), &models.Step{
+		URL:      server.URL + "/export",
+		Stream:   &models.Stream{Format: models.StreamNDJSON, BatchSize: 2},
+		SubSteps: []models.Step{{ID: "detail", URL: server.URL + "/users/{{item.id}}"}},
+	})
+
+	require.Equal(t, 4, apiCalls)
+	require.Len(t, bodies, 2)
+	require.Len(t, bodies[0], 2)
+	require.Equal(t, "c", bodies[1][0]["id"])
+	require.Equal(t, map[string]any{"path": "/users/c"}, bodies[1][0]["detail"])
+}
+
+func TestRecordDecoder_ReportsTruncatedArray(t *testing.T) {
+	dec, err := httpclient.NewRecordDecoder(strings.NewReader(`[{"id":1},{"id":2}`), models.StreamJSONArray, "")
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err := dec.Next()
+		require.NoError(t, err)
+	}
+	_, err = dec.Next()
+	require.Error(t, err)
+}
+
+func TestValidateStream(t *testing.T) {
+	require.NoError(t, ValidateStream(&models.Step{Stream: &models.Stream{Format: models.StreamNDJSON}}))
+	require.Error(t, ValidateStream(&models.Step{Stream: &models.Stream{Format: "csv"}}))
+	require.Error(t, ValidateStream(&models.Step{Stream: &models.Stream{Format: models.StreamJSONPath}}))
+	require.Error(t, ValidateStream(&models.Step{Stream: &models.Stream{Format: models.StreamJSONPath, Path: "$.items[*]"}}))
+	require.Error(t, ValidateStream(&models.Step{
+		Stream:     &models.Stream{Format: models.StreamJSONArray},
+		Pagination: &models.Pagination{Type: models.PaginationCursor},
+	}))
+}
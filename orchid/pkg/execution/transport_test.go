@@ -0,0 +1,187 @@
+package execution
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ramsey-B/orchid/pkg/expressions"
+	"github.com/Ramsey-B/orchid/pkg/httpclient"
+	"github.com/Ramsey-B/orchid/pkg/models"
+)
+
+// testCert is a certificate and its key, PEM encoded
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM string
+	keyPEM  string
+	tlsCert tls.Certificate
+}
+
+// newTestCert issues a certificate signed by parent (self-signed without one)
+func newTestCert(t *testing.T, template *x509.Certificate, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	c := &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		keyPEM:  string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})),
+	}
+	c.tlsCert, err = tls.X509KeyPair([]byte(c.certPEM), []byte(c.keyPEM))
+	require.NoError(t, err)
+	return c
+}
+
+// newMTLSServer starts a server with a private CA that requires client certificates issued by it
+func newMTLSServer(t *testing.T) (*httptest.Server, *testCert, *testCert) {
+	ca := newTestCert(t, &x509.Certificate{
+		Subject: pkix.Name{CommonName: "Test CA"}, IsCA: true, BasicConstraintsValid: true,
+		KeyUsage: x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}, nil)
+	server := newTestCert(t, &x509.Certificate{
+		Subject: pkix.Name{CommonName: "localhost"}, IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca)
+	client := newTestCert(t, &x509.Certificate{
+		Subject: pkix.Name{CommonName: "orchid"}, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"client":"` + r.TLS.PeerCertificates[0].Subject.CommonName + `"}`))
+	}))
+	srv.TLS = &tls.Config{
+		Certificates: []tls.Certificate{server.tlsCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv, ca, client
+}
+
+func TestExecute_DedicatedTransportWithClientCertificate(t *testing.T) {
+	server, ca, client := newMTLSServer(t)
+	executor := newTestExecutor().stepExecutor
+
+	// Secret config values supply the certificate, its key and the private CA
+	config := map[string]any{"tls": map[string]any{"cert": client.certPEM, "key": client.keyPEM, "ca": ca.certPEM}}
+	settings, err := httpclient.ResolveTransport(&models.TransportConfig{
+		ClientCert:    "{{ config.tls.cert }}",
+		ClientKey:     "{{ config.tls.key }}",
+		CABundle:      "{{ config.tls.ca }}",
+		TLSMinVersion: "1.2",
+	}, config, expressions.NewEvaluator())
+	require.NoError(t, err)
+
+	step := &models.Step{Method: http.MethodGet, URL: server.URL}
+	opts := &ExecuteOptions{ConfigID: uuid.New(), Transport: settings}
+	result, err := executor.ExecuteWithOptions(context.Background(), step, NewExecutionContext(), opts)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"client": "orchid"}, result.Response.BodyJSON)
+
+	// The shared transport trusts neither the CA nor has a certificate
+	_, err = executor.Execute(context.Background(), step, NewExecutionContext())
+	require.Error(t, err)
+}
+
+func TestExecute_DedicatedTransportThroughProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A forward proxy receives the absolute URL of the request
+		proxied = r.URL.String()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"via":"proxy"}`))
+	}))
+	defer proxy.Close()
+
+	settings, err := httpclient.ResolveTransport(&models.TransportConfig{ProxyURL: "{{ config.proxy_url }}"},
+		map[string]any{"proxy_url": proxy.URL}, expressions.NewEvaluator())
+	require.NoError(t, err)
+
+	step := &models.Step{Method: http.MethodGet, URL: "http://api.internal.example/users?page=1"}
+	result, err := newTestExecutor().stepExecutor.ExecuteWithOptions(context.Background(), step, NewExecutionContext(),
+		&ExecuteOptions{ConfigID: uuid.New(), Transport: settings})
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"via": "proxy"}, result.Response.BodyJSON)
+	require.Equal(t, "http://api.internal.example/users?page=1", proxied)
+}
+
+func TestClient_ForConfigCachesPerConfig(t *testing.T) {
+	client := httpclient.NewClient(httpclient.DefaultConfig(), newTestExecutor().logger)
+	configID := uuid.New()
+
+	shared, err := client.ForConfig(configID, nil)
+	require.NoError(t, err)
+	require.Same(t, client, shared)
+
+	proxyA := &httpclient.TransportSettings{ProxyURL: "http://proxy-a:3128"}
+	first, err := client.ForConfig(configID, proxyA)
+	require.NoError(t, err)
+	require.NotSame(t, client, first)
+
+	again, err := client.ForConfig(configID, &httpclient.TransportSettings{ProxyURL: "http://proxy-a:3128"})
+	require.NoError(t, err)
+	require.Same(t, first, again)
+
+	// Changed settings rebuild the config's transport, other configs get their own
+	changed, err := client.ForConfig(configID, &httpclient.TransportSettings{ProxyURL: "http://proxy-b:3128"})
+	require.NoError(t, err)
+	require.NotSame(t, first, changed)
+	other, err := client.ForConfig(uuid.New(), proxyA)
+	require.NoError(t, err)
+	require.NotSame(t, changed, other)
+
+	_, err = client.ForConfig(uuid.New(), &httpclient.TransportSettings{ClientCert: "not a certificate", ClientKey: "x"})
+	require.Error(t, err)
+}
+
+func TestResolveAndValidateTransport(t *testing.T) {
+	evaluator := expressions.NewEvaluator()
+
+	// Fields that resolve to empty leave the config on the shared transport
+	settings, err := httpclient.ResolveTransport(&models.TransportConfig{ProxyURL: "{{ config.proxy_url }}"}, map[string]any{}, evaluator)
+	require.NoError(t, err)
+	require.Nil(t, settings)
+
+	require.NoError(t, httpclient.ValidateTransport(nil))
+	require.NoError(t, httpclient.ValidateTransport(&models.TransportConfig{ProxyURL: "socks5://proxy:1080", TLSMinVersion: "1.3"}))
+	require.NoError(t, httpclient.ValidateTransport(&models.TransportConfig{ClientCert: "{{ config.cert }}", ClientKey: "{{ config.key }}"}))
+
+	require.Error(t, httpclient.ValidateTransport(&models.TransportConfig{ClientCert: "{{ config.cert }}"}))
+	require.Error(t, httpclient.ValidateTransport(&models.TransportConfig{TLSMinVersion: "1.4"}))
+	require.Error(t, httpclient.ValidateTransport(&models.TransportConfig{ProxyURL: "ftp://proxy"}))
+}
@@ -0,0 +1,205 @@
+package execution
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ramsey-B/orchid/pkg/expressions"
+	"github.com/Ramsey-B/orchid/pkg/models"
+)
+
+// ValidateWatermark checks that a watermark declaration is complete
+func ValidateWatermark(w *models.Watermark) error {
+	if w == nil {
+		return nil
+	}
+	if w.Expression == "" || w.Param == "" {
+		return errors.New("watermark requires expression and param")
+	}
+	switch w.Compare {
+	case "", models.WatermarkCompareString, models.WatermarkCompareNumber, models.WatermarkCompareTimestamp:
+	default:
+		return fmt.Errorf("unknown watermark compare %q (want string, number or timestamp)", w.Compare)
+	}
+	if w.Initial != "" {
+		if err := ValidateWatermarkValue(w, w.Initial); err != nil {
+			return fmt.Errorf("invalid watermark initial: %w", err)
+		}
+	}
+	return nil
+}
+
+// ValidateWatermarkValue checks that value can be compared the way the watermark declares
+func ValidateWatermarkValue(w *models.Watermark, value string) error {
+	if value == "" {
+		return errors.New("watermark value must not be empty")
+	}
+	_, err := compareWatermarks(w.Compare, value, value)
+	return err
+}
+
+// watermarkCheckpointKey holds, in a pagination checkpoint, the highest watermark of the pages before it
+const watermarkCheckpointKey = "watermark"
+
+// watermarkTracker sends the committed watermark and tracks the highest value an execution sees.
+// A nil tracker (plans without a watermark) does nothing.
+type watermarkTracker struct {
+	cfg       models.Watermark
+	evaluator *expressions.Evaluator
+	value     string // Watermark the execution syncs from ("" before the first commit without initial)
+	candidate string // Highest value seen so far
+	held      bool   // Pagination stopped with pages left, so nothing is committed yet
+}
+
+// newWatermarkTracker creates a tracker starting from the committed watermark
+func newWatermarkTracker(cfg *models.Watermark, evaluator *expressions.Evaluator, committed string) *watermarkTracker {
+	w := &watermarkTracker{cfg: *cfg, evaluator: evaluator, value: committed}
+	if w.value == "" {
+		w.value = cfg.Initial
+	}
+	return w
+}
+
+// apply returns a copy of step that sends the watermark as its param
+func (w *watermarkTracker) apply(step *models.Step) *models.Step {
+	if w == nil || w.value == "" {
+		return step
+	}
+	s := *step
+	s.Params = make(map[string]string, len(step.Params)+1)
+	for k, v := range step.Params {
+		s.Params[k] = v
+	}
+	s.Params[w.cfg.Param] = w.value
+	return &s
+}
+
+// observe evaluates the watermark expression against a page, keeping the highest value.
+// The expression may yield a single value or a list; nulls are ignored.
+func (w *watermarkTracker) observe(data map[string]any) error {
+	if w == nil {
+		return nil
+	}
+	result, err := w.evaluator.Evaluate(w.cfg.Expression, data)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate watermark expression: %w", err)
+	}
+
+	values, ok := result.([]any)
+	if !ok {
+		values = []any{result}
+	}
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		value := formatWatermark(v)
+		if w.candidate != "" {
+			cmp, err := compareWatermarks(w.cfg.Compare, value, w.candidate)
+			if err != nil {
+				return err
+			}
+			if cmp <= 0 {
+				continue
+			}
+		} else if err := ValidateWatermarkValue(&w.cfg, value); err != nil {
+			return err
+		}
+		w.candidate = value
+	}
+	return nil
+}
+
+// restore carries over the highest value an earlier execution saw on the pagination this one resumes
+func (w *watermarkTracker) restore(value string) {
+	if w == nil || value == "" {
+		return
+	}
+	if w.candidate != "" {
+		if cmp, err := compareWatermarks(w.cfg.Compare, value, w.candidate); err != nil || cmp <= 0 {
+			return
+		}
+	}
+	w.candidate = value
+}
+
+// hold keeps the watermark from being committed: the pages left are synced by a later execution,
+// which must still send the watermark this one started from
+func (w *watermarkTracker) hold() {
+	if w != nil {
+		w.held = true
+	}
+}
+
+// high returns the highest value seen so far ("" for none)
+func (w *watermarkTracker) high() string {
+	if w == nil {
+		return ""
+	}
+	return w.candidate
+}
+
+// next returns the candidate when it is ahead of the watermark the execution started from
+// and every page was synced
+func (w *watermarkTracker) next() (string, bool) {
+	if w == nil || w.held || w.candidate == "" {
+		return "", false
+	}
+	if w.value == "" {
+		return w.candidate, true
+	}
+	cmp, err := compareWatermarks(w.cfg.Compare, w.candidate, w.value)
+	return w.candidate, err == nil && cmp > 0
+}
+
+// compareWatermarks compares two watermark values (-1, 0 or 1)
+func compareWatermarks(compare, a, b string) (int, error) {
+	switch compare {
+	case models.WatermarkCompareNumber:
+		x, err := strconv.ParseFloat(a, 64)
+		if err != nil {
+			return 0, fmt.Errorf("watermark %q is not a number", a)
+		}
+		y, err := strconv.ParseFloat(b, 64)
+		if err != nil {
+			return 0, fmt.Errorf("watermark %q is not a number", b)
+		}
+		switch {
+		case x < y:
+			return -1, nil
+		case x > y:
+			return 1, nil
+		}
+		return 0, nil
+	case models.WatermarkCompareTimestamp:
+		x, err := time.Parse(time.RFC3339Nano, a)
+		if err != nil {
+			return 0, fmt.Errorf("watermark %q is not an RFC 3339 timestamp", a)
+		}
+		y, err := time.Parse(time.RFC3339Nano, b)
+		if err != nil {
+			return 0, fmt.Errorf("watermark %q is not an RFC 3339 timestamp", b)
+		}
+		return x.Compare(y), nil
+	default:
+		return strings.Compare(a, b), nil
+	}
+}
+
+// formatWatermark converts an evaluated value to its stored form
+func formatWatermark(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		return v.String()
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
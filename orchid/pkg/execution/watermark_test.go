@@ -0,0 +1,110 @@
+package execution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ramsey-B/orchid/pkg/expressions"
+	"github.com/Ramsey-B/orchid/pkg/models"
+)
+
+func TestWatermarkTracker_KeepsHighestValue(t *testing.T) {
+	cfg := &models.Watermark{
+		Expression: "response.body.items[].updated_at",
+		Param:      "updated_since",
+		Compare:    models.WatermarkCompareTimestamp,
+	}
+	tracker := newWatermarkTracker(cfg, expressions.NewEvaluator(), "2024-01-01T00:00:00Z")
+
+	step := tracker.apply(&models.Step{Params: map[string]string{"limit": "100"}})
+	require.Equal(t, map[string]string{"limit": "100", "updated_since": "2024-01-01T00:00:00Z"}, step.Params)
+
+	page := func(times ...any) map[string]any {
+		items := make([]any, 0, len(times))
+		for _, ts := range times {
+			items = append(items, map[string]any{"updated_at": ts})
+		}
+		return map[string]any{"response": map[string]any{"body": map[string]any{"items": items}}}
+	}
+
+	// Offsets are compared as instants, not strings
+	require.NoError(t, tracker.observe(page("2024-03-01T10:00:00+02:00", nil, "2024-02-01T00:00:00Z")))
+	require.NoError(t, tracker.observe(page("2024-03-01T09:00:00Z")))
+	require.NoError(t, tracker.observe(page()))
+
+	value, ok := tracker.next()
+	require.True(t, ok)
+	require.Equal(t, "2024-03-01T09:00:00Z", value)
+
+	require.Error(t, tracker.observe(page("yesterday")))
+}
+
+func TestWatermarkTracker_DoesNotMoveBackwards(t *testing.T) {
+	cfg := &models.Watermark{Expression: "response.body.max_id", Param: "since_id", Compare: models.WatermarkCompareNumber}
+	tracker := newWatermarkTracker(cfg, expressions.NewEvaluator(), "100")
+
+	require.NoError(t, tracker.observe(map[string]any{"response": map[string]any{"body": map[string]any{"max_id": float64(99)}}}))
+	_, ok := tracker.next()
+	require.False(t, ok)
+
+	var none *watermarkTracker
+	none.hold()
+	none.restore("1")
+	step := &models.Step{}
+	require.Same(t, step, none.apply(step))
+	require.NoError(t, none.observe(nil))
+}
+
+func TestValidateWatermark(t *testing.T) {
+	require.NoError(t, ValidateWatermark(&models.Watermark{Expression: "response.body.cursor", Param: "since"}))
+	require.Error(t, ValidateWatermark(&models.Watermark{Expression: "response.body.cursor"}))
+	require.Error(t, ValidateWatermark(&models.Watermark{Expression: "x", Param: "since", Compare: "date"}))
+	require.Error(t, ValidateWatermark(&models.Watermark{Expression: "x", Param: "since", Compare: models.WatermarkCompareNumber, Initial: "abc"}))
+}
+
+func TestWatermarkTracker_HeldUntilPaginationCompletes(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		if page < 3 {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/items?page=%d>; rel="next"`, server.URL, page+1))
+		}
+		// Newest first, so later pages hold older values
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"max_id": 100 - page})
+	}))
+	defer server.Close()
+
+	executor := newTestExecutor()
+	executor.paginationRepo = &paginationRepo{}
+	cfg := &models.Watermark{Expression: "response.body.max_id", Param: "since_id", Compare: models.WatermarkCompareNumber}
+	step := &models.Step{URL: server.URL + "/items", Pagination: &models.Pagination{Type: models.PaginationLinkHeader, MaxPages: 2}}
+	run := func() *watermarkTracker {
+		watermarks := newWatermarkTracker(cfg, expressions.NewEvaluator(), "")
+		execCtx := NewExecutionContext()
+		execCtx.WithMeta(&ExecutionMeta{StepPath: "root"})
+		_, err := executor.executeStepWithLoop(context.Background(), watermarks.apply(step), execCtx, 10, PlanExecutionInput{},
+			&PlanExecutionOutput{}, &ExecuteOptions{}, nil, watermarks)
+		require.NoError(t, err)
+		return watermarks
+	}
+
+	// max_pages stopped before the last page, so nothing is committed
+	_, ok := run().next()
+	require.False(t, ok)
+
+	// The execution syncing the last page commits the highest value of every page
+	value, ok := run().next()
+	require.True(t, ok)
+	require.Equal(t, "99", value)
+}
@@ -0,0 +1,381 @@
+package expressions
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// go-jmespath has no way to register functions, so custom function calls are evaluated first and
+// replaced with JSON literals before the rest of the expression is handed to it. The arguments are
+// evaluated against the expression's data, so where the current node is something else (inside
+// projections, filters, the right side of a pipe or an expression reference) only calls whose
+// arguments are literals, or such calls, can be used; other calls are rejected when the expression
+// is compiled rather than evaluated against the wrong node.
+// Calls whose result does not depend on the data are evaluated once, when the expression is compiled.
+
+// call is a custom function call in an expression
+type call struct {
+	name   string
+	start  int // expression[start:end] is the whole call
+	end    int
+	args   []string
+	folded string // JSON literal of the result when it does not depend on the data ("" otherwise)
+}
+
+// frame is an open bracket while scanning an expression
+type frame struct {
+	scoped    bool // The current node inside the bracket is not the root
+	piped     bool // A pipe or expression reference made the rest of the bracket relative
+	projected bool // A projection made the rest of the operand relative (until ||, && or a comparison)
+}
+
+// relative reports whether the current node at this point of the bracket is not the root
+func (f *frame) relative() bool {
+	return f.scoped || f.piped || f.projected
+}
+
+// findCalls returns the outermost custom function calls of an expression, in order.
+// Calls nested in their arguments are found when the arguments are evaluated.
+func (e *Evaluator) findCalls(expression string) ([]call, error) {
+	var calls []call
+	stack := []frame{{}}
+	prev := byte(0) // Last significant token: a character, or 'v' after a value and 0 after an operator
+
+	for i := 0; i < len(expression); {
+		c := expression[i]
+		top := &stack[len(stack)-1]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case c == '\'' || c == '"' || c == '`':
+			end, err := skipLiteral(expression, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+			prev = 'v'
+			continue
+		case isIdentStart(c):
+			j := i
+			for j < len(expression) && isIdentChar(expression[j]) {
+				j++
+			}
+			name := expression[i:j]
+			k := j
+			for k < len(expression) && expression[k] == ' ' {
+				k++
+			}
+			if _, ok := e.functions[name]; ok && k < len(expression) && expression[k] == '(' {
+				if prev == '.' {
+					return nil, fmt.Errorf("%s() cannot be used as a sub-expression", name)
+				}
+				end, err := matchClose(expression, k)
+				if err != nil {
+					return nil, err
+				}
+				args, err := splitArgs(expression[k+1 : end-1])
+				if err != nil {
+					return nil, fmt.Errorf("%s(): %w", name, err)
+				}
+				c := call{name: name, start: i, end: end, args: args}
+				if top.relative() && !e.independent(c, true) {
+					return nil, fmt.Errorf("%s() can only be called with literal arguments inside a projection, filter, pipe or expression reference", name)
+				}
+				calls = append(calls, c)
+				i = end
+				prev = 'v'
+				continue
+			}
+			i = j
+			prev = 'v'
+			continue
+		}
+
+		switch c {
+		case '(', '[', '{':
+			f := frame{scoped: top.relative() || prev == '.'}
+			next := nextSignificant(expression, i+1)
+			if c != '(' {
+				// A bracket after a value applies to that value; [? ... ] filters each element
+				f.scoped = f.scoped || prev == 'v' || prev == ']' || prev == ')' || prev == '}' || prev == '*' || prev == '@'
+				if c == '[' && next == '?' {
+					f.scoped = true
+				}
+			}
+			if c == '[' && (next == ']' || next == '*' || next == '?') {
+				// [], [*] and [? ... ] project the rest of the operand
+				top.projected = true
+			}
+			stack = append(stack, f)
+			prev = 0
+		case ')', ']', '}':
+			if len(stack) == 1 {
+				return nil, fmt.Errorf("unbalanced %q", c)
+			}
+			stack = stack[:len(stack)-1]
+			prev = c
+		case '|', '&':
+			if i+1 < len(expression) && expression[i+1] == c {
+				// || and && end a projection
+				i++
+				top.projected = false
+			} else {
+				top.piped = true
+			}
+			prev = 0
+		case '<', '>', '=', '!':
+			// Comparisons end a projection
+			top.projected = false
+			prev = 0
+		case ',':
+			// Each element of a list starts from the bracket's current node again
+			top.piped = false
+			top.projected = false
+			prev = 0
+		case '.':
+			prev = '.'
+		case '*':
+			if prev == '.' || prev == 0 {
+				// .* and a leading * project the rest of the operand
+				top.projected = true
+			}
+			prev = '*'
+		case '@':
+			prev = '@'
+		default:
+			prev = 0
+		}
+		i++
+	}
+
+	if len(stack) != 1 {
+		return nil, fmt.Errorf("unbalanced brackets in %q", expression)
+	}
+	return calls, nil
+}
+
+// skipLiteral returns the index after the string, quoted identifier or JSON literal starting at i
+func skipLiteral(expression string, i int) (int, error) {
+	quote := expression[i]
+	for j := i + 1; j < len(expression); j++ {
+		switch expression[j] {
+		case '\\':
+			j++
+		case quote:
+			return j + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unclosed %c in %q", quote, expression)
+}
+
+// matchClose returns the index after the bracket closing the one at i
+func matchClose(expression string, i int) (int, error) {
+	depth := 0
+	for j := i; j < len(expression); j++ {
+		switch c := expression[j]; c {
+		case '\'', '"', '`':
+			end, err := skipLiteral(expression, j)
+			if err != nil {
+				return 0, err
+			}
+			j = end - 1
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth == 0 {
+				return j + 1, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unclosed ( in %q", expression)
+}
+
+// splitArgs splits the argument list of a call at its top-level commas
+func splitArgs(list string) ([]string, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+
+	var args []string
+	depth, start := 0, 0
+	for j := 0; j < len(list); j++ {
+		switch list[j] {
+		case '\'', '"', '`':
+			end, err := skipLiteral(list, j)
+			if err != nil {
+				return nil, err
+			}
+			j = end - 1
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, strings.TrimSpace(list[start:j]))
+				start = j + 1
+			}
+		}
+	}
+	args = append(args, strings.TrimSpace(list[start:]))
+
+	for _, arg := range args {
+		if arg == "" {
+			return nil, fmt.Errorf("empty argument")
+		}
+	}
+	return args, nil
+}
+
+// nextSignificant returns the first non-space character at or after i (0 at the end)
+func nextSignificant(expression string, i int) byte {
+	for ; i < len(expression); i++ {
+		if c := expression[i]; c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+			return c
+		}
+	}
+	return 0
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
+
+// expand evaluates the custom function calls of an expression and replaces them with JSON literals
+func (e *Evaluator) expand(expression string, calls []call, data interface{}) (string, error) {
+	var b strings.Builder
+	last := 0
+	for _, c := range calls {
+		if c.folded != "" {
+			b.WriteString(expression[last:c.start])
+			b.WriteString(c.folded)
+			last = c.end
+			continue
+		}
+
+		args := make([]interface{}, len(c.args))
+		for i, arg := range c.args {
+			value, err := e.Evaluate(arg, data)
+			if err != nil {
+				return "", err
+			}
+			args[i] = value
+		}
+
+		result, err := e.functions[c.name].Call(args)
+		if err != nil {
+			return "", fmt.Errorf("%s(): %w", c.name, err)
+		}
+
+		b.WriteString(expression[last:c.start])
+		b.WriteString(literal(result))
+		last = c.end
+	}
+	b.WriteString(expression[last:])
+	return b.String(), nil
+}
+
+// fold evaluates the calls whose result does not depend on the data, and reports whether all of them were
+func (e *Evaluator) fold(calls []call) bool {
+	all := true
+	for i := range calls {
+		if !e.constantCall(calls[i]) {
+			all = false
+			continue
+		}
+		args := make([]interface{}, len(calls[i].args))
+		for j, arg := range calls[i].args {
+			value, err := e.Evaluate(arg, nil)
+			if err != nil {
+				return false
+			}
+			args[j] = value
+		}
+		result, err := e.functions[calls[i].name].Call(args)
+		if err != nil {
+			// Reported when the expression is evaluated
+			all = false
+			continue
+		}
+		calls[i].folded = literal(result)
+	}
+	return all
+}
+
+// constantCall reports whether a call always returns the same result
+func (e *Evaluator) constantCall(c call) bool {
+	return e.independent(c, false)
+}
+
+// independent reports whether the arguments of a call are raw string or JSON literals, or calls
+// with such arguments, so the data does not matter. Calls of volatile functions only count if allowed.
+func (e *Evaluator) independent(c call, volatile bool) bool {
+	if !volatile && e.functions[c.name].Volatile {
+		return false
+	}
+	for _, arg := range c.args {
+		if arg[0] == '\'' || arg[0] == '`' {
+			if end, err := skipLiteral(arg, 0); err != nil || end != len(arg) {
+				return false
+			}
+			continue
+		}
+		calls, err := e.findCalls(arg)
+		if err != nil || len(calls) != 1 || calls[0].start != 0 || calls[0].end != len(arg) || !e.independent(calls[0], volatile) {
+			return false
+		}
+	}
+	return true
+}
+
+// literal renders a value as a JMESPath JSON literal
+func literal(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "`null`"
+	}
+	return "`" + strings.ReplaceAll(string(data), "`", "\\`") + "`"
+}
+
+// checkCalls validates the arity and arguments of custom function calls, and the syntax of the
+// expression around them
+func (e *Evaluator) checkCalls(expression string, calls []call) (string, error) {
+	var b strings.Builder
+	last := 0
+	for _, c := range calls {
+		fn := e.functions[c.name]
+		if len(c.args) < fn.MinArgs || (fn.MaxArgs >= 0 && len(c.args) > fn.MaxArgs) {
+			return "", fmt.Errorf("%s() takes %s", c.name, arity(fn))
+		}
+		for _, arg := range c.args {
+			if err := e.Validate(arg); err != nil {
+				return "", err
+			}
+		}
+		b.WriteString(expression[last:c.start])
+		b.WriteString("`null`")
+		last = c.end
+	}
+	b.WriteString(expression[last:])
+	return b.String(), nil
+}
+
+// arity describes the number of arguments a function takes
+func arity(fn Function) string {
+	switch {
+	case fn.MaxArgs < 0:
+		return fmt.Sprintf("at least %d arguments", fn.MinArgs)
+	case fn.MinArgs == fn.MaxArgs:
+		return fmt.Sprintf("%d arguments", fn.MinArgs)
+	default:
+		return fmt.Sprintf("%d to %d arguments", fn.MinArgs, fn.MaxArgs)
+	}
+}
@@ -9,17 +9,35 @@ import (
 
 // Evaluator wraps JMESPath expression evaluation
 type Evaluator struct {
-	cache map[string]*jmespath.JMESPath
-	mu    sync.RWMutex
+	cache     map[string]*compiledExpression
+	functions map[string]Function
+	mu        sync.RWMutex
 }
 
-// NewEvaluator creates a new expression evaluator
+// compiledExpression is a cached expression: compiled by go-jmespath, or with custom function calls
+// that depend on the data and are expanded (and the result compiled) on every evaluation
+type compiledExpression struct {
+	jp    *jmespath.JMESPath
+	calls []call
+}
+
+// NewEvaluator creates a new expression evaluator with the default custom functions
 func NewEvaluator() *Evaluator {
 	return &Evaluator{
-		cache: make(map[string]*jmespath.JMESPath),
+		cache:     make(map[string]*compiledExpression),
+		functions: DefaultFunctions(),
 	}
 }
 
+// RegisterFunction adds (or replaces) a custom function. Call it before the evaluator is shared.
+func (e *Evaluator) RegisterFunction(name string, fn Function) {
+	e.mu.Lock()
+	e.functions[name] = fn
+	// Cached expressions may call it
+	e.cache = make(map[string]*compiledExpression)
+	e.mu.Unlock()
+}
+
 // Evaluate evaluates a JMESPath expression against data
 func (e *Evaluator) Evaluate(expression string, data interface{}) (interface{}, error) {
 	compiled, err := e.getOrCompile(expression)
@@ -27,7 +45,19 @@ func (e *Evaluator) Evaluate(expression string, data interface{}) (interface{},
 		return nil, fmt.Errorf("invalid expression %q: %w", expression, err)
 	}
 
-	result, err := compiled.Search(data)
+	jp := compiled.jp
+	if len(compiled.calls) > 0 {
+		expanded, err := e.expand(expression, compiled.calls, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate expression %q: %w", expression, err)
+		}
+		jp, err = jmespath.Compile(expanded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression %q: %w", expression, err)
+		}
+	}
+
+	result, err := jp.Search(data)
 	if err != nil {
 		return nil, fmt.Errorf("failed to evaluate expression %q: %w", expression, err)
 	}
@@ -150,7 +180,7 @@ func (e *Evaluator) Validate(expression string) error {
 }
 
 // getOrCompile retrieves a compiled expression from cache or compiles it
-func (e *Evaluator) getOrCompile(expression string) (*jmespath.JMESPath, error) {
+func (e *Evaluator) getOrCompile(expression string) (*compiledExpression, error) {
 	// Try read lock first for cache hit
 	e.mu.RLock()
 	if compiled, ok := e.cache[expression]; ok {
@@ -159,9 +189,36 @@ func (e *Evaluator) getOrCompile(expression string) (*jmespath.JMESPath, error)
 	}
 	e.mu.RUnlock()
 
-	// Compile the expression
-	compiled, err := jmespath.Compile(expression)
+	// Compile the expression, or check it around its custom function calls
+	calls, err := e.findCalls(expression)
 	if err != nil {
+		// Prefer the parser's explanation of a syntax error
+		if _, jpErr := jmespath.Compile(expression); jpErr != nil {
+			return nil, jpErr
+		}
+		return nil, err
+	}
+	compiled := &compiledExpression{calls: calls}
+	if len(calls) > 0 {
+		skeleton, err := e.checkCalls(expression, calls)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := jmespath.Compile(skeleton); err != nil {
+			return nil, err
+		}
+		// Expressions whose calls are all constant are expanded once
+		if e.fold(calls) {
+			expanded, err := e.expand(expression, calls, nil)
+			if err != nil {
+				return nil, err
+			}
+			if compiled.jp, err = jmespath.Compile(expanded); err != nil {
+				return nil, err
+			}
+			compiled.calls = nil
+		}
+	} else if compiled.jp, err = jmespath.Compile(expression); err != nil {
 		return nil, err
 	}
 
@@ -176,7 +233,7 @@ func (e *Evaluator) getOrCompile(expression string) (*jmespath.JMESPath, error)
 // ClearCache clears the expression cache
 func (e *Evaluator) ClearCache() {
 	e.mu.Lock()
-	e.cache = make(map[string]*jmespath.JMESPath)
+	e.cache = make(map[string]*compiledExpression)
 	e.mu.Unlock()
 }
 
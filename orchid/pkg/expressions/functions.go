@@ -0,0 +1,226 @@
+package expressions
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Function is a custom function callable from expressions, e.g. format_date(now(), 'date').
+// Arguments are evaluated against the data the expression is evaluated against.
+type Function struct {
+	MinArgs  int
+	MaxArgs  int  // -1 for any number of arguments
+	Volatile bool // Returns something else on every call (now, uuid), so calls are never cached
+	Call     func(args []interface{}) (interface{}, error)
+}
+
+// now returns the current time (replaced in tests)
+var now = time.Now
+
+// dateFormats are the named layouts accepted by format_date
+var dateFormats = map[string]string{
+	"rfc3339":      time.RFC3339,
+	"rfc3339_nano": time.RFC3339Nano,
+	"iso8601":      time.RFC3339,
+	"date":         "2006-01-02",
+	"datetime":     "2006-01-02 15:04:05",
+	"rfc1123":      time.RFC1123,
+}
+
+// dateInputLayouts are tried in order when a date argument is a string
+var dateInputLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	time.RFC1123,
+	time.RFC1123Z,
+}
+
+// DefaultFunctions returns the custom functions every evaluator starts with.
+// JMESPath's built-in functions (length, join, to_number, ...) remain available alongside them.
+func DefaultFunctions() map[string]Function {
+	return map[string]Function{
+		"now":           {MinArgs: 0, MaxArgs: 0, Volatile: true, Call: fnNow},
+		"format_date":   {MinArgs: 2, MaxArgs: 2, Call: fnFormatDate},
+		"add_duration":  {MinArgs: 2, MaxArgs: 2, Call: fnAddDuration},
+		"base64_encode": {MinArgs: 1, MaxArgs: 1, Call: fnBase64Encode},
+		"hmac_sha256":   {MinArgs: 2, MaxArgs: 3, Call: fnHMACSHA256},
+		"url_encode":    {MinArgs: 1, MaxArgs: 1, Call: fnURLEncode},
+		"uuid":          {MinArgs: 0, MaxArgs: 0, Volatile: true, Call: fnUUID},
+		"concat":        {MinArgs: 1, MaxArgs: -1, Call: fnConcat},
+	}
+}
+
+// now() returns the current UTC time as RFC 3339
+func fnNow(args []interface{}) (interface{}, error) {
+	return now().UTC().Format(time.RFC3339), nil
+}
+
+// format_date(date, format) formats a date (RFC 3339 string or unix seconds) with a named format
+// (rfc3339, date, datetime, rfc1123, unix, unix_ms) or a Go layout, in UTC
+func fnFormatDate(args []interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+	t, err := toTime(args[0])
+	if err != nil {
+		return nil, err
+	}
+	format, ok := args[1].(string)
+	if !ok {
+		return nil, errors.New("format must be a string")
+	}
+
+	t = t.UTC()
+	switch format {
+	case "unix":
+		return float64(t.Unix()), nil
+	case "unix_ms":
+		return float64(t.UnixMilli()), nil
+	}
+	if layout, ok := dateFormats[strings.ToLower(format)]; ok {
+		format = layout
+	}
+	return t.Format(format), nil
+}
+
+// add_duration(date, duration) adds a duration ("-24h", "1h30m", "-7d") to a date, returning RFC 3339
+func fnAddDuration(args []interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+	t, err := toTime(args[0])
+	if err != nil {
+		return nil, err
+	}
+	duration, ok := args[1].(string)
+	if !ok {
+		return nil, errors.New("duration must be a string")
+	}
+	d, err := parseDuration(duration)
+	if err != nil {
+		return nil, err
+	}
+	return t.Add(d).UTC().Format(time.RFC3339), nil
+}
+
+// base64_encode(value) encodes a string with standard base64
+func fnBase64Encode(args []interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+	return base64.StdEncoding.EncodeToString([]byte(toString(args[0]))), nil
+}
+
+// hmac_sha256(key, message[, encoding]) signs message with key, encoded as hex (default) or base64
+func fnHMACSHA256(args []interface{}) (interface{}, error) {
+	mac := hmac.New(sha256.New, []byte(toString(args[0])))
+	mac.Write([]byte(toString(args[1])))
+	sum := mac.Sum(nil)
+
+	encoding := "hex"
+	if len(args) == 3 {
+		encoding = toString(args[2])
+	}
+	switch encoding {
+	case "hex":
+		return hex.EncodeToString(sum), nil
+	case "base64":
+		return base64.StdEncoding.EncodeToString(sum), nil
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q (want hex or base64)", encoding)
+	}
+}
+
+// url_encode(value) escapes a value for use in a query string
+func fnURLEncode(args []interface{}) (interface{}, error) {
+	if args[0] == nil {
+		return nil, nil
+	}
+	return url.QueryEscape(toString(args[0])), nil
+}
+
+// uuid() returns a random UUID
+func fnUUID(args []interface{}) (interface{}, error) {
+	return uuid.NewString(), nil
+}
+
+// concat(value, ...) joins its arguments as strings (null is empty)
+func fnConcat(args []interface{}) (interface{}, error) {
+	var b strings.Builder
+	for _, arg := range args {
+		b.WriteString(toString(arg))
+	}
+	return b.String(), nil
+}
+
+// toString converts a scalar argument to a string
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// toTime converts a date argument: a string in a common layout or a number of unix seconds
+func toTime(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case float64:
+		sec := int64(v)
+		return time.Unix(sec, int64((v-float64(sec))*1e9)).UTC(), nil
+	case string:
+		for _, layout := range dateInputLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("cannot parse date %q", v)
+	default:
+		return time.Time{}, fmt.Errorf("date must be a string or a number, got %T", value)
+	}
+}
+
+// parseDuration parses a Go duration, also accepting a leading number of days ("7d", "-1d12h")
+func parseDuration(s string) (time.Duration, error) {
+	value := strings.TrimSpace(s)
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimLeft(value, "+-")
+
+	var d time.Duration
+	if i := strings.IndexByte(value, 'd'); i > 0 {
+		days, err := strconv.Atoi(value[:i])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		d = time.Duration(days) * 24 * time.Hour
+		value = value[i+1:]
+	}
+	if value != "" {
+		rest, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		d += rest
+	}
+	if negative {
+		d = -d
+	}
+	return d, nil
+}
@@ -0,0 +1,123 @@
+package expressions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFunctions_EvaluateAgainstData(t *testing.T) {
+	now = func() time.Time { return time.Date(2024, 3, 10, 12, 30, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	e := NewEvaluator()
+	data := map[string]interface{}{
+		"config":  map[string]interface{}{"user": "api", "password": "s3cret", "secret": "key"},
+		"context": map[string]interface{}{"since": "2024-03-01T00:00:00Z", "count": "42"},
+		"items":   []interface{}{map[string]interface{}{"id": "a"}, map[string]interface{}{"id": "b"}},
+	}
+
+	tests := []struct {
+		expression string
+		want       interface{}
+	}{
+		{"now()", "2024-03-10T12:30:00Z"},
+		{"format_date(add_duration(now(), '-1d'), 'date')", "2024-03-09"},
+		{"format_date(context.since, 'unix')", float64(1709251200)},
+		{"format_date(`1709251200`, '2006/01/02 15:04')", "2024/03/01 00:00"},
+		{"add_duration(context.since, '1d12h')", "2024-03-02T12:00:00Z"},
+		{"format_date(context.missing, 'date')", nil},
+		{"context.watermark || format_date(add_duration(now(), '-7d'), 'rfc3339')", "2024-03-03T12:30:00Z"},
+		{"base64_encode(concat(config.user, ':', config.password))", "YXBpOnMzY3JldA=="},
+		{"hmac_sha256(config.secret, 'The quick brown fox jumps over the lazy dog')",
+			"f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+		{"hmac_sha256('key', '', 'base64')", "XV0TlWPJW1lnub2ajJsjOp3ttFByeUzSMtwbdIMmB9A="},
+		{"url_encode('a b&c=d')", "a+b%26c%3Dd"},
+		{"concat('page-', `2`, null)", "page-2"},
+		{"to_number(context.count)", float64(42)},
+		{"length(items) == `2` && concat('x', 'y') == 'xy'", true},
+		{"[format_date(now(), 'date'), items[0].id]", []interface{}{"2024-03-10", "a"}},
+		{"items[*].id", []interface{}{"a", "b"}},
+		// Calls with literal arguments do not depend on the current node
+		{"items[?id != concat('a', '')].id", []interface{}{"b"}},
+		{"items[*].[id, format_date(now(), 'date')]", []interface{}{[]interface{}{"a", "2024-03-10"}, []interface{}{"b", "2024-03-10"}}},
+		{"items | length(@) > `1` && url_encode('a b')", "a+b"},
+	}
+	for _, tt := range tests {
+		got, err := e.Evaluate(tt.expression, data)
+		require.NoError(t, err, tt.expression)
+		require.Equal(t, tt.want, got, tt.expression)
+	}
+
+	id, err := e.EvaluateString("uuid()", data)
+	require.NoError(t, err)
+	require.Len(t, id, 36)
+}
+
+func TestFunctions_Validate(t *testing.T) {
+	e := NewEvaluator()
+
+	require.NoError(t, e.Validate("format_date(add_duration(now(), '-24h'), 'rfc3339')"))
+	require.NoError(t, e.Validate("concat('a', 'b', 'c')"))
+	// Function names inside literals are not calls
+	require.NoError(t, e.Validate("items[?name == 'now()'].id"))
+
+	require.Error(t, e.Validate("now(`1`)"))
+	require.Error(t, e.Validate("format_date(now())"))
+	require.Error(t, e.Validate("format_date(now(), 'date'"))
+	require.Error(t, e.Validate("concat(a, )"))
+	require.Error(t, e.Validate("concat(a.[)"))
+	// Arguments are evaluated against the expression's data, not a projected element,
+	// so only calls with literal arguments can be used where the current node is something else
+	require.NoError(t, e.Validate("items[?created > format_date(add_duration(now(), '-1d'), 'date')]"))
+	require.NoError(t, e.Validate("map(&concat('x', url_encode('/')), items)"))
+	require.Error(t, e.Validate("items[*].url_encode(id)"))
+	require.Error(t, e.Validate("items[*].[url_encode(id)]"))
+	require.Error(t, e.Validate("items[?created > format_date(created, 'date')]"))
+	require.Error(t, e.Validate("items | concat(@, 'x')"))
+	require.Error(t, e.Validate("map(&url_encode(id), items)"))
+
+	_, err := e.Evaluate("items[?id == url_encode(id)]", map[string]interface{}{"items": []interface{}{}})
+	require.ErrorContains(t, err, "url_encode() can only be called with literal arguments")
+}
+
+func TestFunctions_FoldConstantCalls(t *testing.T) {
+	calls := 0
+	e := NewEvaluator()
+	e.RegisterFunction("count", Function{MinArgs: 1, MaxArgs: 1, Call: func(args []interface{}) (interface{}, error) {
+		calls++
+		return toString(args[0]), nil
+	}})
+
+	// Calls with literal arguments are evaluated once, when the expression is compiled
+	for i := 0; i < 3; i++ {
+		got, err := e.Evaluate("concat(count('a'), id)", map[string]interface{}{"id": "1"})
+		require.NoError(t, err)
+		require.Equal(t, "a1", got)
+	}
+	require.Equal(t, 1, calls)
+	compiled, err := e.getOrCompile("count('a')")
+	require.NoError(t, err)
+	require.Empty(t, compiled.calls)
+
+	// Calls depending on the data or on a volatile function are evaluated every time
+	for i := 0; i < 3; i++ {
+		_, err := e.Evaluate("count(id)", map[string]interface{}{"id": "1"})
+		require.NoError(t, err)
+		_, err = e.Evaluate("count(now())", nil)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 7, calls)
+}
+
+func TestFunctions_Register(t *testing.T) {
+	e := NewEvaluator()
+	e.RegisterFunction("upper", Function{MinArgs: 1, MaxArgs: 1, Call: func(args []interface{}) (interface{}, error) {
+		return "UP:" + toString(args[0]), nil
+	}})
+
+	got, err := e.Evaluate("upper(name)", map[string]interface{}{"name": "x"})
+	require.NoError(t, err)
+	require.Equal(t, "UP:x", got)
+}
@@ -0,0 +1,241 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+)
+
+// GraphQLError is returned for a GraphQL response whose errors array is not empty
+type GraphQLError struct {
+	Messages []string
+}
+
+func (e *GraphQLError) Error() string {
+	return "graphql errors: " + strings.Join(e.Messages, "; ")
+}
+
+// GraphQLErrors returns the errors reported in the body of a GraphQL response, or nil when there are none
+func GraphQLErrors(resp *Response) error {
+	body, ok := resp.BodyJSON.(map[string]any)
+	if !ok {
+		return nil
+	}
+	errs, ok := body["errors"].([]any)
+	if !ok || len(errs) == 0 {
+		return nil
+	}
+
+	messages := make([]string, 0, len(errs))
+	for _, e := range errs {
+		if m, ok := e.(map[string]any); ok {
+			if msg, ok := m["message"].(string); ok {
+				messages = append(messages, msg)
+				continue
+			}
+		}
+		data, _ := json.Marshal(e)
+		messages = append(messages, string(data))
+	}
+	return &GraphQLError{Messages: messages}
+}
+
+// encodeBody encodes a resolved body for its body type, returning the body and its Content-Type
+// (empty when the type implies none)
+func encodeBody(bodyType string, body any) ([]byte, string, error) {
+	switch bodyType {
+	case "", models.BodyTypeJSON:
+		if str, ok := body.(string); ok {
+			return []byte(str), "application/json", nil
+		}
+		data, err := json.Marshal(body)
+		return data, "application/json", err
+	case models.BodyTypeRaw:
+		str, ok := body.(string)
+		if !ok {
+			return nil, "", errors.New("raw body must be a string")
+		}
+		return []byte(str), "", nil
+	case models.BodyTypeForm:
+		return encodeForm(body)
+	case models.BodyTypeMultipart:
+		return encodeMultipart(body)
+	case models.BodyTypeGraphQL:
+		return encodeGraphQL(body)
+	default:
+		return nil, "", fmt.Errorf("unsupported body_type %q", bodyType)
+	}
+}
+
+// encodeForm encodes a body as application/x-www-form-urlencoded.
+// Arrays repeat their key and nested objects use bracket keys (metadata[key]=value).
+// A string body is sent as-is, as an already encoded form.
+func encodeForm(body any) ([]byte, string, error) {
+	const contentType = "application/x-www-form-urlencoded"
+	if str, ok := body.(string); ok {
+		return []byte(str), contentType, nil
+	}
+	m, ok := body.(map[string]any)
+	if !ok {
+		return nil, "", errors.New("form body must be an object or an encoded string")
+	}
+
+	values := url.Values{}
+	for key, value := range m {
+		addFormValue(values, key, value)
+	}
+	return []byte(values.Encode()), contentType, nil
+}
+
+// addFormValue adds a value to a form under key, flattening arrays and objects
+func addFormValue(values url.Values, key string, value any) {
+	switch v := value.(type) {
+	case nil:
+		values.Add(key, "")
+	case map[string]any:
+		for sub, item := range v {
+			addFormValue(values, key+"["+sub+"]", item)
+		}
+	case []any:
+		for i, item := range v {
+			if _, nested := item.(map[string]any); nested {
+				addFormValue(values, key+"["+strconv.Itoa(i)+"]", item)
+				continue
+			}
+			addFormValue(values, key, item)
+		}
+	default:
+		values.Add(key, formString(v))
+	}
+}
+
+// formString converts a scalar body value to its form representation
+func formString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// encodeMultipart encodes a body as multipart/form-data. Every field is a form field, except objects
+// with a filename, which are file parts:
+//
+//	{"file": {"filename": "users.csv", "content": "...", "content_type": "text/csv", "encoding": "base64"}}
+func encodeMultipart(body any) ([]byte, string, error) {
+	m, ok := body.(map[string]any)
+	if !ok {
+		return nil, "", errors.New("multipart body must be an object")
+	}
+
+	// Parts are written in key order so the same body always encodes the same way
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for _, key := range keys {
+		if err := writePart(w, key, m[key]); err != nil {
+			return nil, "", fmt.Errorf("multipart field %s: %w", key, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), w.FormDataContentType(), nil
+}
+
+// writePart writes one multipart field
+func writePart(w *multipart.Writer, name string, value any) error {
+	file, ok := value.(map[string]any)
+	if !ok || file["filename"] == nil {
+		switch value.(type) {
+		case map[string]any, []any:
+			// Non-file objects are sent as JSON
+			data, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			return w.WriteField(name, string(data))
+		}
+		return w.WriteField(name, formString(value))
+	}
+
+	filename := formString(file["filename"])
+	content := []byte(formString(file["content"]))
+	if encoding, _ := file["encoding"].(string); encoding != "" {
+		if encoding != "base64" {
+			return fmt.Errorf("unsupported content encoding %q", encoding)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(string(content))
+		if err != nil {
+			return fmt.Errorf("invalid base64 content: %w", err)
+		}
+		content = decoded
+	}
+	contentType, _ := file["content_type"].(string)
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		escapeQuotes(name), escapeQuotes(filename)))
+	header.Set("Content-Type", contentType)
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(content)
+	return err
+}
+
+// quoteEscaper escapes the quoted parameters of a Content-Disposition header
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
+
+// encodeGraphQL encodes a GraphQL request. The body is the query string, or an object with
+// query, variables and operation_name.
+func encodeGraphQL(body any) ([]byte, string, error) {
+	request := map[string]any{}
+	switch v := body.(type) {
+	case string:
+		request["query"] = v
+	case map[string]any:
+		query, _ := v["query"].(string)
+		if query == "" {
+			return nil, "", errors.New("graphql body requires a query")
+		}
+		request["query"] = query
+		if variables, ok := v["variables"]; ok && variables != nil {
+			request["variables"] = variables
+		}
+		if operation, ok := v["operation_name"].(string); ok && operation != "" {
+			request["operationName"] = operation
+		}
+	default:
+		return nil, "", errors.New("graphql body must be a query string or an object with a query")
+	}
+
+	data, err := json.Marshal(request)
+	return data, "application/json", err
+}
@@ -3,7 +3,6 @@ package httpclient
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -40,8 +39,9 @@ func (b *RequestBuilder) BuildRequest(ctx context.Context, step *models.Step, da
 
 	// Build request body
 	var bodyReader io.Reader
+	var contentType string
 	if step.Body != nil {
-		bodyBytes, err := b.buildBody(step.Body, data)
+		bodyBytes, bodyContentType, err := b.buildBody(step, data)
 		if err != nil {
 			return nil, fmt.Errorf("failed to build body: %w", err)
 		}
@@ -49,6 +49,7 @@ func (b *RequestBuilder) BuildRequest(ctx context.Context, step *models.Step, da
 			return nil, fmt.Errorf("request body too large: %d bytes (max %d)", len(bodyBytes), MaxRequestSize)
 		}
 		bodyReader = bytes.NewReader(bodyBytes)
+		contentType = bodyContentType
 	}
 
 	// Determine method
@@ -72,9 +73,10 @@ func (b *RequestBuilder) BuildRequest(ctx context.Context, step *models.Step, da
 		req.Header.Set(key, resolvedValue)
 	}
 
-	// Set Content-Type if body is present and not already set
-	if step.Body != nil && req.Header.Get("Content-Type") == "" {
-		req.Header.Set("Content-Type", "application/json")
+	// Set Content-Type if body is present and not already set.
+	// Multipart always uses its own, since it carries the boundary of the parts.
+	if contentType != "" && (req.Header.Get("Content-Type") == "" || step.BodyType == models.BodyTypeMultipart) {
+		req.Header.Set("Content-Type", contentType)
 	}
 
 	return req, nil
@@ -110,28 +112,92 @@ func (b *RequestBuilder) buildURL(urlTemplate string, params map[string]string,
 	return parsedURL.String(), nil
 }
 
-// buildBody builds the request body from the step body definition
-func (b *RequestBuilder) buildBody(body any, data map[string]any) ([]byte, error) {
-	// If body is a string, treat it as a template
-	if str, ok := body.(string); ok {
-		resolved, err := b.resolveTemplate(str, data)
+// buildBody builds the request body from the step body definition, encoded for its body_type.
+// It returns the body and the Content-Type it implies.
+func (b *RequestBuilder) buildBody(step *models.Step, data map[string]any) ([]byte, string, error) {
+	body := step.Body
+	graphQL := step.BodyType == models.BodyTypeGraphQL
+
+	switch v := body.(type) {
+	case string:
+		// If body is a string, treat it as a template (a GraphQL query is sent verbatim)
+		if !graphQL {
+			resolved, err := b.resolveTemplate(v, data)
+			if err != nil {
+				return nil, "", err
+			}
+			body = resolved
+		}
+	case map[string]any:
+		if graphQL {
+			resolved, err := b.resolveGraphQL(v, data)
+			if err != nil {
+				return nil, "", err
+			}
+			body = resolved
+			break
+		}
+		// If body is a map, resolve all template values recursively
+		resolved, err := b.resolveMapTemplates(v, data)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
-		return []byte(resolved), nil
+		body = resolved
 	}
 
-	// If body is a map, resolve all template values recursively
-	if m, ok := body.(map[string]any); ok {
-		resolved, err := b.resolveMapTemplates(m, data)
+	return encodeBody(step.BodyType, body)
+}
+
+// resolveGraphQL resolves the variables of a GraphQL body, keeping the query verbatim.
+// A variable that is a single {{ expression }} keeps the type of its result (numbers, booleans, objects).
+func (b *RequestBuilder) resolveGraphQL(body map[string]any, data map[string]any) (map[string]any, error) {
+	result := make(map[string]any, len(body))
+	for key, value := range body {
+		result[key] = value
+	}
+	if variables, ok := body["variables"]; ok {
+		resolved, err := b.resolveTyped(variables, data)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("failed to resolve variables: %w", err)
 		}
-		return json.Marshal(resolved)
+		result["variables"] = resolved
 	}
+	return result, nil
+}
 
-	// Otherwise, just marshal as JSON
-	return json.Marshal(body)
+// resolveTyped resolves templates in a value, keeping the result type of single-expression templates
+func (b *RequestBuilder) resolveTyped(value any, data map[string]any) (any, error) {
+	switch v := value.(type) {
+	case string:
+		matches := templatePattern.FindAllStringSubmatch(v, -1)
+		trimmed := strings.TrimSpace(v)
+		if len(matches) == 1 && strings.HasPrefix(trimmed, "{{") && strings.HasSuffix(trimmed, "}}") {
+			return b.evaluator.Evaluate(strings.TrimSpace(matches[0][1]), data)
+		}
+		return b.resolveTemplate(v, data)
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			resolved, err := b.resolveTyped(item, data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve %s: %w", key, err)
+			}
+			result[key] = resolved
+		}
+		return result, nil
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			resolved, err := b.resolveTyped(item, data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve index %d: %w", i, err)
+			}
+			result[i] = resolved
+		}
+		return result, nil
+	default:
+		return value, nil
+	}
 }
 
 // resolveMapTemplates recursively resolves templates in a map
@@ -2,12 +2,16 @@ package httpclient
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/Gobusters/ectologger"
+
+	"github.com/Ramsey-B/orchid/pkg/secrets"
 )
 
 const (
@@ -23,8 +27,11 @@ const (
 
 // Client wraps the HTTP client with logging and size limits
 type Client struct {
-	client *http.Client
-	logger ectologger.Logger
+	client       *http.Client
+	streamClient *http.Client // Shares the transport; streamed bodies are bounded by the request context instead of a timeout
+	logger       ectologger.Logger
+	cfg          Config
+	transports   *transportCache // Dedicated transports of configs (nil on dedicated clients)
 }
 
 // Config holds HTTP client configuration
@@ -34,6 +41,8 @@ type Config struct {
 	IdleConnTimeout    time.Duration
 	DisableCompression bool
 	DisableKeepAlives  bool
+	MaxTransports      int           // Dedicated per-config transports kept at once (least recently used are closed first)
+	TransportIdleTTL   time.Duration // How long an unused dedicated transport is kept
 }
 
 // DefaultConfig returns default HTTP client configuration
@@ -44,24 +53,33 @@ func DefaultConfig() Config {
 		IdleConnTimeout:    90 * time.Second,
 		DisableCompression: false,
 		DisableKeepAlives:  false,
+		MaxTransports:      DefaultMaxTransports,
+		TransportIdleTTL:   DefaultTransportIdleTTL,
 	}
 }
 
-// NewClient creates a new HTTP client
+// NewClient creates a new HTTP client. Its shared transport serves every config without transport
+// settings; ForConfig returns clients with dedicated transports for the others.
 func NewClient(cfg Config, logger ectologger.Logger) *Client {
-	transport := &http.Transport{
-		MaxIdleConns:       cfg.MaxIdleConns,
-		IdleConnTimeout:    cfg.IdleConnTimeout,
-		DisableCompression: cfg.DisableCompression,
-		DisableKeepAlives:  cfg.DisableKeepAlives,
-	}
+	// The shared transport has no TLS or proxy settings, so building it cannot fail
+	transport, _ := newTransport(cfg, poolShared, nil)
+
+	client := newClient(cfg, logger, transport, poolShared)
+	client.transports = newTransportCache(cfg.MaxTransports, cfg.TransportIdleTTL)
+	return client
+}
 
+// newClient creates a client sending its requests through transport
+func newClient(cfg Config, logger ectologger.Logger, transport *http.Transport, pool string) *Client {
+	roundTripper := &pooledTransport{Transport: transport, pool: pool}
 	return &Client{
 		client: &http.Client{
-			Transport: transport,
+			Transport: roundTripper,
 			Timeout:   cfg.Timeout,
 		},
-		logger: logger,
+		streamClient: &http.Client{Transport: roundTripper},
+		logger:       logger,
+		cfg:          cfg,
 	}
 }
 
@@ -83,13 +101,51 @@ func (c *Client) Do(ctx context.Context, req *http.Request) (*Response, error) {
 	// Execute request
 	resp, err := c.client.Do(req.WithContext(ctx))
 	if err != nil {
-		c.logger.WithContext(ctx).WithError(err).Errorf("HTTP request failed: %s %s", req.Method, req.URL.String())
+		maskURLError(ctx, err)
+		c.logger.WithContext(ctx).WithError(err).Errorf("HTTP request failed: %s %s", req.Method, logURL(ctx, req))
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	duration := time.Since(start)
+	response, err := bufferResponse(resp, time.Since(start))
+	if err != nil {
+		return nil, err
+	}
+
+	c.logger.WithContext(ctx).Debugf("HTTP %s %s -> %d (%s)",
+		req.Method, logURL(ctx, req), resp.StatusCode, response.Duration)
+
+	return response, nil
+}
 
+// maskerKey is the context key of the masker hiding secrets in logged request URLs
+type maskerKey struct{}
+
+// WithMasker returns a context whose requests are logged with masker hiding secrets in their URLs and errors
+func WithMasker(ctx context.Context, masker *secrets.Masker) context.Context {
+	if masker == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, maskerKey{}, masker)
+}
+
+// logURL returns the URL of req to log: without its password, and with the secrets of the context's masker hidden
+func logURL(ctx context.Context, req *http.Request) string {
+	masker, _ := ctx.Value(maskerKey{}).(*secrets.Masker)
+	return masker.Mask(req.URL.Redacted())
+}
+
+// maskURLError hides the secrets of the context's masker in the URL a transport error embeds
+func maskURLError(ctx context.Context, err error) {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		masker, _ := ctx.Value(maskerKey{}).(*secrets.Masker)
+		urlErr.URL = masker.Mask(urlErr.URL)
+	}
+}
+
+// bufferResponse reads the whole body of a response, up to MaxResponseSize
+func bufferResponse(resp *http.Response, duration time.Duration) (*Response, error) {
 	// Check response size
 	if resp.ContentLength > MaxResponseSize {
 		return nil, fmt.Errorf("response too large: %d bytes (max %d)", resp.ContentLength, MaxResponseSize)
@@ -106,6 +162,14 @@ func (c *Client) Do(ctx context.Context, req *http.Request) (*Response, error) {
 		return nil, fmt.Errorf("response body too large: %d bytes (max %d)", len(body), MaxResponseSize)
 	}
 
+	response := responseHeaders(resp, duration)
+	response.Body = body
+	response.ContentLength = int64(len(body))
+	return response, nil
+}
+
+// responseHeaders returns a response with the status and headers of resp, without its body
+func responseHeaders(resp *http.Response, duration time.Duration) *Response {
 	// Extract headers
 	headers := make(map[string]string)
 	for key, values := range resp.Header {
@@ -114,19 +178,12 @@ func (c *Client) Do(ctx context.Context, req *http.Request) (*Response, error) {
 		}
 	}
 
-	response := &Response{
-		StatusCode:    resp.StatusCode,
-		Headers:       headers,
-		Body:          body,
-		ContentType:   resp.Header.Get("Content-Type"),
-		ContentLength: int64(len(body)),
-		Duration:      duration,
+	return &Response{
+		StatusCode:  resp.StatusCode,
+		Headers:     headers,
+		ContentType: resp.Header.Get("Content-Type"),
+		Duration:    duration,
 	}
-
-	c.logger.WithContext(ctx).Debugf("HTTP %s %s -> %d (%s)",
-		req.Method, req.URL.String(), resp.StatusCode, duration)
-
-	return response, nil
 }
 
 // Get performs a GET request
@@ -146,4 +203,5 @@ func (c *Client) Get(ctx context.Context, url string, headers map[string]string)
 // SetTimeout sets a custom timeout for the client
 func (c *Client) SetTimeout(timeout time.Duration) {
 	c.client.Timeout = timeout
+	c.cfg.Timeout = timeout
 }
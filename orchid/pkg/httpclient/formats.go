@@ -0,0 +1,291 @@
+package httpclient
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/htmlindex"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+)
+
+// MaxDecompressedSize bounds the size of a decompressed body (or of all parsed zip entries together)
+const MaxDecompressedSize = 100 * 1024 * 1024 // 100MB
+
+// utf8BOM is the byte order mark some exports put in front of UTF-8 text
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// responseFilename returns the lower-cased file name of a Content-Disposition header, if any
+func responseFilename(resp *Response) string {
+	disposition := resp.Headers["Content-Disposition"]
+	if disposition == "" {
+		return ""
+	}
+	_, params, err := mime.ParseMediaType(disposition)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(path.Base(params["filename"]))
+}
+
+// detectCompression returns the compression of a body: configured, declared by the content type or
+// file name, or recognised from its magic bytes
+func detectCompression(opts *models.ParseConfig, contentType, name string, body []byte) string {
+	if opts.Compression != "" {
+		return opts.Compression
+	}
+
+	switch {
+	case strings.Contains(contentType, "application/gzip"), strings.Contains(contentType, "application/x-gzip"):
+		return models.CompressionGzip
+	case strings.Contains(contentType, "application/zip"), strings.Contains(contentType, "application/x-zip-compressed"):
+		return models.CompressionZip
+	case strings.HasSuffix(name, ".gz"), strings.HasSuffix(name, ".gzip"):
+		return models.CompressionGzip
+	case strings.HasSuffix(name, ".zip"):
+		return models.CompressionZip
+	}
+
+	switch {
+	case bytes.HasPrefix(body, []byte{0x1f, 0x8b}):
+		return models.CompressionGzip
+	case bytes.HasPrefix(body, []byte("PK\x03\x04")):
+		return models.CompressionZip
+	}
+	return models.CompressionNone
+}
+
+// detectFormat returns the format of an uncompressed body: configured, declared by the content type,
+// or implied by the file name
+func detectFormat(opts *models.ParseConfig, contentType, name string) string {
+	if opts.Format != "" {
+		return opts.Format
+	}
+
+	switch {
+	case strings.Contains(contentType, "ndjson"), strings.Contains(contentType, "jsonl"),
+		strings.Contains(contentType, "json-seq"):
+		return models.ParseFormatNDJSON
+	case strings.Contains(contentType, "application/json"), strings.Contains(contentType, "text/json"):
+		return models.ParseFormatJSON
+	case strings.Contains(contentType, "application/xml"), strings.Contains(contentType, "text/xml"):
+		return models.ParseFormatXML
+	case strings.Contains(contentType, "text/csv"), strings.Contains(contentType, "application/csv"):
+		return models.ParseFormatCSV
+	case strings.Contains(contentType, "text/tab-separated-values"):
+		return models.ParseFormatTSV
+	case strings.Contains(contentType, "text/"):
+		return models.ParseFormatText
+	}
+
+	switch path.Ext(name) {
+	case ".json":
+		return models.ParseFormatJSON
+	case ".ndjson", ".jsonl":
+		return models.ParseFormatNDJSON
+	case ".xml":
+		return models.ParseFormatXML
+	case ".csv":
+		return models.ParseFormatCSV
+	case ".tsv", ".tab":
+		return models.ParseFormatTSV
+	case ".txt":
+		return models.ParseFormatText
+	}
+	return models.ParseFormatBinary
+}
+
+// gunzip decompresses a gzip body
+func gunzip(body []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress gzip body: %w", err)
+	}
+	defer zr.Close()
+	return readLimited(zr, MaxDecompressedSize)
+}
+
+// parseZip parses the entries of a zip archive matching the zip_entry pattern (every file by default).
+// Records of array formats are concatenated; any other entry is added as one record.
+func parseZip(body []byte, opts *models.ParseConfig) (any, error) {
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip body: %w", err)
+	}
+
+	records := []any{}
+	budget := int64(MaxDecompressedSize)
+	for _, file := range zr.File {
+		name := file.Name
+		if file.FileInfo().IsDir() || strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(path.Base(name), ".") {
+			continue
+		}
+		if opts.ZipEntry != "" {
+			matched, _ := path.Match(opts.ZipEntry, name)
+			if !matched {
+				if matched, _ = path.Match(opts.ZipEntry, path.Base(name)); !matched {
+					continue
+				}
+			}
+		}
+
+		data, err := readZipEntry(file, budget)
+		if err != nil {
+			return nil, err
+		}
+		budget -= int64(len(data))
+
+		format := detectFormat(opts, "", strings.ToLower(name))
+		if format == models.ParseFormatBinary {
+			return nil, fmt.Errorf("cannot detect the format of zip entry %q: set parse.format", name)
+		}
+		value, err := parseBody(data, format, opts, "")
+		if err != nil {
+			return nil, fmt.Errorf("zip entry %q: %w", name, err)
+		}
+		if items, ok := value.([]any); ok {
+			records = append(records, items...)
+		} else {
+			records = append(records, value)
+		}
+	}
+	return records, nil
+}
+
+// readZipEntry decompresses a zip entry, failing when it exceeds limit
+func readZipEntry(file *zip.File, limit int64) ([]byte, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip entry %q: %w", file.Name, err)
+	}
+	defer rc.Close()
+	return readLimited(rc, limit)
+}
+
+// readLimited reads r completely, failing when it holds more than limit bytes
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress body: %w", err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("decompressed body exceeds %d bytes", MaxDecompressedSize)
+	}
+	return data, nil
+}
+
+// decodeCharset converts text in the configured encoding (or the charset of the content type) to UTF-8
+func decodeCharset(body []byte, encoding, contentType string) ([]byte, error) {
+	configured := encoding != ""
+	if !configured && contentType != "" {
+		if _, params, err := mime.ParseMediaType(contentType); err == nil {
+			encoding = params["charset"]
+		}
+	}
+
+	if encoding != "" && !strings.EqualFold(encoding, "utf-8") && !strings.EqualFold(encoding, "utf8") {
+		enc, err := htmlindex.Get(encoding)
+		switch {
+		case err == nil:
+			decoded, err := enc.NewDecoder().Bytes(body)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode %s body: %w", encoding, err)
+			}
+			body = decoded
+		case configured:
+			return nil, fmt.Errorf("unknown encoding %q", encoding)
+		}
+		// An unknown charset declared by the server is ignored rather than failing the request
+	}
+	return bytes.TrimPrefix(body, utf8BOM), nil
+}
+
+// parseNDJSON parses newline-delimited JSON into an array of its values
+func parseNDJSON(body []byte) ([]any, error) {
+	records := []any{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	for {
+		var record any
+		err := dec.Decode(&record)
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse NDJSON record %d: %w", len(records)+1, err)
+		}
+		records = append(records, record)
+	}
+}
+
+// parseDelimited parses CSV or TSV into an array of objects keyed by column name.
+// Column names come from the header row, parse.columns, or default to column_1, column_2, ...
+func parseDelimited(body []byte, delimiter rune, opts *models.ParseConfig) ([]any, error) {
+	if opts.Delimiter != "" {
+		delimiter, _ = utf8.DecodeRuneInString(opts.Delimiter)
+	}
+
+	reader := csv.NewReader(bytes.NewReader(body))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = delimiter == '\t'
+
+	for i := 0; i < opts.SkipRows; i++ {
+		if _, err := reader.Read(); err != nil {
+			if err == io.EOF {
+				return []any{}, nil
+			}
+			return nil, fmt.Errorf("failed to parse delimited body: %w", err)
+		}
+	}
+
+	columns := opts.Columns
+	if opts.HasHeader() {
+		header, err := reader.Read()
+		if err == io.EOF {
+			return []any{}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse header row: %w", err)
+		}
+		if len(columns) == 0 {
+			columns = make([]string, len(header))
+			for i, name := range header {
+				columns[i] = strings.TrimSpace(name)
+			}
+		}
+	}
+
+	records := []any{}
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse delimited body: %w", err)
+		}
+
+		record := make(map[string]any, len(row))
+		for i, value := range row {
+			record[columnName(columns, i)] = value
+		}
+		records = append(records, record)
+	}
+}
+
+// columnName returns the name of the i-th column, falling back to column_<n> when it has none
+func columnName(columns []string, i int) string {
+	if i < len(columns) && columns[i] != "" {
+		return columns[i]
+	}
+	return fmt.Sprintf("column_%d", i+1)
+}
@@ -6,59 +6,123 @@ import (
 	"encoding/xml"
 	"fmt"
 	"strings"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
 )
 
 // ParseResponse parses the response body based on content type
 func ParseResponse(resp *Response) error {
+	return ParseResponseWith(resp, nil)
+}
+
+// ParseResponseWith parses the response body with the parse options of a step (nil detects everything).
+// Compressed bodies are decompressed first; CSV, TSV and NDJSON bodies become arrays.
+func ParseResponseWith(resp *Response, opts *models.ParseConfig) error {
 	if len(resp.Body) == 0 {
 		return nil
 	}
+	if opts == nil {
+		opts = &models.ParseConfig{}
+	}
 
 	contentType := strings.ToLower(resp.ContentType)
+	name := responseFilename(resp)
 
-	switch {
-	case strings.Contains(contentType, "application/json"):
-		return parseJSON(resp)
-	case strings.Contains(contentType, "text/json"):
-		return parseJSON(resp)
-	case strings.Contains(contentType, "application/xml"):
-		return parseXML(resp)
-	case strings.Contains(contentType, "text/xml"):
-		return parseXML(resp)
-	case strings.Contains(contentType, "text/"):
-		// Text responses - store as string
-		resp.BodyJSON = string(resp.Body)
-		return nil
-	default:
-		// Binary or unknown - base64 encode
-		resp.BodyJSON = map[string]any{
-			"_binary":       true,
-			"_content_type": resp.ContentType,
-			"_base64":       base64.StdEncoding.EncodeToString(resp.Body),
-			"_size":         len(resp.Body),
+	if compression := detectCompression(opts, contentType, name, resp.Body); compression != models.CompressionNone {
+		value, err := parseCompressed(resp, compression, opts, contentType, name)
+		if err != nil {
+			if opts.Compression != "" {
+				return err
+			}
+			// A body that was not declared compressed by the step is kept as binary instead
+			value = binaryBody(resp)
 		}
+		resp.BodyJSON = value
 		return nil
 	}
-}
 
-// parseJSON parses JSON response body
-func parseJSON(resp *Response) error {
-	var result any
-	if err := json.Unmarshal(resp.Body, &result); err != nil {
-		return fmt.Errorf("failed to parse JSON: %w", err)
+	format := detectFormat(opts, contentType, name)
+	if format == models.ParseFormatBinary {
+		// Binary or unknown - base64 encode
+		resp.BodyJSON = binaryBody(resp)
+		return nil
 	}
-	resp.BodyJSON = result
+	value, err := parseBody(resp.Body, format, opts, contentType)
+	if err != nil {
+		return err
+	}
+	resp.BodyJSON = value
 	return nil
 }
 
-// parseXML parses XML response and converts to JSON-like structure
-func parseXML(resp *Response) error {
-	result, err := xmlToMap(resp.Body)
+// parseCompressed decompresses a gzip or zip body and parses its content
+func parseCompressed(resp *Response, compression string, opts *models.ParseConfig, contentType, name string) (any, error) {
+	if compression == models.CompressionZip {
+		return parseZip(resp.Body, opts)
+	}
+
+	body, err := gunzip(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to parse XML: %w", err)
+		return nil, err
 	}
-	resp.BodyJSON = result
-	return nil
+	// The inner format comes from the options, a content type describing it, or the name without .gz
+	inner := strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ".gzip")
+	format := detectFormat(opts, contentType, inner)
+	if format == models.ParseFormatBinary {
+		return binaryBody(resp), nil
+	}
+	return parseBody(body, format, opts, contentType)
+}
+
+// parseBody parses an uncompressed body in a text format
+func parseBody(body []byte, format string, opts *models.ParseConfig, contentType string) (any, error) {
+	// XML declares its own encoding
+	if format != models.ParseFormatXML {
+		decoded, err := decodeCharset(body, opts.Encoding, contentType)
+		if err != nil {
+			return nil, err
+		}
+		body = decoded
+	}
+
+	switch format {
+	case models.ParseFormatJSON:
+		return parseJSONBody(body)
+	case models.ParseFormatNDJSON:
+		return parseNDJSON(body)
+	case models.ParseFormatXML:
+		result, err := xmlToMap(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse XML: %w", err)
+		}
+		return result, nil
+	case models.ParseFormatCSV:
+		return parseDelimited(body, ',', opts)
+	case models.ParseFormatTSV:
+		return parseDelimited(body, '\t', opts)
+	default:
+		// Text responses - store as string
+		return string(body), nil
+	}
+}
+
+// binaryBody describes a body that could not be parsed, base64-encoded
+func binaryBody(resp *Response) map[string]any {
+	return map[string]any{
+		"_binary":       true,
+		"_content_type": resp.ContentType,
+		"_base64":       base64.StdEncoding.EncodeToString(resp.Body),
+		"_size":         len(resp.Body),
+	}
+}
+
+// parseJSONBody parses a JSON body
+func parseJSONBody(body []byte) (any, error) {
+	var result any
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	return result, nil
 }
 
 // XMLNode represents a generic XML node for unmarshaling
@@ -0,0 +1,191 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// StreamFunc consumes the body of a successful streamed response
+type StreamFunc func(resp *Response, body io.Reader) error
+
+// StreamError is returned when a streamed body fails after it started being consumed.
+// Part of the body may already have been processed, so the request must not be retried blindly.
+type StreamError struct {
+	Err error
+}
+
+func (e *StreamError) Error() string {
+	return fmt.Sprintf("streaming response body failed: %v", e.Err)
+}
+
+func (e *StreamError) Unwrap() error {
+	return e.Err
+}
+
+// DoStream executes an HTTP request and passes the body of a 2xx response to consume as it arrives,
+// without buffering it or applying MaxResponseSize. Other responses are buffered like Do.
+// The returned response has no body when it was streamed; ContentLength is the number of bytes consumed.
+func (c *Client) DoStream(ctx context.Context, req *http.Request, consume StreamFunc) (*Response, error) {
+	start := time.Now()
+
+	resp, err := c.streamClient.Do(req.WithContext(ctx))
+	if err != nil {
+		maskURLError(ctx, err)
+		c.logger.WithContext(ctx).WithError(err).Errorf("HTTP request failed: %s %s", req.Method, logURL(ctx, req))
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if !IsSuccessStatus(resp.StatusCode) {
+		return bufferResponse(resp, time.Since(start))
+	}
+
+	response := responseHeaders(resp, time.Since(start))
+	body := &countingReader{r: resp.Body}
+	err = consume(response, body)
+	response.ContentLength = body.n
+	if err != nil {
+		return response, &StreamError{Err: err}
+	}
+
+	c.logger.WithContext(ctx).Debugf("HTTP %s %s -> %d streamed %d bytes (%s)",
+		req.Method, logURL(ctx, req), resp.StatusCode, body.n, time.Since(start))
+
+	return response, nil
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// RecordDecoder decodes the records of a JSON array or NDJSON body one at a time
+type RecordDecoder struct {
+	dec     *json.Decoder
+	ndjson  bool
+	started bool
+	path    []string
+}
+
+// NewRecordDecoder creates a decoder for a streamed body.
+// format is "json_array", "json_path" (with path to the array, e.g. "$.data.items") or "ndjson".
+func NewRecordDecoder(body io.Reader, format, path string) (*RecordDecoder, error) {
+	d := &RecordDecoder{dec: json.NewDecoder(body)}
+
+	switch format {
+	case "json_array":
+	case "json_path":
+		segments, err := parseStreamPath(path)
+		if err != nil {
+			return nil, err
+		}
+		d.path = segments
+	case "ndjson":
+		d.ndjson = true
+	default:
+		return nil, fmt.Errorf("unsupported stream format %q", format)
+	}
+	return d, nil
+}
+
+// Next returns the next record, or io.EOF after the last one
+func (d *RecordDecoder) Next() (any, error) {
+	if d.ndjson {
+		var record any
+		if err := d.dec.Decode(&record); err != nil {
+			return nil, err
+		}
+		return record, nil
+	}
+
+	if !d.started {
+		d.started = true
+		if err := d.seek(); err != nil {
+			return nil, err
+		}
+	}
+	if !d.dec.More() {
+		// Consume the closing bracket so a truncated body is reported
+		if _, err := d.dec.Token(); err != nil {
+			return nil, fmt.Errorf("unterminated array: %w", err)
+		}
+		return nil, io.EOF
+	}
+
+	var record any
+	if err := d.dec.Decode(&record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// seek advances the decoder into the array at the decoder's path, skipping every other value
+func (d *RecordDecoder) seek() error {
+	for _, key := range d.path {
+		if err := expectDelim(d.dec, '{'); err != nil {
+			return fmt.Errorf("expected an object around %q: %w", key, err)
+		}
+		for {
+			if !d.dec.More() {
+				return fmt.Errorf("key %q not found in response body", key)
+			}
+			tok, err := d.dec.Token()
+			if err != nil {
+				return err
+			}
+			if tok == key {
+				break
+			}
+			// Values outside the path are skipped (buffered one at a time)
+			var skip json.RawMessage
+			if err := d.dec.Decode(&skip); err != nil {
+				return err
+			}
+		}
+	}
+	if err := expectDelim(d.dec, '['); err != nil {
+		return fmt.Errorf("expected an array: %w", err)
+	}
+	return nil
+}
+
+// expectDelim reads the next token, which must be delim
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("got %v", tok)
+	}
+	return nil
+}
+
+// parseStreamPath splits a JSONPath of object keys ("$.data.items" or "data.items") into its keys
+func parseStreamPath(path string) ([]string, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return nil, errors.New("stream path is required for json_path")
+	}
+
+	segments := strings.Split(path, ".")
+	for _, segment := range segments {
+		if segment == "" || strings.ContainsAny(segment, "[]*") {
+			return nil, fmt.Errorf("invalid stream path %q: only object keys are supported", path)
+		}
+	}
+	return segments, nil
+}
@@ -0,0 +1,349 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Ramsey-B/orchid/pkg/expressions"
+	"github.com/Ramsey-B/orchid/pkg/metrics"
+	"github.com/Ramsey-B/orchid/pkg/models"
+)
+
+const (
+	// DefaultMaxTransports is the default number of dedicated transports kept at once
+	DefaultMaxTransports = 256
+
+	// DefaultTransportIdleTTL is how long an unused dedicated transport is kept
+	DefaultTransportIdleTTL = 30 * time.Minute
+
+	// Connection pool labels of the pool metrics
+	poolShared    = "shared"
+	poolDedicated = "dedicated"
+)
+
+// tlsVersions are the accepted tls_min_version values
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// proxySchemes are the supported proxy URL schemes
+var proxySchemes = map[string]bool{"http": true, "https": true, "socks5": true, "socks5h": true}
+
+// TransportSettings are the resolved TLS and proxy settings of a config's dedicated transport
+type TransportSettings struct {
+	ClientCert    string
+	ClientKey     string
+	CABundle      string
+	ProxyURL      string
+	TLSMinVersion string
+}
+
+// fingerprint identifies the settings, so a config whose settings change gets a new transport
+func (s *TransportSettings) fingerprint() string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{s.ClientCert, s.ClientKey, s.CABundle, s.ProxyURL, s.TLSMinVersion}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidateTransport checks the settings of a transport configuration that are not templates
+func ValidateTransport(cfg *models.TransportConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	if (cfg.ClientCert == "") != (cfg.ClientKey == "") {
+		return errors.New("transport.client_cert and transport.client_key must be set together")
+	}
+	if cfg.TLSMinVersion != "" && !expressions.HasTemplates(cfg.TLSMinVersion) {
+		if _, ok := tlsVersions[cfg.TLSMinVersion]; !ok {
+			return fmt.Errorf("transport.tls_min_version must be 1.0, 1.1, 1.2 or 1.3, got %q", cfg.TLSMinVersion)
+		}
+	}
+	if cfg.ProxyURL != "" && !expressions.HasTemplates(cfg.ProxyURL) {
+		if err := validateProxyURL(cfg.ProxyURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateProxyURL checks that a proxy URL has a supported scheme and a host
+func validateProxyURL(proxyURL string) error {
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return errors.New("transport.proxy_url is not a valid URL")
+	}
+	if !proxySchemes[u.Scheme] || u.Host == "" {
+		return errors.New("transport.proxy_url must be an http, https, socks5 or socks5h URL with a host")
+	}
+	return nil
+}
+
+// ResolveTransport renders a transport configuration against a config's decrypted values.
+// It returns nil when there is no configuration or every field resolves to empty.
+func ResolveTransport(cfg *models.TransportConfig, config map[string]any, evaluator *expressions.Evaluator) (*TransportSettings, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	template := expressions.NewTemplate(evaluator)
+	data := map[string]any{"config": config}
+	settings := &TransportSettings{}
+	fields := []struct {
+		name  string
+		value string
+		out   *string
+	}{
+		{"client_cert", cfg.ClientCert, &settings.ClientCert},
+		{"client_key", cfg.ClientKey, &settings.ClientKey},
+		{"ca_bundle", cfg.CABundle, &settings.CABundle},
+		{"proxy_url", cfg.ProxyURL, &settings.ProxyURL},
+		{"tls_min_version", cfg.TLSMinVersion, &settings.TLSMinVersion},
+	}
+
+	empty := true
+	for _, field := range fields {
+		if field.value == "" {
+			continue
+		}
+		rendered, err := template.Render(field.value, data)
+		if err != nil {
+			return nil, fmt.Errorf("transport.%s: %w", field.name, err)
+		}
+		*field.out = strings.TrimSpace(rendered)
+		empty = empty && *field.out == ""
+	}
+	if empty {
+		return nil, nil
+	}
+	return settings, nil
+}
+
+// tlsConfig builds the TLS configuration of a dedicated transport
+func (s *TransportSettings) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if s.TLSMinVersion != "" {
+		version, ok := tlsVersions[s.TLSMinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported tls_min_version %q", s.TLSMinVersion)
+		}
+		cfg.MinVersion = version
+	}
+
+	if s.ClientCert != "" || s.ClientKey != "" {
+		cert, err := tls.X509KeyPair([]byte(s.ClientCert), []byte(s.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if s.CABundle != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM([]byte(s.CABundle)) {
+			return nil, errors.New("ca_bundle contains no PEM certificates")
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// newTransport builds a transport whose connections are reported in the pool metrics.
+// Settings are nil for the shared transport.
+func newTransport(cfg Config, pool string, settings *TransportSettings) (*http.Transport, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	transport := &http.Transport{
+		DialContext:        instrumentDial(dialer.DialContext, pool),
+		MaxIdleConns:       cfg.MaxIdleConns,
+		IdleConnTimeout:    cfg.IdleConnTimeout,
+		DisableCompression: cfg.DisableCompression,
+		DisableKeepAlives:  cfg.DisableKeepAlives,
+	}
+	if settings == nil {
+		return transport, nil
+	}
+
+	tlsConfig, err := settings.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+	transport.TLSHandshakeTimeout = 10 * time.Second
+	// A custom TLS config disables HTTP/2 unless it is asked for
+	transport.ForceAttemptHTTP2 = true
+
+	if settings.ProxyURL != "" {
+		if err := validateProxyURL(settings.ProxyURL); err != nil {
+			return nil, err
+		}
+		proxyURL, _ := url.Parse(settings.ProxyURL)
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	return transport, nil
+}
+
+// instrumentDial reports the connections a dial function opens in the pool metrics
+func instrumentDial(dial func(ctx context.Context, network, addr string) (net.Conn, error), pool string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			metrics.RecordHTTPPoolDial(pool, "failed")
+			return nil, err
+		}
+		metrics.RecordHTTPPoolDial(pool, "success")
+		metrics.RecordHTTPPoolConnectionOpened(pool)
+		return &trackedConn{Conn: conn, pool: pool}, nil
+	}
+}
+
+// trackedConn reports its close in the pool metrics
+type trackedConn struct {
+	net.Conn
+	pool string
+	once sync.Once
+}
+
+func (c *trackedConn) Close() error {
+	c.once.Do(func() { metrics.RecordHTTPPoolConnectionClosed(c.pool) })
+	return c.Conn.Close()
+}
+
+// pooledTransport reports whether each request got a new or reused connection
+type pooledTransport struct {
+	*http.Transport
+	pool string
+}
+
+func (t *pooledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			metrics.RecordHTTPPoolConnectionUsed(t.pool, info.Reused)
+		},
+	}
+	return t.Transport.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+}
+
+// transportCache keeps the dedicated clients of configs with transport settings
+type transportCache struct {
+	mu        sync.Mutex
+	max       int
+	idleTTL   time.Duration
+	entries   map[uuid.UUID]*cachedTransport
+	lastEvict time.Time
+}
+
+// cachedTransport is the dedicated client of a config
+type cachedTransport struct {
+	fingerprint string
+	client      *Client
+	transport   *http.Transport
+	lastUsed    time.Time
+}
+
+func newTransportCache(max int, idleTTL time.Duration) *transportCache {
+	if max <= 0 {
+		max = DefaultMaxTransports
+	}
+	if idleTTL <= 0 {
+		idleTTL = DefaultTransportIdleTTL
+	}
+	return &transportCache{max: max, idleTTL: idleTTL, entries: make(map[uuid.UUID]*cachedTransport)}
+}
+
+// ForConfig returns the client to use for a config's requests: the shared client when the config
+// has no transport settings, else a client with a dedicated transport built from them. Dedicated
+// clients are cached per config and rebuilt when its settings change.
+func (c *Client) ForConfig(configID uuid.UUID, settings *TransportSettings) (*Client, error) {
+	if settings == nil || c.transports == nil {
+		return c, nil
+	}
+	return c.transports.get(configID, settings, func() (*Client, *http.Transport, error) {
+		transport, err := newTransport(c.cfg, poolDedicated, settings)
+		if err != nil {
+			return nil, nil, err
+		}
+		return newClient(c.cfg, c.logger, transport, poolDedicated), transport, nil
+	})
+}
+
+// get returns the cached client of a config, building it when it is missing or its settings changed
+func (tc *transportCache) get(configID uuid.UUID, settings *TransportSettings, build func() (*Client, *http.Transport, error)) (*Client, error) {
+	fingerprint := settings.fingerprint()
+	now := time.Now()
+
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	if entry, ok := tc.entries[configID]; ok && entry.fingerprint == fingerprint {
+		entry.lastUsed = now
+		// Most calls are hits, so idle transports of other configs are dropped here too
+		if now.Sub(tc.lastEvict) >= tc.evictInterval() {
+			tc.evict(now)
+			metrics.RecordHTTPDedicatedTransports(len(tc.entries))
+		}
+		return entry.client, nil
+	}
+
+	client, transport, err := build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build transport: %w", err)
+	}
+	if old, ok := tc.entries[configID]; ok {
+		// Requests in flight keep their connections; idle ones of the old settings are dropped
+		old.transport.CloseIdleConnections()
+	}
+	tc.entries[configID] = &cachedTransport{fingerprint: fingerprint, client: client, transport: transport, lastUsed: now}
+	tc.evict(now)
+	metrics.RecordHTTPDedicatedTransports(len(tc.entries))
+	return client, nil
+}
+
+// evictInterval is how often a cache hit looks for idle transports
+func (tc *transportCache) evictInterval() time.Duration {
+	return min(tc.idleTTL/2, time.Minute)
+}
+
+// evict drops transports unused for idleTTL, then the least recently used ones above max
+func (tc *transportCache) evict(now time.Time) {
+	tc.lastEvict = now
+	for id, entry := range tc.entries {
+		if now.Sub(entry.lastUsed) > tc.idleTTL {
+			entry.transport.CloseIdleConnections()
+			delete(tc.entries, id)
+		}
+	}
+	if len(tc.entries) <= tc.max {
+		return
+	}
+
+	ids := make([]uuid.UUID, 0, len(tc.entries))
+	for id := range tc.entries {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return tc.entries[ids[i]].lastUsed.Before(tc.entries[ids[j]].lastUsed)
+	})
+	for _, id := range ids[:len(ids)-tc.max] {
+		tc.entries[id].transport.CloseIdleConnections()
+		delete(tc.entries, id)
+	}
+}
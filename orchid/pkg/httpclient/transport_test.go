@@ -0,0 +1,35 @@
+package httpclient
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportCache_EvictsIdleOnHits(t *testing.T) {
+	tc := newTransportCache(10, time.Minute)
+	build := func() (*Client, *http.Transport, error) {
+		return &Client{}, &http.Transport{}, nil
+	}
+	settings := &TransportSettings{ProxyURL: "http://proxy:3128"}
+	idle, busy := uuid.New(), uuid.New()
+
+	_, err := tc.get(idle, settings, build)
+	require.NoError(t, err)
+	client, err := tc.get(busy, settings, build)
+	require.NoError(t, err)
+	require.Len(t, tc.entries, 2)
+
+	// Only cache hits follow once the idle config stops sending requests
+	tc.entries[idle].lastUsed = time.Now().Add(-2 * time.Minute)
+	tc.lastEvict = time.Now().Add(-time.Minute)
+
+	hit, err := tc.get(busy, settings, build)
+	require.NoError(t, err)
+	require.Same(t, client, hit)
+	require.Len(t, tc.entries, 1)
+	require.Contains(t, tc.entries, busy)
+}
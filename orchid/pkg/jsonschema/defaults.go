@@ -0,0 +1,88 @@
+package jsonschema
+
+import "sort"
+
+// ApplyDefaults returns a copy of values with missing properties filled in from their schema defaults.
+// Defaults are applied recursively into nested objects, following $ref and allOf.
+// Properties that are already present, including explicit nulls, are left untouched.
+func (s *Schema) ApplyDefaults(values map[string]any) map[string]any {
+	result, _ := copyValue(normalize(values)).(map[string]any)
+	if result == nil {
+		result = make(map[string]any)
+	}
+	s.applyDefaults(s.root, result, 0)
+	return result
+}
+
+// applyDefaults fills defaults for the properties n declares
+func (s *Schema) applyDefaults(n *node, obj map[string]any, depth int) {
+	if n == nil || n.boolean != nil || depth > maxDepth {
+		return
+	}
+
+	for _, ref := range []string{n.ref, n.dynamicRef} {
+		if ref == "" {
+			continue
+		}
+		if target, err := s.resolve(ref); err == nil {
+			s.applyDefaults(target, obj, depth+1)
+		}
+	}
+	for _, sub := range n.allOf {
+		s.applyDefaults(sub, obj, depth+1)
+	}
+
+	names := make([]string, 0, len(n.properties))
+	for name := range n.properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		prop := n.properties[name]
+		if _, ok := obj[name]; !ok {
+			if value, ok := s.defaultFor(prop, depth+1); ok {
+				obj[name] = copyValue(value)
+			}
+		}
+		if child, ok := obj[name].(map[string]any); ok {
+			s.applyDefaults(prop, child, depth+1)
+		}
+	}
+}
+
+// defaultFor returns the default declared on a property schema or the schema it references
+func (s *Schema) defaultFor(n *node, depth int) (any, bool) {
+	if n == nil || depth > maxDepth {
+		return nil, false
+	}
+	if n.hasDefault {
+		return n.defaultValue, true
+	}
+	if n.ref != "" {
+		if target, err := s.resolve(n.ref); err == nil {
+			return s.defaultFor(target, depth+1)
+		}
+	}
+	return nil, false
+}
+
+// copyValue deep-copies a decoded JSON value
+func copyValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			result[key] = copyValue(item)
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = copyValue(item)
+		}
+		return result
+	default:
+		return value
+	}
+}
@@ -0,0 +1,94 @@
+package jsonschema
+
+import (
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	uuidPattern     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hostnameLabel   = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+	timeWithZone    = regexp.MustCompile(`^\d{2}:\d{2}:\d{2}(\.\d+)?([zZ]|[+-]([01]\d|2[0-3]):[0-5]\d)$`)
+	dateTimePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}[tT ]\d{2}:\d{2}:\d{2}(\.\d+)?([zZ]|[+-]([01]\d|2[0-3]):[0-5]\d)$`)
+)
+
+// checkFormat validates a string against a known format.
+// It returns an error message, or "" when the value is valid or the format is unknown.
+func checkFormat(format, s string) string {
+	switch format {
+	case "date-time":
+		if !dateTimePattern.MatchString(s) {
+			return "must be an RFC 3339 date-time"
+		}
+		normalized := strings.ToUpper(strings.Replace(s, " ", "T", 1))
+		if _, err := time.Parse(time.RFC3339Nano, normalized); err != nil {
+			return "must be an RFC 3339 date-time"
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", s); err != nil {
+			return "must be a date (YYYY-MM-DD)"
+		}
+	case "time":
+		if !timeWithZone.MatchString(s) {
+			return "must be a time with timezone (HH:MM:SSZ)"
+		}
+		if _, err := time.Parse("15:04:05.999999999Z07:00", strings.ToUpper(s)); err != nil {
+			return "must be a time with timezone (HH:MM:SSZ)"
+		}
+	case "email":
+		addr, err := mail.ParseAddress(s)
+		if err != nil || addr.Address != s {
+			return "must be an email address"
+		}
+	case "hostname":
+		if !isHostname(s) {
+			return "must be a hostname"
+		}
+	case "ipv4":
+		ip := net.ParseIP(s)
+		if ip == nil || ip.To4() == nil || strings.Contains(s, ":") {
+			return "must be an IPv4 address"
+		}
+	case "ipv6":
+		ip := net.ParseIP(s)
+		if ip == nil || !strings.Contains(s, ":") {
+			return "must be an IPv6 address"
+		}
+	case "uri":
+		u, err := url.Parse(s)
+		if err != nil || !u.IsAbs() {
+			return "must be an absolute URI"
+		}
+	case "uri-reference":
+		if _, err := url.Parse(s); err != nil {
+			return "must be a URI reference"
+		}
+	case "uuid":
+		if !uuidPattern.MatchString(s) {
+			return "must be a UUID"
+		}
+	case "regex":
+		if _, err := regexp.Compile(s); err != nil {
+			return "must be a regular expression"
+		}
+	}
+	return ""
+}
+
+// isHostname checks RFC 1123 hostname syntax
+func isHostname(s string) bool {
+	s = strings.TrimSuffix(s, ".")
+	if s == "" || len(s) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(s, ".") {
+		if !hostnameLabel.MatchString(label) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,625 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Draft is the JSON Schema dialect implemented by this package
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// validTypes are the JSON Schema primitive type names
+var validTypes = map[string]bool{
+	"null": true, "boolean": true, "object": true, "array": true,
+	"number": true, "integer": true, "string": true,
+}
+
+// Schema is a compiled JSON Schema.
+//
+// Draft 2020-12 is implemented except for remote references: $ref may point at the
+// schema itself (JSON pointers, $anchor and embedded $id), and $dynamicRef is resolved like $ref.
+// The draft-07 forms of items (array) and additionalItems are accepted for compatibility.
+// Known formats (date-time, date, time, email, hostname, ipv4, ipv6, uri, uri-reference, uuid, regex)
+// are asserted; unknown formats are ignored.
+type Schema struct {
+	root *node
+
+	// Compiled subschemas keyed by JSON pointer from the root
+	pointers map[string]*node
+	// Subschemas keyed by $id and #$anchor
+	ids map[string]*node
+	raw any
+}
+
+// node is a compiled schema object or boolean schema
+type node struct {
+	pointer string
+
+	// Boolean schema (true/false) when set
+	boolean *bool
+
+	ref        string
+	dynamicRef string
+
+	types      []string
+	enum       []any
+	hasEnum    bool
+	constValue any
+	hasConst   bool
+
+	// Objects
+	properties            map[string]*node
+	patternProperties     []patternProperty
+	additionalProperties  *node
+	propertyNames         *node
+	required              []string
+	dependentRequired     map[string][]string
+	dependentSchemas      map[string]*node
+	minProperties         *int
+	maxProperties         *int
+	unevaluatedProperties *node
+
+	// Arrays
+	prefixItems      []*node
+	items            *node
+	contains         *node
+	minContains      *int
+	maxContains      *int
+	minItems         *int
+	maxItems         *int
+	uniqueItems      bool
+	unevaluatedItems *node
+
+	// Strings
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+	format    string
+
+	// Numbers
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+	multipleOf       *float64
+
+	// Applicators
+	allOf []*node
+	anyOf []*node
+	oneOf []*node
+	not   *node
+	ifs   *node
+	then  *node
+	els   *node
+
+	defaultValue any
+	hasDefault   bool
+}
+
+// patternProperty is a compiled patternProperties entry
+type patternProperty struct {
+	re   *regexp.Regexp
+	node *node
+}
+
+// Compile compiles a JSON Schema document (as decoded from JSON)
+func Compile(schema any) (*Schema, error) {
+	s := &Schema{
+		pointers: make(map[string]*node),
+		ids:      make(map[string]*node),
+		raw:      normalize(schema),
+	}
+
+	root, err := s.compile(s.raw, "")
+	if err != nil {
+		return nil, err
+	}
+	s.root = root
+
+	// Every reference must resolve within the document
+	nodes := make([]*node, 0, len(s.pointers))
+	for _, n := range s.pointers {
+		nodes = append(nodes, n)
+	}
+	for _, n := range nodes {
+		for _, ref := range []string{n.ref, n.dynamicRef} {
+			if ref == "" {
+				continue
+			}
+			if _, err := s.resolve(ref); err != nil {
+				return nil, fmt.Errorf("%s: %w", pointerLabel(n.pointer), err)
+			}
+		}
+	}
+	return s, nil
+}
+
+// compile compiles the subschema at pointer
+func (s *Schema) compile(raw any, pointer string) (*node, error) {
+	if n, ok := s.pointers[pointer]; ok {
+		return n, nil
+	}
+
+	n := &node{pointer: pointer}
+	switch v := raw.(type) {
+	case bool:
+		n.boolean = &v
+		s.pointers[pointer] = n
+		return n, nil
+	case map[string]any:
+		s.pointers[pointer] = n
+		if err := s.compileObject(n, v, pointer); err != nil {
+			delete(s.pointers, pointer)
+			return nil, err
+		}
+		return n, nil
+	default:
+		return nil, fmt.Errorf("%s: schema must be an object or boolean", pointerLabel(pointer))
+	}
+}
+
+// compileObject compiles the keywords of a schema object
+func (s *Schema) compileObject(n *node, m map[string]any, pointer string) error {
+	fail := func(keyword, format string, args ...any) error {
+		return fmt.Errorf("%s: %s", pointerLabel(pointer+"/"+keyword), fmt.Sprintf(format, args...))
+	}
+
+	if id, ok := m["$id"].(string); ok && id != "" {
+		s.ids[strings.TrimSuffix(id, "#")] = n
+	}
+	if anchor, ok := m["$anchor"].(string); ok && anchor != "" {
+		s.ids["#"+anchor] = n
+	}
+	if anchor, ok := m["$dynamicAnchor"].(string); ok && anchor != "" {
+		if _, exists := s.ids["#"+anchor]; !exists {
+			s.ids["#"+anchor] = n
+		}
+	}
+
+	if v, ok := m["$ref"]; ok {
+		ref, ok := v.(string)
+		if !ok {
+			return fail("$ref", "must be a string")
+		}
+		n.ref = ref
+	}
+	if v, ok := m["$dynamicRef"]; ok {
+		ref, ok := v.(string)
+		if !ok {
+			return fail("$dynamicRef", "must be a string")
+		}
+		n.dynamicRef = ref
+	}
+
+	// Definitions are compiled so they can be referenced by pointer
+	for _, keyword := range []string{"$defs", "definitions"} {
+		if v, ok := m[keyword]; ok {
+			defs, ok := v.(map[string]any)
+			if !ok {
+				return fail(keyword, "must be an object")
+			}
+			for _, name := range sortedKeys(defs) {
+				if _, err := s.compile(defs[name], pointer+"/"+keyword+"/"+escapePointer(name)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if v, ok := m["type"]; ok {
+		switch t := v.(type) {
+		case string:
+			n.types = []string{t}
+		case []any:
+			for _, item := range t {
+				name, ok := item.(string)
+				if !ok {
+					return fail("type", "must be a string or array of strings")
+				}
+				n.types = append(n.types, name)
+			}
+		default:
+			return fail("type", "must be a string or array of strings")
+		}
+		for _, t := range n.types {
+			if !validTypes[t] {
+				return fail("type", "unknown type %q", t)
+			}
+		}
+	}
+
+	if v, ok := m["enum"]; ok {
+		values, ok := v.([]any)
+		if !ok {
+			return fail("enum", "must be an array")
+		}
+		n.enum = values
+		n.hasEnum = true
+	}
+	if v, ok := m["const"]; ok {
+		n.constValue = v
+		n.hasConst = true
+	}
+	if v, ok := m["default"]; ok {
+		n.defaultValue = v
+		n.hasDefault = true
+	}
+
+	// Object keywords
+	if v, ok := m["properties"]; ok {
+		props, ok := v.(map[string]any)
+		if !ok {
+			return fail("properties", "must be an object")
+		}
+		n.properties = make(map[string]*node, len(props))
+		for _, name := range sortedKeys(props) {
+			child, err := s.compile(props[name], pointer+"/properties/"+escapePointer(name))
+			if err != nil {
+				return err
+			}
+			n.properties[name] = child
+		}
+	}
+	if v, ok := m["patternProperties"]; ok {
+		props, ok := v.(map[string]any)
+		if !ok {
+			return fail("patternProperties", "must be an object")
+		}
+		for _, pattern := range sortedKeys(props) {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fail("patternProperties", "invalid pattern %q: %v", pattern, err)
+			}
+			child, err := s.compile(props[pattern], pointer+"/patternProperties/"+escapePointer(pattern))
+			if err != nil {
+				return err
+			}
+			n.patternProperties = append(n.patternProperties, patternProperty{re: re, node: child})
+		}
+	}
+	var err error
+	if n.additionalProperties, err = s.compileKeyword(m, pointer, "additionalProperties"); err != nil {
+		return err
+	}
+	if n.propertyNames, err = s.compileKeyword(m, pointer, "propertyNames"); err != nil {
+		return err
+	}
+	if n.unevaluatedProperties, err = s.compileKeyword(m, pointer, "unevaluatedProperties"); err != nil {
+		return err
+	}
+	if v, ok := m["required"]; ok {
+		if n.required, err = stringArray(v); err != nil {
+			return fail("required", "%v", err)
+		}
+	}
+	if v, ok := m["dependentRequired"]; ok {
+		deps, ok := v.(map[string]any)
+		if !ok {
+			return fail("dependentRequired", "must be an object")
+		}
+		n.dependentRequired = make(map[string][]string, len(deps))
+		for name, value := range deps {
+			if n.dependentRequired[name], err = stringArray(value); err != nil {
+				return fail("dependentRequired", "%s: %v", name, err)
+			}
+		}
+	}
+	if v, ok := m["dependentSchemas"]; ok {
+		deps, ok := v.(map[string]any)
+		if !ok {
+			return fail("dependentSchemas", "must be an object")
+		}
+		n.dependentSchemas = make(map[string]*node, len(deps))
+		for _, name := range sortedKeys(deps) {
+			if n.dependentSchemas[name], err = s.compile(deps[name], pointer+"/dependentSchemas/"+escapePointer(name)); err != nil {
+				return err
+			}
+		}
+	}
+	if n.minProperties, err = nonNegativeInt(m, "minProperties"); err != nil {
+		return fail("minProperties", "%v", err)
+	}
+	if n.maxProperties, err = nonNegativeInt(m, "maxProperties"); err != nil {
+		return fail("maxProperties", "%v", err)
+	}
+
+	// Array keywords
+	if v, ok := m["prefixItems"]; ok {
+		if n.prefixItems, err = s.compileArray(v, pointer+"/prefixItems"); err != nil {
+			return err
+		}
+	}
+	if v, ok := m["items"]; ok {
+		if list, isList := v.([]any); isList {
+			// Draft-07: items as an array, additionalItems for the rest
+			if n.prefixItems, err = s.compileArray(list, pointer+"/items"); err != nil {
+				return err
+			}
+			if n.items, err = s.compileKeyword(m, pointer, "additionalItems"); err != nil {
+				return err
+			}
+		} else if n.items, err = s.compile(v, pointer+"/items"); err != nil {
+			return err
+		}
+	}
+	if n.contains, err = s.compileKeyword(m, pointer, "contains"); err != nil {
+		return err
+	}
+	if n.unevaluatedItems, err = s.compileKeyword(m, pointer, "unevaluatedItems"); err != nil {
+		return err
+	}
+	for keyword, target := range map[string]**int{
+		"minContains": &n.minContains, "maxContains": &n.maxContains,
+		"minItems": &n.minItems, "maxItems": &n.maxItems,
+		"minLength": &n.minLength, "maxLength": &n.maxLength,
+	} {
+		if *target, err = nonNegativeInt(m, keyword); err != nil {
+			return fail(keyword, "%v", err)
+		}
+	}
+	if v, ok := m["uniqueItems"]; ok {
+		unique, ok := v.(bool)
+		if !ok {
+			return fail("uniqueItems", "must be a boolean")
+		}
+		n.uniqueItems = unique
+	}
+
+	// String keywords
+	if v, ok := m["pattern"]; ok {
+		pattern, ok := v.(string)
+		if !ok {
+			return fail("pattern", "must be a string")
+		}
+		if n.pattern, err = regexp.Compile(pattern); err != nil {
+			return fail("pattern", "invalid pattern: %v", err)
+		}
+	}
+	if v, ok := m["format"]; ok {
+		format, ok := v.(string)
+		if !ok {
+			return fail("format", "must be a string")
+		}
+		n.format = format
+	}
+
+	// Numeric keywords
+	for keyword, target := range map[string]**float64{
+		"minimum": &n.minimum, "maximum": &n.maximum,
+		"exclusiveMinimum": &n.exclusiveMinimum, "exclusiveMaximum": &n.exclusiveMaximum,
+		"multipleOf": &n.multipleOf,
+	} {
+		v, ok := m[keyword]
+		if !ok {
+			continue
+		}
+		f, ok := toFloat(v)
+		if !ok {
+			return fail(keyword, "must be a number")
+		}
+		*target = &f
+	}
+	if n.multipleOf != nil && *n.multipleOf <= 0 {
+		return fail("multipleOf", "must be greater than 0")
+	}
+
+	// Applicators
+	for keyword, target := range map[string]*[]*node{"allOf": &n.allOf, "anyOf": &n.anyOf, "oneOf": &n.oneOf} {
+		v, ok := m[keyword]
+		if !ok {
+			continue
+		}
+		if *target, err = s.compileArray(v, pointer+"/"+keyword); err != nil {
+			return err
+		}
+		if len(*target) == 0 {
+			return fail(keyword, "must not be empty")
+		}
+	}
+	for keyword, target := range map[string]**node{"not": &n.not, "if": &n.ifs, "then": &n.then, "else": &n.els} {
+		if *target, err = s.compileKeyword(m, pointer, keyword); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// compileKeyword compiles a single-subschema keyword when present
+func (s *Schema) compileKeyword(m map[string]any, pointer, keyword string) (*node, error) {
+	v, ok := m[keyword]
+	if !ok {
+		return nil, nil
+	}
+	return s.compile(v, pointer+"/"+keyword)
+}
+
+// compileArray compiles an array of subschemas
+func (s *Schema) compileArray(v any, pointer string) ([]*node, error) {
+	list, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: must be an array of schemas", pointerLabel(pointer))
+	}
+	nodes := make([]*node, 0, len(list))
+	for i, item := range list {
+		child, err := s.compile(item, pointer+"/"+strconv.Itoa(i))
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, child)
+	}
+	return nodes, nil
+}
+
+// resolve finds the subschema a reference points at
+func (s *Schema) resolve(ref string) (*node, error) {
+	if ref == "#" || ref == "" {
+		return s.root, nil
+	}
+	if n, ok := s.ids[ref]; ok {
+		return n, nil
+	}
+
+	base, fragment, _ := strings.Cut(ref, "#")
+	if base != "" {
+		resource, ok := s.ids[base]
+		if !ok {
+			if u, err := url.Parse(base); err == nil && u.IsAbs() {
+				return nil, fmt.Errorf("unsupported remote $ref %q", ref)
+			}
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+		if fragment == "" {
+			return resource, nil
+		}
+		if resource != s.root {
+			return nil, fmt.Errorf("unsupported $ref %q: pointer into an embedded resource", ref)
+		}
+	}
+
+	if !strings.HasPrefix(fragment, "/") {
+		if n, ok := s.ids["#"+fragment]; ok {
+			return n, nil
+		}
+		return nil, fmt.Errorf("unresolvable $ref %q", ref)
+	}
+
+	pointer, err := url.PathUnescape(fragment)
+	if err != nil {
+		return nil, fmt.Errorf("invalid $ref %q: %w", ref, err)
+	}
+	if n, ok := s.pointers[pointer]; ok {
+		return n, nil
+	}
+
+	// Pointer into a location that is not a known subschema keyword
+	raw, ok := lookupPointer(s.raw, pointer)
+	if !ok {
+		return nil, fmt.Errorf("unresolvable $ref %q", ref)
+	}
+	return s.compile(raw, pointer)
+}
+
+// lookupPointer walks a JSON pointer through a decoded document
+func lookupPointer(doc any, pointer string) (any, bool) {
+	current := doc
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch v := current.(type) {
+		case map[string]any:
+			next, ok := v[token]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case []any:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			current = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// escapePointer escapes a JSON pointer reference token
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// pointerLabel formats a schema location for compile errors
+func pointerLabel(pointer string) string {
+	return "schema#" + pointer
+}
+
+// normalize round-trips Go values through JSON so numbers and containers have their decoded types
+func normalize(v any) any {
+	switch v.(type) {
+	case nil, bool, string, float64, map[string]any, []any:
+		if !containsForeignTypes(v) {
+			return v
+		}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+// containsForeignTypes reports whether a value holds anything json.Unmarshal would not produce
+func containsForeignTypes(v any) bool {
+	switch t := v.(type) {
+	case nil, bool, string, float64:
+		return false
+	case map[string]any:
+		for _, item := range t {
+			if containsForeignTypes(item) {
+				return true
+			}
+		}
+		return false
+	case []any:
+		for _, item := range t {
+			if containsForeignTypes(item) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// stringArray decodes an array of unique strings
+func stringArray(v any) ([]string, error) {
+	list, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("must be an array of strings")
+	}
+	out := make([]string, 0, len(list))
+	for _, item := range list {
+		s, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("must be an array of strings")
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// nonNegativeInt decodes an optional non-negative integer keyword
+func nonNegativeInt(m map[string]any, keyword string) (*int, error) {
+	v, ok := m[keyword]
+	if !ok {
+		return nil, nil
+	}
+	f, ok := toFloat(v)
+	if !ok || f < 0 || f != float64(int(f)) {
+		return nil, fmt.Errorf("must be a non-negative integer")
+	}
+	i := int(f)
+	return &i, nil
+}
+
+// sortedKeys returns map keys in a stable order
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
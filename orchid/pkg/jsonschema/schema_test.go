@@ -0,0 +1,152 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func fieldErrors(t *testing.T, err error) map[string]string {
+	t.Helper()
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	fields := make(map[string]string, len(validationErr.Errors))
+	for _, fieldErr := range validationErr.Errors {
+		fields[fieldErr.Field] = fieldErr.Keyword
+	}
+	return fields
+}
+
+func TestSchema_ValidateReportsFieldErrors(t *testing.T) {
+	schema, err := Compile(map[string]any{
+		"$schema":  Draft,
+		"type":     "object",
+		"required": []any{"base_url", "api_key"},
+		"properties": map[string]any{
+			"base_url":  map[string]any{"type": "string", "format": "uri"},
+			"api_key":   map[string]any{"type": "string", "minLength": 8},
+			"page_size": map[string]any{"type": "integer", "minimum": 1, "maximum": 500},
+			"region":    map[string]any{"enum": []any{"us", "eu"}},
+			"scopes": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"$ref": "#/$defs/scope"},
+				"uniqueItems": true,
+			},
+		},
+		"additionalProperties": false,
+		"$defs": map[string]any{
+			"scope": map[string]any{"type": "string", "pattern": "^[a-z.]+$"},
+		},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, schema.Validate(map[string]any{
+		"base_url":  "https://api.example.com",
+		"api_key":   "sk_live_123456",
+		"page_size": 100,
+		"scopes":    []any{"read.users", "read.groups"},
+	}))
+
+	err = schema.Validate(map[string]any{
+		"base_url":  "not a url",
+		"page_size": 2.5,
+		"region":    "apac",
+		"scopes":    []any{"read.users", "WRITE"},
+		"extra":     true,
+	})
+	require.Equal(t, map[string]string{
+		"base_url":  "format",
+		"api_key":   "required",
+		"page_size": "type",
+		"region":    "enum",
+		"scopes[1]": "pattern",
+		"extra":     "additionalProperties",
+	}, fieldErrors(t, err))
+}
+
+func TestSchema_Applicators(t *testing.T) {
+	schema, err := Compile(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"auth_type": map[string]any{"enum": []any{"basic", "token"}},
+		},
+		"if": map[string]any{
+			"properties": map[string]any{"auth_type": map[string]any{"const": "basic"}},
+		},
+		"then": map[string]any{
+			"required":   []any{"username", "password"},
+			"properties": map[string]any{"username": true, "password": true},
+		},
+		"else": map[string]any{
+			"required":   []any{"token"},
+			"properties": map[string]any{"token": true},
+		},
+		"unevaluatedProperties": false,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, schema.Validate(map[string]any{"auth_type": "basic", "username": "u", "password": "p"}))
+	require.NoError(t, schema.Validate(map[string]any{"auth_type": "token", "token": "t"}))
+
+	err = schema.Validate(map[string]any{"auth_type": "token", "username": "u"})
+	require.Equal(t, map[string]string{
+		"token":    "required",
+		"username": "unevaluatedProperties",
+	}, fieldErrors(t, err))
+}
+
+func TestSchema_ApplyDefaults(t *testing.T) {
+	schema, err := Compile(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"page_size": map[string]any{"type": "integer", "default": 100},
+			"region":    map[string]any{"type": "string", "default": "us"},
+			"retry": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"max_retries": map[string]any{"type": "integer", "default": 3},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	input := map[string]any{"region": "eu", "retry": map[string]any{}}
+	values := schema.ApplyDefaults(input)
+	require.Equal(t, map[string]any{
+		"page_size": float64(100),
+		"region":    "eu",
+		"retry":     map[string]any{"max_retries": float64(3)},
+	}, values)
+	require.Empty(t, input["retry"], "input must not be mutated")
+}
+
+func TestCompile_RejectsInvalidSchemas(t *testing.T) {
+	for _, schema := range []map[string]any{
+		{"type": "text"},
+		{"pattern": "("},
+		{"minLength": -1},
+		{"properties": map[string]any{"a": "string"}},
+		{"$ref": "#/$defs/missing"},
+		{"$ref": "https://example.com/schema.json"},
+	} {
+		_, err := Compile(schema)
+		require.Error(t, err, "%v", schema)
+	}
+}
+
+func TestSchema_OpaqueValuesAreAccepted(t *testing.T) {
+	schema, err := Compile(map[string]any{
+		"properties": map[string]any{"api_key": map[string]any{"type": "string"}},
+	})
+	require.NoError(t, err)
+
+	encrypted := map[string]any{"api_key": map[string]any{"$encrypted": map[string]any{"v": 1}}}
+	require.Error(t, schema.Validate(encrypted))
+	require.NoError(t, schema.ValidateWithOptions(encrypted, Options{
+		Opaque: func(value any) bool {
+			m, ok := value.(map[string]any)
+			return ok && m["$encrypted"] != nil
+		},
+	}))
+}
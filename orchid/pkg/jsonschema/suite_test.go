@@ -0,0 +1,744 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Conformance cases in the format of the JSON Schema Test Suite
+// (https://github.com/json-schema-org/JSON-Schema-Test-Suite, tests/draft2020-12), taken from its
+// files of the keywords implemented here. Cases that need remote references are left out.
+
+type suiteGroup struct {
+	Description string      `json:"description"`
+	Schema      any         `json:"schema"`
+	Tests       []suiteTest `json:"tests"`
+}
+
+type suiteTest struct {
+	Description string `json:"description"`
+	Data        any    `json:"data"`
+	Valid       bool   `json:"valid"`
+}
+
+func runSuite(t *testing.T, groups string) {
+	t.Helper()
+	var suite []suiteGroup
+	require.NoError(t, json.Unmarshal([]byte(groups), &suite))
+
+	for _, group := range suite {
+		t.Run(group.Description, func(t *testing.T) {
+			schema, err := Compile(group.Schema)
+			require.NoError(t, err)
+			for _, test := range group.Tests {
+				err := schema.Validate(test.Data)
+				if test.Valid {
+					require.NoError(t, err, test.Description)
+				} else {
+					require.Error(t, err, test.Description)
+				}
+			}
+		})
+	}
+}
+
+func TestSuite_Ref(t *testing.T) {
+	runSuite(t, `[
+		{
+			"description": "root pointer ref",
+			"schema": {"properties": {"foo": {"$ref": "#"}}, "additionalProperties": false},
+			"tests": [
+				{"description": "match", "data": {"foo": false}, "valid": true},
+				{"description": "recursive match", "data": {"foo": {"foo": false}}, "valid": true},
+				{"description": "mismatch", "data": {"bar": false}, "valid": false},
+				{"description": "recursive mismatch", "data": {"foo": {"bar": false}}, "valid": false}
+			]
+		},
+		{
+			"description": "relative pointer ref to object",
+			"schema": {"properties": {"foo": {"type": "integer"}, "bar": {"$ref": "#/properties/foo"}}},
+			"tests": [
+				{"description": "match", "data": {"bar": 3}, "valid": true},
+				{"description": "mismatch", "data": {"bar": true}, "valid": false}
+			]
+		},
+		{
+			"description": "relative pointer ref to array",
+			"schema": {"prefixItems": [{"type": "integer"}, {"$ref": "#/prefixItems/0"}]},
+			"tests": [
+				{"description": "match array", "data": [1, 2], "valid": true},
+				{"description": "mismatch array", "data": [1, "foo"], "valid": false}
+			]
+		},
+		{
+			"description": "escaped pointer ref",
+			"schema": {
+				"$defs": {
+					"tilde~field": {"type": "integer"},
+					"slash/field": {"type": "integer"},
+					"percent%field": {"type": "integer"}
+				},
+				"properties": {
+					"tilde": {"$ref": "#/$defs/tilde~0field"},
+					"slash": {"$ref": "#/$defs/slash~1field"},
+					"percent": {"$ref": "#/$defs/percent%25field"}
+				}
+			},
+			"tests": [
+				{"description": "slash invalid", "data": {"slash": "aoeu"}, "valid": false},
+				{"description": "tilde invalid", "data": {"tilde": "aoeu"}, "valid": false},
+				{"description": "percent invalid", "data": {"percent": "aoeu"}, "valid": false},
+				{"description": "slash valid", "data": {"slash": 123}, "valid": true},
+				{"description": "tilde valid", "data": {"tilde": 123}, "valid": true},
+				{"description": "percent valid", "data": {"percent": 123}, "valid": true}
+			]
+		},
+		{
+			"description": "nested refs",
+			"schema": {
+				"$defs": {
+					"a": {"type": "integer"},
+					"b": {"$ref": "#/$defs/a"},
+					"c": {"$ref": "#/$defs/b"}
+				},
+				"$ref": "#/$defs/c"
+			},
+			"tests": [
+				{"description": "nested ref valid", "data": 5, "valid": true},
+				{"description": "nested ref invalid", "data": "a", "valid": false}
+			]
+		},
+		{
+			"description": "ref applies alongside sibling keywords",
+			"schema": {
+				"$defs": {"reffed": {"type": "array"}},
+				"properties": {"foo": {"$ref": "#/$defs/reffed", "maxItems": 2}}
+			},
+			"tests": [
+				{"description": "ref valid, maxItems valid", "data": {"foo": []}, "valid": true},
+				{"description": "ref valid, maxItems invalid", "data": {"foo": [1, 2, 3]}, "valid": false},
+				{"description": "ref invalid", "data": {"foo": "string"}, "valid": false}
+			]
+		},
+		{
+			"description": "$ref to boolean schema true",
+			"schema": {"$ref": "#/$defs/bool", "$defs": {"bool": true}},
+			"tests": [
+				{"description": "any value is valid", "data": "foo", "valid": true}
+			]
+		},
+		{
+			"description": "$ref to boolean schema false",
+			"schema": {"$ref": "#/$defs/bool", "$defs": {"bool": false}},
+			"tests": [
+				{"description": "any value is invalid", "data": "foo", "valid": false}
+			]
+		},
+		{
+			"description": "recursive references between schemas",
+			"schema": {
+				"$defs": {
+					"node": {
+						"type": "object",
+						"properties": {
+							"value": {"type": "number"},
+							"subtree": {"$ref": "#/$defs/tree"}
+						},
+						"required": ["value"]
+					},
+					"tree": {
+						"type": "object",
+						"properties": {
+							"meta": {"type": "string"},
+							"nodes": {"type": "array", "items": {"$ref": "#/$defs/node"}}
+						},
+						"required": ["meta", "nodes"]
+					}
+				},
+				"$ref": "#/$defs/tree"
+			},
+			"tests": [
+				{
+					"description": "valid tree",
+					"data": {"meta": "root", "nodes": [
+						{"value": 1, "subtree": {"meta": "child", "nodes": [{"value": 1.1}, {"value": 1.2}]}},
+						{"value": 2, "subtree": {"meta": "child", "nodes": [{"value": 2.1}]}}
+					]},
+					"valid": true
+				},
+				{
+					"description": "invalid tree",
+					"data": {"meta": "root", "nodes": [
+						{"value": 1, "subtree": {"meta": "child", "nodes": [{"value": "string is invalid"}]}}
+					]},
+					"valid": false
+				}
+			]
+		},
+		{
+			"description": "$ref to $anchor",
+			"schema": {"$ref": "#foo", "$defs": {"A": {"$anchor": "foo", "type": "integer"}}},
+			"tests": [
+				{"description": "match", "data": 1, "valid": true},
+				{"description": "mismatch", "data": "a", "valid": false}
+			]
+		},
+		{
+			"description": "$ref to an embedded $id",
+			"schema": {
+				"$ref": "https://example.com/int.json",
+				"$defs": {"A": {"$id": "https://example.com/int.json", "type": "integer"}}
+			},
+			"tests": [
+				{"description": "match", "data": 1, "valid": true},
+				{"description": "mismatch", "data": "a", "valid": false}
+			]
+		},
+		{
+			"description": "$ref with the draft-07 definitions keyword",
+			"schema": {"definitions": {"positive": {"minimum": 0}}, "items": {"$ref": "#/definitions/positive"}},
+			"tests": [
+				{"description": "match", "data": [0, 1], "valid": true},
+				{"description": "mismatch", "data": [-1], "valid": false}
+			]
+		}
+	]`)
+}
+
+func TestSuite_Applicators(t *testing.T) {
+	runSuite(t, `[
+		{
+			"description": "allOf",
+			"schema": {"allOf": [
+				{"properties": {"bar": {"type": "integer"}}, "required": ["bar"]},
+				{"properties": {"foo": {"type": "string"}}, "required": ["foo"]}
+			]},
+			"tests": [
+				{"description": "allOf", "data": {"foo": "baz", "bar": 2}, "valid": true},
+				{"description": "mismatch second", "data": {"foo": "baz"}, "valid": false},
+				{"description": "mismatch first", "data": {"bar": 2}, "valid": false},
+				{"description": "wrong type", "data": {"foo": "baz", "bar": "quux"}, "valid": false}
+			]
+		},
+		{
+			"description": "allOf with base schema",
+			"schema": {
+				"properties": {"bar": {"type": "integer"}},
+				"required": ["bar"],
+				"allOf": [
+					{"properties": {"foo": {"type": "string"}}, "required": ["foo"]},
+					{"properties": {"baz": {"type": "null"}}, "required": ["baz"]}
+				]
+			},
+			"tests": [
+				{"description": "valid", "data": {"foo": "quux", "bar": 2, "baz": null}, "valid": true},
+				{"description": "mismatch base schema", "data": {"foo": "quux", "baz": null}, "valid": false},
+				{"description": "mismatch first allOf", "data": {"bar": 2, "baz": null}, "valid": false},
+				{"description": "mismatch second allOf", "data": {"foo": "quux", "bar": 2}, "valid": false},
+				{"description": "mismatch both", "data": {"bar": 2}, "valid": false}
+			]
+		},
+		{
+			"description": "allOf with boolean schemas, some false",
+			"schema": {"allOf": [true, false]},
+			"tests": [
+				{"description": "any value is invalid", "data": "foo", "valid": false}
+			]
+		},
+		{
+			"description": "anyOf",
+			"schema": {"anyOf": [{"type": "integer"}, {"minimum": 2}]},
+			"tests": [
+				{"description": "first anyOf valid", "data": 1, "valid": true},
+				{"description": "second anyOf valid", "data": 2.5, "valid": true},
+				{"description": "both anyOf valid", "data": 3, "valid": true},
+				{"description": "neither anyOf valid", "data": 1.5, "valid": false}
+			]
+		},
+		{
+			"description": "anyOf with base schema",
+			"schema": {"type": "string", "anyOf": [{"maxLength": 2}, {"minLength": 4}]},
+			"tests": [
+				{"description": "mismatch base schema", "data": 3, "valid": false},
+				{"description": "one anyOf valid", "data": "foobar", "valid": true},
+				{"description": "both anyOf invalid", "data": "foo", "valid": false}
+			]
+		},
+		{
+			"description": "anyOf with boolean schemas, all false",
+			"schema": {"anyOf": [false, false]},
+			"tests": [
+				{"description": "any value is invalid", "data": "foo", "valid": false}
+			]
+		},
+		{
+			"description": "anyOf with boolean schemas, some true",
+			"schema": {"anyOf": [true, false]},
+			"tests": [
+				{"description": "any value is valid", "data": "foo", "valid": true}
+			]
+		},
+		{
+			"description": "oneOf",
+			"schema": {"oneOf": [{"type": "integer"}, {"minimum": 2}]},
+			"tests": [
+				{"description": "first oneOf valid", "data": 1, "valid": true},
+				{"description": "second oneOf valid", "data": 2.5, "valid": true},
+				{"description": "both oneOf valid", "data": 3, "valid": false},
+				{"description": "neither oneOf valid", "data": 1.5, "valid": false}
+			]
+		},
+		{
+			"description": "oneOf with required",
+			"schema": {"type": "object", "oneOf": [{"required": ["foo", "bar"]}, {"required": ["foo", "baz"]}]},
+			"tests": [
+				{"description": "both invalid", "data": {"bar": 2}, "valid": false},
+				{"description": "first valid", "data": {"foo": 1, "bar": 2}, "valid": true},
+				{"description": "second valid", "data": {"foo": 1, "baz": 3}, "valid": true},
+				{"description": "both valid", "data": {"foo": 1, "bar": 2, "baz": 3}, "valid": false}
+			]
+		},
+		{
+			"description": "oneOf with boolean schemas, one true",
+			"schema": {"oneOf": [true, false, false]},
+			"tests": [
+				{"description": "any value is valid", "data": "foo", "valid": true}
+			]
+		},
+		{
+			"description": "oneOf with boolean schemas, more than one true",
+			"schema": {"oneOf": [true, true, false]},
+			"tests": [
+				{"description": "any value is invalid", "data": "foo", "valid": false}
+			]
+		},
+		{
+			"description": "nested allOf, anyOf and oneOf",
+			"schema": {"allOf": [{"anyOf": [{"oneOf": [{"type": "null"}]}]}]},
+			"tests": [
+				{"description": "null is valid", "data": null, "valid": true},
+				{"description": "anything non-null is invalid", "data": 123, "valid": false}
+			]
+		}
+	]`)
+}
+
+func TestSuite_Format(t *testing.T) {
+	runSuite(t, `[
+		{
+			"description": "date-time format",
+			"schema": {"format": "date-time"},
+			"tests": [
+				{"description": "non-strings are ignored", "data": 12, "valid": true},
+				{"description": "a valid date-time string", "data": "1963-06-19T08:30:06.283185Z", "valid": true},
+				{"description": "a valid date-time string without second fraction", "data": "1963-06-19T08:30:06Z", "valid": true},
+				{"description": "a valid date-time string with plus offset", "data": "1937-01-01T12:00:27.87+00:20", "valid": true},
+				{"description": "case-insensitive T and Z", "data": "1963-06-19t08:30:06.283185z", "valid": true},
+				{"description": "an invalid day in date-time string", "data": "1990-02-31T15:59:59.123-08:00", "valid": false},
+				{"description": "an invalid offset in date-time string", "data": "1990-12-31T15:59:59-24:00", "valid": false},
+				{"description": "an invalid date-time string", "data": "06/19/1963 08:30:06 PST", "valid": false},
+				{"description": "only RFC3339 not all of ISO 8601 are valid", "data": "2013-350T01:01:01", "valid": false}
+			]
+		},
+		{
+			"description": "date format",
+			"schema": {"format": "date"},
+			"tests": [
+				{"description": "a valid date string", "data": "1963-06-19", "valid": true},
+				{"description": "a valid leap day", "data": "2020-02-29", "valid": true},
+				{"description": "an invalid leap day", "data": "2021-02-29", "valid": false},
+				{"description": "an invalid date string", "data": "06/19/1963", "valid": false},
+				{"description": "non-padded month dates are not valid", "data": "1998-1-20", "valid": false}
+			]
+		},
+		{
+			"description": "time format",
+			"schema": {"format": "time"},
+			"tests": [
+				{"description": "a valid time string", "data": "08:30:06Z", "valid": true},
+				{"description": "a valid time string with second fraction", "data": "23:20:50.52Z", "valid": true},
+				{"description": "a valid time string with plus offset", "data": "08:30:06+00:20", "valid": true},
+				{"description": "no time offset", "data": "12:00:00", "valid": false},
+				{"description": "an invalid offset hour", "data": "08:30:06-24:00", "valid": false},
+				{"description": "an invalid time string with invalid hour", "data": "24:00:00Z", "valid": false},
+				{"description": "an invalid time string", "data": "08:30:06 PST", "valid": false}
+			]
+		},
+		{
+			"description": "email format",
+			"schema": {"format": "email"},
+			"tests": [
+				{"description": "a valid e-mail address", "data": "joe.bloggs@example.com", "valid": true},
+				{"description": "an invalid e-mail address", "data": "2962", "valid": false},
+				{"description": "a display name is not an e-mail address", "data": "Joe Bloggs <joe.bloggs@example.com>", "valid": false}
+			]
+		},
+		{
+			"description": "hostname format",
+			"schema": {"format": "hostname"},
+			"tests": [
+				{"description": "a valid host name", "data": "www.example.com", "valid": true},
+				{"description": "a host name starting with an illegal character", "data": "-a-host-name-that-starts-with--", "valid": false},
+				{"description": "a host name containing illegal characters", "data": "not_a_valid_host_name", "valid": false},
+				{"description": "a host name with a component too long", "data": "a-vvvvvvvvvvvvvvvveeeeeeeeeeeeeeeerrrrrrrrrrrrrrrryyyyyyyyyyyyyyyy-long-host-name-component", "valid": false}
+			]
+		},
+		{
+			"description": "ipv4 format",
+			"schema": {"format": "ipv4"},
+			"tests": [
+				{"description": "a valid IP address", "data": "192.168.0.1", "valid": true},
+				{"description": "an IP address with too many components", "data": "127.0.0.0.1", "valid": false},
+				{"description": "an IP address with out-of-range values", "data": "256.256.256.256", "valid": false},
+				{"description": "leading zeroes should be rejected", "data": "087.10.0.1", "valid": false},
+				{"description": "an IPv6 address", "data": "::ffff:192.168.0.1", "valid": false}
+			]
+		},
+		{
+			"description": "ipv6 format",
+			"schema": {"format": "ipv6"},
+			"tests": [
+				{"description": "a valid IPv6 address", "data": "::1", "valid": true},
+				{"description": "mixed format with ipv4", "data": "::ffff:192.168.0.1", "valid": true},
+				{"description": "an IPv6 address with out-of-range values", "data": "12345::", "valid": false},
+				{"description": "an IPv4 address", "data": "127.0.0.1", "valid": false}
+			]
+		},
+		{
+			"description": "uri format",
+			"schema": {"format": "uri"},
+			"tests": [
+				{"description": "a valid URL with anchor tag", "data": "http://foo.bar/?baz=qux#quux", "valid": true},
+				{"description": "a valid URN", "data": "urn:oasis:names:specification:docbook:dtd:xml:4.1.2", "valid": true},
+				{"description": "an invalid protocol-relative URI Reference", "data": "//foo.bar/?baz=qux#quux", "valid": false},
+				{"description": "an invalid relative URI Reference", "data": "/abc", "valid": false}
+			]
+		},
+		{
+			"description": "uri-reference format",
+			"schema": {"format": "uri-reference"},
+			"tests": [
+				{"description": "a valid URI", "data": "http://foo.bar/?baz=qux#quux", "valid": true},
+				{"description": "a valid relative URI Reference", "data": "/abc", "valid": true},
+				{"description": "a valid URI fragment", "data": "#fragment", "valid": true},
+				{"description": "an invalid URI Reference", "data": "http://foo bar/", "valid": false}
+			]
+		},
+		{
+			"description": "uuid format",
+			"schema": {"format": "uuid"},
+			"tests": [
+				{"description": "all upper-case", "data": "2EB8AA08-AA98-11EA-B4AA-73B441D16380", "valid": true},
+				{"description": "all lower-case", "data": "2eb8aa08-aa98-11ea-b4aa-73b441d16380", "valid": true},
+				{"description": "wrong length", "data": "2eb8aa08-aa98-11ea-b4aa-73b441d1638", "valid": false},
+				{"description": "no dashes", "data": "2eb8aa08aa9811eab4aa73b441d16380", "valid": false},
+				{"description": "invalid character", "data": "2eb8aa08-aa98-11ea-b4ga-73b441d16380", "valid": false}
+			]
+		},
+		{
+			"description": "regex format",
+			"schema": {"format": "regex"},
+			"tests": [
+				{"description": "a valid regular expression", "data": "([abc])+\\s+$", "valid": true},
+				{"description": "a regular expression with unclosed parens is invalid", "data": "^(abc]", "valid": false}
+			]
+		},
+		{
+			"description": "unknown format",
+			"schema": {"format": "custom-format"},
+			"tests": [
+				{"description": "unknown formats ignore strings", "data": "anything", "valid": true}
+			]
+		}
+	]`)
+}
+
+func TestSuite_UnevaluatedProperties(t *testing.T) {
+	runSuite(t, `[
+		{
+			"description": "unevaluatedProperties false",
+			"schema": {"type": "object", "unevaluatedProperties": false},
+			"tests": [
+				{"description": "with no unevaluated properties", "data": {}, "valid": true},
+				{"description": "with unevaluated properties", "data": {"foo": "foo"}, "valid": false}
+			]
+		},
+		{
+			"description": "unevaluatedProperties schema",
+			"schema": {"type": "object", "unevaluatedProperties": {"type": "string", "minLength": 3}},
+			"tests": [
+				{"description": "with valid unevaluated properties", "data": {"foo": "foo"}, "valid": true},
+				{"description": "with invalid unevaluated properties", "data": {"foo": "fo"}, "valid": false}
+			]
+		},
+		{
+			"description": "unevaluatedProperties with adjacent properties",
+			"schema": {"type": "object", "properties": {"foo": {"type": "string"}}, "unevaluatedProperties": false},
+			"tests": [
+				{"description": "with no unevaluated properties", "data": {"foo": "foo"}, "valid": true},
+				{"description": "with unevaluated properties", "data": {"foo": "foo", "bar": "bar"}, "valid": false}
+			]
+		},
+		{
+			"description": "unevaluatedProperties with adjacent patternProperties",
+			"schema": {"type": "object", "patternProperties": {"^foo": {"type": "string"}}, "unevaluatedProperties": false},
+			"tests": [
+				{"description": "with no unevaluated properties", "data": {"foo": "foo"}, "valid": true},
+				{"description": "with unevaluated properties", "data": {"foo": "foo", "bar": "bar"}, "valid": false}
+			]
+		},
+		{
+			"description": "unevaluatedProperties with adjacent additionalProperties",
+			"schema": {"type": "object", "properties": {"foo": {"type": "string"}}, "additionalProperties": true, "unevaluatedProperties": false},
+			"tests": [
+				{"description": "with additional properties", "data": {"foo": "foo", "bar": "bar"}, "valid": true}
+			]
+		},
+		{
+			"description": "unevaluatedProperties with nested properties",
+			"schema": {
+				"type": "object",
+				"properties": {"foo": {"type": "string"}},
+				"allOf": [{"properties": {"bar": {"type": "string"}}}],
+				"unevaluatedProperties": false
+			},
+			"tests": [
+				{"description": "with no additional properties", "data": {"foo": "foo", "bar": "bar"}, "valid": true},
+				{"description": "with additional properties", "data": {"foo": "foo", "bar": "bar", "baz": "baz"}, "valid": false}
+			]
+		},
+		{
+			"description": "unevaluatedProperties with anyOf",
+			"schema": {
+				"type": "object",
+				"properties": {"foo": {"type": "string"}},
+				"anyOf": [
+					{"properties": {"bar": {"const": "bar"}}, "required": ["bar"]},
+					{"properties": {"baz": {"const": "baz"}}, "required": ["baz"]},
+					{"properties": {"quux": {"const": "quux"}}, "required": ["quux"]}
+				],
+				"unevaluatedProperties": false
+			},
+			"tests": [
+				{"description": "when one matches and has no unevaluated properties", "data": {"foo": "foo", "bar": "bar"}, "valid": true},
+				{"description": "when one matches and has unevaluated properties", "data": {"foo": "foo", "bar": "bar", "baz": "not-baz"}, "valid": false},
+				{"description": "when two match and has no unevaluated properties", "data": {"foo": "foo", "bar": "bar", "baz": "baz"}, "valid": true},
+				{"description": "when two match and has unevaluated properties", "data": {"foo": "foo", "bar": "bar", "baz": "baz", "quux": "not-quux"}, "valid": false}
+			]
+		},
+		{
+			"description": "unevaluatedProperties with oneOf",
+			"schema": {
+				"type": "object",
+				"properties": {"foo": {"type": "string"}},
+				"oneOf": [
+					{"properties": {"bar": {"const": "bar"}}, "required": ["bar"]},
+					{"properties": {"baz": {"const": "baz"}}, "required": ["baz"]}
+				],
+				"unevaluatedProperties": false
+			},
+			"tests": [
+				{"description": "with no unevaluated properties", "data": {"foo": "foo", "bar": "bar"}, "valid": true},
+				{"description": "with unevaluated properties", "data": {"foo": "foo", "bar": "bar", "quux": "quux"}, "valid": false}
+			]
+		},
+		{
+			"description": "unevaluatedProperties with not",
+			"schema": {
+				"type": "object",
+				"properties": {"foo": {"type": "string"}},
+				"not": {"not": {"properties": {"bar": {"const": "bar"}}, "required": ["bar"]}},
+				"unevaluatedProperties": false
+			},
+			"tests": [
+				{"description": "with unevaluated properties", "data": {"foo": "foo", "bar": "bar"}, "valid": false}
+			]
+		},
+		{
+			"description": "unevaluatedProperties with dependentSchemas",
+			"schema": {
+				"type": "object",
+				"properties": {"foo": {"type": "string"}},
+				"dependentSchemas": {"foo": {"properties": {"bar": {"const": "bar"}}, "required": ["bar"]}},
+				"unevaluatedProperties": false
+			},
+			"tests": [
+				{"description": "with no unevaluated properties", "data": {"foo": "foo", "bar": "bar"}, "valid": true},
+				{"description": "with unevaluated properties", "data": {"bar": "bar"}, "valid": false}
+			]
+		},
+		{
+			"description": "unevaluatedProperties with $ref",
+			"schema": {
+				"$defs": {"bar": {"properties": {"bar": {"type": "string"}}}},
+				"type": "object",
+				"$ref": "#/$defs/bar",
+				"properties": {"foo": {"type": "string"}},
+				"unevaluatedProperties": false
+			},
+			"tests": [
+				{"description": "with no unevaluated properties", "data": {"foo": "foo", "bar": "bar"}, "valid": true},
+				{"description": "with unevaluated properties", "data": {"foo": "foo", "bar": "bar", "baz": "baz"}, "valid": false}
+			]
+		},
+		{
+			"description": "nested unevaluatedProperties, outer false, inner true, properties inside",
+			"schema": {
+				"type": "object",
+				"allOf": [{"properties": {"foo": {"type": "string"}}, "unevaluatedProperties": true}],
+				"unevaluatedProperties": false
+			},
+			"tests": [
+				{"description": "with no nested unevaluated properties", "data": {"foo": "foo"}, "valid": true},
+				{"description": "with nested unevaluated properties", "data": {"foo": "foo", "bar": "bar"}, "valid": true}
+			]
+		},
+		{
+			"description": "cousin unevaluatedProperties, true and false, false with properties",
+			"schema": {
+				"type": "object",
+				"allOf": [
+					{"unevaluatedProperties": true},
+					{"properties": {"foo": {"type": "string"}}, "unevaluatedProperties": false}
+				]
+			},
+			"tests": [
+				{"description": "with no nested unevaluated properties", "data": {"foo": "foo"}, "valid": true},
+				{"description": "with nested unevaluated properties", "data": {"foo": "foo", "bar": "bar"}, "valid": false}
+			]
+		},
+		{
+			"description": "unevaluatedProperties can't see inside cousins",
+			"schema": {
+				"allOf": [
+					{"properties": {"foo": true}},
+					{"unevaluatedProperties": false}
+				]
+			},
+			"tests": [
+				{"description": "always fails", "data": {"foo": 1}, "valid": false}
+			]
+		}
+	]`)
+}
+
+func TestSuite_UnevaluatedItems(t *testing.T) {
+	runSuite(t, `[
+		{
+			"description": "unevaluatedItems false",
+			"schema": {"unevaluatedItems": false},
+			"tests": [
+				{"description": "with no unevaluated items", "data": [], "valid": true},
+				{"description": "with unevaluated items", "data": ["foo"], "valid": false}
+			]
+		},
+		{
+			"description": "unevaluatedItems as schema",
+			"schema": {"unevaluatedItems": {"type": "string"}},
+			"tests": [
+				{"description": "with valid unevaluated items", "data": ["foo"], "valid": true},
+				{"description": "with invalid unevaluated items", "data": [42], "valid": false}
+			]
+		},
+		{
+			"description": "unevaluatedItems with uniform items",
+			"schema": {"items": {"type": "string"}, "unevaluatedItems": false},
+			"tests": [
+				{"description": "unevaluatedItems doesn't apply", "data": ["foo", "bar"], "valid": true}
+			]
+		},
+		{
+			"description": "unevaluatedItems with tuple",
+			"schema": {"prefixItems": [{"type": "string"}], "unevaluatedItems": false},
+			"tests": [
+				{"description": "with no unevaluated items", "data": ["foo"], "valid": true},
+				{"description": "with unevaluated items", "data": ["foo", "bar"], "valid": false}
+			]
+		},
+		{
+			"description": "unevaluatedItems with nested tuple",
+			"schema": {
+				"prefixItems": [{"type": "string"}],
+				"allOf": [{"prefixItems": [true, {"type": "number"}]}],
+				"unevaluatedItems": false
+			},
+			"tests": [
+				{"description": "with no unevaluated items", "data": ["foo", 42], "valid": true},
+				{"description": "with unevaluated items", "data": ["foo", 42, true], "valid": false}
+			]
+		},
+		{
+			"description": "unevaluatedItems with anyOf",
+			"schema": {
+				"prefixItems": [{"const": "foo"}],
+				"anyOf": [
+					{"prefixItems": [true, {"const": "bar"}]},
+					{"prefixItems": [true, true, {"const": "baz"}]}
+				],
+				"unevaluatedItems": false
+			},
+			"tests": [
+				{"description": "when one schema matches and has no unevaluated items", "data": ["foo", "bar"], "valid": true},
+				{"description": "when one schema matches and has unevaluated items", "data": ["foo", "bar", 42], "valid": false},
+				{"description": "when two schemas match and has no unevaluated items", "data": ["foo", "bar", "baz"], "valid": true},
+				{"description": "when two schemas match and has unevaluated items", "data": ["foo", "bar", "baz", 42], "valid": false}
+			]
+		},
+		{
+			"description": "unevaluatedItems with oneOf",
+			"schema": {
+				"prefixItems": [{"const": "foo"}],
+				"oneOf": [
+					{"prefixItems": [true, {"const": "bar"}]},
+					{"prefixItems": [true, {"const": "baz"}]}
+				],
+				"unevaluatedItems": false
+			},
+			"tests": [
+				{"description": "with no unevaluated items", "data": ["foo", "bar"], "valid": true},
+				{"description": "with unevaluated items", "data": ["foo", "bar", 42], "valid": false}
+			]
+		},
+		{
+			"description": "unevaluatedItems with $ref",
+			"schema": {
+				"$defs": {"bar": {"prefixItems": [true, {"type": "string"}]}},
+				"$ref": "#/$defs/bar",
+				"prefixItems": [{"type": "string"}],
+				"unevaluatedItems": false
+			},
+			"tests": [
+				{"description": "with no unevaluated items", "data": ["foo", "bar"], "valid": true},
+				{"description": "with unevaluated items", "data": ["foo", "bar", "baz"], "valid": false}
+			]
+		},
+		{
+			"description": "unevaluatedItems depends on multiple nested contains",
+			"schema": {
+				"allOf": [
+					{"contains": {"multipleOf": 2}},
+					{"contains": {"multipleOf": 3}}
+				],
+				"unevaluatedItems": {"multipleOf": 5}
+			},
+			"tests": [
+				{"description": "5 not evaluated, passes unevaluatedItems", "data": [2, 3, 4, 5, 6], "valid": true},
+				{"description": "7 not evaluated, fails unevaluatedItems", "data": [2, 3, 4, 7, 8], "valid": false}
+			]
+		},
+		{
+			"description": "unevaluatedItems can't see inside cousins",
+			"schema": {
+				"allOf": [
+					{"prefixItems": [true]},
+					{"unevaluatedItems": false}
+				]
+			},
+			"tests": [
+				{"description": "always fails", "data": [1], "valid": false}
+			]
+		}
+	]`)
+}
@@ -0,0 +1,607 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxDepth bounds $ref recursion that does not consume the instance
+const maxDepth = 256
+
+// FieldError describes a value that does not satisfy the schema
+type FieldError struct {
+	Field   string `json:"field"` // Dotted path to the value, e.g. "oauth.scopes[0]" ("" for the root)
+	Keyword string `json:"keyword"`
+	Message string `json:"message"`
+}
+
+// String returns "field: message"
+func (e FieldError) String() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError is returned when an instance does not satisfy a schema
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+// Error joins the field errors into a single message
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fieldErr := range e.Errors {
+		messages = append(messages, fieldErr.String())
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// Options customizes validation
+type Options struct {
+	// Opaque reports values that are accepted without validation, such as encrypted secrets
+	Opaque func(value any) bool
+}
+
+// Validate validates an instance against the schema.
+// It returns a *ValidationError listing every failing field, or nil when the instance is valid.
+func (s *Schema) Validate(instance any) error {
+	return s.ValidateWithOptions(instance, Options{})
+}
+
+// ValidateWithOptions validates an instance with custom options
+func (s *Schema) ValidateWithOptions(instance any, opts Options) error {
+	v := &validator{schema: s, opts: opts}
+	errs, _ := v.validate(s.root, normalize(instance), "", 0)
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: errs}
+}
+
+// validator holds the state of a single validation
+type validator struct {
+	schema *Schema
+	opts   Options
+}
+
+// evaluated tracks the properties and items a schema evaluated, for unevaluated* keywords
+type evaluated struct {
+	props    map[string]bool
+	items    map[int]bool
+	allProps bool
+	allItems bool
+}
+
+func newEvaluated() *evaluated {
+	return &evaluated{props: make(map[string]bool), items: make(map[int]bool)}
+}
+
+// merge adds the annotations of a passing subschema
+func (e *evaluated) merge(other *evaluated) {
+	if other == nil {
+		return
+	}
+	for key := range other.props {
+		e.props[key] = true
+	}
+	for i := range other.items {
+		e.items[i] = true
+	}
+	e.allProps = e.allProps || other.allProps
+	e.allItems = e.allItems || other.allItems
+}
+
+// validate validates inst against n, returning the errors and the evaluation annotations
+func (v *validator) validate(n *node, inst any, field string, depth int) ([]FieldError, *evaluated) {
+	ev := newEvaluated()
+
+	if v.opts.Opaque != nil && v.opts.Opaque(inst) {
+		ev.allProps, ev.allItems = true, true
+		return nil, ev
+	}
+	if n.boolean != nil {
+		if *n.boolean {
+			ev.allProps, ev.allItems = true, true
+			return nil, ev
+		}
+		return []FieldError{{Field: field, Keyword: "false", Message: "is not allowed"}}, ev
+	}
+	if depth > maxDepth {
+		return []FieldError{{Field: field, Keyword: "$ref", Message: "schema recursion is too deep"}}, ev
+	}
+
+	var errs []FieldError
+	fail := func(keyword, format string, args ...any) {
+		errs = append(errs, FieldError{Field: field, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, ref := range []string{n.ref, n.dynamicRef} {
+		if ref == "" {
+			continue
+		}
+		target, err := v.schema.resolve(ref)
+		if err != nil {
+			fail("$ref", "%v", err)
+			continue
+		}
+		refErrs, refEv := v.validate(target, inst, field, depth+1)
+		errs = append(errs, refErrs...)
+		if len(refErrs) == 0 {
+			ev.merge(refEv)
+		}
+	}
+
+	if len(n.types) > 0 {
+		matched := false
+		for _, t := range n.types {
+			if matchesType(t, inst) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail("type", "must be of type %s", strings.Join(n.types, " or "))
+			// Type-specific keywords are meaningless for the wrong type
+			return errs, ev
+		}
+	}
+
+	if n.hasConst && !equal(inst, n.constValue) {
+		fail("const", "must be %s", formatValue(n.constValue))
+	}
+	if n.hasEnum {
+		found := false
+		for _, allowed := range n.enum {
+			if equal(inst, allowed) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			values := make([]string, 0, len(n.enum))
+			for _, allowed := range n.enum {
+				values = append(values, formatValue(allowed))
+			}
+			fail("enum", "must be one of %s", strings.Join(values, ", "))
+		}
+	}
+
+	switch value := inst.(type) {
+	case map[string]any:
+		errs = append(errs, v.validateObject(n, value, field, depth, ev)...)
+	case []any:
+		errs = append(errs, v.validateArray(n, value, field, depth, ev)...)
+	case string:
+		errs = append(errs, validateString(n, value, field)...)
+	case float64:
+		errs = append(errs, validateNumber(n, value, field)...)
+	}
+
+	errs = append(errs, v.validateApplicators(n, inst, field, depth, ev)...)
+
+	// unevaluated* see the annotations of every other keyword, so they run last
+	if obj, ok := inst.(map[string]any); ok && n.unevaluatedProperties != nil && !ev.allProps {
+		for _, key := range sortedKeys(obj) {
+			if ev.props[key] {
+				continue
+			}
+			if n.unevaluatedProperties.isFalse() {
+				errs = append(errs, FieldError{Field: joinField(field, key), Keyword: "unevaluatedProperties", Message: "is not allowed"})
+				continue
+			}
+			propErrs, _ := v.validate(n.unevaluatedProperties, obj[key], joinField(field, key), depth+1)
+			errs = append(errs, propErrs...)
+			ev.props[key] = true
+		}
+	}
+	if arr, ok := inst.([]any); ok && n.unevaluatedItems != nil && !ev.allItems {
+		for i, item := range arr {
+			if ev.items[i] {
+				continue
+			}
+			itemErrs, _ := v.validate(n.unevaluatedItems, item, indexField(field, i), depth+1)
+			errs = append(errs, itemErrs...)
+			ev.items[i] = true
+		}
+	}
+
+	return errs, ev
+}
+
+// validateObject applies the object keywords
+func (v *validator) validateObject(n *node, obj map[string]any, field string, depth int, ev *evaluated) []FieldError {
+	var errs []FieldError
+
+	for _, name := range n.required {
+		if _, ok := obj[name]; !ok {
+			errs = append(errs, FieldError{Field: joinField(field, name), Keyword: "required", Message: "is required"})
+		}
+	}
+	for _, name := range sortedStringKeys(n.dependentRequired) {
+		if _, ok := obj[name]; !ok {
+			continue
+		}
+		for _, dep := range n.dependentRequired[name] {
+			if _, ok := obj[dep]; !ok {
+				errs = append(errs, FieldError{Field: joinField(field, dep), Keyword: "dependentRequired", Message: fmt.Sprintf("is required when %q is present", name)})
+			}
+		}
+	}
+	if n.minProperties != nil && len(obj) < *n.minProperties {
+		errs = append(errs, FieldError{Field: field, Keyword: "minProperties", Message: fmt.Sprintf("must have at least %d properties", *n.minProperties)})
+	}
+	if n.maxProperties != nil && len(obj) > *n.maxProperties {
+		errs = append(errs, FieldError{Field: field, Keyword: "maxProperties", Message: fmt.Sprintf("must have at most %d properties", *n.maxProperties)})
+	}
+
+	for _, key := range sortedKeys(obj) {
+		value := obj[key]
+		keyField := joinField(field, key)
+		matched := false
+
+		if propNode, ok := n.properties[key]; ok {
+			matched = true
+			propErrs, _ := v.validate(propNode, value, keyField, depth+1)
+			errs = append(errs, propErrs...)
+		}
+		for _, pp := range n.patternProperties {
+			if pp.re.MatchString(key) {
+				matched = true
+				propErrs, _ := v.validate(pp.node, value, keyField, depth+1)
+				errs = append(errs, propErrs...)
+			}
+		}
+		if matched {
+			ev.props[key] = true
+		} else if n.additionalProperties != nil {
+			ev.props[key] = true
+			if n.additionalProperties.isFalse() {
+				errs = append(errs, FieldError{Field: keyField, Keyword: "additionalProperties", Message: "is not allowed"})
+			} else {
+				propErrs, _ := v.validate(n.additionalProperties, value, keyField, depth+1)
+				errs = append(errs, propErrs...)
+			}
+		}
+
+		if n.propertyNames != nil {
+			nameErrs, _ := v.validate(n.propertyNames, key, "", depth+1)
+			for _, nameErr := range nameErrs {
+				errs = append(errs, FieldError{Field: keyField, Keyword: "propertyNames", Message: "property name " + nameErr.Message})
+			}
+		}
+	}
+
+	for _, name := range sortedNodeKeys(n.dependentSchemas) {
+		if _, ok := obj[name]; !ok {
+			continue
+		}
+		depErrs, depEv := v.validate(n.dependentSchemas[name], obj, field, depth+1)
+		errs = append(errs, depErrs...)
+		if len(depErrs) == 0 {
+			ev.merge(depEv)
+		}
+	}
+
+	return errs
+}
+
+// validateArray applies the array keywords
+func (v *validator) validateArray(n *node, arr []any, field string, depth int, ev *evaluated) []FieldError {
+	var errs []FieldError
+
+	if n.minItems != nil && len(arr) < *n.minItems {
+		errs = append(errs, FieldError{Field: field, Keyword: "minItems", Message: fmt.Sprintf("must have at least %d items", *n.minItems)})
+	}
+	if n.maxItems != nil && len(arr) > *n.maxItems {
+		errs = append(errs, FieldError{Field: field, Keyword: "maxItems", Message: fmt.Sprintf("must have at most %d items", *n.maxItems)})
+	}
+
+	for i, item := range arr {
+		var itemNode *node
+		if i < len(n.prefixItems) {
+			itemNode = n.prefixItems[i]
+		} else if n.items != nil {
+			itemNode = n.items
+		}
+		if itemNode == nil {
+			continue
+		}
+		ev.items[i] = true
+		if itemNode.isFalse() {
+			errs = append(errs, FieldError{Field: indexField(field, i), Keyword: "items", Message: "is not allowed"})
+			continue
+		}
+		itemErrs, _ := v.validate(itemNode, item, indexField(field, i), depth+1)
+		errs = append(errs, itemErrs...)
+	}
+
+	if n.contains != nil {
+		matches := 0
+		for i, item := range arr {
+			if itemErrs, _ := v.validate(n.contains, item, indexField(field, i), depth+1); len(itemErrs) == 0 {
+				matches++
+				ev.items[i] = true
+			}
+		}
+		minContains := 1
+		if n.minContains != nil {
+			minContains = *n.minContains
+		}
+		if matches < minContains {
+			errs = append(errs, FieldError{Field: field, Keyword: "contains", Message: fmt.Sprintf("must contain at least %d matching items", minContains)})
+		}
+		if n.maxContains != nil && matches > *n.maxContains {
+			errs = append(errs, FieldError{Field: field, Keyword: "maxContains", Message: fmt.Sprintf("must contain at most %d matching items", *n.maxContains)})
+		}
+	}
+
+	if n.uniqueItems {
+		for i := 0; i < len(arr); i++ {
+			for j := i + 1; j < len(arr); j++ {
+				if equal(arr[i], arr[j]) {
+					errs = append(errs, FieldError{Field: field, Keyword: "uniqueItems", Message: fmt.Sprintf("items %d and %d are identical", i, j)})
+				}
+			}
+		}
+	}
+
+	return errs
+}
+
+// validateApplicators applies allOf, anyOf, oneOf, not and if/then/else
+func (v *validator) validateApplicators(n *node, inst any, field string, depth int, ev *evaluated) []FieldError {
+	var errs []FieldError
+
+	for _, sub := range n.allOf {
+		subErrs, subEv := v.validate(sub, inst, field, depth+1)
+		errs = append(errs, subErrs...)
+		if len(subErrs) == 0 {
+			ev.merge(subEv)
+		}
+	}
+
+	if len(n.anyOf) > 0 {
+		passed := false
+		for _, sub := range n.anyOf {
+			// Every branch is evaluated so its annotations are collected
+			if subErrs, subEv := v.validate(sub, inst, field, depth+1); len(subErrs) == 0 {
+				passed = true
+				ev.merge(subEv)
+			}
+		}
+		if !passed {
+			errs = append(errs, FieldError{Field: field, Keyword: "anyOf", Message: "must match at least one of the allowed schemas"})
+		}
+	}
+
+	if len(n.oneOf) > 0 {
+		var passing []*evaluated
+		for _, sub := range n.oneOf {
+			if subErrs, subEv := v.validate(sub, inst, field, depth+1); len(subErrs) == 0 {
+				passing = append(passing, subEv)
+			}
+		}
+		switch len(passing) {
+		case 1:
+			ev.merge(passing[0])
+		case 0:
+			errs = append(errs, FieldError{Field: field, Keyword: "oneOf", Message: "must match exactly one of the allowed schemas"})
+		default:
+			errs = append(errs, FieldError{Field: field, Keyword: "oneOf", Message: fmt.Sprintf("must match exactly one of the allowed schemas, matched %d", len(passing))})
+		}
+	}
+
+	if n.not != nil {
+		if subErrs, _ := v.validate(n.not, inst, field, depth+1); len(subErrs) == 0 {
+			errs = append(errs, FieldError{Field: field, Keyword: "not", Message: "must not match the disallowed schema"})
+		}
+	}
+
+	if n.ifs != nil {
+		ifErrs, ifEv := v.validate(n.ifs, inst, field, depth+1)
+		branch := n.els
+		if len(ifErrs) == 0 {
+			ev.merge(ifEv)
+			branch = n.then
+		}
+		if branch != nil {
+			branchErrs, branchEv := v.validate(branch, inst, field, depth+1)
+			errs = append(errs, branchErrs...)
+			if len(branchErrs) == 0 {
+				ev.merge(branchEv)
+			}
+		}
+	}
+
+	return errs
+}
+
+// validateString applies the string keywords
+func validateString(n *node, s string, field string) []FieldError {
+	var errs []FieldError
+	length := utf8.RuneCountInString(s)
+
+	if n.minLength != nil && length < *n.minLength {
+		errs = append(errs, FieldError{Field: field, Keyword: "minLength", Message: fmt.Sprintf("must be at least %d characters", *n.minLength)})
+	}
+	if n.maxLength != nil && length > *n.maxLength {
+		errs = append(errs, FieldError{Field: field, Keyword: "maxLength", Message: fmt.Sprintf("must be at most %d characters", *n.maxLength)})
+	}
+	if n.pattern != nil && !n.pattern.MatchString(s) {
+		errs = append(errs, FieldError{Field: field, Keyword: "pattern", Message: fmt.Sprintf("must match pattern %q", n.pattern.String())})
+	}
+	if n.format != "" {
+		if msg := checkFormat(n.format, s); msg != "" {
+			errs = append(errs, FieldError{Field: field, Keyword: "format", Message: msg})
+		}
+	}
+	return errs
+}
+
+// validateNumber applies the numeric keywords
+func validateNumber(n *node, f float64, field string) []FieldError {
+	var errs []FieldError
+	add := func(keyword, format string, args ...any) {
+		errs = append(errs, FieldError{Field: field, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if n.minimum != nil && f < *n.minimum {
+		add("minimum", "must be >= %s", formatNumber(*n.minimum))
+	}
+	if n.maximum != nil && f > *n.maximum {
+		add("maximum", "must be <= %s", formatNumber(*n.maximum))
+	}
+	if n.exclusiveMinimum != nil && f <= *n.exclusiveMinimum {
+		add("exclusiveMinimum", "must be > %s", formatNumber(*n.exclusiveMinimum))
+	}
+	if n.exclusiveMaximum != nil && f >= *n.exclusiveMaximum {
+		add("exclusiveMaximum", "must be < %s", formatNumber(*n.exclusiveMaximum))
+	}
+	if n.multipleOf != nil {
+		quotient := f / *n.multipleOf
+		if math.IsInf(quotient, 0) || math.Abs(quotient-math.Round(quotient)) > 1e-9 {
+			add("multipleOf", "must be a multiple of %s", formatNumber(*n.multipleOf))
+		}
+	}
+	return errs
+}
+
+// isFalse reports whether the node is the boolean schema false
+func (n *node) isFalse() bool {
+	return n.boolean != nil && !*n.boolean
+}
+
+// matchesType reports whether inst is of the named JSON type
+func matchesType(t string, inst any) bool {
+	switch t {
+	case "null":
+		return inst == nil
+	case "boolean":
+		_, ok := inst.(bool)
+		return ok
+	case "object":
+		_, ok := inst.(map[string]any)
+		return ok
+	case "array":
+		_, ok := inst.([]any)
+		return ok
+	case "string":
+		_, ok := inst.(string)
+		return ok
+	case "number":
+		_, ok := inst.(float64)
+		return ok
+	case "integer":
+		f, ok := inst.(float64)
+		return ok && f == math.Trunc(f) && !math.IsInf(f, 0)
+	}
+	return false
+}
+
+// equal compares two decoded JSON values
+func equal(a, b any) bool {
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for key, value := range av {
+			other, ok := bv[key]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		bv, ok := b.([]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !equal(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case nil:
+		return b == nil
+	default:
+		if af, ok := toFloat(a); ok {
+			bf, ok := toFloat(b)
+			return ok && af == bf
+		}
+		return a == b
+	}
+}
+
+// toFloat converts JSON and Go numeric values to float64
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// formatValue renders a value for error messages
+func formatValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+// formatNumber renders a number without a trailing ".0"
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// joinField appends a property name to a dotted field path
+func joinField(field, name string) string {
+	if field == "" {
+		return name
+	}
+	return field + "." + name
+}
+
+// indexField appends an array index to a field path
+func indexField(field string, i int) string {
+	return field + "[" + strconv.Itoa(i) + "]"
+}
+
+func sortedStringKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedNodeKeys(m map[string]*node) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
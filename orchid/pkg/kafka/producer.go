@@ -123,6 +123,9 @@ type APIResponseMessage struct {
 
 	// Extracted data (optional)
 	ExtractedData map[string]any `json:"extracted_data,omitempty"`
+
+	// Set when the message is re-published from the response archive
+	Replayed bool `json:"replayed,omitempty"`
 }
 
 // ExecutionEventMessage is a lifecycle event for a plan execution.
@@ -0,0 +1,65 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+)
+
+const (
+	// SinkKindResponse marks a message that would have gone to the response topic
+	SinkKindResponse = "response"
+	// SinkKindError marks a message that would have gone to the error topic
+	SinkKindError = "error"
+	// SinkKindEvent marks an execution lifecycle event
+	SinkKindEvent = "event"
+)
+
+// SinkMessage is a message captured by a MemorySink
+type SinkMessage struct {
+	Kind     string                 `json:"kind"`
+	Response *APIResponseMessage    `json:"response,omitempty"`
+	Event    *ExecutionEventMessage `json:"event,omitempty"`
+}
+
+// MemorySink collects messages in memory instead of producing them to Kafka.
+// Plan previews use it to show what an execution would have emitted.
+type MemorySink struct {
+	mu       sync.Mutex
+	messages []SinkMessage
+}
+
+// NewMemorySink creates an empty in-memory sink
+func NewMemorySink() *MemorySink {
+	return &MemorySink{}
+}
+
+// Publish captures a response message
+func (s *MemorySink) Publish(ctx context.Context, msg *APIResponseMessage) error {
+	s.add(SinkMessage{Kind: SinkKindResponse, Response: msg})
+	return nil
+}
+
+// PublishError captures an error-topic message
+func (s *MemorySink) PublishError(ctx context.Context, msg *APIResponseMessage) error {
+	s.add(SinkMessage{Kind: SinkKindError, Response: msg})
+	return nil
+}
+
+// PublishExecutionEvent captures a lifecycle event
+func (s *MemorySink) PublishExecutionEvent(ctx context.Context, evt *ExecutionEventMessage) error {
+	s.add(SinkMessage{Kind: SinkKindEvent, Event: evt})
+	return nil
+}
+
+// Messages returns the captured messages in publish order
+func (s *MemorySink) Messages() []SinkMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SinkMessage(nil), s.messages...)
+}
+
+func (s *MemorySink) add(msg SinkMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, msg)
+}
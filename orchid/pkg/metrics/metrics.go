@@ -2,6 +2,8 @@
 package metrics
 
 import (
+	"strconv"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -53,6 +55,49 @@ var (
 		[]string{"method"},
 	)
 
+	// HTTPPoolOpenConnections tracks open outbound connections by pool (shared, or dedicated to a config)
+	HTTPPoolOpenConnections = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "orchid",
+			Subsystem: "http_client",
+			Name:      "pool_open_connections",
+			Help:      "Number of open outbound connections by connection pool",
+		},
+		[]string{"pool"},
+	)
+
+	// HTTPPoolDials tracks new outbound connections by pool and status (success, failed)
+	HTTPPoolDials = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "orchid",
+			Subsystem: "http_client",
+			Name:      "pool_dials_total",
+			Help:      "Total number of outbound connections dialed by connection pool and status",
+		},
+		[]string{"pool", "status"},
+	)
+
+	// HTTPPoolConnectionsUsed tracks connections handed to outbound requests by pool and whether they were reused
+	HTTPPoolConnectionsUsed = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "orchid",
+			Subsystem: "http_client",
+			Name:      "pool_connections_used_total",
+			Help:      "Total number of connections used by outbound requests by connection pool and reuse",
+		},
+		[]string{"pool", "reused"},
+	)
+
+	// HTTPDedicatedTransports tracks the dedicated per-config transports currently cached
+	HTTPDedicatedTransports = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "orchid",
+			Subsystem: "http_client",
+			Name:      "dedicated_transports",
+			Help:      "Number of cached dedicated transports (configs with TLS or proxy settings)",
+		},
+	)
+
 	// QueueJobsProcessed tracks jobs processed from the queue
 	QueueJobsProcessed = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -74,6 +119,39 @@ var (
 		},
 	)
 
+	// QueueJobsDispatched tracks jobs handed to workers per lane
+	QueueJobsDispatched = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "orchid",
+			Subsystem: "queue",
+			Name:      "jobs_dispatched_total",
+			Help:      "Total number of jobs handed to workers per lane",
+		},
+		[]string{"lane"},
+	)
+
+	// QueueJobsRejected tracks jobs not published because the tenant was at its cap
+	QueueJobsRejected = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "orchid",
+			Subsystem: "queue",
+			Name:      "jobs_rejected_total",
+			Help:      "Total number of jobs rejected because the tenant had too many jobs in flight",
+		},
+		[]string{"tenant_id", "lane"},
+	)
+
+	// QueueJobsOverlapped tracks jobs that found their plan/config already running, by overlap policy
+	QueueJobsOverlapped = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "orchid",
+			Subsystem: "queue",
+			Name:      "jobs_overlapped_total",
+			Help:      "Total number of jobs that found an execution of their plan/config already running",
+		},
+		[]string{"policy"},
+	)
+
 	// DLQJobsTotal tracks jobs sent to the dead letter queue
 	DLQJobsTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -85,6 +163,17 @@ var (
 		[]string{"tenant_id", "reason"},
 	)
 
+	// DLQJobsRetried tracks DLQ entries re-enqueued, by reason and trigger (manual or auto)
+	DLQJobsRetried = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "orchid",
+			Subsystem: "dlq",
+			Name:      "jobs_retried_total",
+			Help:      "Total number of dead letter queue jobs re-enqueued",
+		},
+		[]string{"reason", "trigger"},
+	)
+
 	// SchedulerPlansScheduled tracks plans scheduled
 	SchedulerPlansScheduled = promauto.NewCounter(
 		prometheus.CounterOpts{
@@ -118,6 +207,17 @@ var (
 		[]string{"tenant_id", "limit_name"},
 	)
 
+	// RateLimitAdaptiveRate tracks the learned rate of adaptive rate limits
+	RateLimitAdaptiveRate = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "orchid",
+			Subsystem: "ratelimit",
+			Name:      "adaptive_requests",
+			Help:      "Requests per window learned by adaptive rate limits",
+		},
+		[]string{"tenant_id", "limit_name"},
+	)
+
 	// KafkaMessagesPublished tracks Kafka messages published
 	KafkaMessagesPublished = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -140,6 +240,39 @@ var (
 		},
 	)
 
+	// ArchiveSegmentsWritten tracks segments of archived responses written to the archive store
+	ArchiveSegmentsWritten = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "orchid",
+			Subsystem: "archive",
+			Name:      "segments_written_total",
+			Help:      "Total number of archived response segments written to the archive store",
+		},
+		[]string{"status"},
+	)
+
+	// NotificationsQueued tracks webhook deliveries queued for notification subscriptions, by event
+	NotificationsQueued = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "orchid",
+			Subsystem: "notifications",
+			Name:      "queued_total",
+			Help:      "Total number of notification webhook deliveries queued",
+		},
+		[]string{"event"},
+	)
+
+	// NotificationDeliveryAttempts tracks webhook delivery attempts, by event and result (delivered, retry or failed)
+	NotificationDeliveryAttempts = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "orchid",
+			Subsystem: "notifications",
+			Name:      "delivery_attempts_total",
+			Help:      "Total number of notification webhook delivery attempts",
+		},
+		[]string{"event", "result"},
+	)
+
 	// AuthTokenRefreshes tracks auth token refresh operations
 	AuthTokenRefreshes = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -151,6 +284,17 @@ var (
 		[]string{"tenant_id", "status"},
 	)
 
+	// AuthReauthentications tracks re-authentications triggered by reauth_on statuses
+	AuthReauthentications = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "orchid",
+			Subsystem: "auth",
+			Name:      "reauthentications_total",
+			Help:      "Total number of re-authentications triggered by rejected tokens",
+		},
+		[]string{"tenant_id", "status"},
+	)
+
 	// DatabaseQueryDuration tracks database query duration
 	DatabaseQueryDuration = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
@@ -188,19 +332,104 @@ func RecordHTTPRequest(method, statusCode string, durationSeconds float64) {
 	HTTPRequestDuration.WithLabelValues(method).Observe(durationSeconds)
 }
 
+// RecordHTTPPoolDial records a new outbound connection (success, failed)
+func RecordHTTPPoolDial(pool, status string) {
+	HTTPPoolDials.WithLabelValues(pool, status).Inc()
+}
+
+// RecordHTTPPoolConnectionOpened records an outbound connection opened by a pool
+func RecordHTTPPoolConnectionOpened(pool string) {
+	HTTPPoolOpenConnections.WithLabelValues(pool).Inc()
+}
+
+// RecordHTTPPoolConnectionClosed records an outbound connection of a pool being closed
+func RecordHTTPPoolConnectionClosed(pool string) {
+	HTTPPoolOpenConnections.WithLabelValues(pool).Dec()
+}
+
+// RecordHTTPPoolConnectionUsed records the connection an outbound request got, new or reused
+func RecordHTTPPoolConnectionUsed(pool string, reused bool) {
+	HTTPPoolConnectionsUsed.WithLabelValues(pool, strconv.FormatBool(reused)).Inc()
+}
+
+// RecordHTTPDedicatedTransports records the number of cached dedicated transports
+func RecordHTTPDedicatedTransports(count int) {
+	HTTPDedicatedTransports.Set(float64(count))
+}
+
+// RecordAuthTokenRefresh records how an auth token was obtained (refreshed, authenticated, refresh_failed, failed)
+func RecordAuthTokenRefresh(tenantID, status string) {
+	AuthTokenRefreshes.WithLabelValues(tenantID, status).Inc()
+}
+
+// RecordAuthReauthentication records a re-authentication triggered by a reauth_on status (success, reused, failed)
+func RecordAuthReauthentication(tenantID, status string) {
+	AuthReauthentications.WithLabelValues(tenantID, status).Inc()
+}
+
 // RecordQueueJob records a queue job processing metric
 func RecordQueueJob(status string) {
 	QueueJobsProcessed.WithLabelValues(status).Inc()
 }
 
+// RecordQueueDispatch records a job handed to a worker
+func RecordQueueDispatch(lane string) {
+	QueueJobsDispatched.WithLabelValues(lane).Inc()
+}
+
+// RecordQueueRejection records a job rejected by the tenant's cap
+func RecordQueueRejection(tenantID, lane string) {
+	QueueJobsRejected.WithLabelValues(tenantID, lane).Inc()
+}
+
+// RecordQueueOverlap records a job that found its plan/config already running
+func RecordQueueOverlap(policy string) {
+	QueueJobsOverlapped.WithLabelValues(policy).Inc()
+}
+
+// RecordRateLimitHit records a request held back by a rate limit
+func RecordRateLimitHit(tenantID, limitName string) {
+	RateLimitHits.WithLabelValues(tenantID, limitName).Inc()
+}
+
+// RecordRateLimitWait records the time a request waited for a rate limit
+func RecordRateLimitWait(tenantID, limitName string, durationSeconds float64) {
+	RateLimitWaitTime.WithLabelValues(tenantID, limitName).Observe(durationSeconds)
+}
+
+// RecordRateLimitAdaptiveRate records the learned rate of an adaptive rate limit
+func RecordRateLimitAdaptiveRate(tenantID, limitName string, requests float64) {
+	RateLimitAdaptiveRate.WithLabelValues(tenantID, limitName).Set(requests)
+}
+
 // RecordDLQJob records a dead letter queue job
 func RecordDLQJob(tenantID, reason string) {
 	DLQJobsTotal.WithLabelValues(tenantID, reason).Inc()
 }
 
+// RecordDLQRetry records a DLQ entry re-enqueued manually or by an automatic retry policy
+func RecordDLQRetry(reason, trigger string) {
+	DLQJobsRetried.WithLabelValues(reason, trigger).Inc()
+}
+
+// RecordNotificationQueued records a webhook delivery queued for a notification subscription
+func RecordNotificationQueued(event string) {
+	NotificationsQueued.WithLabelValues(event).Inc()
+}
+
+// RecordNotificationDelivery records a webhook delivery attempt
+func RecordNotificationDelivery(event, result string) {
+	NotificationDeliveryAttempts.WithLabelValues(event, result).Inc()
+}
+
 // RecordKafkaPublish records a Kafka publish operation
 func RecordKafkaPublish(topic, status string, durationSeconds float64) {
 	KafkaMessagesPublished.WithLabelValues(topic, status).Inc()
 	KafkaPublishDuration.Observe(durationSeconds)
 }
 
+// RecordArchiveSegment records a segment of archived responses written to the archive store
+func RecordArchiveSegment(status string) {
+	ArchiveSegmentsWritten.WithLabelValues(status).Inc()
+}
+
@@ -9,20 +9,23 @@ import (
 
 // AuthFlow defines an authentication flow for an integration
 type AuthFlow struct {
-	ID             uuid.UUID                      `db:"id" json:"id"`
-	TenantID       uuid.UUID                      `db:"tenant_id" json:"tenant_id"`
-	IntegrationID  uuid.UUID                      `db:"integration_id" json:"integration_id"`
-	Name           string                         `db:"name" json:"name"`
-	PlanDefinition database.JSONB[map[string]any] `db:"plan_definition" json:"plan_definition"`
-	TokenPath      string                         `db:"token_path" json:"token_path"`
-	HeaderName     string                         `db:"header_name" json:"header_name"`
-	HeaderFormat   *string                        `db:"header_format" json:"header_format,omitempty"`
-	RefreshPath    *string                        `db:"refresh_path" json:"refresh_path,omitempty"`
-	ExpiresInPath  *string                        `db:"expires_in_path" json:"expires_in_path,omitempty"`
-	TTLSeconds     *int                           `db:"ttl_seconds" json:"ttl_seconds,omitempty"`
-	SkewSeconds    *int                           `db:"skew_seconds" json:"skew_seconds,omitempty"`
-	CreatedAt      time.Time                      `db:"created_at" json:"created_at"`
-	UpdatedAt      time.Time                      `db:"updated_at" json:"updated_at"`
+	ID                uuid.UUID                      `db:"id" json:"id"`
+	TenantID          uuid.UUID                      `db:"tenant_id" json:"tenant_id"`
+	IntegrationID     uuid.UUID                      `db:"integration_id" json:"integration_id"`
+	Name              string                         `db:"name" json:"name"`
+	PlanDefinition    database.JSONB[map[string]any] `db:"plan_definition" json:"plan_definition"`
+	RefreshDefinition database.JSONB[map[string]any] `db:"refresh_definition" json:"refresh_definition"` // Optional step that exchanges auth.refresh_token
+	TokenPath         string                         `db:"token_path" json:"token_path"`
+	HeaderName        string                         `db:"header_name" json:"header_name"`
+	HeaderFormat      *string                        `db:"header_format" json:"header_format,omitempty"`
+	RefreshPath       *string                        `db:"refresh_path" json:"refresh_path,omitempty"`
+	ExpiresInPath     *string                        `db:"expires_in_path" json:"expires_in_path,omitempty"`
+	TTLSeconds        *int                           `db:"ttl_seconds" json:"ttl_seconds,omitempty"`
+	SkewSeconds       *int                           `db:"skew_seconds" json:"skew_seconds,omitempty"`
+	ReauthOn          database.JSONB[[]int]          `db:"reauth_on" json:"reauth_on"` // Statuses that invalidate the token and re-run the flow
+	Signer            database.JSONB[*SignerConfig]  `db:"signer" json:"signer"`       // Optional per-request signature (AWS SigV4, HMAC or JWT)
+	CreatedAt         time.Time                      `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time                      `db:"updated_at" json:"updated_at"`
 }
 
 // TableName returns the database table name
@@ -30,3 +33,61 @@ func (AuthFlow) TableName() string {
 	return "auth_flows"
 }
 
+// Signer types
+const (
+	SignerAWSSigV4 = "aws_sigv4" // AWS Signature Version 4
+	SignerHMAC     = "hmac"      // HMAC of a templated string to sign, sent in templated headers
+	SignerJWT      = "jwt"       // Signed JWT, e.g. an OAuth private_key_jwt client assertion or a service account assertion
+)
+
+// Requests a signer applies to
+const (
+	SignerApplyToRequests     = "requests"      // Every request of the plans using the auth flow
+	SignerApplyToTokenRequest = "token_request" // The auth flow's own token and refresh requests
+)
+
+// SignerConfig signs requests with credentials from the config. String fields are templates resolved
+// against the config, e.g. "{{ config.secret_key }}".
+type SignerConfig struct {
+	Type    string `json:"type"`               // aws_sigv4, hmac or jwt
+	ApplyTo string `json:"apply_to,omitempty"` // requests (default) or token_request
+
+	// aws_sigv4
+	Region       string `json:"region,omitempty"`
+	Service      string `json:"service,omitempty"` // e.g. "execute-api", "s3", "es"
+	AccessKey    string `json:"access_key,omitempty"`
+	SecretKey    string `json:"secret_key,omitempty"`
+	SessionToken string `json:"session_token,omitempty"` // Temporary credentials only
+
+	// hmac (and the key of HS256 JWTs)
+	Secret       string            `json:"secret,omitempty"`
+	Algorithm    string            `json:"algorithm,omitempty"`      // hmac: sha256 (default), sha1 or sha512. jwt: RS256 (default), ES256 or HS256
+	Encoding     string            `json:"encoding,omitempty"`       // hex (default) or base64
+	StringToSign string            `json:"string_to_sign,omitempty"` // Template over request.* (method, path, query, body, body_sha256, timestamp, nonce, ...)
+	Headers      map[string]string `json:"headers,omitempty"`        // Headers to set, templates that can also use signature
+
+	// jwt
+	PrivateKey   string         `json:"private_key,omitempty"` // PEM encoded RSA or EC private key
+	KeyID        string         `json:"key_id,omitempty"`      // kid header
+	Issuer       string         `json:"issuer,omitempty"`
+	Subject      string         `json:"subject,omitempty"`
+	Audience     string         `json:"audience,omitempty"`
+	Scope        string         `json:"scope,omitempty"`
+	ExpiresIn    int            `json:"expires_in,omitempty"`    // Lifetime in seconds (default 300)
+	Claims       map[string]any `json:"claims,omitempty"`        // Additional claims
+	Header       string         `json:"header,omitempty"`        // Header carrying the JWT (default Authorization)
+	HeaderFormat string         `json:"header_format,omitempty"` // Header value, with {token} replaced by the JWT (default "Bearer {token}")
+	FormField    string         `json:"form_field,omitempty"`    // Form field carrying the JWT instead of a header, e.g. "client_assertion" or "assertion"
+}
+
+// AppliesTo reports whether the signer signs the given kind of request
+func (c *SignerConfig) AppliesTo(kind string) bool {
+	if c == nil {
+		return false
+	}
+	applyTo := c.ApplyTo
+	if applyTo == "" {
+		applyTo = SignerApplyToRequests
+	}
+	return applyTo == kind
+}
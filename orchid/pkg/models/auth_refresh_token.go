@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Ramsey-B/stem/pkg/database"
+)
+
+// AuthRefreshToken holds the latest refresh token issued for an auth flow/config combination.
+// The token is stored as an encrypted envelope (see secrets.Cipher); tokens persisted before
+// encryption was introduced are plain JSON strings until they are next used.
+type AuthRefreshToken struct {
+	TenantID     uuid.UUID           `db:"tenant_id" json:"tenant_id"`
+	AuthFlowID   uuid.UUID           `db:"auth_flow_id" json:"auth_flow_id"`
+	ConfigID     uuid.UUID           `db:"config_id" json:"config_id"`
+	RefreshToken database.JSONB[any] `db:"refresh_token" json:"-"`
+	CreatedAt    time.Time           `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time           `db:"updated_at" json:"updated_at"`
+}
+
+// TableName returns the database table name
+func (AuthRefreshToken) TableName() string {
+	return "auth_refresh_tokens"
+}
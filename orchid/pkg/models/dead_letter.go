@@ -20,6 +20,16 @@ const (
 	DLQReasonUnknown      DeadLetterReason = "unknown"
 )
 
+// Valid reports whether r is a known reason
+func (r DeadLetterReason) Valid() bool {
+	switch r {
+	case DLQReasonMaxRetries, DLQReasonInvalidJob, DLQReasonPlanNotFound, DLQReasonConfigError,
+		DLQReasonAuthError, DLQReasonTimeout, DLQReasonPanic, DLQReasonUnknown:
+		return true
+	}
+	return false
+}
+
 // DeadLetterJob represents a job that failed and was moved to the dead letter queue
 type DeadLetterJob struct {
 	ID           uuid.UUID        `json:"id" db:"id"`
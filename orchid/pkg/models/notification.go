@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Ramsey-B/stem/pkg/database"
+)
+
+// NotificationEvent is an execution outcome a subscription is notified of
+type NotificationEvent string
+
+const (
+	// NotificationEventExecutionFailed fires when a plan/config failed threshold times in a row
+	NotificationEventExecutionFailed NotificationEvent = "execution.failed"
+	// NotificationEventAuthError fires when the auth flow of a plan/config failed threshold times in a row
+	NotificationEventAuthError NotificationEvent = "execution.auth_error"
+	// NotificationEventExecutionRecovered fires when a plan/config succeeds after a notified failure streak
+	NotificationEventExecutionRecovered NotificationEvent = "execution.recovered"
+)
+
+// Valid reports whether e is a known event
+func (e NotificationEvent) Valid() bool {
+	switch e {
+	case NotificationEventExecutionFailed, NotificationEventAuthError, NotificationEventExecutionRecovered:
+		return true
+	}
+	return false
+}
+
+// NotificationSubscription sends an execution outcome of a tenant's plans to a webhook
+type NotificationSubscription struct {
+	ID        uuid.UUID                      `db:"id" json:"id"`
+	TenantID  uuid.UUID                      `db:"tenant_id" json:"tenant_id"`
+	Name      string                         `db:"name" json:"name"`
+	Event     NotificationEvent              `db:"event" json:"event"`
+	PlanKey   *string                        `db:"plan_key" json:"plan_key,omitempty"`   // Only executions of this plan (any plan when unset)
+	ConfigID  *uuid.UUID                     `db:"config_id" json:"config_id,omitempty"` // Only executions with this config (any config when unset)
+	Threshold int                            `db:"threshold" json:"threshold"`           // Failures in a row that fire the subscription
+	URL       string                         `db:"url" json:"url"`
+	Secret    database.JSONB[map[string]any] `db:"secret" json:"-"` // Envelope-encrypted signing secret
+	Enabled   bool                           `db:"enabled" json:"enabled"`
+	CreatedAt time.Time                      `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time                      `db:"updated_at" json:"updated_at"`
+}
+
+// TableName returns the database table name
+func (NotificationSubscription) TableName() string {
+	return "notification_subscriptions"
+}
+
+// NotificationDeliveryStatus represents the state of a webhook delivery
+type NotificationDeliveryStatus string
+
+const (
+	NotificationDeliveryPending   NotificationDeliveryStatus = "pending"   // Waiting for its next attempt
+	NotificationDeliveryDelivered NotificationDeliveryStatus = "delivered" // The webhook answered with a 2xx status
+	NotificationDeliveryFailed    NotificationDeliveryStatus = "failed"    // Every attempt failed
+)
+
+// NotificationDelivery is an event sent, or to be sent, to a subscription's webhook
+type NotificationDelivery struct {
+	ID             uuid.UUID                      `db:"id" json:"id"`
+	TenantID       uuid.UUID                      `db:"tenant_id" json:"tenant_id"`
+	SubscriptionID uuid.UUID                      `db:"subscription_id" json:"subscription_id"`
+	Event          NotificationEvent              `db:"event" json:"event"`
+	ExecutionID    *uuid.UUID                     `db:"execution_id" json:"execution_id,omitempty"`
+	Payload        database.JSONB[map[string]any] `db:"payload" json:"payload"`
+	Status         NotificationDeliveryStatus     `db:"status" json:"status"`
+	Attempts       int                            `db:"attempts" json:"attempts"`
+	ResponseStatus *int                           `db:"response_status" json:"response_status,omitempty"` // Status of the last attempt
+	ErrorMessage   *string                        `db:"error_message" json:"error_message,omitempty"`     // Error of the last failed attempt
+	NextAttemptAt  time.Time                      `db:"next_attempt_at" json:"next_attempt_at"`
+	DeliveredAt    *time.Time                     `db:"delivered_at" json:"delivered_at,omitempty"`
+	CreatedAt      time.Time                      `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time                      `db:"updated_at" json:"updated_at"`
+}
+
+// TableName returns the database table name
+func (NotificationDelivery) TableName() string {
+	return "notification_deliveries"
+}
@@ -9,21 +9,58 @@ import (
 
 // Plan defines an API polling plan
 type Plan struct {
-	Key            string                         `db:"key" json:"key"`
-	TenantID       uuid.UUID                      `db:"tenant_id" json:"tenant_id"`
-	IntegrationID  uuid.UUID                      `db:"integration_id" json:"integration_id"`
-	Integration    string                         `db:"integration" json:"integration"`
-	Name           string                         `db:"name" json:"name"`
-	Description    *string                        `db:"description" json:"description,omitempty"`
-	PlanDefinition database.JSONB[map[string]any] `db:"plan_definition" json:"plan_definition"`
-	Enabled        bool                           `db:"enabled" json:"enabled"`
-	WaitSeconds    *int                           `db:"wait_seconds" json:"wait_seconds,omitempty"`
-	RepeatCount    *int                           `db:"repeat_count" json:"repeat_count,omitempty"`
-	CreatedAt      time.Time                      `db:"created_at" json:"created_at"`
-	UpdatedAt      time.Time                      `db:"updated_at" json:"updated_at"`
+	Key              string                           `db:"key" json:"key"`
+	TenantID         uuid.UUID                        `db:"tenant_id" json:"tenant_id"`
+	IntegrationID    uuid.UUID                        `db:"integration_id" json:"integration_id"`
+	Integration      string                           `db:"integration" json:"integration"`
+	Name             string                           `db:"name" json:"name"`
+	Description      *string                          `db:"description" json:"description,omitempty"`
+	PlanDefinition   database.JSONB[map[string]any]   `db:"plan_definition" json:"plan_definition"`
+	Enabled          bool                             `db:"enabled" json:"enabled"`
+	WaitSeconds      *int                             `db:"wait_seconds" json:"wait_seconds,omitempty"`
+	RepeatCount      *int                             `db:"repeat_count" json:"repeat_count,omitempty"`
+	ScheduleCron     *string                          `db:"schedule_cron" json:"schedule_cron,omitempty"`
+	ScheduleTimezone *string                          `db:"schedule_timezone" json:"schedule_timezone,omitempty"`
+	BlackoutWindows  database.JSONB[[]BlackoutWindow] `db:"blackout_windows" json:"blackout_windows"`
+	OverlapPolicy    OverlapPolicy                    `db:"overlap_policy" json:"overlap_policy"`
+	CreatedAt        time.Time                        `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time                        `db:"updated_at" json:"updated_at"`
+}
+
+// BlackoutWindow is a recurring time-of-day window during which a plan is not scheduled.
+// When End is earlier than Start the window wraps past midnight and Days refers to the day it starts.
+type BlackoutWindow struct {
+	Days     []string `json:"days,omitempty"`     // mon, tue, ... sun; empty means every day
+	Start    string   `json:"start"`              // HH:MM (inclusive)
+	End      string   `json:"end"`                // HH:MM (exclusive)
+	Timezone string   `json:"timezone,omitempty"` // IANA name; defaults to the plan's schedule_timezone
 }
 
 // TableName returns the database table name
 func (Plan) TableName() string {
 	return "plans"
 }
+
+// OverlapPolicy decides what happens to a job of a plan/config that already has an execution running
+type OverlapPolicy string
+
+const (
+	// OverlapPolicySkip drops the new job
+	OverlapPolicySkip OverlapPolicy = "skip"
+	// OverlapPolicyQueue holds the new job until the running execution ends
+	OverlapPolicyQueue OverlapPolicy = "queue"
+	// OverlapPolicyCancelPrevious cancels the running execution and starts the new job once it has stopped
+	OverlapPolicyCancelPrevious OverlapPolicy = "cancel_previous"
+
+	// DefaultOverlapPolicy is the overlap policy of plans that don't set one
+	DefaultOverlapPolicy = OverlapPolicyQueue
+)
+
+// Valid returns whether the policy is known
+func (p OverlapPolicy) Valid() bool {
+	switch p {
+	case OverlapPolicySkip, OverlapPolicyQueue, OverlapPolicyCancelPrevious:
+		return true
+	}
+	return false
+}
@@ -4,17 +4,20 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/Ramsey-B/stem/pkg/database"
 )
 
 // ExecutionStatus represents the status of a plan execution
 type ExecutionStatus string
 
 const (
-	ExecutionStatusPending ExecutionStatus = "pending"
-	ExecutionStatusRunning ExecutionStatus = "running"
-	ExecutionStatusSuccess ExecutionStatus = "success"
-	ExecutionStatusFailed  ExecutionStatus = "failed"
-	ExecutionStatusAborted ExecutionStatus = "aborted"
+	ExecutionStatusPending   ExecutionStatus = "pending"
+	ExecutionStatusRunning   ExecutionStatus = "running"
+	ExecutionStatusSuccess   ExecutionStatus = "success"
+	ExecutionStatusFailed    ExecutionStatus = "failed"
+	ExecutionStatusAborted   ExecutionStatus = "aborted"
+	ExecutionStatusCancelled ExecutionStatus = "cancelled"
 )
 
 // ErrorType represents the type of error in a failed execution
@@ -24,31 +27,83 @@ const (
 	ErrorTypeTransient ErrorType = "transient"
 	ErrorTypePermanent ErrorType = "permanent"
 	ErrorTypeRateLimit ErrorType = "rate_limit"
+	ErrorTypeAuth      ErrorType = "auth" // The auth flow of the plan failed
 )
 
 // PlanExecution tracks an individual plan/step execution
 type PlanExecution struct {
-	ID                 uuid.UUID       `db:"id" json:"id"`
-	TenantID           uuid.UUID       `db:"tenant_id" json:"tenant_id"`
-	PlanKey            string          `db:"plan_key" json:"plan_key"`
-	ConfigID           uuid.UUID       `db:"config_id" json:"config_id"`
-	ParentExecutionID  *uuid.UUID      `db:"parent_execution_id" json:"parent_execution_id,omitempty"`
-	Status             ExecutionStatus `db:"status" json:"status"`
-	StepPath           *string         `db:"step_path" json:"step_path,omitempty"`
-	StartedAt          *time.Time      `db:"started_at" json:"started_at,omitempty"`
-	CompletedAt        *time.Time      `db:"completed_at" json:"completed_at,omitempty"`
-	ErrorMessage       *string         `db:"error_message" json:"error_message,omitempty"`
-	ErrorType          *ErrorType      `db:"error_type" json:"error_type,omitempty"`
-	RetryCount         int             `db:"retry_count" json:"retry_count"`
-	RequestURL         *string         `db:"request_url" json:"request_url,omitempty"`
-	RequestMethod      *string         `db:"request_method" json:"request_method,omitempty"`
-	ResponseStatusCode *int            `db:"response_status_code" json:"response_status_code,omitempty"`
-	ResponseSizeBytes  *int64          `db:"response_size_bytes" json:"response_size_bytes,omitempty"`
-	CreatedAt          time.Time       `db:"created_at" json:"created_at"`
-	UpdatedAt          time.Time       `db:"updated_at" json:"updated_at"`
+	ID                 uuid.UUID                           `db:"id" json:"id"`
+	TenantID           uuid.UUID                           `db:"tenant_id" json:"tenant_id"`
+	PlanKey            string                              `db:"plan_key" json:"plan_key"`
+	ConfigID           uuid.UUID                           `db:"config_id" json:"config_id"`
+	ParentExecutionID  *uuid.UUID                          `db:"parent_execution_id" json:"parent_execution_id,omitempty"`
+	Resume             bool                                `db:"resume" json:"resume,omitempty"`         // Continues from the checkpoint of ParentExecutionID
+	ResumedAt          *time.Time                          `db:"resumed_at" json:"resumed_at,omitempty"` // When a resume of this execution was queued
+	Status             ExecutionStatus                     `db:"status" json:"status"`
+	StepPath           *string                             `db:"step_path" json:"step_path,omitempty"`
+	StartedAt          *time.Time                          `db:"started_at" json:"started_at,omitempty"`
+	CompletedAt        *time.Time                          `db:"completed_at" json:"completed_at,omitempty"`
+	ErrorMessage       *string                             `db:"error_message" json:"error_message,omitempty"`
+	ErrorType          *ErrorType                          `db:"error_type" json:"error_type,omitempty"`
+	RetryCount         int                                 `db:"retry_count" json:"retry_count"`
+	RequestURL         *string                             `db:"request_url" json:"request_url,omitempty"`
+	RequestMethod      *string                             `db:"request_method" json:"request_method,omitempty"`
+	ResponseStatusCode *int                                `db:"response_status_code" json:"response_status_code,omitempty"`
+	ResponseSizeBytes  *int64                              `db:"response_size_bytes" json:"response_size_bytes,omitempty"`
+	Checkpoint         database.JSONB[ExecutionCheckpoint] `db:"checkpoint" json:"checkpoint"` // Loop state saved after every page
+	CreatedAt          time.Time                           `db:"created_at" json:"created_at"`
+	UpdatedAt          time.Time                           `db:"updated_at" json:"updated_at"`
+}
+
+// Active returns whether the execution has not completed yet
+func (e *PlanExecution) Active() bool {
+	return e.Status == ExecutionStatusPending || e.Status == ExecutionStatusRunning
+}
+
+// Resumable reports whether the execution stopped early and left a checkpoint to resume from
+func (e *PlanExecution) Resumable() bool {
+	if e.Status != ExecutionStatusFailed && e.Status != ExecutionStatusAborted {
+		return false
+	}
+	return !e.Checkpoint.Data.IsZero()
 }
 
 // TableName returns the database table name
 func (PlanExecution) TableName() string {
 	return "plan_executions"
 }
+
+// ExecutionCheckpoint is the loop state of an execution, saved after every page.
+// A resumed execution restores it and continues with the next page.
+type ExecutionCheckpoint struct {
+	LoopCount  int                 `json:"loop_count"`           // Pages (loop iterations) completed
+	Prev       *CheckpointResponse `json:"prev,omitempty"`       // Response of the last completed page, exposed as prev
+	Context    map[string]any      `json:"context,omitempty"`    // Plan context after the last completed page
+	Fanout     *FanoutCheckpoint   `json:"fanout,omitempty"`     // Fanout progress of the page in flight
+	Pagination map[string]any      `json:"pagination,omitempty"` // Position of the next page, without secrets
+	Watermark  string              `json:"watermark,omitempty"`  // Highest watermark seen by completed pages
+}
+
+// IsZero reports whether the checkpoint holds no progress
+func (c ExecutionCheckpoint) IsZero() bool {
+	return c.LoopCount == 0 && c.Fanout == nil
+}
+
+// CheckpointResponse is a checkpointed page response
+type CheckpointResponse struct {
+	StatusCode int               `json:"status_code"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       any               `json:"body,omitempty"`
+}
+
+// FanoutCheckpoint records the fanout items of a page that already completed
+type FanoutCheckpoint struct {
+	Total int                          `json:"total"` // Items the page fanned out over
+	Items map[int]FanoutItemCheckpoint `json:"items"` // Completed items by index
+}
+
+// FanoutItemCheckpoint is a completed fanout item
+type FanoutItemCheckpoint struct {
+	Payload     map[string]any `json:"payload"`                // Enriched item emitted with the page
+	PolicyError bool           `json:"policy_error,omitempty"` // A sub-step matched ignore_on
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Ramsey-B/stem/pkg/database"
+)
+
+// PlanPaginationCheckpoint is the position of a paginated step of a plan/config combination,
+// stored while pages remain so the next execution resumes from the next page
+type PlanPaginationCheckpoint struct {
+	TenantID    uuid.UUID                      `db:"tenant_id" json:"tenant_id"`
+	PlanKey     string                         `db:"plan_key" json:"plan_key"`
+	ConfigID    uuid.UUID                      `db:"config_id" json:"config_id"`
+	StepKey     string                         `db:"step_key" json:"step_key"`
+	State       database.JSONB[map[string]any] `db:"state" json:"state"`
+	ExecutionID *uuid.UUID                     `db:"execution_id" json:"execution_id,omitempty"` // Execution that stored the position
+	CreatedAt   time.Time                      `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time                      `db:"updated_at" json:"updated_at"`
+}
+
+// TableName returns the database table name
+func (PlanPaginationCheckpoint) TableName() string {
+	return "plan_pagination_checkpoints"
+}
@@ -8,23 +8,33 @@ import (
 
 // PlanStatistics holds aggregated statistics for a plan/config combination
 type PlanStatistics struct {
-	ID                     uuid.UUID  `db:"id" json:"id"`
-	TenantID               uuid.UUID  `db:"tenant_id" json:"tenant_id"`
-	PlanKey                string     `db:"plan_key" json:"plan_key"`
-	ConfigID               uuid.UUID  `db:"config_id" json:"config_id"`
-	LastExecutionAt        *time.Time `db:"last_execution_at" json:"last_execution_at,omitempty"`
-	LastSuccessAt          *time.Time `db:"last_success_at" json:"last_success_at,omitempty"`
-	LastFailureAt          *time.Time `db:"last_failure_at" json:"last_failure_at,omitempty"`
-	TotalExecutions        int64      `db:"total_executions" json:"total_executions"`
-	TotalSuccesses         int64      `db:"total_successes" json:"total_successes"`
-	TotalFailures          int64      `db:"total_failures" json:"total_failures"`
-	TotalAPICalls          int64      `db:"total_api_calls" json:"total_api_calls"`
-	AverageExecutionTimeMs *int       `db:"average_execution_time_ms" json:"average_execution_time_ms,omitempty"`
-	CreatedAt              time.Time  `db:"created_at" json:"created_at"`
-	UpdatedAt              time.Time  `db:"updated_at" json:"updated_at"`
+	ID                      uuid.UUID  `db:"id" json:"id"`
+	TenantID                uuid.UUID  `db:"tenant_id" json:"tenant_id"`
+	PlanKey                 string     `db:"plan_key" json:"plan_key"`
+	ConfigID                uuid.UUID  `db:"config_id" json:"config_id"`
+	LastExecutionAt         *time.Time `db:"last_execution_at" json:"last_execution_at,omitempty"`
+	LastSuccessAt           *time.Time `db:"last_success_at" json:"last_success_at,omitempty"`
+	LastFailureAt           *time.Time `db:"last_failure_at" json:"last_failure_at,omitempty"`
+	TotalExecutions         int64      `db:"total_executions" json:"total_executions"`
+	TotalSuccesses          int64      `db:"total_successes" json:"total_successes"`
+	TotalFailures           int64      `db:"total_failures" json:"total_failures"`
+	TotalAPICalls           int64      `db:"total_api_calls" json:"total_api_calls"`
+	ConsecutiveFailures     int        `db:"consecutive_failures" json:"consecutive_failures"`           // Failed executions in a row, reset by a success
+	ConsecutiveAuthFailures int        `db:"consecutive_auth_failures" json:"consecutive_auth_failures"` // Auth failures in a row, reset by a success or another failure
+	LastStreak              int        `db:"last_streak" json:"-"`                                       // Failures in a row before the last recorded execution
+	AverageExecutionTimeMs  *int       `db:"average_execution_time_ms" json:"average_execution_time_ms,omitempty"`
+	NextRunAt               *time.Time `db:"next_run_at" json:"next_run_at,omitempty"`
+	CreatedAt               time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt               time.Time  `db:"updated_at" json:"updated_at"`
 }
 
 // TableName returns the database table name
 func (PlanStatistics) TableName() string {
 	return "plan_statistics"
 }
+
+// FailureStreak is the failure streak of a plan/config as an execution was recorded
+type FailureStreak struct {
+	PreviousFailures int // Failed executions in a row before this one
+	AuthFailures     int // Auth failures in a row, including this one
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PlanWatermark is the committed high-water mark of an incremental sync for a plan/config combination
+type PlanWatermark struct {
+	TenantID      uuid.UUID  `db:"tenant_id" json:"tenant_id"`
+	PlanKey       string     `db:"plan_key" json:"plan_key"`
+	ConfigID      uuid.UUID  `db:"config_id" json:"config_id"`
+	Value         string     `db:"value" json:"value"`
+	PreviousValue *string    `db:"previous_value" json:"previous_value,omitempty"` // Value before the last change
+	ExecutionID   *uuid.UUID `db:"execution_id" json:"execution_id,omitempty"`     // Execution that committed the value (nil when set through the API)
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt     time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// TableName returns the database table name
+func (PlanWatermark) TableName() string {
+	return "plan_watermarks"
+}
@@ -2,9 +2,13 @@ package models
 
 // Step defines a single step in a plan execution
 type Step struct {
-	// Optional ID for addressing sub-steps in fanout enrichment / debugging
+	// Optional ID for addressing sub-steps in fanout enrichment / debugging.
+	// Required for the steps of a DAG plan, whose outputs are addressable as steps.<id>.
 	ID string `json:"id,omitempty"`
 
+	// IDs of the top-level steps that must complete before this one starts (DAG plans only)
+	DependsOn []string `json:"depends_on,omitempty"`
+
 	// EmitToKafka controls whether Orchid emits the main step response to Kafka.
 	// Defaults to true. For fanout-heavy plans, setting this to false can reduce noise,
 	// letting you focus on the per-item fanout messages (root.fanout[*]).
@@ -22,6 +26,9 @@ type Step struct {
 	Params  map[string]string `json:"params,omitempty"`  // Query parameters (static and templated)
 	Body    any               `json:"body,omitempty"`    // Request body (static or templated)
 
+	// BodyType selects how Body is encoded: json (default), form, multipart, raw or graphql
+	BodyType string `json:"body_type,omitempty"`
+
 	// Timeout configuration
 	TimeoutSeconds int `json:"timeout_seconds,omitempty"` // Request timeout. Defaults to 30
 
@@ -31,8 +38,11 @@ type Step struct {
 	// Status code policy
 	// - AbortOn: if status is in this list, publish to error topic and abort the plan.
 	// - IgnoreOn: if status is in this list, publish to error topic and continue (do not send to Lotus success topic).
+	// - ReauthOn: if status is in this list, invalidate the cached token, re-run the auth flow once and retry.
+	//   Overrides the auth flow's reauth_on.
 	AbortOn  []int `json:"abort_on,omitempty"`
 	IgnoreOn []int `json:"ignore_on,omitempty"`
+	ReauthOn []int `json:"reauth_on,omitempty"`
 
 	// Conditions (JMESPath expressions that evaluate to bool)
 	While     string `json:"while,omitempty"`      // Continue looping while true
@@ -44,6 +54,17 @@ type Step struct {
 	// Context management (JMESPath expressions to extract and store values)
 	SetContext map[string]string `json:"set_context,omitempty"` // key -> JMESPath expression to store in context
 
+	// Declarative pagination: the step is repeated for each page until the API reports no more pages
+	Pagination *Pagination `json:"pagination,omitempty"`
+
+	// Parse overrides how the response body is parsed (format, CSV options, encoding, compression).
+	// By default the format is detected from the Content-Type, file name and compression of the response.
+	Parse *ParseConfig `json:"parse,omitempty"`
+
+	// Stream decodes a successful response body record by record instead of buffering it,
+	// emitting each batch of records as it is read (bulk export endpoints)
+	Stream *Stream `json:"stream,omitempty"`
+
 	// Sub-steps for fanout
 	IterateOver string `json:"iterate_over,omitempty"` // JMESPath expression returning array to iterate
 	SubSteps    []Step `json:"sub_steps,omitempty"`    // Steps to execute for each item
@@ -53,6 +74,15 @@ type Step struct {
 	AuthFlowID string `json:"auth_flow_id,omitempty"` // Auth flow to use for this step
 }
 
+// Request body types
+const (
+	BodyTypeJSON      = "json"      // Body is JSON-encoded (default)
+	BodyTypeForm      = "form"      // application/x-www-form-urlencoded
+	BodyTypeMultipart = "multipart" // multipart/form-data; objects with a filename are file parts
+	BodyTypeRaw       = "raw"       // A string body sent as-is, with the Content-Type of the headers
+	BodyTypeGraphQL   = "graphql"   // A query and its variables; an errors array in the response fails the step
+)
+
 // RetryConfig defines retry behavior for a step
 type RetryConfig struct {
 	MaxRetries   int    `json:"max_retries,omitempty"`   // Maximum retry attempts. Defaults to 3
@@ -61,6 +91,123 @@ type RetryConfig struct {
 	MaxDelay     int    `json:"max_delay,omitempty"`     // Maximum delay in milliseconds. Defaults to 60000
 }
 
+// Pagination strategies
+const (
+	PaginationCursor     = "cursor"      // Cursor from the response body sent back as a query param
+	PaginationOffset     = "offset"      // offset/limit query params
+	PaginationPage       = "page"        // Page number query param
+	PaginationLinkHeader = "link_header" // RFC 5988 Link header with rel="next"
+	PaginationNextURL    = "next_url"    // Next page URL in the response body
+)
+
+// DefaultMaxPages is the default max_pages guard for paginated steps
+const DefaultMaxPages = 100
+
+// Pagination configures how a step fetches successive pages.
+// Pagination stops when the strategy finds no next page, when items_path yields an empty
+// (or, with page_size, a short) page, when has_more_path is false or when max_pages is reached.
+type Pagination struct {
+	Type string `json:"type"` // "cursor", "offset", "page", "link_header", "next_url"
+
+	// cursor
+	CursorParam string `json:"cursor_param,omitempty"` // Query param carrying the cursor (required for cursor)
+	CursorPath  string `json:"cursor_path,omitempty"`  // JMESPath to the next cursor, e.g. "response.body.next_cursor" (required for cursor)
+
+	// CursorVariable sends the cursor as a GraphQL variable instead of a query param (graphql body_type),
+	// e.g. "after" with cursor_path "response.body.data.users.pageInfo.endCursor"
+	CursorVariable string `json:"cursor_variable,omitempty"`
+
+	// offset
+	OffsetParam string `json:"offset_param,omitempty"` // Defaults to "offset"
+	LimitParam  string `json:"limit_param,omitempty"`  // Defaults to "limit"
+
+	// page
+	PageParam string `json:"page_param,omitempty"` // Defaults to "page"
+	StartPage *int   `json:"start_page,omitempty"` // Defaults to 1
+	SizeParam string `json:"size_param,omitempty"` // Optional query param carrying page_size
+
+	// next_url
+	NextURLPath string `json:"next_url_path,omitempty"` // JMESPath to the next page URL (required for next_url)
+
+	// Shared
+	PageSize    int    `json:"page_size,omitempty"`     // Items per page (sent as limit/size, and a shorter page ends pagination)
+	ItemsPath   string `json:"items_path,omitempty"`    // JMESPath to the page's items (required for offset without page_size)
+	HasMorePath string `json:"has_more_path,omitempty"` // JMESPath to a boolean "more pages" flag
+	TotalPath   string `json:"total_path,omitempty"`    // JMESPath to the total item count (offset and page)
+	MaxPages    int    `json:"max_pages,omitempty"`     // Pages fetched per execution. Defaults to 100
+}
+
+// Response body formats
+const (
+	ParseFormatJSON   = "json"
+	ParseFormatNDJSON = "ndjson" // Newline-delimited JSON, parsed into an array
+	ParseFormatXML    = "xml"
+	ParseFormatCSV    = "csv"    // Parsed into an array of objects keyed by column
+	ParseFormatTSV    = "tsv"    // Tab-separated CSV
+	ParseFormatText   = "text"   // Kept as a string
+	ParseFormatBinary = "binary" // Base64-encoded
+)
+
+// Response body compressions
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+	CompressionZip  = "zip" // Every matching file of the archive is parsed and their records concatenated
+)
+
+// ParseConfig configures how a response body is parsed. Unset fields are detected from the response.
+type ParseConfig struct {
+	Format      string   `json:"format,omitempty"`      // json, ndjson, xml, csv, tsv, text or binary
+	Compression string   `json:"compression,omitempty"` // none, gzip or zip (detected from Content-Type, file name or magic bytes)
+	ZipEntry    string   `json:"zip_entry,omitempty"`   // Glob of the zip entries to parse, e.g. "*.csv" (default: every file)
+	Encoding    string   `json:"encoding,omitempty"`    // Character encoding, e.g. "utf-16le", "iso-8859-1", "windows-1252" (default: Content-Type charset, else UTF-8)
+	Delimiter   string   `json:"delimiter,omitempty"`   // CSV field delimiter (default "," for csv, tab for tsv)
+	Header      *bool    `json:"header,omitempty"`      // The first row holds the column names. Defaults to true
+	Columns     []string `json:"columns,omitempty"`     // Column names, replacing those of the header row (or naming the columns of a file without one)
+	SkipRows    int      `json:"skip_rows,omitempty"`   // Rows skipped before the header (report preambles)
+}
+
+// HasHeader reports whether the first row of a CSV body holds the column names
+func (c *ParseConfig) HasHeader() bool {
+	return c == nil || c.Header == nil || *c.Header
+}
+
+// Stream formats
+const (
+	StreamJSONArray = "json_array" // The body is a JSON array
+	StreamJSONPath  = "json_path"  // The body is a JSON object with the array at path
+	StreamNDJSON    = "ndjson"     // The body is newline-delimited JSON, one record per line
+)
+
+// DefaultStreamTimeoutSeconds is the default timeout of a streaming step, which reads its whole body
+const DefaultStreamTimeoutSeconds = 3600
+
+// Stream configures incremental decoding of a large response body.
+// Streamed records are emitted (and fanned out over, with sub_steps) in batches as they are read,
+// so conditions, set_context and pagination see the response without its body.
+type Stream struct {
+	Format    string `json:"format"`               // "json_array", "json_path" or "ndjson"
+	Path      string `json:"path,omitempty"`       // JSONPath to the array for json_path, e.g. "$.data.items" (required for json_path)
+	BatchSize int    `json:"batch_size,omitempty"` // Records per emitted message. Defaults to 1
+}
+
+// Watermark comparison types
+const (
+	WatermarkCompareString    = "string"    // Lexicographic (ISO-8601 timestamps in one format compare correctly)
+	WatermarkCompareNumber    = "number"    // Numeric (ids, epoch seconds)
+	WatermarkCompareTimestamp = "timestamp" // RFC 3339 timestamps in any offset
+)
+
+// Watermark declares the high-water mark of an incremental (delta) sync.
+// The expression is evaluated against every page and the highest value becomes the candidate;
+// the candidate is committed only when the execution completes successfully.
+type Watermark struct {
+	Expression string `json:"expression"`        // JMESPath over each page, e.g. "max(response.body.items[].updated_at)"
+	Param      string `json:"param"`             // Query param of the main step that receives the committed watermark
+	Initial    string `json:"initial,omitempty"` // Sent before the first commit (the param is omitted when empty)
+	Compare    string `json:"compare,omitempty"` // "string", "number" or "timestamp". Defaults to "string"
+}
+
 // PlanDefinition is the full plan structure stored in JSONB
 type PlanDefinition struct {
 	// Optional stable key for manual testing / binding to plans across systems.
@@ -70,6 +217,12 @@ type PlanDefinition struct {
 	// Main step (entry point)
 	Step Step `json:"step"`
 
+	// Top-level steps executed as a DAG (instead of step): each starts once its depends_on completed
+	Steps []Step `json:"steps,omitempty"`
+
+	// Max top-level steps of a DAG plan running at once
+	MaxParallelSteps int `json:"max_parallel_steps,omitempty"` // Defaults to 4
+
 	// Global rate limit configuration
 	RateLimits []RateLimitConfig `json:"rate_limits,omitempty"`
 
@@ -78,6 +231,30 @@ type PlanDefinition struct {
 
 	// Maximum nesting depth for sub-steps
 	MaxNestingDepth int `json:"max_nesting_depth,omitempty"` // Defaults to 5
+
+	// Incremental sync high-water mark, committed only when an execution succeeds
+	Watermark *Watermark `json:"watermark,omitempty"`
+}
+
+// DefaultMaxParallelSteps is the default number of DAG steps running at once
+const DefaultMaxParallelSteps = 4
+
+// EntrySteps returns the top-level steps of the plan: the DAG steps, or the main step
+func (p *PlanDefinition) EntrySteps() []Step {
+	if len(p.Steps) > 0 {
+		return p.Steps
+	}
+	return []Step{p.Step}
+}
+
+// AuthFlowID returns the auth flow of the plan (the first top-level step that declares one)
+func (p *PlanDefinition) AuthFlowID() string {
+	for _, step := range p.EntrySteps() {
+		if step.AuthFlowID != "" {
+			return step.AuthFlowID
+		}
+	}
+	return ""
 }
 
 // RateLimitConfig defines rate limiting for a step or group
@@ -95,8 +272,26 @@ type RateLimitConfig struct {
 
 	// Dynamic rate limit extraction from response headers
 	Dynamic *DynamicRateLimit `json:"dynamic,omitempty"`
+
+	// Adaptive learns the rate the API tolerates from 429 and 5xx responses (bounded by Requests)
+	Adaptive *AdaptiveRateLimit `json:"adaptive,omitempty"`
+
+	// Level is where the limit is declared, which decides who shares its bucket
+	Level RateLimitLevel `json:"-"`
 }
 
+// RateLimitLevel is where a rate limit is declared
+type RateLimitLevel string
+
+const (
+	// RateLimitLevelPlan limits are declared in a plan definition and apply to that plan only
+	RateLimitLevelPlan RateLimitLevel = ""
+	// RateLimitLevelIntegration limits are shared by every plan and auth flow of the integration
+	RateLimitLevelIntegration RateLimitLevel = "integration"
+	// RateLimitLevelConfig limits are shared by every plan and auth flow using the config's credentials
+	RateLimitLevelConfig RateLimitLevel = "config"
+)
+
 // DynamicRateLimit extracts rate limit info from response headers
 type DynamicRateLimit struct {
 	RemainingHeader string `json:"remaining_header,omitempty"` // Header containing remaining requests
@@ -105,6 +300,15 @@ type DynamicRateLimit struct {
 	RetryAfter      string `json:"retry_after,omitempty"`      // Header for retry delay (on 429)
 }
 
+// AdaptiveRateLimit tunes the effective request rate of a bucket by additive-increase/multiplicative-decrease.
+// Every 429 or 5xx response multiplies the rate by DecreaseFactor (at most once per window);
+// every window without errors adds IncreaseStep, until the configured Requests is reached again.
+type AdaptiveRateLimit struct {
+	MinRequests    int     `json:"min_requests,omitempty"`    // Floor of the learned rate. Defaults to 1
+	IncreaseStep   int     `json:"increase_step,omitempty"`   // Requests added per window without errors. Defaults to 1
+	DecreaseFactor float64 `json:"decrease_factor,omitempty"` // Factor applied on an error, between 0 and 1. Defaults to 0.5
+}
+
 // DefaultStep returns a step with default values applied
 func DefaultStep() Step {
 	return Step{
@@ -0,0 +1,355 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Gobusters/ectologger"
+
+	"github.com/Ramsey-B/orchid/pkg/metrics"
+	"github.com/Ramsey-B/orchid/pkg/models"
+	"github.com/Ramsey-B/orchid/pkg/secrets"
+	"github.com/Ramsey-B/stem/pkg/tracing"
+)
+
+var (
+	// ErrDispatcherAlreadyRunning is returned when trying to start an already running dispatcher
+	ErrDispatcherAlreadyRunning = errors.New("dispatcher already running")
+)
+
+const (
+	// DefaultPollInterval is the default interval between checks for due deliveries
+	DefaultPollInterval = 10 * time.Second
+
+	// DefaultBatchSize is the number of due deliveries fetched per poll
+	DefaultBatchSize = 100
+
+	// DefaultConcurrency is the number of deliveries sent at once
+	DefaultConcurrency = 10
+
+	// DefaultTimeout is the default timeout of a webhook request
+	DefaultTimeout = 10 * time.Second
+
+	// DefaultMaxAttempts is the default number of attempts before a delivery fails
+	DefaultMaxAttempts = 8
+
+	// DefaultRetryBackoff is the default wait before the second attempt, doubled for every further one
+	DefaultRetryBackoff = 30 * time.Second
+
+	// DefaultMaxBackoff caps the wait between attempts
+	DefaultMaxBackoff = time.Hour
+
+	// Webhook request headers
+	HeaderEvent     = "X-Orchid-Event"
+	HeaderDelivery  = "X-Orchid-Delivery"
+	HeaderTimestamp = "X-Orchid-Timestamp"
+	HeaderSignature = "X-Orchid-Signature"
+
+	// maxResponseBody is how much of a webhook response is drained so its connection can be reused
+	maxResponseBody = 64 << 10
+)
+
+// now returns the current time (replaced in tests)
+var now = time.Now
+
+// Sign returns the signature of a webhook body sent at timestamp (unix seconds):
+// "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the subscription secret.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Config holds configuration for the dispatcher
+type Config struct {
+	// PollInterval is how often to check for due deliveries
+	PollInterval time.Duration
+
+	// BatchSize is the maximum number of deliveries attempted per poll
+	BatchSize int
+
+	// Concurrency is the number of deliveries sent at once
+	Concurrency int
+
+	// Timeout is the timeout of a webhook request
+	Timeout time.Duration
+
+	// MaxAttempts is the number of attempts before a delivery fails
+	MaxAttempts int
+
+	// RetryBackoff is the wait before the second attempt; every further attempt doubles it
+	RetryBackoff time.Duration
+
+	// MaxBackoff caps the wait between attempts
+	MaxBackoff time.Duration
+}
+
+// DefaultConfig returns the default dispatcher configuration
+func DefaultConfig() Config {
+	return Config{
+		PollInterval: DefaultPollInterval,
+		BatchSize:    DefaultBatchSize,
+		Concurrency:  DefaultConcurrency,
+		Timeout:      DefaultTimeout,
+		MaxAttempts:  DefaultMaxAttempts,
+		RetryBackoff: DefaultRetryBackoff,
+		MaxBackoff:   DefaultMaxBackoff,
+	}
+}
+
+// backoff returns the wait after a delivery's attempts-th failed attempt
+func (c Config) backoff(attempts int) time.Duration {
+	wait := c.RetryBackoff
+	for i := 1; i < attempts && wait < c.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, c.MaxBackoff)
+}
+
+// Dispatcher sends queued webhook deliveries, retrying failed attempts with exponential backoff
+type Dispatcher struct {
+	repo   DispatcherRepository
+	cipher *secrets.Cipher
+	client *http.Client
+	config Config
+	logger ectologger.Logger
+
+	// Coordination
+	stopCh   chan struct{}
+	stoppedC chan struct{}
+	running  bool
+	mu       sync.RWMutex
+}
+
+// NewDispatcher creates a new dispatcher. Subscription secrets are decrypted with cipher.
+func NewDispatcher(repo DispatcherRepository, cipher *secrets.Cipher, config Config, logger ectologger.Logger) *Dispatcher {
+	// Apply defaults
+	defaults := DefaultConfig()
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaults.PollInterval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = defaults.Concurrency
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaults.Timeout
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = defaults.RetryBackoff
+	}
+	if config.MaxBackoff < config.RetryBackoff {
+		config.MaxBackoff = max(defaults.MaxBackoff, config.RetryBackoff)
+	}
+
+	return &Dispatcher{
+		repo:     repo,
+		cipher:   cipher,
+		client:   &http.Client{Timeout: config.Timeout},
+		config:   config,
+		logger:   logger,
+		stopCh:   make(chan struct{}),
+		stoppedC: make(chan struct{}),
+	}
+}
+
+// Start starts the dispatcher
+func (d *Dispatcher) Start(ctx context.Context) error {
+	d.mu.Lock()
+	if d.running {
+		d.mu.Unlock()
+		return ErrDispatcherAlreadyRunning
+	}
+	d.running = true
+	d.mu.Unlock()
+
+	d.logger.WithContext(ctx).Infof("Starting notification dispatcher: poll_interval=%s max_attempts=%d",
+		d.config.PollInterval, d.config.MaxAttempts)
+
+	go d.pollLoop(ctx)
+	return nil
+}
+
+// Stop stops the dispatcher gracefully, waiting for the deliveries in flight
+func (d *Dispatcher) Stop(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.running {
+		d.mu.Unlock()
+		return nil
+	}
+	d.running = false
+	d.mu.Unlock()
+
+	d.logger.WithContext(ctx).Info("Stopping notification dispatcher...")
+
+	close(d.stopCh)
+
+	select {
+	case <-d.stoppedC:
+		d.logger.WithContext(ctx).Info("Notification dispatcher stopped gracefully")
+	case <-ctx.Done():
+		d.logger.WithContext(ctx).Warn("Notification dispatcher shutdown timed out")
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+// pollLoop continuously sends due deliveries
+func (d *Dispatcher) pollLoop(ctx context.Context) {
+	defer close(d.stoppedC)
+
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.stopCh:
+			d.logger.WithContext(ctx).Debug("Notification dispatcher poll loop stopping")
+			return
+		case <-ticker.C:
+			d.Dispatch(ctx)
+		}
+	}
+}
+
+// Dispatch attempts the deliveries that are due and returns how many it attempted
+func (d *Dispatcher) Dispatch(ctx context.Context) int {
+	ctx, span := tracing.StartSpan(ctx, "Dispatcher.Dispatch")
+	defer span.End()
+
+	deliveries, err := d.repo.ListDue(ctx, d.config.BatchSize)
+	if err != nil {
+		d.logger.WithContext(ctx).WithError(err).Error("Failed to list due notification deliveries")
+		return 0
+	}
+
+	var wg sync.WaitGroup
+	count := 0
+	slots := make(chan struct{}, d.config.Concurrency)
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		// The claim is taken once a slot is free and outlasts the attempt, so no other dispatcher sends the delivery meanwhile
+		slots <- struct{}{}
+		claimed, err := d.repo.Claim(ctx, delivery, now().Add(2*d.config.Timeout))
+		if err != nil || !claimed {
+			<-slots
+			continue
+		}
+		count++
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			d.attempt(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+	return count
+}
+
+// attempt sends a delivery and records the result
+func (d *Dispatcher) attempt(ctx context.Context, delivery *DueDelivery) {
+	attempts := delivery.Attempts + 1
+	responseStatus, err := d.send(ctx, delivery)
+
+	attemptedAt := now()
+	result := Attempt{ResponseStatus: responseStatus, NextAttemptAt: attemptedAt}
+	outcome := "delivered"
+	switch {
+	case err == nil:
+		result.Status = models.NotificationDeliveryDelivered
+		result.DeliveredAt = &attemptedAt
+	case attempts >= d.config.MaxAttempts:
+		result.Status = models.NotificationDeliveryFailed
+		outcome = "failed"
+	default:
+		result.Status = models.NotificationDeliveryPending
+		result.NextAttemptAt = attemptedAt.Add(d.config.backoff(attempts))
+		outcome = "retry"
+	}
+	if err != nil {
+		message := err.Error()
+		result.ErrorMessage = &message
+		d.logger.WithContext(ctx).WithError(err).Warnf("Notification delivery %s to %s failed (attempt %d/%d)",
+			delivery.ID, delivery.URL, attempts, d.config.MaxAttempts)
+	}
+
+	metrics.RecordNotificationDelivery(string(delivery.Event), outcome)
+	if recordErr := d.repo.RecordAttempt(ctx, delivery, result); recordErr != nil {
+		d.logger.WithContext(ctx).WithError(recordErr).Warnf("Failed to record attempt of notification delivery %s", delivery.ID)
+	}
+}
+
+// send posts a delivery's payload to its webhook, returning the response status when there was one.
+// Any status other than 2xx is a failed attempt.
+func (d *Dispatcher) send(ctx context.Context, delivery *DueDelivery) (*int, error) {
+	secret, err := d.secret(ctx, delivery)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(delivery.Payload.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook request: %w", err)
+	}
+	timestamp := now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Orchid-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, string(delivery.Event))
+	req.Header.Set(HeaderDelivery, delivery.ID.String())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	status := resp.StatusCode
+	if status < 200 || status >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &status, fmt.Errorf("webhook responded with status %d: %s", status, bytes.TrimSpace(data))
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+	return &status, nil
+}
+
+// secret decrypts the signing secret of a delivery's subscription
+func (d *Dispatcher) secret(ctx context.Context, delivery *DueDelivery) (string, error) {
+	value, err := d.cipher.DecryptValue(ctx, delivery.Secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt subscription secret: %w", err)
+	}
+	secret, ok := value.(string)
+	if !ok || secret == "" {
+		return "", errors.New("subscription secret is not a string")
+	}
+	return secret, nil
+}
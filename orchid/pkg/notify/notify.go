@@ -0,0 +1,237 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/Gobusters/ectologger"
+	"github.com/google/uuid"
+
+	"github.com/Ramsey-B/orchid/pkg/metrics"
+	"github.com/Ramsey-B/orchid/pkg/models"
+	"github.com/Ramsey-B/orchid/pkg/repositories"
+	"github.com/Ramsey-B/stem/pkg/database"
+	"github.com/Ramsey-B/stem/pkg/tracing"
+)
+
+// Outcome is a completed execution, evaluated against the notification subscriptions of its tenant
+type Outcome struct {
+	TenantID    uuid.UUID
+	ExecutionID uuid.UUID
+	PlanKey     string
+	Integration string
+	ConfigID    uuid.UUID
+	Status      models.ExecutionStatus
+	ErrorType   *models.ErrorType // classifyError result of a failed execution
+	Error       string
+	CompletedAt time.Time
+
+	// Failed executions of the plan/config in a row before this one was recorded
+	PreviousFailures int
+	// Auth failures of the plan/config in a row, including this one
+	AuthFailures int
+}
+
+// Failed reports whether the execution counts as a failure of its plan/config
+func (o Outcome) Failed() bool {
+	return o.Status != models.ExecutionStatusSuccess
+}
+
+// ConsecutiveFailures returns the failed executions in a row of the plan/config, including this one
+func (o Outcome) ConsecutiveFailures() int {
+	if o.Failed() {
+		return o.PreviousFailures + 1
+	}
+	return 0
+}
+
+// events returns the events the outcome may fire
+func (o Outcome) events() []models.NotificationEvent {
+	if !o.Failed() {
+		if o.PreviousFailures > 0 {
+			return []models.NotificationEvent{models.NotificationEventExecutionRecovered}
+		}
+		return nil
+	}
+	events := []models.NotificationEvent{models.NotificationEventExecutionFailed}
+	if o.ErrorType != nil && *o.ErrorType == models.ErrorTypeAuth {
+		events = append(events, models.NotificationEventAuthError)
+	}
+	return events
+}
+
+// Fires reports whether an outcome fires a subscription. Failure events fire once per failure
+// streak, when it reaches the subscription's threshold; recoveries fire for streaks that did.
+// Auth errors count auth failures in a row only, so other failures do not use up their threshold.
+func Fires(subscription *models.NotificationSubscription, outcome Outcome) bool {
+	if !subscription.Enabled {
+		return false
+	}
+	if subscription.PlanKey != nil && *subscription.PlanKey != outcome.PlanKey {
+		return false
+	}
+	if subscription.ConfigID != nil && *subscription.ConfigID != outcome.ConfigID {
+		return false
+	}
+
+	threshold := max(subscription.Threshold, 1)
+	switch subscription.Event {
+	case models.NotificationEventExecutionFailed:
+		return outcome.Failed() && outcome.ConsecutiveFailures() == threshold
+	case models.NotificationEventAuthError:
+		return outcome.Failed() && outcome.ErrorType != nil && *outcome.ErrorType == models.ErrorTypeAuth &&
+			outcome.AuthFailures == threshold
+	case models.NotificationEventExecutionRecovered:
+		return !outcome.Failed() && outcome.PreviousFailures >= threshold
+	}
+	return false
+}
+
+// Payload is the JSON body of a webhook
+type Payload struct {
+	ID                  uuid.UUID                `json:"id"` // Delivery ID, the same for every attempt
+	Event               models.NotificationEvent `json:"event"`
+	SubscriptionID      uuid.UUID                `json:"subscription_id"`
+	TenantID            uuid.UUID                `json:"tenant_id"`
+	ExecutionID         uuid.UUID                `json:"execution_id"`
+	PlanKey             string                   `json:"plan_key"`
+	Integration         string                   `json:"integration,omitempty"`
+	ConfigID            uuid.UUID                `json:"config_id"`
+	Status              models.ExecutionStatus   `json:"status"`
+	ErrorType           *models.ErrorType        `json:"error_type,omitempty"`
+	Error               string                   `json:"error,omitempty"`
+	ConsecutiveFailures int                      `json:"consecutive_failures"`
+	AuthFailures        int                      `json:"consecutive_auth_failures,omitempty"` // Auth failures in a row of an auth error
+	PreviousFailures    int                      `json:"previous_failures,omitempty"`         // Streak a recovery ended
+	OccurredAt          time.Time                `json:"occurred_at"`
+}
+
+// ValidateSubscription checks the settings of a notification subscription
+func ValidateSubscription(subscription *models.NotificationSubscription) error {
+	if subscription.Name == "" {
+		return errors.New("name is required")
+	}
+	if !subscription.Event.Valid() {
+		return fmt.Errorf("event must be %s, %s or %s", models.NotificationEventExecutionFailed,
+			models.NotificationEventAuthError, models.NotificationEventExecutionRecovered)
+	}
+	if subscription.Threshold < 0 {
+		return errors.New("threshold must be positive")
+	}
+	u, err := url.Parse(subscription.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an http or https URL")
+	}
+	return nil
+}
+
+// Notifier queues webhook deliveries for the subscriptions an execution outcome fires.
+// The Dispatcher sends them.
+type Notifier struct {
+	subscriptions repositories.NotificationSubscriptionRepo
+	deliveries    repositories.NotificationDeliveryRepo
+	logger        ectologger.Logger
+}
+
+// NewNotifier creates a new notifier
+func NewNotifier(subscriptions repositories.NotificationSubscriptionRepo, deliveries repositories.NotificationDeliveryRepo, logger ectologger.Logger) *Notifier {
+	return &Notifier{
+		subscriptions: subscriptions,
+		deliveries:    deliveries,
+		logger:        logger,
+	}
+}
+
+// Notify queues a delivery to every enabled subscription of the tenant the outcome fires.
+// ctx must carry the outcome's tenant.
+func (n *Notifier) Notify(ctx context.Context, outcome Outcome) error {
+	ctx, span := tracing.StartSpan(ctx, "Notifier.Notify")
+	defer span.End()
+
+	events := outcome.events()
+	if len(events) == 0 {
+		return nil
+	}
+
+	subscriptions, err := n.subscriptions.ListEnabled(ctx, events)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range subscriptions {
+		subscription := &subscriptions[i]
+		if !Fires(subscription, outcome) {
+			continue
+		}
+		if err := n.queue(ctx, subscription, outcome); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		n.logger.WithContext(ctx).Infof("Queued %s notification for subscription %s (plan=%s config=%s failures=%d)",
+			subscription.Event, subscription.ID, outcome.PlanKey, outcome.ConfigID, outcome.ConsecutiveFailures())
+	}
+	return errors.Join(errs...)
+}
+
+// queue creates the pending delivery of an outcome to a subscription
+func (n *Notifier) queue(ctx context.Context, subscription *models.NotificationSubscription, outcome Outcome) error {
+	payload := Payload{
+		ID:                  uuid.New(),
+		Event:               subscription.Event,
+		SubscriptionID:      subscription.ID,
+		TenantID:            outcome.TenantID,
+		ExecutionID:         outcome.ExecutionID,
+		PlanKey:             outcome.PlanKey,
+		Integration:         outcome.Integration,
+		ConfigID:            outcome.ConfigID,
+		Status:              outcome.Status,
+		ErrorType:           outcome.ErrorType,
+		Error:               outcome.Error,
+		ConsecutiveFailures: outcome.ConsecutiveFailures(),
+		OccurredAt:          outcome.CompletedAt,
+	}
+	switch subscription.Event {
+	case models.NotificationEventExecutionRecovered:
+		payload.PreviousFailures = outcome.PreviousFailures
+	case models.NotificationEventAuthError:
+		payload.AuthFailures = outcome.AuthFailures
+	}
+
+	data, err := toMap(payload)
+	if err != nil {
+		return err
+	}
+
+	executionID := outcome.ExecutionID
+	delivery := &models.NotificationDelivery{
+		ID:             payload.ID,
+		SubscriptionID: subscription.ID,
+		Event:          subscription.Event,
+		ExecutionID:    &executionID,
+		Payload:        database.JSONB[map[string]any]{Data: data},
+		Status:         models.NotificationDeliveryPending,
+	}
+	if err := n.deliveries.Create(ctx, delivery); err != nil {
+		return err
+	}
+	metrics.RecordNotificationQueued(string(subscription.Event))
+	return nil
+}
+
+// toMap converts a payload to the JSON object stored with its delivery
+func toMap(payload Payload) (map[string]any, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode notification payload: %w", err)
+	}
+	var out map[string]any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to encode notification payload: %w", err)
+	}
+	return out, nil
+}
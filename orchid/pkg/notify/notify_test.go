@@ -0,0 +1,334 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Gobusters/ectologger/zapadapter"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+	"github.com/Ramsey-B/orchid/pkg/repositories"
+	"github.com/Ramsey-B/orchid/pkg/secrets"
+	"github.com/Ramsey-B/stem/pkg/database"
+)
+
+// fakeSubscriptions returns a fixed set of subscriptions
+type fakeSubscriptions struct {
+	repositories.NotificationSubscriptionRepo
+	subscriptions []models.NotificationSubscription
+}
+
+func (f *fakeSubscriptions) ListEnabled(ctx context.Context, events []models.NotificationEvent) ([]models.NotificationSubscription, error) {
+	var out []models.NotificationSubscription
+	for _, subscription := range f.subscriptions {
+		for _, event := range events {
+			if subscription.Enabled && subscription.Event == event {
+				out = append(out, subscription)
+			}
+		}
+	}
+	return out, nil
+}
+
+// fakeDeliveries records created deliveries
+type fakeDeliveries struct {
+	repositories.NotificationDeliveryRepo
+	created []*models.NotificationDelivery
+}
+
+func (f *fakeDeliveries) Create(ctx context.Context, delivery *models.NotificationDelivery) error {
+	f.created = append(f.created, delivery)
+	return nil
+}
+
+// fakeDispatcherRepo keeps due deliveries in memory
+type fakeDispatcherRepo struct {
+	mu         sync.Mutex
+	deliveries []DueDelivery
+	attempts   []Attempt
+}
+
+func (f *fakeDispatcherRepo) ListDue(ctx context.Context, limit int) ([]DueDelivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var due []DueDelivery
+	for _, delivery := range f.deliveries {
+		if delivery.Status == models.NotificationDeliveryPending && !delivery.NextAttemptAt.After(now()) {
+			due = append(due, delivery)
+		}
+	}
+	return due, nil
+}
+
+func (f *fakeDispatcherRepo) Claim(ctx context.Context, delivery *DueDelivery, until time.Time) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stored := f.find(delivery.ID)
+	if stored.Status != models.NotificationDeliveryPending || !stored.NextAttemptAt.Equal(delivery.NextAttemptAt) {
+		return false, nil
+	}
+	stored.NextAttemptAt = until
+	return true, nil
+}
+
+func (f *fakeDispatcherRepo) RecordAttempt(ctx context.Context, delivery *DueDelivery, attempt Attempt) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stored := f.find(delivery.ID)
+	stored.Status = attempt.Status
+	stored.Attempts++
+	stored.NextAttemptAt = attempt.NextAttemptAt
+	f.attempts = append(f.attempts, attempt)
+	return nil
+}
+
+func (f *fakeDispatcherRepo) find(id uuid.UUID) *DueDelivery {
+	for i := range f.deliveries {
+		if f.deliveries[i].ID == id {
+			return &f.deliveries[i]
+		}
+	}
+	return nil
+}
+
+func newTestCipher(t *testing.T) *secrets.Cipher {
+	t.Helper()
+	provider, err := secrets.NewLocalKeyProviderFromKeys(bytes.Repeat([]byte{7}, 32))
+	require.NoError(t, err)
+	return secrets.NewCipher(provider)
+}
+
+// setNow freezes the dispatcher clock for a test
+func setNow(t *testing.T, at time.Time) {
+	t.Helper()
+	previous := now
+	now = func() time.Time { return at }
+	t.Cleanup(func() { now = previous })
+}
+
+func failedOutcome(previous int, errorType models.ErrorType) Outcome {
+	authFailures := 0
+	if errorType == models.ErrorTypeAuth {
+		authFailures = previous + 1
+	}
+	return Outcome{
+		TenantID:         uuid.New(),
+		ExecutionID:      uuid.New(),
+		PlanKey:          "users",
+		ConfigID:         uuid.New(),
+		Status:           models.ExecutionStatusFailed,
+		ErrorType:        &errorType,
+		Error:            "boom",
+		CompletedAt:      time.Now(),
+		PreviousFailures: previous,
+		AuthFailures:     authFailures,
+	}
+}
+
+func TestFires_FailureThreshold(t *testing.T) {
+	subscription := &models.NotificationSubscription{Event: models.NotificationEventExecutionFailed, Threshold: 3, Enabled: true}
+
+	// Fires once per streak, when it reaches the threshold
+	require.False(t, Fires(subscription, failedOutcome(1, models.ErrorTypeTransient)))
+	require.True(t, Fires(subscription, failedOutcome(2, models.ErrorTypeTransient)))
+	require.False(t, Fires(subscription, failedOutcome(3, models.ErrorTypeTransient)))
+
+	// A threshold of 0 behaves like 1
+	subscription.Threshold = 0
+	require.True(t, Fires(subscription, failedOutcome(0, models.ErrorTypeTransient)))
+
+	subscription.Enabled = false
+	require.False(t, Fires(subscription, failedOutcome(0, models.ErrorTypeTransient)))
+}
+
+func TestFires_AuthErrorAndFilters(t *testing.T) {
+	outcome := failedOutcome(0, models.ErrorTypeAuth)
+	planKey := "orders"
+	subscription := &models.NotificationSubscription{Event: models.NotificationEventAuthError, Threshold: 1, Enabled: true}
+
+	require.True(t, Fires(subscription, outcome))
+	require.False(t, Fires(subscription, failedOutcome(0, models.ErrorTypeTransient)))
+
+	subscription.PlanKey = &planKey
+	require.False(t, Fires(subscription, outcome))
+
+	subscription.PlanKey = &outcome.PlanKey
+	otherConfig := uuid.New()
+	subscription.ConfigID = &otherConfig
+	require.False(t, Fires(subscription, outcome))
+
+	subscription.ConfigID = &outcome.ConfigID
+	require.True(t, Fires(subscription, outcome))
+}
+
+func TestFires_AuthErrorCountsAuthFailuresOnly(t *testing.T) {
+	subscription := &models.NotificationSubscription{Event: models.NotificationEventAuthError, Threshold: 2, Enabled: true}
+
+	// Transient failures before the first auth failure do not count toward the threshold
+	outcome := failedOutcome(3, models.ErrorTypeAuth)
+	outcome.AuthFailures = 1
+	require.False(t, Fires(subscription, outcome))
+
+	outcome.AuthFailures = 2
+	require.True(t, Fires(subscription, outcome))
+}
+
+func TestFires_Recovered(t *testing.T) {
+	subscription := &models.NotificationSubscription{Event: models.NotificationEventExecutionRecovered, Threshold: 3, Enabled: true}
+	recovered := Outcome{Status: models.ExecutionStatusSuccess, PreviousFailures: 3}
+
+	require.True(t, Fires(subscription, recovered))
+
+	// The streak never reached the threshold, so there is nothing to recover from
+	recovered.PreviousFailures = 2
+	require.False(t, Fires(subscription, recovered))
+}
+
+func TestNotifier_QueuesFiredSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	zapLogger, _ := zap.NewDevelopment()
+	logger := zapadapter.NewZapEctoLogger(zapLogger, nil)
+
+	failed := models.NotificationSubscription{ID: uuid.New(), Event: models.NotificationEventExecutionFailed, Threshold: 2, Enabled: true}
+	auth := models.NotificationSubscription{ID: uuid.New(), Event: models.NotificationEventAuthError, Threshold: 1, Enabled: true}
+	subscriptions := &fakeSubscriptions{subscriptions: []models.NotificationSubscription{failed, auth}}
+	deliveries := &fakeDeliveries{}
+	notifier := NewNotifier(subscriptions, deliveries, logger)
+
+	// The second auth failure in a row fires the failure subscription only
+	outcome := failedOutcome(1, models.ErrorTypeAuth)
+	require.NoError(t, notifier.Notify(ctx, outcome))
+	require.Len(t, deliveries.created, 1)
+
+	delivery := deliveries.created[0]
+	require.Equal(t, failed.ID, delivery.SubscriptionID)
+	require.Equal(t, models.NotificationDeliveryPending, delivery.Status)
+	require.Equal(t, delivery.ID.String(), delivery.Payload.Data["id"])
+	require.Equal(t, "users", delivery.Payload.Data["plan_key"])
+	require.Equal(t, "auth", delivery.Payload.Data["error_type"])
+	require.EqualValues(t, 2, delivery.Payload.Data["consecutive_failures"])
+
+	// Successes without a failure streak fire nothing
+	require.NoError(t, notifier.Notify(ctx, Outcome{Status: models.ExecutionStatusSuccess}))
+	require.Len(t, deliveries.created, 1)
+}
+
+func TestDispatcher_SendsSignedWebhook(t *testing.T) {
+	ctx := context.Background()
+	zapLogger, _ := zap.NewDevelopment()
+	logger := zapadapter.NewZapEctoLogger(zapLogger, nil)
+	cipher := newTestCipher(t)
+	at := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	setNow(t, at)
+
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	secret, err := cipher.EncryptValue(ctx, "s3cret")
+	require.NoError(t, err)
+	delivery := DueDelivery{
+		NotificationDelivery: models.NotificationDelivery{
+			ID:            uuid.New(),
+			Event:         models.NotificationEventExecutionFailed,
+			Payload:       database.JSONB[map[string]any]{Data: map[string]any{"plan_key": "users"}},
+			Status:        models.NotificationDeliveryPending,
+			NextAttemptAt: at,
+		},
+		URL:    server.URL,
+		Secret: secret,
+	}
+	repo := &fakeDispatcherRepo{deliveries: []DueDelivery{delivery}}
+	dispatcher := NewDispatcher(repo, cipher, Config{}, logger)
+
+	require.Equal(t, 1, dispatcher.Dispatch(ctx))
+	require.NotNil(t, received)
+	require.Equal(t, string(models.NotificationEventExecutionFailed), received.Header.Get(HeaderEvent))
+	require.Equal(t, delivery.ID.String(), received.Header.Get(HeaderDelivery))
+
+	timestamp, err := strconv.ParseInt(received.Header.Get(HeaderTimestamp), 10, 64)
+	require.NoError(t, err)
+	require.Equal(t, at.Unix(), timestamp)
+	require.Equal(t, Sign("s3cret", timestamp, body), received.Header.Get(HeaderSignature))
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(body, &payload))
+	require.Equal(t, "users", payload["plan_key"])
+
+	require.Len(t, repo.attempts, 1)
+	require.Equal(t, models.NotificationDeliveryDelivered, repo.attempts[0].Status)
+	require.Equal(t, http.StatusNoContent, *repo.attempts[0].ResponseStatus)
+	require.NotNil(t, repo.attempts[0].DeliveredAt)
+
+	// Delivered deliveries are not sent again
+	require.Equal(t, 0, dispatcher.Dispatch(ctx))
+}
+
+func TestDispatcher_RetriesWithBackoffThenFails(t *testing.T) {
+	ctx := context.Background()
+	zapLogger, _ := zap.NewDevelopment()
+	logger := zapadapter.NewZapEctoLogger(zapLogger, nil)
+	cipher := newTestCipher(t)
+	at := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	setNow(t, at)
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	secret, err := cipher.EncryptValue(ctx, "s3cret")
+	require.NoError(t, err)
+	repo := &fakeDispatcherRepo{deliveries: []DueDelivery{{
+		NotificationDelivery: models.NotificationDelivery{
+			ID:            uuid.New(),
+			Event:         models.NotificationEventExecutionFailed,
+			Status:        models.NotificationDeliveryPending,
+			NextAttemptAt: at,
+		},
+		URL:    server.URL,
+		Secret: secret,
+	}}}
+	config := Config{MaxAttempts: 3, RetryBackoff: time.Minute, MaxBackoff: 90 * time.Second}
+	dispatcher := NewDispatcher(repo, cipher, config, logger)
+
+	require.Equal(t, 1, dispatcher.Dispatch(ctx))
+	require.Equal(t, models.NotificationDeliveryPending, repo.attempts[0].Status)
+	require.Equal(t, at.Add(time.Minute), repo.attempts[0].NextAttemptAt)
+	require.Equal(t, http.StatusServiceUnavailable, *repo.attempts[0].ResponseStatus)
+	require.Contains(t, *repo.attempts[0].ErrorMessage, "unavailable")
+
+	// Not due until the backoff has passed
+	require.Equal(t, 0, dispatcher.Dispatch(ctx))
+
+	at = at.Add(time.Minute)
+	setNow(t, at)
+	require.Equal(t, 1, dispatcher.Dispatch(ctx))
+	require.Equal(t, at.Add(90*time.Second), repo.attempts[1].NextAttemptAt) // Doubled, then capped
+
+	at = at.Add(90 * time.Second)
+	setNow(t, at)
+	require.Equal(t, 1, dispatcher.Dispatch(ctx))
+	require.Equal(t, models.NotificationDeliveryFailed, repo.attempts[2].Status)
+	require.Equal(t, 3, calls)
+
+	require.Equal(t, 0, dispatcher.Dispatch(ctx))
+}
@@ -0,0 +1,173 @@
+package notify
+
+import (
+	"context"
+	"time"
+
+	"github.com/Gobusters/ectologger"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+	"github.com/Ramsey-B/stem/pkg/database"
+	"github.com/Ramsey-B/stem/pkg/tracing"
+)
+
+// DueDelivery is a pending delivery due for an attempt, with the webhook of its subscription
+type DueDelivery struct {
+	models.NotificationDelivery
+	URL    string
+	Secret map[string]any // Envelope-encrypted signing secret
+}
+
+// Attempt is the result of a delivery attempt
+type Attempt struct {
+	Status         models.NotificationDeliveryStatus
+	ResponseStatus *int
+	ErrorMessage   *string
+	NextAttemptAt  time.Time
+	DeliveredAt    *time.Time
+}
+
+// DispatcherRepository defines the interface for dispatcher data access
+// This is separate from tenant-scoped repositories as it needs cross-tenant access
+type DispatcherRepository interface {
+	// ListDue returns pending deliveries of enabled subscriptions whose next attempt is due
+	ListDue(ctx context.Context, limit int) ([]DueDelivery, error)
+
+	// Claim moves the next attempt of a due delivery to until, returning false if another dispatcher already did
+	Claim(ctx context.Context, delivery *DueDelivery, until time.Time) (bool, error)
+
+	// RecordAttempt stores the result of an attempt
+	RecordAttempt(ctx context.Context, delivery *DueDelivery, attempt Attempt) error
+}
+
+// DispatcherRepositoryImpl implements DispatcherRepository with cross-tenant access
+// This is a system-level repository not scoped to a single tenant
+type DispatcherRepositoryImpl struct {
+	db     database.DB
+	logger ectologger.Logger
+}
+
+// NewDispatcherRepository creates a new dispatcher repository
+func NewDispatcherRepository(db database.DB, logger ectologger.Logger) *DispatcherRepositoryImpl {
+	return &DispatcherRepositoryImpl{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// ListDue returns pending deliveries of enabled subscriptions whose next attempt is due, oldest first.
+// Deliveries of disabled subscriptions stay pending until the subscription is enabled again.
+func (r *DispatcherRepositoryImpl) ListDue(ctx context.Context, limit int) ([]DueDelivery, error) {
+	ctx, span := tracing.StartSpan(ctx, "DispatcherRepository.ListDue")
+	defer span.End()
+
+	// Use parameterized timestamp instead of NOW() for Citus compatibility
+	query := `
+		SELECT
+			d.id, d.tenant_id, d.subscription_id, d.event, d.execution_id, d.payload, d.status,
+			d.attempts, d.next_attempt_at, d.created_at, d.updated_at,
+			s.url, s.secret
+		FROM notification_deliveries d
+		INNER JOIN notification_subscriptions s ON d.tenant_id = s.tenant_id AND d.subscription_id = s.id
+		WHERE d.status = $1 AND d.next_attempt_at <= $2 AND s.enabled = true
+		ORDER BY d.next_attempt_at ASC
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, models.NotificationDeliveryPending, time.Now(), limit)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("Failed to query due notification deliveries")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []DueDelivery
+	for rows.Next() {
+		var delivery DueDelivery
+		var secret database.JSONB[map[string]any]
+
+		err := rows.Scan(
+			&delivery.ID,
+			&delivery.TenantID,
+			&delivery.SubscriptionID,
+			&delivery.Event,
+			&delivery.ExecutionID,
+			&delivery.Payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.NextAttemptAt,
+			&delivery.CreatedAt,
+			&delivery.UpdatedAt,
+			&delivery.URL,
+			&secret,
+		)
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).Error("Failed to scan due notification delivery")
+			continue
+		}
+
+		delivery.Secret = secret.Data
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("Error iterating due notification deliveries")
+		return nil, err
+	}
+
+	r.logger.WithContext(ctx).Debugf("Found %d due notification deliveries", len(deliveries))
+	return deliveries, nil
+}
+
+// Claim moves the next attempt of a due delivery from the value the dispatcher observed to until.
+// The update is conditional so that when several dispatchers race for the same delivery only one of
+// them wins; the others get false and must not send it. A dispatcher that dies mid-attempt leaves
+// the delivery to be retried at until.
+func (r *DispatcherRepositoryImpl) Claim(ctx context.Context, delivery *DueDelivery, until time.Time) (bool, error) {
+	ctx, span := tracing.StartSpan(ctx, "DispatcherRepository.Claim")
+	defer span.End()
+
+	query := `
+		UPDATE notification_deliveries
+		SET next_attempt_at = $1, updated_at = $2
+		WHERE tenant_id = $3 AND id = $4 AND status = $5 AND next_attempt_at = $6`
+
+	result, err := r.db.ExecContext(ctx, query, until, time.Now(), delivery.TenantID, delivery.ID,
+		models.NotificationDeliveryPending, delivery.NextAttemptAt)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"tenant_id":   delivery.TenantID,
+			"delivery_id": delivery.ID,
+		}).Error("Failed to claim notification delivery")
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows == 1, nil
+}
+
+// RecordAttempt stores the result of an attempt and counts it
+func (r *DispatcherRepositoryImpl) RecordAttempt(ctx context.Context, delivery *DueDelivery, attempt Attempt) error {
+	ctx, span := tracing.StartSpan(ctx, "DispatcherRepository.RecordAttempt")
+	defer span.End()
+
+	query := `
+		UPDATE notification_deliveries
+		SET status = $1, attempts = attempts + 1, response_status = $2, error_message = $3,
+			next_attempt_at = $4, delivered_at = $5, updated_at = $6
+		WHERE tenant_id = $7 AND id = $8`
+
+	_, err := r.db.ExecContext(ctx, query, attempt.Status, attempt.ResponseStatus, attempt.ErrorMessage,
+		attempt.NextAttemptAt, attempt.DeliveredAt, time.Now(), delivery.TenantID, delivery.ID)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"tenant_id":   delivery.TenantID,
+			"delivery_id": delivery.ID,
+		}).Error("Failed to record notification delivery attempt")
+		return err
+	}
+	return nil
+}
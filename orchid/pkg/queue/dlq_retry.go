@@ -0,0 +1,143 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Ramsey-B/orchid/pkg/execution"
+	"github.com/Ramsey-B/orchid/pkg/metrics"
+	"github.com/Ramsey-B/orchid/pkg/models"
+	"github.com/Ramsey-B/orchid/pkg/redis"
+	"github.com/Ramsey-B/stem/pkg/tracing"
+)
+
+const (
+	// DefaultDLQRetryInterval is how often DLQ entries are checked against the automatic retry policies
+	DefaultDLQRetryInterval = time.Minute
+
+	// dlqRetryLockKey is the lease key that lets one processor at a time retry DLQ entries
+	dlqRetryLockKey = "dlq:auto-retry"
+)
+
+// DLQRetryPolicy re-enqueues the DLQ entries of a reason automatically.
+// An entry is retried once it spent Cooldown in the DLQ; every automatic retry of the same job doubles the cooldown.
+type DLQRetryPolicy struct {
+	Reason     models.DeadLetterReason
+	Cooldown   time.Duration
+	MaxRetries int // Automatic retries per job before its entry stays in the DLQ
+}
+
+// DefaultDLQRetryPolicies retries timeouts and auth errors, which are usually resolved by the upstream API
+func DefaultDLQRetryPolicies() []DLQRetryPolicy {
+	return []DLQRetryPolicy{
+		{Reason: models.DLQReasonTimeout, Cooldown: 5 * time.Minute, MaxRetries: 3},
+		{Reason: models.DLQReasonAuthError, Cooldown: 5 * time.Minute, MaxRetries: 3},
+	}
+}
+
+// due reports whether an entry is ready for its next automatic retry at now
+func (p DLQRetryPolicy) due(entry *redis.DLQEntry, now time.Time) bool {
+	if entry.Reason != p.Reason || entry.AutoRetries >= p.MaxRetries {
+		return false
+	}
+	cooldown := p.Cooldown << entry.AutoRetries
+	return !now.Before(entry.CreatedAt.Add(cooldown))
+}
+
+// dlqReason returns the DLQ reason of the error a job failed with
+func dlqReason(err error) models.DeadLetterReason {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, execution.ErrExecutionTimeout):
+		return models.DLQReasonTimeout
+	case errors.Is(err, execution.ErrAuthFlowFailed):
+		return models.DLQReasonAuthError
+	case errors.Is(err, execution.ErrPlanNotFound):
+		return models.DLQReasonPlanNotFound
+	case errors.Is(err, execution.ErrConfigNotFound):
+		return models.DLQReasonConfigError
+	case errors.Is(err, ErrInvalidJobMessage):
+		return models.DLQReasonInvalidJob
+	default:
+		return models.DLQReasonMaxRetries
+	}
+}
+
+// recordFailure remembers why a job failed, so it reaches the DLQ with that reason once it runs out of retries
+func (p *Processor) recordFailure(ctx context.Context, item jobItem, err error) {
+	if p.dlq == nil || err == nil {
+		return
+	}
+	failure := redis.DLQFailure{Reason: dlqReason(err), ErrorMessage: err.Error()}
+	if recordErr := p.dlq.RecordFailure(ctx, item.message.Stream, item.message.ID, failure); recordErr != nil {
+		p.logger.WithContext(ctx).WithError(recordErr).Warnf("Failed to record failure of message %s", item.message.ID)
+	}
+}
+
+// dlqRetryLoop periodically re-enqueues the DLQ entries that are due under a retry policy
+func (p *Processor) dlqRetryLoop(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	if p.dlq == nil || len(p.config.DLQRetryPolicies) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(p.config.DLQRetryInterval)
+	defer ticker.Stop()
+
+	p.logger.WithContext(ctx).Debug("DLQ retry loop started")
+
+	for {
+		select {
+		case <-p.stopCh:
+			p.logger.WithContext(ctx).Debug("DLQ retry loop stopping")
+			return
+		case <-ticker.C:
+			p.retryDeadLetters(ctx)
+		}
+	}
+}
+
+// retryDeadLetters re-enqueues the DLQ entries that are due under a retry policy.
+// Only one processor retries per interval; the others find the lease taken.
+func (p *Processor) retryDeadLetters(ctx context.Context) {
+	ctx, span := tracing.StartSpan(ctx, "Processor.retryDeadLetters")
+	defer span.End()
+
+	if p.leases != nil {
+		// The lease is left to expire, so the entries are checked once per interval across processors
+		if _, err := p.leases.Acquire(ctx, dlqRetryLockKey, p.config.DLQRetryInterval); err != nil {
+			if !errors.Is(err, redis.ErrLockNotAcquired) {
+				p.logger.WithContext(ctx).WithError(err).Warn("Failed to acquire DLQ retry lease")
+			}
+			return
+		}
+	}
+
+	now := time.Now()
+	for _, policy := range p.config.DLQRetryPolicies {
+		entries, err := p.dlq.Find(ctx, redis.DLQFilter{Reason: policy.Reason}, 0)
+		if err != nil {
+			p.logger.WithContext(ctx).WithError(err).Warnf("Failed to find DLQ entries of reason %s", policy.Reason)
+			continue
+		}
+
+		for i := range entries {
+			entry := &entries[i]
+			if !policy.due(entry, now) {
+				continue
+			}
+			if err := p.dlq.RetryEntry(ctx, entry, p.queue, true); err != nil {
+				// Tenants at their cap are retried on a later tick
+				if !errors.Is(err, redis.ErrTenantAtCapacity) {
+					p.logger.WithContext(ctx).WithError(err).Warnf("Failed to retry DLQ entry %s", entry.MessageID)
+				}
+				continue
+			}
+			metrics.RecordDLQRetry(string(entry.Reason), "auto")
+			p.logger.WithContext(ctx).Infof("Automatically retried DLQ entry %s (reason=%s, retry %d/%d)",
+				entry.MessageID, entry.Reason, entry.AutoRetries+1, policy.MaxRetries)
+		}
+	}
+}
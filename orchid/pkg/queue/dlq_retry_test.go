@@ -0,0 +1,56 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ramsey-B/orchid/pkg/execution"
+	"github.com/Ramsey-B/orchid/pkg/models"
+	"github.com/Ramsey-B/orchid/pkg/redis"
+)
+
+func TestDLQRetryPolicy_DueWithBackoff(t *testing.T) {
+	policy := DLQRetryPolicy{Reason: models.DLQReasonTimeout, Cooldown: 5 * time.Minute, MaxRetries: 3}
+	added := time.Now()
+	entry := &redis.DLQEntry{Reason: models.DLQReasonTimeout, CreatedAt: added}
+
+	require.False(t, policy.due(entry, added.Add(4*time.Minute)))
+	require.True(t, policy.due(entry, added.Add(5*time.Minute)))
+
+	// Every automatic retry doubles the cooldown
+	entry.AutoRetries = 2
+	require.False(t, policy.due(entry, added.Add(19*time.Minute)))
+	require.True(t, policy.due(entry, added.Add(20*time.Minute)))
+
+	// Jobs that keep failing stay in the DLQ
+	entry.AutoRetries = 3
+	require.False(t, policy.due(entry, added.Add(24*time.Hour)))
+
+	// Other reasons are left alone
+	require.False(t, policy.due(&redis.DLQEntry{Reason: models.DLQReasonAuthError, CreatedAt: added}, added.Add(time.Hour)))
+}
+
+func TestDLQReason(t *testing.T) {
+	require.Equal(t, models.DLQReasonTimeout, dlqReason(fmt.Errorf("step failed: %w", context.DeadlineExceeded)))
+	require.Equal(t, models.DLQReasonTimeout, dlqReason(execution.ErrExecutionTimeout))
+	require.Equal(t, models.DLQReasonAuthError, dlqReason(fmt.Errorf("%w: token endpoint returned 500", execution.ErrAuthFlowFailed)))
+	require.Equal(t, models.DLQReasonPlanNotFound, dlqReason(execution.ErrPlanNotFound))
+	require.Equal(t, models.DLQReasonMaxRetries, dlqReason(errors.New("upstream returned 502")))
+}
+
+func TestDLQFilter_Matches(t *testing.T) {
+	entry := &redis.DLQEntry{MessageID: "1-0", TenantID: "t1", PlanKey: "contacts", ConfigID: "c1", Reason: models.DLQReasonTimeout}
+
+	require.True(t, redis.DLQFilter{}.Matches(entry))
+	require.True(t, redis.DLQFilter{TenantID: "t1", PlanKey: "contacts", Reason: models.DLQReasonTimeout}.Matches(entry))
+	require.True(t, redis.DLQFilter{IDs: []string{"0-1", "1-0"}}.Matches(entry))
+	require.False(t, redis.DLQFilter{TenantID: "t2"}.Matches(entry))
+	require.False(t, redis.DLQFilter{ConfigID: "c2"}.Matches(entry))
+	require.False(t, redis.DLQFilter{Reason: models.DLQReasonAuthError}.Matches(entry))
+	require.False(t, redis.DLQFilter{IDs: []string{"2-0"}}.Matches(entry))
+}
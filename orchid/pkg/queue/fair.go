@@ -0,0 +1,75 @@
+package queue
+
+import (
+	"sort"
+
+	"github.com/Ramsey-B/orchid/pkg/redis"
+)
+
+// fairScheduler orders the tenants of a lane by stride scheduling: every job dispatched for a
+// tenant advances its pass by 1/weight and the tenant with the lowest pass is served first.
+// Over time each tenant with queued jobs gets a share of the workers proportional to its weight,
+// however many jobs it has queued.
+type fairScheduler struct {
+	pass map[redis.Lane]map[string]float64
+}
+
+// newFairScheduler creates a new fair scheduler
+func newFairScheduler() *fairScheduler {
+	return &fairScheduler{pass: make(map[redis.Lane]map[string]float64)}
+}
+
+// order returns the tenants of a lane in the order they should be served.
+// Tenants that are new to the lane start at the lowest pass, so an idle tenant
+// doesn't build up credit it could later use to take over the workers.
+func (s *fairScheduler) order(lane redis.Lane, tenants []string) []string {
+	passes := s.pass[lane]
+	if passes == nil {
+		passes = make(map[string]float64)
+		s.pass[lane] = passes
+	}
+
+	active := make(map[string]bool, len(tenants))
+	for _, tenantID := range tenants {
+		active[tenantID] = true
+	}
+	lowest := 0.0
+	first := true
+	for tenantID, pass := range passes {
+		if !active[tenantID] {
+			delete(passes, tenantID)
+			continue
+		}
+		if first || pass < lowest {
+			lowest = pass
+			first = false
+		}
+	}
+	for _, tenantID := range tenants {
+		if _, ok := passes[tenantID]; !ok {
+			passes[tenantID] = lowest
+		}
+	}
+
+	ordered := append([]string(nil), tenants...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if passes[ordered[i]] != passes[ordered[j]] {
+			return passes[ordered[i]] < passes[ordered[j]]
+		}
+		return ordered[i] < ordered[j]
+	})
+	return ordered
+}
+
+// served records that a job of a tenant was dispatched
+func (s *fairScheduler) served(lane redis.Lane, tenantID string, weight int) {
+	if weight <= 0 {
+		weight = 1
+	}
+	passes := s.pass[lane]
+	if passes == nil {
+		passes = make(map[string]float64)
+		s.pass[lane] = passes
+	}
+	passes[tenantID] += 1 / float64(weight)
+}
@@ -0,0 +1,43 @@
+package queue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ramsey-B/orchid/pkg/redis"
+)
+
+// dispatch simulates n dispatches with every tenant always having jobs queued
+func dispatch(s *fairScheduler, tenants []string, weights map[string]int, n int) map[string]int {
+	served := make(map[string]int)
+	for i := 0; i < n; i++ {
+		tenantID := s.order(redis.LaneScheduled, tenants)[0]
+		s.served(redis.LaneScheduled, tenantID, weights[tenantID])
+		served[tenantID]++
+	}
+	return served
+}
+
+func TestFairScheduler_SharesByWeight(t *testing.T) {
+	s := newFairScheduler()
+	served := dispatch(s, []string{"big", "small", "tiny"}, map[string]int{"big": 2, "small": 1, "tiny": 1}, 400)
+
+	require.Equal(t, 200, served["big"])
+	require.Equal(t, 100, served["small"])
+	require.Equal(t, 100, served["tiny"])
+}
+
+func TestFairScheduler_NewTenantStartsAtLowestPass(t *testing.T) {
+	s := newFairScheduler()
+	dispatch(s, []string{"a"}, nil, 50)
+
+	// A tenant joining a busy lane is served next, but gets no credit for the time it was idle
+	served := dispatch(s, []string{"a", "b"}, nil, 10)
+	require.Equal(t, 5, served["a"])
+	require.Equal(t, 5, served["b"])
+
+	// Tenants that left the lane are forgotten
+	s.order(redis.LaneScheduled, []string{"b"})
+	require.NotContains(t, s.pass[redis.LaneScheduled], "a")
+}
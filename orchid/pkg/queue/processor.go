@@ -28,13 +28,19 @@ var (
 
 	// ErrInvalidJobMessage is returned when a job message is invalid
 	ErrInvalidJobMessage = errors.New("invalid job message")
+
+	// ErrLeaseLost cancels an execution whose plan/config lease could not be renewed
+	ErrLeaseLost = errors.New("execution lease lost")
+
+	// errLeaseBusy is returned when another execution still holds the lease after the overlap wait
+	errLeaseBusy = errors.New("execution lease busy")
 )
 
 const (
 	// DefaultBatchSize is the default number of messages to consume at once
 	DefaultBatchSize = 10
 
-	// DefaultBlockTimeout is how long to block waiting for messages
+	// DefaultBlockTimeout is how long an idle processor waits for new jobs before polling the lanes again
 	DefaultBlockTimeout = 5 * time.Second
 
 	// DefaultMaxRetries is the default number of retries for a job
@@ -46,25 +52,28 @@ const (
 	// DefaultClaimMinIdle is the minimum idle time before claiming a message
 	DefaultClaimMinIdle = 60 * time.Second
 
+	// LeaseKeyPrefix is the key prefix of the locker holding the plan/config leases
+	LeaseKeyPrefix = "orchid:lease:"
+
+	// DefaultLeaseTTL is how long the lease of a plan/config outlives a processor that stops renewing it
+	DefaultLeaseTTL = 30 * time.Second
+
+	// DefaultOverlapWait is how long a job waits for the running execution of its plan/config before it is requeued
+	DefaultOverlapWait = 5 * time.Second
+
 	// JobTypePlanExecution is the job type for plan execution
 	JobTypePlanExecution = "plan_execution"
 )
 
 // ProcessorConfig holds configuration for the job processor
 type ProcessorConfig struct {
-	// Stream name for the job queue
-	Stream string
-
-	// Consumer group name
-	ConsumerGroup string
-
 	// Consumer name (unique per instance)
 	ConsumerName string
 
-	// Number of messages to fetch per batch
+	// Number of pending messages to inspect per stream when claiming
 	BatchSize int64
 
-	// How long to block waiting for new messages
+	// How long to block waiting for new jobs when all lanes are empty
 	BlockTimeout time.Duration
 
 	// Maximum number of retries for a job
@@ -78,6 +87,18 @@ type ProcessorConfig struct {
 
 	// Number of worker goroutines
 	WorkerCount int
+
+	// TTL of the plan/config lease held while an execution runs, renewed at a third of it
+	LeaseTTL time.Duration
+
+	// How long a job waits for the running execution of its plan/config before it is requeued
+	OverlapWait time.Duration
+
+	// Policies re-enqueueing DLQ entries automatically, by reason (none disables automatic retries)
+	DLQRetryPolicies []DLQRetryPolicy
+
+	// How often DLQ entries are checked against the retry policies
+	DLQRetryInterval time.Duration
 }
 
 // DefaultProcessorConfig returns the default processor configuration
@@ -88,8 +109,6 @@ func DefaultProcessorConfig() ProcessorConfig {
 	}
 
 	return ProcessorConfig{
-		Stream:        "orchid:jobs",
-		ConsumerGroup: "orchid-workers",
 		ConsumerName:  hostname,
 		BatchSize:     DefaultBatchSize,
 		BlockTimeout:  DefaultBlockTimeout,
@@ -97,6 +116,11 @@ func DefaultProcessorConfig() ProcessorConfig {
 		ClaimInterval: DefaultClaimInterval,
 		ClaimMinIdle:  DefaultClaimMinIdle,
 		WorkerCount:   1,
+		LeaseTTL:      DefaultLeaseTTL,
+		OverlapWait:   DefaultOverlapWait,
+
+		DLQRetryPolicies: DefaultDLQRetryPolicies(),
+		DLQRetryInterval: DefaultDLQRetryInterval,
 	}
 }
 
@@ -110,6 +134,7 @@ type PlanExecutionJob struct {
 	// Optional fields
 	ContextOverride   map[string]any `json:"context_override,omitempty"`
 	ParentExecutionID string         `json:"parent_execution_id,omitempty"`
+	Resume            bool           `json:"resume,omitempty"` // Continue from the checkpoint of ParentExecutionID
 	ScheduledAt       time.Time      `json:"scheduled_at,omitempty"`
 }
 
@@ -118,38 +143,65 @@ type JobResult struct {
 	JobID       string
 	MessageID   string
 	Success     bool
+	Requeued    bool // Moved to the back of its stream to wait for the running execution of its plan/config
 	Error       error
 	Duration    time.Duration
 	ExecutionID uuid.UUID
 }
 
-// Processor processes jobs from a Redis Streams queue
+// Processor processes jobs from the lanes of the job queue. Lanes are served by priority
+// and the tenants within a lane by weighted fair scheduling, one job per free worker.
 type Processor struct {
 	streams      *redis.Streams
+	queue        *redis.JobQueue
 	dlq          *redis.DeadLetterQueue
+	canceller    *redis.ExecutionCanceller
+	leases       leaseLocker
 	planExecutor *execution.PlanExecutor
 	config       ProcessorConfig
 	logger       ectologger.Logger
+	fair         *fairScheduler
 
 	// Channels for coordination
 	stopCh   chan struct{}
 	stoppedC chan struct{}
 	jobsCh   chan jobItem
+	slots    chan struct{} // One per busy worker, so jobs are only taken off the queue when a worker is free
 
 	// State
 	running bool
 	mu      sync.RWMutex
 }
 
+// leaseLocker acquires the plan/config leases (a *redis.Locker)
+type leaseLocker interface {
+	Acquire(ctx context.Context, key string, ttl time.Duration) (*redis.Lock, error)
+	AcquireAs(ctx context.Context, key, owner string, ttl time.Duration) (*redis.Lock, error)
+	TryAcquireAs(ctx context.Context, key, owner string, ttl time.Duration, timeout time.Duration) (*redis.Lock, error)
+	Owner(ctx context.Context, key string) (string, error)
+}
+
+// lease is a plan/config lease held by a running execution (a *redis.Lock)
+type lease interface {
+	Extend(ctx context.Context, ttl time.Duration) error
+	Release(ctx context.Context) error
+}
+
 type jobItem struct {
+	lane    redis.Lane
 	message redis.StreamMessage
 	job     *redis.JobMessage
 }
 
-// NewProcessor creates a new job processor
+// NewProcessor creates a new job processor.
+// Plan executions hold a lease from leases (a locker with LeaseKeyPrefix), so executions
+// of the same plan/config never overlap.
 func NewProcessor(
 	streams *redis.Streams,
+	jobQueue *redis.JobQueue,
 	dlq *redis.DeadLetterQueue,
+	canceller *redis.ExecutionCanceller,
+	leases *redis.Locker,
 	planExecutor *execution.PlanExecutor,
 	config ProcessorConfig,
 	logger ectologger.Logger,
@@ -173,17 +225,35 @@ func NewProcessor(
 	if config.WorkerCount <= 0 {
 		config.WorkerCount = 1
 	}
+	if config.LeaseTTL <= 0 {
+		config.LeaseTTL = DefaultLeaseTTL
+	}
+	if config.OverlapWait <= 0 {
+		config.OverlapWait = DefaultOverlapWait
+	}
+	if config.DLQRetryInterval <= 0 {
+		config.DLQRetryInterval = DefaultDLQRetryInterval
+	}
 
-	return &Processor{
+	p := &Processor{
 		streams:      streams,
+		queue:        jobQueue,
 		dlq:          dlq,
+		canceller:    canceller,
 		planExecutor: planExecutor,
 		config:       config,
 		logger:       logger,
+		fair:         newFairScheduler(),
 		stopCh:       make(chan struct{}),
 		stoppedC:     make(chan struct{}),
-		jobsCh:       make(chan jobItem, config.BatchSize*2),
+		jobsCh:       make(chan jobItem, config.WorkerCount),
+		slots:        make(chan struct{}, config.WorkerCount),
 	}
+	// Keep the interface nil without a locker, so leases stay disabled
+	if leases != nil {
+		p.leases = leases
+	}
+	return p
 }
 
 // Start starts the processor
@@ -199,35 +269,40 @@ func (p *Processor) Start(ctx context.Context) error {
 	ctx, span := tracing.StartSpan(ctx, "Processor.Start")
 	defer span.End()
 
-	p.logger.WithContext(ctx).Infof("Starting job processor: stream=%s group=%s consumer=%s workers=%d",
-		p.config.Stream, p.config.ConsumerGroup, p.config.ConsumerName, p.config.WorkerCount)
-
-	// Create consumer group if it doesn't exist
-	if err := p.streams.CreateConsumerGroup(ctx, p.config.Stream, p.config.ConsumerGroup); err != nil {
-		p.logger.WithContext(ctx).WithError(err).Error("Failed to create consumer group")
-		return fmt.Errorf("failed to create consumer group: %w", err)
-	}
+	// Consumer groups are created with the tenant streams when jobs are published
+	p.logger.WithContext(ctx).Infof("Starting job processor: queue=%s group=%s consumer=%s workers=%d",
+		p.queue.Prefix(), p.queue.ConsumerGroup(), p.config.ConsumerName, p.config.WorkerCount)
 
 	// Start workers
-	var wg sync.WaitGroup
+	var workers sync.WaitGroup
 	for i := 0; i < p.config.WorkerCount; i++ {
-		wg.Add(1)
-		go p.worker(ctx, &wg, i)
+		workers.Add(1)
+		go p.worker(ctx, &workers, i)
 	}
 
-	// Start consumer loop
-	wg.Add(1)
-	go p.consumeLoop(ctx, &wg)
+	// Start dispatch loop
+	var feeders sync.WaitGroup
+	feeders.Add(1)
+	go p.dispatchLoop(ctx, &feeders)
 
 	// Start claimer for stale messages
-	wg.Add(1)
-	go p.claimLoop(ctx, &wg)
+	feeders.Add(1)
+	go p.claimLoop(ctx, &feeders)
+
+	// Start listener for cancellations
+	feeders.Add(1)
+	go p.cancelLoop(ctx, &feeders)
+
+	// Start automatic retries of DLQ entries
+	feeders.Add(1)
+	go p.dlqRetryLoop(ctx, &feeders)
 
-	// Wait for stop signal
+	// Wait for stop signal; the jobs channel is closed once nothing sends to it anymore
 	go func() {
 		<-p.stopCh
+		feeders.Wait()
 		close(p.jobsCh)
-		wg.Wait()
+		workers.Wait()
 		close(p.stoppedC)
 	}()
 
@@ -268,58 +343,78 @@ func (p *Processor) IsRunning() bool {
 	return p.running
 }
 
-// consumeLoop continuously consumes messages from the stream
-func (p *Processor) consumeLoop(ctx context.Context, wg *sync.WaitGroup) {
+// dispatchLoop hands the next job to a worker whenever one is free
+func (p *Processor) dispatchLoop(ctx context.Context, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	p.logger.WithContext(ctx).Debug("Consumer loop started")
+	p.logger.WithContext(ctx).Debug("Dispatch loop started")
 
 	for {
+		// Wait for a free worker, so queued jobs stay available to fair scheduling until they can start
 		select {
+		case p.slots <- struct{}{}:
 		case <-p.stopCh:
-			p.logger.WithContext(ctx).Debug("Consumer loop stopping")
+			p.logger.WithContext(ctx).Debug("Dispatch loop stopping")
 			return
-		default:
 		}
 
-		// Consume messages
-		messages, err := p.streams.Consume(
-			ctx,
-			p.config.Stream,
-			p.config.ConsumerGroup,
-			p.config.ConsumerName,
-			p.config.BatchSize,
-			p.config.BlockTimeout,
-		)
+		item, ok := p.nextJob(ctx)
+		if !ok {
+			<-p.slots
+			if err := p.queue.Wait(ctx, p.config.BlockTimeout); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				p.logger.WithContext(ctx).WithError(err).Warn("Failed to wait for jobs")
+				time.Sleep(time.Second) // Back off on error
+			}
+			continue
+		}
+
+		select {
+		case p.jobsCh <- item:
+		case <-p.stopCh:
+			return
+		}
+	}
+}
 
+// nextJob takes the next job off the queue: the first lane with jobs, and within it
+// the tenant that is furthest behind its fair share
+func (p *Processor) nextJob(ctx context.Context) (jobItem, bool) {
+	for _, lane := range redis.Lanes {
+		tenants, err := p.queue.Tenants(ctx, lane)
 		if err != nil {
-			if ctx.Err() != nil {
-				return
-			}
-			p.logger.WithContext(ctx).WithError(err).Warn("Failed to consume messages")
-			time.Sleep(time.Second) // Back off on error
+			p.logger.WithContext(ctx).WithError(err).Warnf("Failed to list tenants of lane %s", lane)
 			continue
 		}
 
-		// Send messages to workers
-		for _, msg := range messages {
-			job, err := p.parseJobMessage(msg)
+		for _, tenantID := range p.fair.order(lane, tenants) {
+			msg, err := p.queue.Next(ctx, lane, tenantID, p.config.ConsumerName)
+			if err != nil {
+				p.logger.WithContext(ctx).WithError(err).Warnf("Failed to read lane %s of tenant %s", lane, tenantID)
+				continue
+			}
+			if msg == nil {
+				continue
+			}
+
+			job, err := p.parseJobMessage(*msg)
 			if err != nil {
 				p.logger.WithContext(ctx).WithError(err).Warnf("Failed to parse job message %s", msg.ID)
-				// Acknowledge invalid messages to prevent reprocessing
-				if ackErr := p.streams.Ack(ctx, p.config.Stream, p.config.ConsumerGroup, msg.ID); ackErr != nil {
+				// Remove invalid messages to prevent reprocessing
+				if ackErr := p.queue.Complete(ctx, *msg, "", ""); ackErr != nil {
 					p.logger.WithContext(ctx).WithError(ackErr).Warnf("Failed to ack invalid message %s", msg.ID)
 				}
 				continue
 			}
 
-			select {
-			case p.jobsCh <- jobItem{message: msg, job: job}:
-			case <-p.stopCh:
-				return
-			}
+			p.fair.served(lane, tenantID, p.queue.Weight(tenantID))
+			metrics.RecordQueueDispatch(string(lane))
+			return jobItem{lane: lane, message: *msg, job: job}, true
 		}
 	}
+	return jobItem{}, false
 }
 
 // claimLoop periodically claims stale pending messages
@@ -342,20 +437,36 @@ func (p *Processor) claimLoop(ctx context.Context, wg *sync.WaitGroup) {
 	}
 }
 
-// claimPendingMessages claims stale pending messages from other consumers
+// claimPendingMessages claims stale pending messages of every tenant stream
 func (p *Processor) claimPendingMessages(ctx context.Context) {
 	ctx, span := tracing.StartSpan(ctx, "Processor.claimPendingMessages")
 	defer span.End()
 
+	for _, lane := range redis.Lanes {
+		tenants, err := p.queue.Tenants(ctx, lane)
+		if err != nil {
+			p.logger.WithContext(ctx).WithError(err).Warnf("Failed to list tenants of lane %s", lane)
+			continue
+		}
+		for _, tenantID := range tenants {
+			if !p.claimStream(ctx, lane, p.queue.Stream(lane, tenantID)) {
+				return
+			}
+		}
+	}
+}
+
+// claimStream claims the stale pending messages of a stream, returning false when the processor stops
+func (p *Processor) claimStream(ctx context.Context, lane redis.Lane, stream string) bool {
 	// Get pending messages
-	pending, err := p.streams.Pending(ctx, p.config.Stream, p.config.ConsumerGroup, p.config.BatchSize)
+	pending, err := p.streams.Pending(ctx, stream, p.queue.ConsumerGroup(), p.config.BatchSize)
 	if err != nil {
-		p.logger.WithContext(ctx).WithError(err).Warn("Failed to get pending messages")
-		return
+		p.logger.WithContext(ctx).WithError(err).Warnf("Failed to get pending messages of %s", stream)
+		return true
 	}
 
 	if len(pending) == 0 {
-		return
+		return true
 	}
 
 	// Filter messages that have been idle long enough
@@ -368,25 +479,35 @@ func (p *Processor) claimPendingMessages(ctx context.Context) {
 			} else {
 				p.logger.WithContext(ctx).Warnf("Message %s exceeded max retries (%d), moving to DLQ", msg.ID, msg.RetryCount)
 				// Move to dead letter queue
-				p.moveToDLQ(ctx, msg.ID, int(msg.RetryCount), models.DLQReasonMaxRetries, "exceeded maximum retry count")
+				p.moveToDLQ(ctx, stream, msg.ID, int(msg.RetryCount), models.DLQReasonMaxRetries, "exceeded maximum retry count")
 			}
 		}
 	}
 
 	if len(staleIDs) == 0 {
-		return
+		return true
+	}
+
+	// Take a free worker for every message before claiming it: XCLAIM counts a delivery, so a message
+	// claimed without a worker to run it would move toward the DLQ without ever running
+	free := p.reserveSlots(len(staleIDs))
+	if free == 0 {
+		// No free worker, the messages are claimed later
+		return true
 	}
+	staleIDs = staleIDs[:free]
 
-	p.logger.WithContext(ctx).Infof("Claiming %d stale pending messages", len(staleIDs))
+	p.logger.WithContext(ctx).Infof("Claiming %d stale pending messages of %s", len(staleIDs), stream)
 
 	// Claim the messages
-	claimed, err := p.streams.Claim(ctx, p.config.Stream, p.config.ConsumerGroup, p.config.ConsumerName, p.config.ClaimMinIdle, staleIDs...)
+	claimed, err := p.streams.Claim(ctx, stream, p.queue.ConsumerGroup(), p.config.ConsumerName, p.config.ClaimMinIdle, staleIDs...)
 	if err != nil {
 		p.logger.WithContext(ctx).WithError(err).Warn("Failed to claim pending messages")
-		return
+		p.releaseSlots(free)
+		return true
 	}
 
-	// Send claimed messages to workers
+	// Send claimed messages to the reserved workers
 	for _, msg := range claimed {
 		job, err := p.parseJobMessage(msg)
 		if err != nil {
@@ -395,11 +516,74 @@ func (p *Processor) claimPendingMessages(ctx context.Context) {
 		}
 
 		select {
-		case p.jobsCh <- jobItem{message: msg, job: job}:
+		case p.jobsCh <- jobItem{lane: lane, message: msg, job: job}:
+			free--
 		case <-p.stopCh:
-			return
+			p.releaseSlots(free)
+			return false
+		}
+	}
+
+	// Workers reserved for messages another consumer claimed first, or that could not be parsed
+	p.releaseSlots(free)
+	return true
+}
+
+// reserveSlots takes up to n free workers without waiting, returning how many it took
+func (p *Processor) reserveSlots(n int) int {
+	for taken := 0; taken < n; taken++ {
+		select {
+		case p.slots <- struct{}{}:
 		default:
-			// Channel full, skip for now
+			return taken
+		}
+	}
+	return n
+}
+
+// releaseSlots frees n workers taken by reserveSlots
+func (p *Processor) releaseSlots(n int) {
+	for ; n > 0; n-- {
+		<-p.slots
+	}
+}
+
+// cancelLoop cancels the executions running on this processor when they are cancelled through the API
+func (p *Processor) cancelLoop(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	if p.canceller == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-p.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	p.logger.WithContext(ctx).Debug("Cancel loop started")
+
+	for {
+		err := p.canceller.Listen(ctx, func(ctx context.Context, executionID uuid.UUID) {
+			if p.planExecutor.Cancel(executionID) {
+				p.logger.WithContext(ctx).Infof("Cancelling execution %s", executionID)
+			}
+		})
+		if ctx.Err() != nil {
+			p.logger.WithContext(ctx).Debug("Cancel loop stopping")
+			return
+		}
+
+		p.logger.WithContext(ctx).WithError(err).Warn("Cancellation subscription ended, resubscribing")
+		select {
+		case <-time.After(time.Second): // Back off on error
+		case <-ctx.Done():
+			return
 		}
 	}
 }
@@ -411,17 +595,25 @@ func (p *Processor) worker(ctx context.Context, wg *sync.WaitGroup, id int) {
 	p.logger.WithContext(ctx).Debugf("Worker %d started", id)
 
 	for item := range p.jobsCh {
+		metrics.QueueJobsInFlight.Inc()
 		result := p.processJob(ctx, item)
+		metrics.QueueJobsInFlight.Dec()
 
-		if result.Success {
-			// Acknowledge successful job
-			if err := p.streams.Ack(ctx, p.config.Stream, p.config.ConsumerGroup, item.message.ID); err != nil {
+		if result.Requeued {
+			metrics.RecordQueueJob("requeued")
+		} else if result.Success {
+			// Acknowledge successful job and release its slot in the tenant's cap
+			if err := p.queue.Complete(ctx, item.message, item.job.TenantID, item.job.ID); err != nil {
 				p.logger.WithContext(ctx).WithError(err).Warnf("Failed to ack message %s", item.message.ID)
 			}
+			metrics.RecordQueueJob("success")
 		} else {
 			// Log failure - message will be reclaimed after ClaimMinIdle
 			p.logger.WithContext(ctx).WithError(result.Error).Warnf("Job %s failed, will be retried", result.JobID)
+			p.recordFailure(ctx, item, result.Error)
+			metrics.RecordQueueJob("failed")
 		}
+		<-p.slots
 	}
 
 	p.logger.WithContext(ctx).Debugf("Worker %d stopped", id)
@@ -446,7 +638,7 @@ func (p *Processor) processJob(ctx context.Context, item jobItem) *JobResult {
 
 	switch item.job.Type {
 	case JobTypePlanExecution:
-		err := p.processPlanExecution(ctx, item.job, result)
+		err := p.processPlanExecution(ctx, item, result)
 		if err != nil {
 			result.Error = err
 			result.Success = false
@@ -461,7 +653,9 @@ func (p *Processor) processJob(ctx context.Context, item jobItem) *JobResult {
 
 	result.Duration = time.Since(start)
 
-	if result.Success {
+	if result.Requeued {
+		p.logger.WithContext(ctx).Infof("Job %s requeued after %s", item.job.ID, result.Duration)
+	} else if result.Success {
 		p.logger.WithContext(ctx).Infof("Job %s completed successfully in %s", item.job.ID, result.Duration)
 	} else {
 		p.logger.WithContext(ctx).WithError(result.Error).Warnf("Job %s failed after %s", item.job.ID, result.Duration)
@@ -471,10 +665,12 @@ func (p *Processor) processJob(ctx context.Context, item jobItem) *JobResult {
 }
 
 // processPlanExecution processes a plan execution job
-func (p *Processor) processPlanExecution(ctx context.Context, job *redis.JobMessage, result *JobResult) error {
+func (p *Processor) processPlanExecution(ctx context.Context, item jobItem, result *JobResult) error {
 	ctx, span := tracing.StartSpan(ctx, "Processor.processPlanExecution")
 	defer span.End()
 
+	job := item.job
+
 	// Parse job payload
 	payloadBytes, err := json.Marshal(job.Payload)
 	if err != nil {
@@ -509,11 +705,13 @@ func (p *Processor) processPlanExecution(ctx context.Context, job *redis.JobMess
 
 	// Build execution input
 	input := execution.PlanExecutionInput{
+		ExecutionID:     uuid.New(),
 		PlanKey:         planKey,
 		Integration:     execJob.Integration,
 		ConfigID:        configID,
 		TenantID:        tenantID,
 		ContextOverride: execJob.ContextOverride,
+		Resume:          execJob.Resume,
 	}
 
 	if execJob.ParentExecutionID != "" {
@@ -522,9 +720,47 @@ func (p *Processor) processPlanExecution(ctx context.Context, job *redis.JobMess
 			input.ParentExecutionID = &parentID
 		}
 	}
+	if input.Resume && input.ParentExecutionID == nil {
+		return httperror.NewHTTPErrorf(http.StatusBadRequest, "%v: resume requires parent_execution_id", ErrInvalidJobMessage)
+	}
+
+	// Hold the lease of the plan/config while the plan executes; the execution is cancelled when the
+	// lease is lost, as another execution of the plan/config may start
+	execCtx, cancelExec := context.WithCancelCause(ctx)
+	defer cancelExec(nil)
+	if p.leases != nil {
+		lease, err := p.acquireLease(ctx, item, input)
+		if errors.Is(err, errLeaseBusy) {
+			if _, err := p.queue.Requeue(ctx, item.lane, item.message, item.job); err != nil {
+				return err
+			}
+			result.Requeued = true
+			p.logger.WithContext(ctx).Infof("Requeued job %s: plan %s still has an execution running for config %s",
+				item.job.ID, input.PlanKey, input.ConfigID)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if lease == nil {
+			return nil
+		}
+		defer p.holdLease(ctx, lease, func() {
+			cancelExec(fmt.Errorf("%w: %w", execution.ErrExecutionCancelled, ErrLeaseLost))
+		})()
+	}
 
 	// Execute the plan
-	output, err := p.planExecutor.Execute(ctx, input)
+	output, err := p.planExecutor.Execute(execCtx, input)
+	if errors.Is(err, execution.ErrExecutionCancelled) {
+		// Cancelled executions are done, not retried
+		if errors.Is(context.Cause(execCtx), ErrLeaseLost) {
+			p.logger.WithContext(ctx).Warnf("Execution %s was cancelled: its lease was lost", output.ExecutionID)
+		} else {
+			p.logger.WithContext(ctx).Infof("Execution %s was cancelled", output.ExecutionID)
+		}
+		err = nil
+	}
 	if err != nil {
 		return err
 	}
@@ -533,6 +769,104 @@ func (p *Processor) processPlanExecution(ctx context.Context, job *redis.JobMess
 	return nil
 }
 
+// leaseKey returns the key of the lease held by the execution of a plan/config
+func leaseKey(input execution.PlanExecutionInput) string {
+	return fmt.Sprintf("%s:%s:%s", input.TenantID, input.PlanKey, input.ConfigID)
+}
+
+// acquireLease acquires the lease of the job's plan/config on behalf of its execution, applying the plan's
+// overlap policy when another execution holds it. It returns nil when the job is skipped (the job is acked),
+// and errLeaseBusy when the running execution did not end in time and the job must be requeued.
+func (p *Processor) acquireLease(ctx context.Context, item jobItem, input execution.PlanExecutionInput) (*redis.Lock, error) {
+	key := leaseKey(input)
+	owner := input.ExecutionID.String()
+
+	lease, err := p.leases.AcquireAs(ctx, key, owner, p.config.LeaseTTL)
+	if !errors.Is(err, redis.ErrLockNotAcquired) {
+		return lease, err
+	}
+
+	policy, err := p.planExecutor.OverlapPolicy(ctx, input.PlanKey)
+	if err != nil {
+		p.logger.WithContext(ctx).WithError(err).Warnf("Failed to get overlap policy of plan %s, using %s", input.PlanKey, models.DefaultOverlapPolicy)
+		policy = models.DefaultOverlapPolicy
+	}
+	metrics.RecordQueueOverlap(string(policy))
+
+	switch policy {
+	case models.OverlapPolicySkip:
+		p.logger.WithContext(ctx).Infof("Skipping job %s: plan %s already has an execution running for config %s",
+			item.job.ID, input.PlanKey, input.ConfigID)
+		return nil, nil
+
+	case models.OverlapPolicyCancelPrevious:
+		running, err := p.leases.Owner(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if executionID, err := uuid.Parse(running); err == nil && p.canceller != nil {
+			p.logger.WithContext(ctx).Infof("Job %s cancels execution %s of plan %s", item.job.ID, executionID, input.PlanKey)
+			if _, err := p.canceller.Cancel(ctx, executionID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Wait for the running execution to end; when it doesn't in time, the job goes behind the tenant's other jobs
+	lease, err = p.leases.TryAcquireAs(ctx, key, owner, p.config.LeaseTTL, p.config.OverlapWait)
+	if errors.Is(err, redis.ErrLockNotAcquired) {
+		return nil, errLeaseBusy
+	}
+	return lease, err
+}
+
+// holdLease renews a lease in the background until the returned function releases it.
+// lost is called when the lease expired, or could not be renewed for as long as its TTL.
+func (p *Processor) holdLease(ctx context.Context, lease lease, lost func()) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(p.config.LeaseTTL / 3)
+		defer ticker.Stop()
+
+		renewed := time.Now()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				err := lease.Extend(ctx, p.config.LeaseTTL)
+				if errors.Is(err, redis.ErrLockNotHeld) {
+					p.logger.WithContext(ctx).Warn("Execution lease expired, cancelling the execution")
+					lost()
+					return
+				}
+				if err != nil && time.Since(renewed) >= p.config.LeaseTTL {
+					p.logger.WithContext(ctx).WithError(err).Warn("Failed to renew execution lease before it expired, cancelling the execution")
+					lost()
+					return
+				}
+				if err != nil {
+					p.logger.WithContext(ctx).WithError(err).Warn("Failed to renew execution lease")
+					continue
+				}
+				renewed = time.Now()
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+		if err := lease.Release(ctx); err != nil && !errors.Is(err, redis.ErrLockNotHeld) {
+			p.logger.WithContext(ctx).WithError(err).Warn("Failed to release execution lease")
+		}
+	}
+}
+
 // parseJobMessage parses a stream message into a JobMessage
 func (p *Processor) parseJobMessage(msg redis.StreamMessage) (*redis.JobMessage, error) {
 	// The payload should already be the job structure
@@ -549,8 +883,9 @@ func (p *Processor) parseJobMessage(msg redis.StreamMessage) (*redis.JobMessage,
 	return &job, nil
 }
 
-// PublishPlanExecution publishes a plan execution job to the queue
-func PublishPlanExecution(ctx context.Context, streams *redis.Streams, stream string, job PlanExecutionJob) (string, error) {
+// PublishPlanExecution publishes a plan execution job to a lane of the queue.
+// It fails with redis.ErrTenantAtCapacity when the tenant already has its maximum number of jobs queued or running.
+func PublishPlanExecution(ctx context.Context, jobQueue *redis.JobQueue, lane redis.Lane, job PlanExecutionJob) (string, error) {
 	msg := &redis.JobMessage{
 		ID:        uuid.New().String(),
 		TenantID:  job.TenantID,
@@ -563,24 +898,29 @@ func PublishPlanExecution(ctx context.Context, streams *redis.Streams, stream st
 			"tenant_id":           job.TenantID,
 			"context_override":    job.ContextOverride,
 			"parent_execution_id": job.ParentExecutionID,
+			"resume":              job.Resume,
 			"scheduled_at":        job.ScheduledAt,
 		},
 	}
 
-	return streams.Publish(ctx, stream, msg)
+	messageID, err := jobQueue.Publish(ctx, lane, msg)
+	if errors.Is(err, redis.ErrTenantAtCapacity) {
+		metrics.RecordQueueRejection(job.TenantID, string(lane))
+	}
+	return messageID, err
 }
 
-// moveToDLQ moves a failed job to the dead letter queue
-func (p *Processor) moveToDLQ(ctx context.Context, messageID string, retryCount int, reason models.DeadLetterReason, errorMsg string) {
+// moveToDLQ moves a failed job of a stream to the dead letter queue
+func (p *Processor) moveToDLQ(ctx context.Context, stream, messageID string, retryCount int, reason models.DeadLetterReason, errorMsg string) {
 	ctx, span := tracing.StartSpan(ctx, "Processor.moveToDLQ")
 	defer span.End()
 
 	// Get the original message to store in DLQ
-	messages, err := p.streams.Range(ctx, p.config.Stream, messageID, messageID)
+	messages, err := p.streams.Range(ctx, stream, messageID, messageID)
 	if err != nil || len(messages) == 0 {
 		p.logger.WithContext(ctx).WithError(err).Warnf("Failed to get message %s for DLQ", messageID)
 		// Still ack the message to prevent infinite retries
-		if ackErr := p.streams.Ack(ctx, p.config.Stream, p.config.ConsumerGroup, messageID); ackErr != nil {
+		if ackErr := p.queue.Complete(ctx, redis.StreamMessage{ID: messageID, Stream: stream}, "", ""); ackErr != nil {
 			p.logger.WithContext(ctx).WithError(ackErr).Warnf("Failed to ack failed message %s", messageID)
 		}
 		return
@@ -590,7 +930,7 @@ func (p *Processor) moveToDLQ(ctx context.Context, messageID string, retryCount
 	job, err := p.parseJobMessage(msg)
 	if err != nil {
 		p.logger.WithContext(ctx).WithError(err).Warnf("Failed to parse message %s for DLQ", messageID)
-		if ackErr := p.streams.Ack(ctx, p.config.Stream, p.config.ConsumerGroup, messageID); ackErr != nil {
+		if ackErr := p.queue.Complete(ctx, msg, "", ""); ackErr != nil {
 			p.logger.WithContext(ctx).WithError(ackErr).Warnf("Failed to ack failed message %s", messageID)
 		}
 		return
@@ -610,6 +950,16 @@ func (p *Processor) moveToDLQ(ctx context.Context, messageID string, retryCount
 
 	// Add to DLQ if available
 	if p.dlq != nil {
+		// Jobs that ran out of retries keep the reason of their last failure
+		failure, err := p.dlq.TakeFailure(ctx, stream, messageID)
+		if err != nil {
+			p.logger.WithContext(ctx).WithError(err).Warnf("Failed to get last failure of message %s", messageID)
+		}
+		if failure != nil && reason == models.DLQReasonMaxRetries {
+			reason = failure.Reason
+			errorMsg = fmt.Sprintf("%s: %s", errorMsg, failure.ErrorMessage)
+		}
+
 		entry := &redis.DLQEntry{
 			TenantID:     job.TenantID,
 			PlanKey:      planKey,
@@ -618,6 +968,7 @@ func (p *Processor) moveToDLQ(ctx context.Context, messageID string, retryCount
 			Reason:       reason,
 			ErrorMessage: errorMsg,
 			RetryCount:   retryCount,
+			AutoRetries:  job.DLQRetries,
 		}
 
 		if _, dlqErr := p.dlq.Add(ctx, entry); dlqErr != nil {
@@ -628,8 +979,8 @@ func (p *Processor) moveToDLQ(ctx context.Context, messageID string, retryCount
 		}
 	}
 
-	// Ack the original message
-	if ackErr := p.streams.Ack(ctx, p.config.Stream, p.config.ConsumerGroup, messageID); ackErr != nil {
+	// Ack the original message and release its slot in the tenant's cap
+	if ackErr := p.queue.Complete(ctx, msg, job.TenantID, job.ID); ackErr != nil {
 		p.logger.WithContext(ctx).WithError(ackErr).Warnf("Failed to ack message %s after DLQ", messageID)
 	}
 }
@@ -0,0 +1,211 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Gobusters/ectologger/zapadapter"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/Ramsey-B/orchid/pkg/execution"
+	"github.com/Ramsey-B/orchid/pkg/models"
+	"github.com/Ramsey-B/orchid/pkg/redis"
+	"github.com/Ramsey-B/orchid/pkg/repositories"
+)
+
+// fakeLeases hands out leases from memory; a held lease is only freed when release is set
+type fakeLeases struct {
+	mu      sync.Mutex
+	owners  map[string]string
+	waited  bool
+	release bool // TryAcquireAs finds the lease released
+}
+
+func (f *fakeLeases) Acquire(ctx context.Context, key string, ttl time.Duration) (*redis.Lock, error) {
+	return f.AcquireAs(ctx, key, uuid.New().String(), ttl)
+}
+
+func (f *fakeLeases) AcquireAs(ctx context.Context, key, owner string, ttl time.Duration) (*redis.Lock, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, held := f.owners[key]; held {
+		return nil, redis.ErrLockNotAcquired
+	}
+	f.owners[key] = owner
+	return &redis.Lock{}, nil
+}
+
+func (f *fakeLeases) TryAcquireAs(ctx context.Context, key, owner string, ttl time.Duration, timeout time.Duration) (*redis.Lock, error) {
+	f.mu.Lock()
+	f.waited = true
+	if f.release {
+		delete(f.owners, key)
+	}
+	f.mu.Unlock()
+	return f.AcquireAs(ctx, key, owner, ttl)
+}
+
+func (f *fakeLeases) Owner(ctx context.Context, key string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.owners[key], nil
+}
+
+// fakeLease counts renewals and fails them with err
+type fakeLease struct {
+	mu       sync.Mutex
+	extended int
+	released bool
+	err      error
+}
+
+func (f *fakeLease) Extend(ctx context.Context, ttl time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.extended++
+	return nil
+}
+
+func (f *fakeLease) Release(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.released = true
+	return nil
+}
+
+// fakePlans returns a plan with a fixed overlap policy
+type fakePlans struct {
+	repositories.PlanRepo
+	policy models.OverlapPolicy
+}
+
+func (f *fakePlans) GetByKey(ctx context.Context, key string) (*models.Plan, error) {
+	return &models.Plan{Key: key, OverlapPolicy: f.policy}, nil
+}
+
+func newLeaseTestProcessor(leases *fakeLeases, policy models.OverlapPolicy) *Processor {
+	zapLogger, _ := zap.NewDevelopment()
+	logger := zapadapter.NewZapEctoLogger(zapLogger, nil)
+	planExecutor := execution.NewPlanExecutor(&fakePlans{policy: policy}, nil, nil, nil, nil, nil, nil, nil, nil,
+		nil, nil, nil, nil, nil, nil, nil, nil, execution.DefaultPlanExecutorConfig(), logger)
+
+	config := DefaultProcessorConfig()
+	config.LeaseTTL = 30 * time.Millisecond
+	p := NewProcessor(nil, nil, nil, nil, nil, planExecutor, config, logger)
+	p.leases = leases
+	return p
+}
+
+func leaseTestInput() execution.PlanExecutionInput {
+	return execution.PlanExecutionInput{
+		ExecutionID: uuid.New(),
+		PlanKey:     "contacts",
+		ConfigID:    uuid.New(),
+		TenantID:    uuid.New(),
+	}
+}
+
+func TestAcquireLease_Free(t *testing.T) {
+	leases := &fakeLeases{owners: map[string]string{}}
+	p := newLeaseTestProcessor(leases, models.OverlapPolicySkip)
+	input := leaseTestInput()
+
+	lease, err := p.acquireLease(context.Background(), jobItem{job: &redis.JobMessage{ID: "job-1"}}, input)
+	require.NoError(t, err)
+	require.NotNil(t, lease)
+	require.Equal(t, input.ExecutionID.String(), leases.owners[leaseKey(input)])
+	require.False(t, leases.waited)
+}
+
+func TestAcquireLease_SkipWhileHeld(t *testing.T) {
+	input := leaseTestInput()
+	leases := &fakeLeases{owners: map[string]string{leaseKey(input): uuid.New().String()}}
+	p := newLeaseTestProcessor(leases, models.OverlapPolicySkip)
+
+	lease, err := p.acquireLease(context.Background(), jobItem{job: &redis.JobMessage{ID: "job-1"}}, input)
+	require.NoError(t, err)
+	require.Nil(t, lease)
+	require.False(t, leases.waited)
+}
+
+func TestAcquireLease_RequeueWhileHeld(t *testing.T) {
+	input := leaseTestInput()
+	running := uuid.New().String()
+	leases := &fakeLeases{owners: map[string]string{leaseKey(input): running}}
+	p := newLeaseTestProcessor(leases, models.OverlapPolicyQueue)
+
+	// The running execution does not end within the overlap wait
+	lease, err := p.acquireLease(context.Background(), jobItem{job: &redis.JobMessage{ID: "job-1"}}, input)
+	require.ErrorIs(t, err, errLeaseBusy)
+	require.Nil(t, lease)
+	require.True(t, leases.waited)
+	require.Equal(t, running, leases.owners[leaseKey(input)])
+
+	// It ends within the overlap wait
+	leases.release = true
+	lease, err = p.acquireLease(context.Background(), jobItem{job: &redis.JobMessage{ID: "job-1"}}, input)
+	require.NoError(t, err)
+	require.NotNil(t, lease)
+	require.Equal(t, input.ExecutionID.String(), leases.owners[leaseKey(input)])
+}
+
+func TestHoldLease_Renews(t *testing.T) {
+	p := newLeaseTestProcessor(&fakeLeases{owners: map[string]string{}}, models.OverlapPolicyQueue)
+	held := &fakeLease{}
+	var lost atomic.Bool
+
+	release := p.holdLease(context.Background(), held, func() { lost.Store(true) })
+	time.Sleep(100 * time.Millisecond)
+	release()
+
+	require.GreaterOrEqual(t, held.extended, 2)
+	require.True(t, held.released)
+	require.False(t, lost.Load())
+}
+
+func TestHoldLease_CancelsWhenLost(t *testing.T) {
+	p := newLeaseTestProcessor(&fakeLeases{owners: map[string]string{}}, models.OverlapPolicyQueue)
+
+	for name, err := range map[string]error{
+		"expired":          redis.ErrLockNotHeld,
+		"renewals failing": errors.New("connection refused"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			held := &fakeLease{err: err}
+			lost := make(chan struct{})
+
+			release := p.holdLease(context.Background(), held, func() { close(lost) })
+			select {
+			case <-lost:
+			case <-time.After(time.Second):
+				t.Fatal("lost lease did not cancel the execution")
+			}
+			release()
+			require.True(t, held.released)
+		})
+	}
+}
+
+func TestReserveSlots_OnlyFreeWorkers(t *testing.T) {
+	config := DefaultProcessorConfig()
+	config.WorkerCount = 3
+	p := NewProcessor(nil, nil, nil, nil, nil, nil, config, nil)
+
+	// One worker is busy, so only two stale messages may be claimed
+	p.slots <- struct{}{}
+	require.Equal(t, 2, p.reserveSlots(5))
+	require.Equal(t, 0, p.reserveSlots(1))
+
+	p.releaseSlots(2)
+	require.Equal(t, 1, p.reserveSlots(1))
+	require.Len(t, p.slots, 2)
+}
@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/Ramsey-B/orchid/pkg/metrics"
+	"github.com/Ramsey-B/orchid/pkg/models"
+	"github.com/Ramsey-B/orchid/pkg/redis"
+	"github.com/Ramsey-B/stem/pkg/tracing"
+)
+
+const (
+	// DefaultAdaptiveDecreaseFactor is the factor the learned rate is multiplied by on an error
+	DefaultAdaptiveDecreaseFactor = 0.5
+
+	// DefaultAdaptiveIncreaseStep is the number of requests added to the learned rate per window without errors
+	DefaultAdaptiveIncreaseStep = 1
+)
+
+// adaptiveRate returns the tuning of the learned rate of an adaptive limit, with defaults applied
+func adaptiveRate(limit models.RateLimitConfig) redis.AdaptiveRate {
+	adaptive := limit.Adaptive
+	rate := redis.AdaptiveRate{
+		Max:            int64(limit.Requests),
+		Min:            int64(adaptive.MinRequests),
+		IncreaseStep:   int64(adaptive.IncreaseStep),
+		DecreaseFactor: adaptive.DecreaseFactor,
+		Window:         time.Duration(limit.WindowSecs) * time.Second,
+		Retention:      BucketRetention,
+	}
+	if rate.Min <= 0 {
+		rate.Min = 1
+	}
+	if rate.Min > rate.Max {
+		rate.Min = rate.Max
+	}
+	if rate.IncreaseStep <= 0 {
+		rate.IncreaseStep = DefaultAdaptiveIncreaseStep
+	}
+	if rate.DecreaseFactor <= 0 || rate.DecreaseFactor >= 1 {
+		rate.DecreaseFactor = DefaultAdaptiveDecreaseFactor
+	}
+	return rate
+}
+
+// isOverloaded reports whether a response status tells the API is over capacity
+func isOverloaded(statusCode int) bool {
+	return statusCode == 429 || statusCode >= 500
+}
+
+// applyAdaptive returns the limit with the learned rate of its bucket applied
+func (m *Manager) applyAdaptive(ctx context.Context, key string, limit models.RateLimitConfig) models.RateLimitConfig {
+	if limit.Adaptive == nil {
+		return limit
+	}
+	learned, err := m.limiter.LearnedRate(ctx, key)
+	if err != nil {
+		m.logger.WithContext(ctx).WithError(err).Warnf("Failed to get learned rate of %s", key)
+		return limit
+	}
+	return withLearnedRate(limit, learned)
+}
+
+// withLearnedRate returns the limit with its requests lowered to the learned rate (0 means none was learned)
+func withLearnedRate(limit models.RateLimitConfig, learned int64) models.RateLimitConfig {
+	if learned > 0 && learned < int64(limit.Requests) {
+		limit.Requests = int(learned)
+	}
+	return limit
+}
+
+// Observe adapts the learned rate of the adaptive limits matching a request to its response status.
+// 429 and 5xx responses decrease the rate; successful responses let it recover.
+func (m *Manager) Observe(ctx context.Context, req CheckRequest, statusCode int) {
+	failed := isOverloaded(statusCode)
+	if !failed && (statusCode < 200 || statusCode >= 400) {
+		return
+	}
+
+	for _, limit := range m.findMatchingLimits(req.URL, req.RateLimits) {
+		if limit.Adaptive == nil {
+			continue
+		}
+		m.adapt(ctx, req, limit, failed)
+	}
+}
+
+// adapt applies one outcome to the learned rate of an adaptive limit
+func (m *Manager) adapt(ctx context.Context, req CheckRequest, limit models.RateLimitConfig, failed bool) {
+	ctx, span := tracing.StartSpan(ctx, "RateLimitManager.adapt")
+	defer span.End()
+
+	key := m.buildKey(req, limit)
+	// The learned rate stays bounded by the configured rate, or by an active override of it
+	limit = m.applyOverride(ctx, key, limit)
+
+	rate, err := m.limiter.Adapt(ctx, key, adaptiveRate(limit), failed)
+	if err != nil {
+		m.logger.WithContext(ctx).WithError(err).Warnf("Failed to adapt rate of %s", key)
+		return
+	}
+	if failed {
+		m.logger.WithContext(ctx).Debugf("Adaptive rate limit %s: %d requests per %ds after error", limit.Name, rate, limit.WindowSecs)
+	}
+	metrics.RecordRateLimitAdaptiveRate(req.TenantID.String(), limit.Name, float64(rate))
+}
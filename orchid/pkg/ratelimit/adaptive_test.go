@@ -0,0 +1,43 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+)
+
+func TestAdaptiveRate_Defaults(t *testing.T) {
+	limit := models.RateLimitConfig{Name: "api", Requests: 100, WindowSecs: 60, Adaptive: &models.AdaptiveRateLimit{}}
+
+	rate := adaptiveRate(limit)
+	require.Equal(t, int64(100), rate.Max)
+	require.Equal(t, int64(1), rate.Min)
+	require.Equal(t, int64(DefaultAdaptiveIncreaseStep), rate.IncreaseStep)
+	require.Equal(t, DefaultAdaptiveDecreaseFactor, rate.DecreaseFactor)
+	require.Equal(t, time.Minute, rate.Window)
+
+	limit.Adaptive = &models.AdaptiveRateLimit{MinRequests: 10, IncreaseStep: 5, DecreaseFactor: 0.7}
+	rate = adaptiveRate(limit)
+	require.Equal(t, int64(10), rate.Min)
+	require.Equal(t, int64(5), rate.IncreaseStep)
+	require.Equal(t, 0.7, rate.DecreaseFactor)
+}
+
+func TestWithLearnedRate(t *testing.T) {
+	limit := models.RateLimitConfig{Name: "api", Requests: 100, WindowSecs: 60}
+
+	require.Equal(t, 100, withLearnedRate(limit, 0).Requests)
+	require.Equal(t, 25, withLearnedRate(limit, 25).Requests)
+	// The learned rate never exceeds the configured (or overridden) rate
+	require.Equal(t, 100, withLearnedRate(limit, 400).Requests)
+}
+
+func TestIsOverloaded(t *testing.T) {
+	require.True(t, isOverloaded(429))
+	require.True(t, isOverloaded(503))
+	require.False(t, isOverloaded(200))
+	require.False(t, isOverloaded(404))
+}
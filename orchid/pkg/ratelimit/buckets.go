@@ -0,0 +1,280 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+	"github.com/Ramsey-B/orchid/pkg/redis"
+	"github.com/Ramsey-B/stem/pkg/tracing"
+)
+
+const (
+	// BucketRetention is how long a bucket without requests stays listed
+	BucketRetention = 24 * time.Hour
+
+	// bucketRegisterInterval is how often a check refreshes the registration of a bucket
+	bucketRegisterInterval = time.Minute
+
+	// maxRegisteredBuckets bounds the registrations remembered in memory
+	maxRegisteredBuckets = 10000
+)
+
+// ErrBucketNotFound is returned when a bucket is not registered for the tenant
+var ErrBucketNotFound = errors.New("rate limit bucket not found")
+
+// BucketStatus is the current state of a rate limit bucket.
+// Requests, WindowSecs and MaxConcurrent are the configured limits; an active Override replaces them.
+type BucketStatus struct {
+	redis.RateLimitBucket
+
+	Remaining       int64                    `json:"remaining"`                  // Requests left in the window
+	ResetAt         *time.Time               `json:"reset_at,omitempty"`         // When the oldest request leaves the window
+	InFlight        int64                    `json:"in_flight"`                  // Concurrency slots in use
+	BlockedUntil    *time.Time               `json:"blocked_until,omitempty"`    // End of a dynamic block (e.g. Retry-After)
+	LearnedRequests int64                    `json:"learned_requests,omitempty"` // Rate an adaptive limit backed off to (0 when at the configured rate)
+	Override        *redis.RateLimitOverride `json:"override,omitempty"`
+}
+
+// bucketID returns the ID of a bucket key, safe to use in URLs
+func bucketID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+// registerBucket records the bucket of a check so it can be listed, at most once per interval
+func (m *Manager) registerBucket(ctx context.Context, req CheckRequest, limit models.RateLimitConfig, key string) {
+	now := time.Now()
+
+	m.registeredMu.Lock()
+	if last, ok := m.registered[key]; ok && now.Sub(last) < bucketRegisterInterval {
+		m.registeredMu.Unlock()
+		return
+	}
+	if len(m.registered) >= maxRegisteredBuckets {
+		m.registered = make(map[string]time.Time)
+	}
+	m.registered[key] = now
+	m.registeredMu.Unlock()
+
+	bucket := redis.RateLimitBucket{
+		ID:            bucketID(key),
+		Key:           key,
+		TenantID:      req.TenantID.String(),
+		IntegrationID: req.IntegrationID.String(),
+		LimitName:     limit.Name,
+		Level:         string(limit.Level),
+		Scope:         limit.Scope,
+		Requests:      limit.Requests,
+		WindowSecs:    limit.WindowSecs,
+		MaxConcurrent: limit.MaxConcurrent,
+		Adaptive:      limit.Adaptive != nil,
+		LastUsedAt:    now,
+	}
+	if bucket.Level == "" {
+		bucket.Level = "plan"
+	}
+	if bucket.Scope == "" {
+		bucket.Scope = "global"
+	}
+	if limit.Level == models.RateLimitLevelConfig {
+		bucket.ConfigID = req.ConfigID.String()
+	}
+	switch bucket.Scope {
+	case "per_config":
+		bucket.ConfigID = req.ConfigID.String()
+	case "per_endpoint":
+		bucket.Endpoint = limit.Endpoint
+	}
+
+	if err := m.limiter.RegisterBucket(ctx, bucket, BucketRetention); err != nil {
+		m.logger.WithContext(ctx).WithError(err).Warnf("Failed to register rate limit bucket %s", key)
+	}
+}
+
+// applyOverride returns the limit with the active override of its bucket applied
+func (m *Manager) applyOverride(ctx context.Context, key string, limit models.RateLimitConfig) models.RateLimitConfig {
+	override, err := m.limiter.Override(ctx, key)
+	if err != nil {
+		m.logger.WithContext(ctx).WithError(err).Warnf("Failed to get rate limit override of %s", key)
+		return limit
+	}
+	return withOverride(limit, override)
+}
+
+// withOverride returns the limit with the non-zero fields of override applied
+func withOverride(limit models.RateLimitConfig, override *redis.RateLimitOverride) models.RateLimitConfig {
+	if override == nil {
+		return limit
+	}
+
+	if override.Requests > 0 {
+		limit.Requests = override.Requests
+	}
+	if override.WindowSecs > 0 {
+		limit.WindowSecs = override.WindowSecs
+	}
+	if override.MaxConcurrent > 0 {
+		limit.MaxConcurrent = override.MaxConcurrent
+	}
+	return limit
+}
+
+// Buckets returns the state of the buckets of a tenant, optionally only those of an integration
+func (m *Manager) Buckets(ctx context.Context, tenantID uuid.UUID, integrationID *uuid.UUID) ([]BucketStatus, error) {
+	ctx, span := tracing.StartSpan(ctx, "RateLimitManager.Buckets")
+	defer span.End()
+
+	buckets, err := m.limiter.Buckets(ctx, tenantID.String(), BucketRetention)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]BucketStatus, 0, len(buckets))
+	for _, bucket := range buckets {
+		if integrationID != nil && bucket.IntegrationID != integrationID.String() {
+			continue
+		}
+		status, err := m.bucketStatus(ctx, bucket)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, *status)
+	}
+	return statuses, nil
+}
+
+// Bucket returns the state of a bucket of a tenant
+func (m *Manager) Bucket(ctx context.Context, tenantID uuid.UUID, id string) (*BucketStatus, error) {
+	ctx, span := tracing.StartSpan(ctx, "RateLimitManager.Bucket")
+	defer span.End()
+
+	bucket, err := m.getBucket(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	return m.bucketStatus(ctx, *bucket)
+}
+
+// ResetBucket empties the window of a bucket, lifts its dynamic block and forgets its learned rate.
+// Concurrency slots are left to the requests holding them.
+func (m *Manager) ResetBucket(ctx context.Context, tenantID uuid.UUID, id string) error {
+	ctx, span := tracing.StartSpan(ctx, "RateLimitManager.ResetBucket")
+	defer span.End()
+
+	bucket, err := m.getBucket(ctx, tenantID, id)
+	if err != nil {
+		return err
+	}
+	if err := m.limiter.Reset(ctx, bucket.Key); err != nil {
+		return err
+	}
+	if err := m.limiter.Unblock(ctx, bucket.Key); err != nil {
+		return err
+	}
+	if err := m.limiter.ForgetRate(ctx, bucket.Key); err != nil {
+		return err
+	}
+
+	m.logger.WithContext(ctx).Infof("Reset rate limit bucket %s", bucket.Key)
+	return nil
+}
+
+// OverrideBucket replaces the limits of a bucket until ttl elapses
+func (m *Manager) OverrideBucket(ctx context.Context, tenantID uuid.UUID, id string, override redis.RateLimitOverride, ttl time.Duration) (*BucketStatus, error) {
+	ctx, span := tracing.StartSpan(ctx, "RateLimitManager.OverrideBucket")
+	defer span.End()
+
+	bucket, err := m.getBucket(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.limiter.SetOverride(ctx, bucket.Key, override, ttl); err != nil {
+		return nil, err
+	}
+
+	m.logger.WithContext(ctx).Infof("Overrode rate limit bucket %s for %s", bucket.Key, ttl)
+	return m.bucketStatus(ctx, *bucket)
+}
+
+// ClearOverride restores the configured limits of a bucket
+func (m *Manager) ClearOverride(ctx context.Context, tenantID uuid.UUID, id string) error {
+	ctx, span := tracing.StartSpan(ctx, "RateLimitManager.ClearOverride")
+	defer span.End()
+
+	bucket, err := m.getBucket(ctx, tenantID, id)
+	if err != nil {
+		return err
+	}
+	return m.limiter.ClearOverride(ctx, bucket.Key)
+}
+
+// getBucket returns a registered bucket of a tenant
+func (m *Manager) getBucket(ctx context.Context, tenantID uuid.UUID, id string) (*redis.RateLimitBucket, error) {
+	bucket, err := m.limiter.Bucket(ctx, tenantID.String(), id)
+	if err != nil {
+		return nil, err
+	}
+	if bucket == nil {
+		return nil, fmt.Errorf("%w: %s", ErrBucketNotFound, id)
+	}
+	return bucket, nil
+}
+
+// bucketStatus reads the current state of a bucket
+func (m *Manager) bucketStatus(ctx context.Context, bucket redis.RateLimitBucket) (*BucketStatus, error) {
+	status := &BucketStatus{RateLimitBucket: bucket}
+
+	override, err := m.limiter.Override(ctx, bucket.Key)
+	if err != nil {
+		return nil, err
+	}
+	status.Override = override
+
+	limit := withOverride(models.RateLimitConfig{
+		Requests:      bucket.Requests,
+		WindowSecs:    bucket.WindowSecs,
+		MaxConcurrent: bucket.MaxConcurrent,
+	}, override)
+	if bucket.Adaptive {
+		if status.LearnedRequests, err = m.limiter.LearnedRate(ctx, bucket.Key); err != nil {
+			return nil, err
+		}
+		limit = withLearnedRate(limit, status.LearnedRequests)
+	}
+	window := time.Duration(limit.WindowSecs) * time.Second
+
+	usage, err := m.limiter.Usage(ctx, bucket.Key, window)
+	if err != nil {
+		return nil, err
+	}
+	status.Remaining = int64(limit.Requests) - usage.Count
+	if status.Remaining < 0 {
+		status.Remaining = 0
+	}
+	if !usage.OldestAt.IsZero() {
+		resetAt := usage.OldestAt.Add(window)
+		status.ResetAt = &resetAt
+	}
+	if usage.BlockedFor > 0 {
+		blockedUntil := time.Now().Add(usage.BlockedFor)
+		status.BlockedUntil = &blockedUntil
+	}
+
+	if limit.MaxConcurrent > 0 && m.locker != nil {
+		slots := make([]string, limit.MaxConcurrent)
+		for i := range slots {
+			slots[i] = fmt.Sprintf("%s:slot:%d", bucket.Key, i)
+		}
+		if status.InFlight, err = m.locker.Held(ctx, slots...); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
@@ -0,0 +1,35 @@
+package ratelimit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+	"github.com/Ramsey-B/orchid/pkg/redis"
+)
+
+func TestWithOverride(t *testing.T) {
+	limit := models.RateLimitConfig{Name: "contacts", Requests: 100, WindowSecs: 60, MaxConcurrent: 5}
+
+	require.Equal(t, limit, withOverride(limit, nil))
+
+	// Zero fields keep the configured value
+	overridden := withOverride(limit, &redis.RateLimitOverride{Requests: 500})
+	require.Equal(t, 500, overridden.Requests)
+	require.Equal(t, 60, overridden.WindowSecs)
+	require.Equal(t, 5, overridden.MaxConcurrent)
+
+	overridden = withOverride(limit, &redis.RateLimitOverride{WindowSecs: 1, MaxConcurrent: 1})
+	require.Equal(t, 100, overridden.Requests)
+	require.Equal(t, 1, overridden.WindowSecs)
+	require.Equal(t, 1, overridden.MaxConcurrent)
+}
+
+func TestBucketID(t *testing.T) {
+	id := bucketID("tenant:contacts:endpoint:https://api.example.com/contacts?page=2")
+	require.Len(t, id, 16)
+	require.Regexp(t, "^[0-9a-f]+$", id)
+	require.Equal(t, id, bucketID("tenant:contacts:endpoint:https://api.example.com/contacts?page=2"))
+	require.NotEqual(t, id, bucketID("tenant:contacts"))
+}
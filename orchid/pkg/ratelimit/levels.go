@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+)
+
+// WithLevel returns a copy of limits declared at level.
+// Integration and config level limits share one bucket across every plan and auth flow.
+func WithLevel(level models.RateLimitLevel, limits []models.RateLimitConfig) []models.RateLimitConfig {
+	if len(limits) == 0 {
+		return nil
+	}
+	leveled := make([]models.RateLimitConfig, len(limits))
+	for i, limit := range limits {
+		limit.Level = level
+		leveled[i] = limit
+	}
+	return leveled
+}
+
+// CredentialKey identifies the credentials a config authenticates with: a hash of the tenant, the auth flow
+// and the config's secret values. Configs with the same credentials get the same key, so they share their
+// config level and per_config buckets. It returns "" without secret values, leaving the buckets per config.
+func CredentialKey(tenantID, authFlowID string, secretValues []string) string {
+	if len(secretValues) == 0 {
+		return ""
+	}
+	sorted := slices.Clone(secretValues)
+	slices.Sort(sorted)
+
+	hash := sha256.New()
+	hash.Write([]byte(tenantID))
+	hash.Write([]byte{0})
+	hash.Write([]byte(authFlowID))
+	for _, value := range sorted {
+		hash.Write([]byte{0})
+		hash.Write([]byte(value))
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
+
+// configKey returns the part of a bucket key identifying the config's credentials
+func (r CheckRequest) configKey() string {
+	if r.Credential != "" {
+		return "credential:" + r.Credential
+	}
+	return "config:" + r.ConfigID.String()
+}
+
+// ValidateLimits checks that every limit has a unique name, a positive quota and a valid endpoint and scope
+func ValidateLimits(limits []models.RateLimitConfig) error {
+	names := make(map[string]bool, len(limits))
+	for i, limit := range limits {
+		if limit.Name == "" {
+			return fmt.Errorf("rate_limits[%d]: name is required", i)
+		}
+		if names[limit.Name] {
+			return fmt.Errorf("rate_limits[%d]: duplicate name %q", i, limit.Name)
+		}
+		names[limit.Name] = true
+
+		if limit.Requests <= 0 || limit.WindowSecs <= 0 {
+			return fmt.Errorf("rate_limits[%d]: requests and window_secs must be positive", i)
+		}
+		if limit.MaxConcurrent < 0 {
+			return fmt.Errorf("rate_limits[%d]: max_concurrent must not be negative", i)
+		}
+		if limit.Endpoint != "" {
+			if _, err := regexp.Compile(limit.Endpoint); err != nil {
+				return fmt.Errorf("rate_limits[%d]: invalid endpoint: %w", i, err)
+			}
+		}
+		if adaptive := limit.Adaptive; adaptive != nil {
+			if adaptive.MinRequests < 0 || adaptive.MinRequests > limit.Requests {
+				return fmt.Errorf("rate_limits[%d]: adaptive.min_requests must be between 0 and requests", i)
+			}
+			if adaptive.IncreaseStep < 0 {
+				return fmt.Errorf("rate_limits[%d]: adaptive.increase_step must not be negative", i)
+			}
+			if adaptive.DecreaseFactor < 0 || adaptive.DecreaseFactor >= 1 {
+				return fmt.Errorf("rate_limits[%d]: adaptive.decrease_factor must be between 0 and 1", i)
+			}
+		}
+		switch limit.Scope {
+		case "", "global", "per_config", "per_endpoint":
+		default:
+			return fmt.Errorf("rate_limits[%d]: scope must be global, per_config or per_endpoint", i)
+		}
+	}
+	return nil
+}
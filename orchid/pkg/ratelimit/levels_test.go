@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+)
+
+func TestBuildKey_Levels(t *testing.T) {
+	m := &Manager{}
+	tenantID, integrationID := uuid.New(), uuid.New()
+	limit := models.RateLimitConfig{Name: "api", Requests: 100, WindowSecs: 60}
+
+	// Plans of an integration checking the same config share one bucket per level
+	reqA := CheckRequest{TenantID: tenantID, IntegrationID: integrationID, ConfigID: uuid.New(), URL: "https://api.example.com/a"}
+	reqB := CheckRequest{TenantID: tenantID, IntegrationID: integrationID, ConfigID: uuid.New(), URL: "https://api.example.com/b"}
+
+	integrationLimit := WithLevel(models.RateLimitLevelIntegration, []models.RateLimitConfig{limit})[0]
+	require.Equal(t, tenantID.String()+":integration:"+integrationID.String()+":api", m.buildKey(reqA, integrationLimit))
+	require.Equal(t, m.buildKey(reqA, integrationLimit), m.buildKey(reqB, integrationLimit))
+
+	configLimit := WithLevel(models.RateLimitLevelConfig, []models.RateLimitConfig{limit})[0]
+	require.Equal(t, tenantID.String()+":config:"+reqA.ConfigID.String()+":api", m.buildKey(reqA, configLimit))
+	require.NotEqual(t, m.buildKey(reqA, configLimit), m.buildKey(reqB, configLimit))
+
+	// A config level limit is already per config
+	configLimit.Scope = "per_config"
+	require.Equal(t, tenantID.String()+":config:"+reqA.ConfigID.String()+":api", m.buildKey(reqA, configLimit))
+
+	// Configs with the same credentials share their config level and per_config buckets
+	credential := CredentialKey(tenantID.String(), "flow", []string{"key", "secret"})
+	reqA.Credential, reqB.Credential = credential, credential
+	require.Equal(t, tenantID.String()+":credential:"+credential+":api", m.buildKey(reqA, configLimit))
+	require.Equal(t, m.buildKey(reqA, configLimit), m.buildKey(reqB, configLimit))
+	integrationLimit.Scope = "per_config"
+	require.Equal(t, m.buildKey(reqA, integrationLimit), m.buildKey(reqB, integrationLimit))
+	reqB.Credential = CredentialKey(tenantID.String(), "flow", []string{"key", "other"})
+	require.NotEqual(t, m.buildKey(reqA, configLimit), m.buildKey(reqB, configLimit))
+	require.NotEqual(t, m.buildKey(reqA, integrationLimit), m.buildKey(reqB, integrationLimit))
+
+	// Plan level keys are unchanged
+	require.Equal(t, tenantID.String()+":api", m.buildKey(reqA, limit))
+	require.NotEqual(t, m.buildKey(reqA, limit), m.buildKey(reqA, integrationLimit))
+
+	// Endpoint buckets are keyed by a hash of the rendered URL, which may carry credentials
+	endpointLimit := models.RateLimitConfig{Name: "api", Requests: 10, WindowSecs: 1, Scope: "per_endpoint"}
+	reqA.URL = "https://api.example.com/a?api_key=sk_live_secret"
+	require.Equal(t, tenantID.String()+":api:endpoint:"+bucketID(reqA.URL), m.buildKey(reqA, endpointLimit))
+	require.NotContains(t, m.buildKey(reqA, endpointLimit), "sk_live_secret")
+	require.NotEqual(t, m.buildKey(reqA, endpointLimit), m.buildKey(reqB, endpointLimit))
+}
+
+func TestCredentialKey(t *testing.T) {
+	key := CredentialKey("tenant", "flow", []string{"client-id", "client-secret"})
+	require.Len(t, key, 32)
+	require.NotContains(t, key, "client-secret")
+
+	// The order of the secret values does not matter
+	require.Equal(t, key, CredentialKey("tenant", "flow", []string{"client-secret", "client-id"}))
+
+	// Another tenant, auth flow or secret is another credential
+	require.NotEqual(t, key, CredentialKey("other", "flow", []string{"client-id", "client-secret"}))
+	require.NotEqual(t, key, CredentialKey("tenant", "other", []string{"client-id", "client-secret"}))
+	require.NotEqual(t, key, CredentialKey("tenant", "flow", []string{"client-id", "rotated"}))
+
+	// Without secrets the buckets stay per config
+	require.Empty(t, CredentialKey("tenant", "flow", nil))
+}
+
+func TestValidateLimits(t *testing.T) {
+	require.NoError(t, ValidateLimits(nil))
+	require.NoError(t, ValidateLimits([]models.RateLimitConfig{
+		{Name: "api", Requests: 100, WindowSecs: 60},
+		{Name: "search", Endpoint: "/search", Requests: 10, WindowSecs: 1, Scope: "per_endpoint"},
+	}))
+
+	require.ErrorContains(t, ValidateLimits([]models.RateLimitConfig{{Requests: 1, WindowSecs: 1}}), "name is required")
+	require.ErrorContains(t, ValidateLimits([]models.RateLimitConfig{{Name: "api", WindowSecs: 1}}), "must be positive")
+	require.ErrorContains(t, ValidateLimits([]models.RateLimitConfig{{Name: "api", Requests: 1, WindowSecs: 1, Endpoint: "("}}), "invalid endpoint")
+	require.ErrorContains(t, ValidateLimits([]models.RateLimitConfig{{Name: "api", Requests: 1, WindowSecs: 1, Scope: "per_plan"}}), "scope must be")
+	require.ErrorContains(t, ValidateLimits([]models.RateLimitConfig{
+		{Name: "api", Requests: 10, WindowSecs: 1, Adaptive: &models.AdaptiveRateLimit{DecreaseFactor: 1.5}},
+	}), "decrease_factor")
+	require.ErrorContains(t, ValidateLimits([]models.RateLimitConfig{
+		{Name: "api", Requests: 1, WindowSecs: 1},
+		{Name: "api", Requests: 2, WindowSecs: 1},
+	}), "duplicate name")
+}
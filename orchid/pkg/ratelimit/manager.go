@@ -11,6 +11,7 @@ import (
 	"github.com/Gobusters/ectologger"
 	"github.com/google/uuid"
 
+	"github.com/Ramsey-B/orchid/pkg/metrics"
 	"github.com/Ramsey-B/orchid/pkg/models"
 	"github.com/Ramsey-B/orchid/pkg/redis"
 	"github.com/Ramsey-B/stem/pkg/tracing"
@@ -25,6 +26,10 @@ type Manager struct {
 	// Cache compiled regexes for endpoint matching
 	regexCache map[string]*regexp.Regexp
 	regexMu    sync.RWMutex
+
+	// When each bucket was last registered, so checks only register a bucket once per interval
+	registered   map[string]time.Time
+	registeredMu sync.Mutex
 }
 
 // NewManager creates a new rate limit manager
@@ -34,6 +39,7 @@ func NewManager(redisClient *redis.Client, logger ectologger.Logger) *Manager {
 		locker:     redis.NewLocker(redisClient, "orchid:concurrency:"),
 		logger:     logger,
 		regexCache: make(map[string]*regexp.Regexp),
+		registered: make(map[string]time.Time),
 	}
 }
 
@@ -42,6 +48,7 @@ type CheckRequest struct {
 	TenantID      uuid.UUID
 	IntegrationID uuid.UUID
 	ConfigID      uuid.UUID
+	Credential    string // CredentialKey of the config; configs with the same credentials share their buckets
 	URL           string
 	RateLimits    []models.RateLimitConfig
 }
@@ -81,6 +88,9 @@ func (m *Manager) Check(ctx context.Context, req CheckRequest) (*CheckResult, er
 	// Check each matching limit
 	for _, limit := range matchingLimits {
 		key := m.buildKey(req, limit)
+		m.registerBucket(ctx, req, limit, key)
+		limit = m.applyOverride(ctx, key, limit)
+		limit = m.applyAdaptive(ctx, key, limit)
 		window := time.Duration(limit.WindowSecs) * time.Second
 
 		// Concurrency limiting (max in-flight) for this bucket
@@ -103,6 +113,7 @@ func (m *Manager) Check(ctx context.Context, req CheckRequest) (*CheckResult, er
 				for _, rel := range releases {
 					rel()
 				}
+				metrics.RecordRateLimitHit(req.TenantID.String(), limit.Name)
 				return &CheckResult{
 					Allowed:      false,
 					RetryAfter:   200 * time.Millisecond,
@@ -119,6 +130,7 @@ func (m *Manager) Check(ctx context.Context, req CheckRequest) (*CheckResult, er
 			for _, rel := range releases {
 				rel()
 			}
+			metrics.RecordRateLimitHit(req.TenantID.String(), limit.Name)
 			return &CheckResult{
 				Allowed:      false,
 				RetryAfter:   ttl,
@@ -140,6 +152,7 @@ func (m *Manager) Check(ctx context.Context, req CheckRequest) (*CheckResult, er
 			for _, rel := range releases {
 				rel()
 			}
+			metrics.RecordRateLimitHit(req.TenantID.String(), limit.Name)
 			return &CheckResult{
 				Allowed:      false,
 				RetryAfter:   result.RetryIn,
@@ -170,7 +183,16 @@ func (m *Manager) WaitForLimit(ctx context.Context, req CheckRequest, maxWait ti
 	ctx, span := tracing.StartSpan(ctx, "RateLimitManager.WaitForLimit")
 	defer span.End()
 
-	deadline := time.Now().Add(maxWait)
+	start := time.Now()
+	deadline := start.Add(maxWait)
+
+	// Limit the request last waited for, recorded with the time spent waiting
+	throttledBy := ""
+	recordWait := func() {
+		if throttledBy != "" {
+			metrics.RecordRateLimitWait(req.TenantID.String(), throttledBy, time.Since(start).Seconds())
+		}
+	}
 
 	for {
 		result, err := m.Check(ctx, req)
@@ -179,11 +201,14 @@ func (m *Manager) WaitForLimit(ctx context.Context, req CheckRequest, maxWait ti
 		}
 
 		if result.Allowed {
+			recordWait()
 			return result.Release, nil
 		}
+		throttledBy = result.LimitName
 
 		// Check if we'd exceed max wait
 		if time.Now().Add(result.RetryAfter).After(deadline) {
+			recordWait()
 			return nil, fmt.Errorf("rate limit %s would exceed max wait time of %v", result.LimitName, maxWait)
 		}
 
@@ -191,6 +216,7 @@ func (m *Manager) WaitForLimit(ctx context.Context, req CheckRequest, maxWait ti
 
 		select {
 		case <-ctx.Done():
+			recordWait()
 			return nil, ctx.Err()
 		case <-time.After(result.RetryAfter):
 			// Continue and check again
@@ -322,15 +348,29 @@ func (m *Manager) getOrCompileRegex(pattern string) *regexp.Regexp {
 	return re
 }
 
-// buildKey builds the Redis key for a rate limit bucket
+// buildKey builds the Redis key for a rate limit bucket.
+// Integration and config level limits get one bucket per integration or credentials,
+// shared by every plan and auth flow that checks them.
 func (m *Manager) buildKey(req CheckRequest, limit models.RateLimitConfig) string {
 	base := fmt.Sprintf("%s:%s", req.TenantID, limit.Name)
 
+	switch limit.Level {
+	case models.RateLimitLevelIntegration:
+		base = fmt.Sprintf("%s:integration:%s:%s", req.TenantID, req.IntegrationID, limit.Name)
+	case models.RateLimitLevelConfig:
+		// Already one bucket per credentials
+		base = fmt.Sprintf("%s:%s:%s", req.TenantID, req.configKey(), limit.Name)
+		if limit.Scope == "per_config" {
+			return base
+		}
+	}
+
 	switch limit.Scope {
 	case "per_config":
-		return fmt.Sprintf("%s:config:%s", base, req.ConfigID)
+		return fmt.Sprintf("%s:%s", base, req.configKey())
 	case "per_endpoint":
-		return fmt.Sprintf("%s:endpoint:%s", base, req.URL)
+		// Rendered URLs may carry credentials (e.g. an api_key query param), so only their hash is kept
+		return fmt.Sprintf("%s:endpoint:%s", base, bucketID(req.URL))
 	case "global", "":
 		return base
 	default:
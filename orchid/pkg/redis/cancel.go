@@ -0,0 +1,67 @@
+package redis
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// DefaultCancelChannel is the default pub/sub channel for execution cancellations
+const DefaultCancelChannel = "orchid:executions:cancel"
+
+// ExecutionCanceller signals the cancellation of a plan execution to every processor,
+// so the one running it can stop it
+type ExecutionCanceller struct {
+	client  *Client
+	channel string
+}
+
+// NewExecutionCanceller creates a new execution canceller
+func NewExecutionCanceller(client *Client, channel string) *ExecutionCanceller {
+	if channel == "" {
+		channel = DefaultCancelChannel
+	}
+	return &ExecutionCanceller{
+		client:  client,
+		channel: channel,
+	}
+}
+
+// Cancel publishes the cancellation of an execution, returning the number of processors that received it
+func (c *ExecutionCanceller) Cancel(ctx context.Context, executionID uuid.UUID) (int64, error) {
+	receivers, err := c.client.rdb.Publish(ctx, c.channel, executionID.String()).Result()
+	if err != nil {
+		return 0, err
+	}
+	c.client.logger.WithContext(ctx).Infof("Published cancellation of execution %s to %d processors", executionID, receivers)
+	return receivers, nil
+}
+
+// Listen calls handle for every cancellation until ctx is done or the subscription fails
+func (c *ExecutionCanceller) Listen(ctx context.Context, handle func(ctx context.Context, executionID uuid.UUID)) error {
+	pubsub := c.client.rdb.Subscribe(ctx, c.channel)
+	defer pubsub.Close()
+
+	// Wait for the subscription to be confirmed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return err
+	}
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			executionID, err := uuid.Parse(msg.Payload)
+			if err != nil {
+				c.client.logger.WithContext(ctx).Warnf("Ignoring invalid cancellation %q", msg.Payload)
+				continue
+			}
+			handle(ctx, executionID)
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/Gobusters/ectologger"
@@ -20,6 +22,12 @@ const (
 
 	// DLQMaxLen is the maximum length of the DLQ stream (oldest entries trimmed)
 	DLQMaxLen = 10000
+
+	// dlqScanPage is the number of entries read per page when scanning the DLQ
+	dlqScanPage = 500
+
+	// dlqFailureTTL is how long the last failure of a queued job is remembered
+	dlqFailureTTL = 24 * time.Hour
 )
 
 // DeadLetterQueue handles dead letter queue operations
@@ -43,6 +51,7 @@ func NewDeadLetterQueue(client *Client, streamName string, logger ectologger.Log
 
 // DLQEntry represents a dead letter queue entry
 type DLQEntry struct {
+	MessageID    string                  `json:"message_id"` // Stream message ID, used to get, retry or delete the entry
 	ID           string                  `json:"id"`
 	TenantID     string                  `json:"tenant_id"`
 	PlanKey      string                  `json:"plan_key"`
@@ -52,10 +61,42 @@ type DLQEntry struct {
 	Reason       models.DeadLetterReason `json:"reason"`
 	ErrorMessage string                  `json:"error_message"`
 	RetryCount   int                     `json:"retry_count"`
+	AutoRetries  int                     `json:"auto_retries,omitempty"` // Times the job was already re-enqueued from the DLQ by a policy
 	CreatedAt    time.Time               `json:"created_at"`
 	TraceID      string                  `json:"trace_id,omitempty"`
 }
 
+// DLQFilter selects DLQ entries. Empty fields match every entry.
+type DLQFilter struct {
+	TenantID string
+	PlanKey  string
+	ConfigID string
+	Reason   models.DeadLetterReason
+	Since    time.Time // Entries added to the DLQ at or after Since
+	Until    time.Time // Entries added to the DLQ at or before Until
+	IDs      []string  // Message IDs
+}
+
+// Matches reports whether an entry matches the filter
+func (f DLQFilter) Matches(entry *DLQEntry) bool {
+	if f.TenantID != "" && entry.TenantID != f.TenantID {
+		return false
+	}
+	if f.PlanKey != "" && entry.PlanKey != f.PlanKey {
+		return false
+	}
+	if f.ConfigID != "" && entry.ConfigID != f.ConfigID {
+		return false
+	}
+	if f.Reason != "" && entry.Reason != f.Reason {
+		return false
+	}
+	if len(f.IDs) > 0 && !slices.Contains(f.IDs, entry.MessageID) {
+		return false
+	}
+	return true
+}
+
 // Add adds a job to the dead letter queue
 func (d *DeadLetterQueue) Add(ctx context.Context, entry *DLQEntry) (string, error) {
 	ctx, span := tracing.StartSpan(ctx, "DLQ.Add")
@@ -123,6 +164,7 @@ func (d *DeadLetterQueue) List(ctx context.Context, count int64) ([]DLQEntry, er
 			d.logger.WithContext(ctx).WithError(err).Warnf("Failed to unmarshal DLQ entry: %s", msg.ID)
 			continue
 		}
+		entry.MessageID = msg.ID
 		entries = append(entries, entry)
 	}
 
@@ -131,22 +173,90 @@ func (d *DeadLetterQueue) List(ctx context.Context, count int64) ([]DLQEntry, er
 
 // ListByTenant returns entries for a specific tenant
 func (d *DeadLetterQueue) ListByTenant(ctx context.Context, tenantID string, count int64) ([]DLQEntry, error) {
-	entries, err := d.List(ctx, count*2) // Fetch more to filter
+	if count <= 0 {
+		count = 100
+	}
+	return d.Find(ctx, DLQFilter{TenantID: tenantID}, int(count))
+}
+
+// Find returns the entries matching filter, newest first. A limit of 0 returns every match.
+func (d *DeadLetterQueue) Find(ctx context.Context, filter DLQFilter, limit int) ([]DLQEntry, error) {
+	ctx, span := tracing.StartSpan(ctx, "DLQ.Find")
+	defer span.End()
+
+	entries := make([]DLQEntry, 0)
+	err := d.scan(ctx, filter, func(entry DLQEntry) bool {
+		entries = append(entries, entry)
+		return limit <= 0 || len(entries) < limit
+	})
 	if err != nil {
 		return nil, err
 	}
+	return entries, nil
+}
 
-	filtered := make([]DLQEntry, 0)
-	for _, entry := range entries {
-		if entry.TenantID == tenantID {
-			filtered = append(filtered, entry)
-			if int64(len(filtered)) >= count {
-				break
-			}
+// FindN returns the first limit entries matching filter, newest first, and the number of entries matching it
+func (d *DeadLetterQueue) FindN(ctx context.Context, filter DLQFilter, limit int) ([]DLQEntry, int, error) {
+	ctx, span := tracing.StartSpan(ctx, "DLQ.FindN")
+	defer span.End()
+
+	entries := make([]DLQEntry, 0)
+	matched := 0
+	err := d.scan(ctx, filter, func(entry DLQEntry) bool {
+		if len(entries) < limit {
+			entries = append(entries, entry)
 		}
+		matched++
+		return true
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, matched, nil
+}
+
+// scan passes the entries matching filter to visit, newest first, until visit returns false
+func (d *DeadLetterQueue) scan(ctx context.Context, filter DLQFilter, visit func(entry DLQEntry) bool) error {
+	// Stream IDs start with the time the entry was added, so the time range bounds the scan
+	upper, lower := "+", "-"
+	if !filter.Until.IsZero() {
+		upper = strconv.FormatInt(filter.Until.UnixMilli(), 10)
 	}
+	if !filter.Since.IsZero() {
+		lower = strconv.FormatInt(filter.Since.UnixMilli(), 10)
+	}
+
+	for {
+		messages, err := d.client.Redis().XRevRangeN(ctx, d.streamName, upper, lower, dlqScanPage).Result()
+		if err != nil {
+			return fmt.Errorf("failed to read DLQ: %w", err)
+		}
 
-	return filtered, nil
+		for _, msg := range messages {
+			data, ok := msg.Values["data"].(string)
+			if !ok {
+				continue
+			}
+			var entry DLQEntry
+			if err := json.Unmarshal([]byte(data), &entry); err != nil {
+				d.logger.WithContext(ctx).WithError(err).Warnf("Failed to unmarshal DLQ entry: %s", msg.ID)
+				continue
+			}
+			entry.MessageID = msg.ID
+			if !filter.Matches(&entry) {
+				continue
+			}
+			if !visit(entry) {
+				return nil
+			}
+		}
+
+		if len(messages) < dlqScanPage {
+			return nil
+		}
+		// Continue below the oldest entry of the page
+		upper = "(" + messages[len(messages)-1].ID
+	}
 }
 
 // Get retrieves a specific DLQ entry by message ID
@@ -172,6 +282,7 @@ func (d *DeadLetterQueue) Get(ctx context.Context, messageID string) (*DLQEntry,
 	if err := json.Unmarshal([]byte(data), &entry); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal DLQ entry: %w", err)
 	}
+	entry.MessageID = messages[0].ID
 
 	return &entry, nil
 }
@@ -199,8 +310,8 @@ func (d *DeadLetterQueue) Count(ctx context.Context) (int64, error) {
 	return d.client.Redis().XLen(ctx, d.streamName).Result()
 }
 
-// Retry re-enqueues a DLQ entry back to the main job queue
-func (d *DeadLetterQueue) Retry(ctx context.Context, messageID string, jobQueue *Streams, queueName string) error {
+// Retry re-enqueues a DLQ entry on the retry lane of the job queue
+func (d *DeadLetterQueue) Retry(ctx context.Context, messageID string, jobQueue *JobQueue) error {
 	ctx, span := tracing.StartSpan(ctx, "DLQ.Retry")
 	defer span.End()
 
@@ -212,24 +323,70 @@ func (d *DeadLetterQueue) Retry(ctx context.Context, messageID string, jobQueue
 		return fmt.Errorf("DLQ entry not found: %s", messageID)
 	}
 
+	return d.RetryEntry(ctx, entry, jobQueue, false)
+}
+
+// RetryEntry re-enqueues the original job of an entry on the retry lane of the job queue and removes the entry.
+// Automatic retries are counted on the job, so a policy can stop retrying a job that keeps failing.
+func (d *DeadLetterQueue) RetryEntry(ctx context.Context, entry *DLQEntry, jobQueue *JobQueue, auto bool) error {
 	if entry.OriginalJob == nil {
-		return fmt.Errorf("DLQ entry has no original job: %s", messageID)
+		return fmt.Errorf("DLQ entry has no original job: %s", entry.MessageID)
 	}
 
 	// Reset attempts for retry
-	entry.OriginalJob.Attempts = 0
+	job := *entry.OriginalJob
+	job.Attempts = 0
+	job.DLQRetries = entry.AutoRetries
+	if auto {
+		job.DLQRetries++
+	}
 
 	// Re-enqueue the original job
-	_, err = jobQueue.Publish(ctx, queueName, entry.OriginalJob)
-	if err != nil {
+	if _, err := jobQueue.Publish(ctx, LaneRetry, &job); err != nil {
 		return fmt.Errorf("failed to re-enqueue job: %w", err)
 	}
 
 	// Delete from DLQ
-	if err := d.Delete(ctx, messageID); err != nil {
+	if err := d.Delete(ctx, entry.MessageID); err != nil {
 		d.logger.WithContext(ctx).WithError(err).Warn("Failed to delete DLQ entry after retry")
 	}
 
-	d.logger.WithContext(ctx).Infof("Retried DLQ entry: %s plan=%s", messageID, entry.PlanKey)
+	d.logger.WithContext(ctx).Infof("Retried DLQ entry: %s plan=%s", entry.MessageID, entry.PlanKey)
 	return nil
 }
+
+// DLQFailure is the last failure of a queued job, kept until the job succeeds or is moved to the DLQ
+type DLQFailure struct {
+	Reason       models.DeadLetterReason `json:"reason"`
+	ErrorMessage string                  `json:"error_message"`
+}
+
+func (d *DeadLetterQueue) failureKey(stream, messageID string) string {
+	return d.streamName + ":failure:" + stream + ":" + messageID
+}
+
+// RecordFailure remembers why a queued job last failed, so it reaches the DLQ with that reason
+func (d *DeadLetterQueue) RecordFailure(ctx context.Context, stream, messageID string, failure DLQFailure) error {
+	data, err := json.Marshal(failure)
+	if err != nil {
+		return fmt.Errorf("failed to marshal DLQ failure: %w", err)
+	}
+	return d.client.Redis().Set(ctx, d.failureKey(stream, messageID), data, dlqFailureTTL).Err()
+}
+
+// TakeFailure returns and forgets the last failure of a queued job, or nil if none was recorded
+func (d *DeadLetterQueue) TakeFailure(ctx context.Context, stream, messageID string) (*DLQFailure, error) {
+	data, err := d.client.Redis().GetDel(ctx, d.failureKey(stream, messageID)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var failure DLQFailure
+	if err := json.Unmarshal([]byte(data), &failure); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal DLQ failure: %w", err)
+	}
+	return &failure, nil
+}
@@ -0,0 +1,347 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Lane is a priority lane of the job queue
+type Lane string
+
+const (
+	// LaneManual holds jobs triggered through the API
+	LaneManual Lane = "manual"
+	// LaneRetry holds DLQ retries and resumed executions
+	LaneRetry Lane = "retry"
+	// LaneScheduled holds jobs published by the scheduler
+	LaneScheduled Lane = "scheduled"
+)
+
+// Lanes lists the lanes in the order they are served: a lane is only read when the lanes before it are empty
+var Lanes = []Lane{LaneManual, LaneRetry, LaneScheduled}
+
+var (
+	// ErrTenantAtCapacity is returned when a tenant already has its maximum number of jobs queued or running
+	ErrTenantAtCapacity = errors.New("tenant has too many jobs in flight")
+)
+
+const (
+	// DefaultJobQueuePrefix is the default key prefix of the job queue
+	DefaultJobQueuePrefix = "orchid:jobs"
+
+	// DefaultJobConsumerGroup is the default consumer group of the job queue
+	DefaultJobConsumerGroup = "orchid-workers"
+
+	// DefaultInFlightTTL is how long a job counts against its tenant's cap when it is never completed
+	DefaultInFlightTTL = time.Hour
+
+	// wakeListMaxLen bounds the list idle processors block on
+	wakeListMaxLen = 100
+)
+
+// JobQueueConfig holds configuration for the job queue
+type JobQueueConfig struct {
+	// Key prefix of the lane streams
+	Prefix string
+
+	// Consumer group created on every tenant stream
+	ConsumerGroup string
+
+	// Jobs a tenant may have queued or running at once (0 = unlimited)
+	MaxInFlightPerTenant int
+
+	// Per-tenant overrides of MaxInFlightPerTenant, keyed by tenant ID
+	TenantMaxInFlight map[string]int
+
+	// Share of the workers a tenant gets when several tenants have jobs queued (default 1)
+	DefaultTenantWeight int
+
+	// Per-tenant overrides of DefaultTenantWeight, keyed by tenant ID
+	TenantWeights map[string]int
+
+	// How long a job that is never completed (e.g. its processor crashed) counts against its tenant's cap
+	InFlightTTL time.Duration
+}
+
+// JobQueue is a job queue with a Redis stream per tenant and lane, so processors can
+// serve lanes by priority and tenants fairly. Every tenant's queued and running jobs
+// are tracked to enforce its cap.
+type JobQueue struct {
+	client *Client
+	config JobQueueConfig
+}
+
+// NewJobQueue creates a new job queue
+func NewJobQueue(client *Client, config JobQueueConfig) *JobQueue {
+	if config.Prefix == "" {
+		config.Prefix = DefaultJobQueuePrefix
+	}
+	if config.ConsumerGroup == "" {
+		config.ConsumerGroup = DefaultJobConsumerGroup
+	}
+	if config.DefaultTenantWeight <= 0 {
+		config.DefaultTenantWeight = 1
+	}
+	if config.InFlightTTL <= 0 {
+		config.InFlightTTL = DefaultInFlightTTL
+	}
+	return &JobQueue{
+		client: client,
+		config: config,
+	}
+}
+
+// Prefix returns the key prefix of the queue
+func (q *JobQueue) Prefix() string {
+	return q.config.Prefix
+}
+
+// ConsumerGroup returns the consumer group of the tenant streams
+func (q *JobQueue) ConsumerGroup() string {
+	return q.config.ConsumerGroup
+}
+
+// Stream returns the stream holding the jobs of a tenant in a lane
+func (q *JobQueue) Stream(lane Lane, tenantID string) string {
+	return q.config.Prefix + ":" + string(lane) + ":" + tenantID
+}
+
+func (q *JobQueue) tenantsKey(lane Lane) string {
+	return q.config.Prefix + ":tenants:" + string(lane)
+}
+
+func (q *JobQueue) inFlightKey(tenantID string) string {
+	return q.config.Prefix + ":inflight:" + tenantID
+}
+
+func (q *JobQueue) wakeKey() string {
+	return q.config.Prefix + ":wake"
+}
+
+// Weight returns the scheduling weight of a tenant
+func (q *JobQueue) Weight(tenantID string) int {
+	if w, ok := q.config.TenantWeights[tenantID]; ok && w > 0 {
+		return w
+	}
+	return q.config.DefaultTenantWeight
+}
+
+// MaxInFlight returns the cap of a tenant (0 = unlimited)
+func (q *JobQueue) MaxInFlight(tenantID string) int {
+	if limit, ok := q.config.TenantMaxInFlight[tenantID]; ok {
+		return limit
+	}
+	return q.config.MaxInFlightPerTenant
+}
+
+var publishScript = redis.NewScript(`
+	local stream = KEYS[1]
+	local tenants = KEYS[2]
+	local inflight = KEYS[3]
+	local wake = KEYS[4]
+	local now = tonumber(ARGV[4])
+	local ttl = tonumber(ARGV[5])
+	local limit = tonumber(ARGV[6])
+
+	-- Jobs that were never completed stop counting after the TTL
+	redis.call("zremrangebyscore", inflight, "-inf", now - ttl)
+	if limit > 0 and redis.call("zcard", inflight) >= limit then
+		return false
+	end
+
+	if redis.call("exists", stream) == 0 then
+		redis.call("xgroup", "create", stream, ARGV[1], "0", "MKSTREAM")
+	end
+	local id = redis.call("xadd", stream, "*", "data", ARGV[7])
+	redis.call("zadd", inflight, now, ARGV[3])
+	redis.call("pexpire", inflight, ttl)
+	redis.call("sadd", tenants, ARGV[2])
+
+	-- Wake up an idle processor
+	redis.call("lpush", wake, "1")
+	redis.call("ltrim", wake, 0, tonumber(ARGV[8]) - 1)
+	return id
+`)
+
+// Publish adds a job to its tenant's stream in lane. It fails with ErrTenantAtCapacity
+// when the tenant already has its maximum number of jobs queued or running.
+func (q *JobQueue) Publish(ctx context.Context, lane Lane, job *JobMessage) (string, error) {
+	if job.TenantID == "" {
+		return "", errors.New("job requires a tenant_id")
+	}
+	if job.ID == "" {
+		job.ID = uuid.New().String()
+	}
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = time.Now()
+	}
+
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	stream := q.Stream(lane, job.TenantID)
+	result, err := publishScript.Run(ctx, q.client.rdb,
+		[]string{stream, q.tenantsKey(lane), q.inFlightKey(job.TenantID), q.wakeKey()},
+		q.config.ConsumerGroup,
+		job.TenantID,
+		job.ID,
+		time.Now().UnixMilli(),
+		q.config.InFlightTTL.Milliseconds(),
+		q.MaxInFlight(job.TenantID),
+		string(payload),
+		wakeListMaxLen,
+	).Text()
+	if err == redis.Nil {
+		return "", fmt.Errorf("%w: tenant %s has %d jobs queued or running", ErrTenantAtCapacity, job.TenantID, q.MaxInFlight(job.TenantID))
+	}
+	if err != nil {
+		q.client.logger.WithContext(ctx).WithError(err).Errorf("Failed to publish to stream %s", stream)
+		return "", err
+	}
+
+	q.client.logger.WithContext(ctx).Infof("Published job %s to stream %s (message ID: %s)", job.ID, stream, result)
+	return result, nil
+}
+
+// InFlight returns the number of jobs a tenant has queued or running
+func (q *JobQueue) InFlight(ctx context.Context, tenantID string) (int64, error) {
+	key := q.inFlightKey(tenantID)
+	cutoff := time.Now().Add(-q.config.InFlightTTL).UnixMilli()
+
+	pipe := q.client.rdb.Pipeline()
+	pipe.ZRemRangeByScore(ctx, key, "-inf", fmt.Sprintf("%d", cutoff))
+	countCmd := pipe.ZCard(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return countCmd.Val(), nil
+}
+
+// AtCapacity returns whether a tenant has reached its cap
+func (q *JobQueue) AtCapacity(ctx context.Context, tenantID string) (bool, error) {
+	limit := q.MaxInFlight(tenantID)
+	if limit <= 0 {
+		return false, nil
+	}
+	count, err := q.InFlight(ctx, tenantID)
+	if err != nil {
+		return false, err
+	}
+	return count >= int64(limit), nil
+}
+
+// Tenants returns the tenants with jobs in a lane
+func (q *JobQueue) Tenants(ctx context.Context, lane Lane) ([]string, error) {
+	return q.client.rdb.SMembers(ctx, q.tenantsKey(lane)).Result()
+}
+
+var pruneScript = redis.NewScript(`
+	if redis.call("xlen", KEYS[1]) == 0 then
+		redis.call("srem", KEYS[2], ARGV[1])
+		return 1
+	end
+	return 0
+`)
+
+// Next reads the next undelivered job of a tenant in a lane without blocking.
+// It returns nil when there is none, dropping the tenant from the lane once its stream is empty.
+func (q *JobQueue) Next(ctx context.Context, lane Lane, tenantID, consumer string) (*StreamMessage, error) {
+	stream := q.Stream(lane, tenantID)
+	results, err := q.client.rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    q.config.ConsumerGroup,
+		Consumer: consumer,
+		Streams:  []string{stream, ">"},
+		Count:    1,
+		Block:    -1,
+	}).Result()
+	if err != nil && err != redis.Nil && !strings.HasPrefix(err.Error(), "NOGROUP") {
+		return nil, err
+	}
+
+	for _, result := range results {
+		for _, msg := range result.Messages {
+			data, ok := msg.Values["data"].(string)
+			if !ok {
+				continue
+			}
+
+			var payload map[string]interface{}
+			if err := json.Unmarshal([]byte(data), &payload); err != nil {
+				q.client.logger.WithContext(ctx).WithError(err).Warnf("Failed to unmarshal message %s", msg.ID)
+				continue
+			}
+
+			return &StreamMessage{
+				ID:      msg.ID,
+				Stream:  stream,
+				Payload: payload,
+			}, nil
+		}
+	}
+
+	if err := pruneScript.Run(ctx, q.client.rdb, []string{stream, q.tenantsKey(lane)}, tenantID).Err(); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// Complete acknowledges and deletes a job message and releases its slot in the tenant's cap
+func (q *JobQueue) Complete(ctx context.Context, msg StreamMessage, tenantID, jobID string) error {
+	pipe := q.client.rdb.TxPipeline()
+	pipe.XAck(ctx, msg.Stream, q.config.ConsumerGroup, msg.ID)
+	pipe.XDel(ctx, msg.Stream, msg.ID)
+	if tenantID != "" && jobID != "" {
+		pipe.ZRem(ctx, q.inFlightKey(tenantID), jobID)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+var requeueScript = redis.NewScript(`
+	local stream = KEYS[1]
+	redis.call("xack", stream, ARGV[1], ARGV[2])
+	redis.call("xdel", stream, ARGV[2])
+	local id = redis.call("xadd", stream, "*", "data", ARGV[5])
+	redis.call("sadd", KEYS[2], ARGV[3])
+	-- The job keeps its slot in the tenant's cap
+	redis.call("zadd", KEYS[3], ARGV[6], ARGV[4])
+	redis.call("pexpire", KEYS[3], ARGV[7])
+	return id
+`)
+
+// Requeue moves a delivered job to the back of its tenant's stream in lane, keeping its slot in the tenant's cap
+func (q *JobQueue) Requeue(ctx context.Context, lane Lane, msg StreamMessage, job *JobMessage) (string, error) {
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	return requeueScript.Run(ctx, q.client.rdb,
+		[]string{msg.Stream, q.tenantsKey(lane), q.inFlightKey(job.TenantID)},
+		q.config.ConsumerGroup,
+		msg.ID,
+		job.TenantID,
+		job.ID,
+		string(payload),
+		time.Now().UnixMilli(),
+		q.config.InFlightTTL.Milliseconds(),
+	).Text()
+}
+
+// Wait blocks until a job is published or the timeout elapses
+func (q *JobQueue) Wait(ctx context.Context, timeout time.Duration) error {
+	err := q.client.rdb.BLPop(ctx, timeout, q.wakeKey()).Err()
+	if err == redis.Nil {
+		return nil
+	}
+	return err
+}
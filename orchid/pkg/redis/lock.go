@@ -43,8 +43,13 @@ func NewLocker(client *Client, keyPrefix string) *Locker {
 
 // Acquire attempts to acquire a lock
 func (l *Locker) Acquire(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	return l.AcquireAs(ctx, key, uuid.New().String(), ttl)
+}
+
+// AcquireAs attempts to acquire a lock on behalf of owner, which Owner returns while the lock is held
+func (l *Locker) AcquireAs(ctx context.Context, key, owner string, ttl time.Duration) (*Lock, error) {
 	lockKey := l.keyPrefix + key
-	lockValue := uuid.New().String()
+	lockValue := owner
 
 	// Try to set the lock using SET NX (only if not exists)
 	ok, err := l.client.rdb.SetNX(ctx, lockKey, lockValue, ttl).Result()
@@ -68,11 +73,16 @@ func (l *Locker) Acquire(ctx context.Context, key string, ttl time.Duration) (*L
 
 // TryAcquire attempts to acquire a lock, retrying with backoff
 func (l *Locker) TryAcquire(ctx context.Context, key string, ttl time.Duration, timeout time.Duration) (*Lock, error) {
+	return l.TryAcquireAs(ctx, key, uuid.New().String(), ttl, timeout)
+}
+
+// TryAcquireAs attempts to acquire a lock on behalf of owner, retrying with backoff
+func (l *Locker) TryAcquireAs(ctx context.Context, key, owner string, ttl time.Duration, timeout time.Duration) (*Lock, error) {
 	deadline := time.Now().Add(timeout)
 	backoff := 10 * time.Millisecond
 
 	for time.Now().Before(deadline) {
-		lock, err := l.Acquire(ctx, key, ttl)
+		lock, err := l.AcquireAs(ctx, key, owner, ttl)
 		if err == nil {
 			return lock, nil
 		}
@@ -95,6 +105,27 @@ func (l *Locker) TryAcquire(ctx context.Context, key string, ttl time.Duration,
 	return nil, ErrLockNotAcquired
 }
 
+// Owner returns the owner of a held lock, or an empty string when the lock is free
+func (l *Locker) Owner(ctx context.Context, key string) (string, error) {
+	owner, err := l.client.rdb.Get(ctx, l.keyPrefix+key).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return owner, err
+}
+
+// Held returns how many of the given locks are held
+func (l *Locker) Held(ctx context.Context, keys ...string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	lockKeys := make([]string, len(keys))
+	for i, key := range keys {
+		lockKeys[i] = l.keyPrefix + key
+	}
+	return l.client.rdb.Exists(ctx, lockKeys...).Result()
+}
+
 // Release releases the lock
 func (lock *Lock) Release(ctx context.Context) error {
 	// Use Lua script to ensure we only delete if we own the lock
@@ -0,0 +1,101 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// AdaptiveRate tunes a learned request rate of a key
+type AdaptiveRate struct {
+	Max            int64         // Configured requests per window; the learned rate never exceeds it
+	Min            int64         // Floor of the learned rate
+	IncreaseStep   int64         // Requests added per window without errors
+	DecreaseFactor float64       // Factor the rate is multiplied by on an error
+	Window         time.Duration // At most one change per window
+	Retention      time.Duration // How long a learned rate is kept without changes
+}
+
+// adaptScript applies one additive increase or multiplicative decrease to the learned rate of a key.
+// Decreases happen at most once per window, increases once per window after the last change.
+var adaptScript = goredis.NewScript(`
+	local key = KEYS[1]
+	local now = tonumber(ARGV[1])
+	local window_ms = tonumber(ARGV[2])
+	local max = tonumber(ARGV[3])
+	local min = tonumber(ARGV[4])
+	local step = tonumber(ARGV[5])
+	local factor = tonumber(ARGV[6])
+	local decrease = ARGV[7] == "1"
+	local retention_ms = tonumber(ARGV[8])
+
+	local state = redis.call("hmget", key, "rate", "changed_at", "decreased_at")
+	local rate = tonumber(state[1]) or max
+	local changed_at = tonumber(state[2]) or 0
+	local decreased_at = tonumber(state[3]) or 0
+	if rate > max then
+		rate = max
+	end
+
+	if decrease then
+		if now - decreased_at < window_ms then
+			return rate
+		end
+		rate = math.max(min, math.floor(rate * factor))
+		redis.call("hset", key, "rate", rate, "changed_at", now, "decreased_at", now)
+		redis.call("pexpire", key, retention_ms)
+		return rate
+	end
+
+	if state[1] == false or rate >= max or now - changed_at < window_ms then
+		return rate
+	end
+	rate = math.min(max, rate + step)
+	if rate >= max then
+		-- Back at the configured rate: nothing left to learn
+		redis.call("del", key)
+		return rate
+	end
+	redis.call("hset", key, "rate", rate, "changed_at", now)
+	redis.call("pexpire", key, retention_ms)
+	return rate
+`)
+
+func (r *RateLimiter) adaptiveKey(key string) string {
+	return r.keyPrefix + key + ":adaptive"
+}
+
+// Adapt records the outcome of a request against the learned rate of a key and returns the new rate.
+// An error multiplies the rate by DecreaseFactor; a success adds IncreaseStep, back up to Max.
+func (r *RateLimiter) Adapt(ctx context.Context, key string, rate AdaptiveRate, failed bool) (int64, error) {
+	decrease := "0"
+	if failed {
+		decrease = "1"
+	}
+	return adaptScript.Run(ctx, r.client.rdb, []string{r.adaptiveKey(key)},
+		time.Now().UnixMilli(),
+		rate.Window.Milliseconds(),
+		rate.Max,
+		rate.Min,
+		rate.IncreaseStep,
+		strconv.FormatFloat(rate.DecreaseFactor, 'f', -1, 64),
+		decrease,
+		rate.Retention.Milliseconds(),
+	).Int64()
+}
+
+// LearnedRate returns the learned rate of a key, or 0 if none was learned
+func (r *RateLimiter) LearnedRate(ctx context.Context, key string) (int64, error) {
+	rate, err := r.client.rdb.HGet(ctx, r.adaptiveKey(key), "rate").Int64()
+	if err == goredis.Nil {
+		return 0, nil
+	}
+	return rate, err
+}
+
+// ForgetRate drops the learned rate of a key, restoring the configured rate
+func (r *RateLimiter) ForgetRate(ctx context.Context, key string) error {
+	return r.client.rdb.Del(ctx, r.adaptiveKey(key)).Err()
+}
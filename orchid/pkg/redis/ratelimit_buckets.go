@@ -0,0 +1,174 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// RateLimitBucket describes a rate limit bucket requests were checked against
+type RateLimitBucket struct {
+	ID            string    `json:"id"`
+	Key           string    `json:"key"`
+	TenantID      string    `json:"tenant_id"`
+	IntegrationID string    `json:"integration_id"`
+	ConfigID      string    `json:"config_id,omitempty"` // Set for per_config and config level buckets
+	Endpoint      string    `json:"endpoint,omitempty"`  // Endpoint pattern of the limit, set for per_endpoint buckets
+	LimitName     string    `json:"limit_name"`
+	Level         string    `json:"level"` // Where the limit is declared: plan, integration or config
+	Scope         string    `json:"scope"`
+	Requests      int       `json:"requests"`
+	WindowSecs    int       `json:"window_secs"`
+	MaxConcurrent int       `json:"max_concurrent,omitempty"`
+	Adaptive      bool      `json:"adaptive,omitempty"` // Learns its rate from 429 and 5xx responses
+	LastUsedAt    time.Time `json:"last_used_at"`
+}
+
+// RateLimitOverride temporarily replaces the configured limits of a bucket.
+// Zero fields keep the configured value.
+type RateLimitOverride struct {
+	Requests      int       `json:"requests,omitempty"`
+	WindowSecs    int       `json:"window_secs,omitempty"`
+	MaxConcurrent int       `json:"max_concurrent,omitempty"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+// RateLimitUsage is the current state of a sliding window
+type RateLimitUsage struct {
+	Count      int64         // Requests in the window
+	OldestAt   time.Time     // Time of the oldest request in the window (zero when empty)
+	BlockedFor time.Duration // Remaining dynamic block (e.g. Retry-After), 0 when not blocked
+}
+
+func (r *RateLimiter) bucketsKey(tenantID string) string {
+	return r.keyPrefix + "buckets:" + tenantID
+}
+
+func (r *RateLimiter) overrideKey(key string) string {
+	return r.keyPrefix + key + ":override"
+}
+
+// RegisterBucket records that requests were checked against a bucket, so it can be listed.
+// Buckets of a tenant are forgotten after retention without requests.
+func (r *RateLimiter) RegisterBucket(ctx context.Context, bucket RateLimitBucket, retention time.Duration) error {
+	data, err := json.Marshal(bucket)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rate limit bucket: %w", err)
+	}
+
+	key := r.bucketsKey(bucket.TenantID)
+	pipe := r.client.rdb.Pipeline()
+	pipe.HSet(ctx, key, bucket.ID, data)
+	pipe.Expire(ctx, key, retention)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// Buckets returns the registered buckets of a tenant, dropping those unused for longer than retention
+func (r *RateLimiter) Buckets(ctx context.Context, tenantID string, retention time.Duration) ([]RateLimitBucket, error) {
+	key := r.bucketsKey(tenantID)
+	values, err := r.client.rdb.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-retention)
+	buckets := make([]RateLimitBucket, 0, len(values))
+	var stale []string
+	for id, data := range values {
+		var bucket RateLimitBucket
+		if err := json.Unmarshal([]byte(data), &bucket); err != nil || bucket.LastUsedAt.Before(cutoff) {
+			stale = append(stale, id)
+			continue
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	if len(stale) > 0 {
+		if err := r.client.rdb.HDel(ctx, key, stale...).Err(); err != nil {
+			r.client.logger.WithContext(ctx).WithError(err).Warnf("Failed to drop %d stale rate limit buckets", len(stale))
+		}
+	}
+	return buckets, nil
+}
+
+// Bucket returns a registered bucket of a tenant, or nil if there is none
+func (r *RateLimiter) Bucket(ctx context.Context, tenantID, id string) (*RateLimitBucket, error) {
+	data, err := r.client.rdb.HGet(ctx, r.bucketsKey(tenantID), id).Result()
+	if err == goredis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var bucket RateLimitBucket
+	if err := json.Unmarshal([]byte(data), &bucket); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal rate limit bucket: %w", err)
+	}
+	return &bucket, nil
+}
+
+// Usage returns the requests in the sliding window of a key and its dynamic block
+func (r *RateLimiter) Usage(ctx context.Context, key string, window time.Duration) (*RateLimitUsage, error) {
+	rateKey := r.keyPrefix + key
+	windowStart := time.Now().Add(-window)
+
+	pipe := r.client.rdb.Pipeline()
+	pipe.ZRemRangeByScore(ctx, rateKey, "-inf", fmt.Sprintf("%d", windowStart.UnixMilli()))
+	countCmd := pipe.ZCard(ctx, rateKey)
+	oldestCmd := pipe.ZRangeWithScores(ctx, rateKey, 0, 0)
+	blockCmd := pipe.PTTL(ctx, r.blockKey(key))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	usage := &RateLimitUsage{Count: countCmd.Val()}
+	if oldest := oldestCmd.Val(); len(oldest) > 0 {
+		usage.OldestAt = time.UnixMilli(int64(oldest[0].Score))
+	}
+	if ttl := blockCmd.Val(); ttl > 0 {
+		usage.BlockedFor = ttl
+	}
+	return usage, nil
+}
+
+// Unblock lifts the dynamic block of a key
+func (r *RateLimiter) Unblock(ctx context.Context, key string) error {
+	return r.client.rdb.Del(ctx, r.blockKey(key)).Err()
+}
+
+// SetOverride replaces the limits of a key until ttl elapses
+func (r *RateLimiter) SetOverride(ctx context.Context, key string, override RateLimitOverride, ttl time.Duration) error {
+	override.ExpiresAt = time.Now().Add(ttl)
+	data, err := json.Marshal(override)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rate limit override: %w", err)
+	}
+	return r.client.rdb.Set(ctx, r.overrideKey(key), data, ttl).Err()
+}
+
+// Override returns the active override of a key, or nil if there is none
+func (r *RateLimiter) Override(ctx context.Context, key string) (*RateLimitOverride, error) {
+	data, err := r.client.rdb.Get(ctx, r.overrideKey(key)).Result()
+	if err == goredis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var override RateLimitOverride
+	if err := json.Unmarshal([]byte(data), &override); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal rate limit override: %w", err)
+	}
+	return &override, nil
+}
+
+// ClearOverride removes the override of a key
+func (r *RateLimiter) ClearOverride(ctx context.Context, key string) error {
+	return r.client.rdb.Del(ctx, r.overrideKey(key)).Err()
+}
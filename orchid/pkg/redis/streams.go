@@ -25,6 +25,9 @@ type JobMessage struct {
 	Payload   map[string]interface{} `json:"payload"`
 	CreatedAt time.Time              `json:"created_at"`
 	Attempts  int                    `json:"attempts"`
+
+	// DLQRetries counts the times the job was re-enqueued from the DLQ by an automatic retry policy
+	DLQRetries int `json:"dlq_retries,omitempty"`
 }
 
 // Streams provides Redis Streams operations for job queues
@@ -49,12 +49,12 @@ func (r *AuthFlowRepository) Create(ctx context.Context, authFlow *models.AuthFl
 
 	ib := database.NewInsertBuilder()
 	ib.InsertInto(authFlowsTable).
-		Cols("id", "tenant_id", "integration_id", "name", "plan_definition",
+		Cols("id", "tenant_id", "integration_id", "name", "plan_definition", "refresh_definition",
 			"token_path", "header_name", "header_format", "refresh_path",
-			"expires_in_path", "ttl_seconds", "skew_seconds", "created_at", "updated_at").
-		Values(authFlow.ID, authFlow.TenantID, authFlow.IntegrationID, authFlow.Name, authFlow.PlanDefinition,
+			"expires_in_path", "ttl_seconds", "skew_seconds", "reauth_on", "signer", "created_at", "updated_at").
+		Values(authFlow.ID, authFlow.TenantID, authFlow.IntegrationID, authFlow.Name, authFlow.PlanDefinition, authFlow.RefreshDefinition,
 			authFlow.TokenPath, authFlow.HeaderName, authFlow.HeaderFormat, authFlow.RefreshPath,
-			authFlow.ExpiresInPath, authFlow.TTLSeconds, authFlow.SkewSeconds,
+			authFlow.ExpiresInPath, authFlow.TTLSeconds, authFlow.SkewSeconds, authFlow.ReauthOn, authFlow.Signer,
 			sqlbuilder.Raw("NOW()"), sqlbuilder.Raw("NOW()")).
 		Returning("created_at", "updated_at")
 
@@ -153,6 +153,7 @@ func (r *AuthFlowRepository) Update(ctx context.Context, authFlow *models.AuthFl
 		Set(
 			ub.Assign("name", authFlow.Name),
 			ub.Assign("plan_definition", authFlow.PlanDefinition),
+			ub.Assign("refresh_definition", authFlow.RefreshDefinition),
 			ub.Assign("token_path", authFlow.TokenPath),
 			ub.Assign("header_name", authFlow.HeaderName),
 			ub.Assign("header_format", authFlow.HeaderFormat),
@@ -160,6 +161,8 @@ func (r *AuthFlowRepository) Update(ctx context.Context, authFlow *models.AuthFl
 			ub.Assign("expires_in_path", authFlow.ExpiresInPath),
 			ub.Assign("ttl_seconds", authFlow.TTLSeconds),
 			ub.Assign("skew_seconds", authFlow.SkewSeconds),
+			ub.Assign("reauth_on", authFlow.ReauthOn),
+			ub.Assign("signer", authFlow.Signer),
 			ub.Assign("updated_at", sqlbuilder.Raw("NOW()")),
 		).
 		Where(ub.Equal("tenant_id", tenantID), ub.Equal("id", authFlow.ID))
@@ -0,0 +1,142 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Gobusters/ectoerror/httperror"
+	"github.com/Gobusters/ectologger"
+	"github.com/google/uuid"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+	"github.com/Ramsey-B/stem/pkg/database"
+	"github.com/Ramsey-B/stem/pkg/tracing"
+)
+
+const authRefreshTokensTable = "auth_refresh_tokens"
+
+var authRefreshTokenStruct = database.NewStruct(new(models.AuthRefreshToken))
+
+// AuthRefreshTokenRepository handles database operations for persisted refresh tokens
+type AuthRefreshTokenRepository struct {
+	*Repository
+}
+
+// NewAuthRefreshTokenRepository creates a new auth refresh token repository
+func NewAuthRefreshTokenRepository(db database.DB, logger ectologger.Logger) *AuthRefreshTokenRepository {
+	return &AuthRefreshTokenRepository{
+		Repository: NewRepository(db, logger),
+	}
+}
+
+// Upsert stores the latest refresh token for an auth flow/config combination
+// Using raw SQL for upsert with ON CONFLICT
+func (r *AuthRefreshTokenRepository) Upsert(ctx context.Context, token *models.AuthRefreshToken) error {
+	ctx, span := tracing.StartSpan(ctx, "AuthRefreshTokenRepository.Upsert")
+	defer span.End()
+
+	tenantID, err := GetTenantID(ctx)
+	if err != nil {
+		return err
+	}
+	token.TenantID = tenantID
+
+	now := time.Now()
+
+	// Use parameterized timestamp instead of NOW() for Citus compatibility
+	query := `
+		INSERT INTO auth_refresh_tokens (tenant_id, auth_flow_id, config_id, refresh_token, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (tenant_id, auth_flow_id, config_id)
+		DO UPDATE SET refresh_token = $4, updated_at = $5
+		RETURNING created_at, updated_at`
+
+	err = r.DB().QueryRowContext(ctx, query,
+		token.TenantID,
+		token.AuthFlowID,
+		token.ConfigID,
+		token.RefreshToken,
+		now,
+	).Scan(&token.CreatedAt, &token.UpdatedAt)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"auth_flow_id": token.AuthFlowID,
+			"config_id":    token.ConfigID,
+		}).Error("failed to upsert auth refresh token")
+		return httperror.NewHTTPError(http.StatusInternalServerError, "failed to upsert auth refresh token")
+	}
+
+	r.logger.WithContext(ctx).WithFields(map[string]any{
+		"auth_flow_id": token.AuthFlowID,
+		"config_id":    token.ConfigID,
+	}).Debugf("Upserted %s", authRefreshTokensTable)
+	return nil
+}
+
+// Get retrieves the refresh token for an auth flow/config combination
+func (r *AuthRefreshTokenRepository) Get(ctx context.Context, authFlowID, configID uuid.UUID) (*models.AuthRefreshToken, error) {
+	ctx, span := tracing.StartSpan(ctx, "AuthRefreshTokenRepository.Get")
+	defer span.End()
+
+	tenantID, err := GetTenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sb := authRefreshTokenStruct.SelectFrom(authRefreshTokensTable)
+	sb.Where(sb.Equal("tenant_id", tenantID), sb.Equal("auth_flow_id", authFlowID), sb.Equal("config_id", configID))
+
+	query, args := sb.Build()
+	var token models.AuthRefreshToken
+	err = r.DB().GetContext(ctx, &token, query, args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, httperror.NewHTTPErrorf(http.StatusNotFound, "refresh token for auth flow %s with config %s does not exist", authFlowID, configID)
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"auth_flow_id": authFlowID,
+			"config_id":    configID,
+		}).Error("failed to get auth refresh token")
+		return nil, httperror.NewHTTPError(http.StatusInternalServerError, "failed to get auth refresh token")
+	}
+
+	r.logger.WithContext(ctx).WithFields(map[string]any{
+		"auth_flow_id": authFlowID,
+		"config_id":    configID,
+	}).Debugf("Retrieved %s", authRefreshTokensTable)
+	return &token, nil
+}
+
+// Delete removes the refresh token for an auth flow/config combination
+// Deleting a token that does not exist is not an error
+func (r *AuthRefreshTokenRepository) Delete(ctx context.Context, authFlowID, configID uuid.UUID) error {
+	ctx, span := tracing.StartSpan(ctx, "AuthRefreshTokenRepository.Delete")
+	defer span.End()
+
+	tenantID, err := GetTenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	db := database.NewDeleteBuilder()
+	db.DeleteFrom(authRefreshTokensTable).
+		Where(db.Equal("tenant_id", tenantID), db.Equal("auth_flow_id", authFlowID), db.Equal("config_id", configID))
+
+	query, args := db.Build()
+	if _, err := r.DB().ExecContext(ctx, query, args...); err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"auth_flow_id": authFlowID,
+			"config_id":    configID,
+		}).Error("failed to delete auth refresh token")
+		return httperror.NewHTTPError(http.StatusInternalServerError, "failed to delete auth refresh token")
+	}
+
+	r.logger.WithContext(ctx).WithFields(map[string]any{
+		"auth_flow_id": authFlowID,
+		"config_id":    configID,
+	}).Debugf("Deleted %s", authRefreshTokensTable)
+	return nil
+}
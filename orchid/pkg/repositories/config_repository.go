@@ -49,9 +49,9 @@ func (r *ConfigRepository) Create(ctx context.Context, config *models.Config) er
 
 	ib := database.NewInsertBuilder()
 	ib.InsertInto(configsTable).
-		Cols("id", "tenant_id", "integration_id", "name", "values", "enabled", "created_at", "updated_at").
+		Cols("id", "tenant_id", "integration_id", "name", "values", "enabled", "rate_limits", "created_at", "updated_at").
 		Values(config.ID, config.TenantID, config.IntegrationID, config.Name, config.Values, config.Enabled,
-			sqlbuilder.Raw("NOW()"), sqlbuilder.Raw("NOW()")).
+			config.RateLimits, sqlbuilder.Raw("NOW()"), sqlbuilder.Raw("NOW()")).
 		Returning("created_at", "updated_at")
 
 	query, args := ib.Build()
@@ -171,18 +171,7 @@ func (r *ConfigRepository) Update(ctx context.Context, config *models.Config) er
 		return err
 	}
 
-	ub := database.NewUpdateBuilder()
-	ub.Update(configsTable).
-		Set(
-			ub.Assign("name", config.Name),
-			ub.Assign("values", config.Values),
-			ub.Assign("enabled", config.Enabled),
-			ub.Assign("updated_at", sqlbuilder.Raw("NOW()")),
-		).
-		Where(ub.Equal("tenant_id", tenantID), ub.Equal("id", config.ID))
-	ub.SQL("RETURNING updated_at")
-
-	query, args := ub.Build()
+	query, args := buildConfigUpdate(tenantID, config)
 	err = r.DB().QueryRowContext(ctx, query, args...).Scan(&config.UpdatedAt)
 	if errors.Is(err, sql.ErrNoRows) {
 		return httperror.NewHTTPErrorf(http.StatusNotFound, "config %s does not exist", config.ID)
@@ -200,6 +189,22 @@ func (r *ConfigRepository) Update(ctx context.Context, config *models.Config) er
 	return nil
 }
 
+// buildConfigUpdate builds the statement that updates a config and returns its updated_at
+func buildConfigUpdate(tenantID uuid.UUID, config *models.Config) (string, []any) {
+	ub := database.NewUpdateBuilder()
+	ub.Update(configsTable).
+		Set(
+			ub.Assign("name", config.Name),
+			ub.Assign("values", config.Values),
+			ub.Assign("enabled", config.Enabled),
+			ub.Assign("rate_limits", config.RateLimits),
+			ub.Assign("updated_at", sqlbuilder.Raw("NOW()")),
+		).
+		Where(ub.Equal("tenant_id", tenantID), ub.Equal("id", config.ID))
+	ub.SQL("RETURNING updated_at")
+	return ub.Build()
+}
+
 // SetEnabled enables or disables a config
 func (r *ConfigRepository) SetEnabled(ctx context.Context, id uuid.UUID, enabled bool) error {
 	ctx, span := tracing.StartSpan(ctx, "ConfigRepository.SetEnabled")
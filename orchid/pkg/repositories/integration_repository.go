@@ -49,9 +49,9 @@ func (r *IntegrationRepository) Create(ctx context.Context, integration *models.
 
 	ib := database.NewInsertBuilder()
 	ib.InsertInto(integrationsTable).
-		Cols("id", "tenant_id", "name", "description", "config_schema", "created_at", "updated_at").
+		Cols("id", "tenant_id", "name", "description", "config_schema", "rate_limits", "transport", "created_at", "updated_at").
 		Values(integration.ID, integration.TenantID, integration.Name, integration.Description, integration.ConfigSchema,
-			sqlbuilder.Raw("NOW()"), sqlbuilder.Raw("NOW()")).
+			integration.RateLimits, integration.Transport, sqlbuilder.Raw("NOW()"), sqlbuilder.Raw("NOW()")).
 		Returning("created_at", "updated_at")
 
 	query, args := ib.Build()
@@ -173,18 +173,7 @@ func (r *IntegrationRepository) Update(ctx context.Context, integration *models.
 		return err
 	}
 
-	ub := database.NewUpdateBuilder()
-	ub.Update(integrationsTable).
-		Set(
-			ub.Assign("name", integration.Name),
-			ub.Assign("description", integration.Description),
-			ub.Assign("config_schema", integration.ConfigSchema),
-			ub.Assign("updated_at", sqlbuilder.Raw("NOW()")),
-		).
-		Where(ub.Equal("tenant_id", tenantID), ub.Equal("id", integration.ID))
-	ub.SQL("RETURNING updated_at")
-
-	query, args := ub.Build()
+	query, args := buildIntegrationUpdate(tenantID, integration)
 	result := r.DB().QueryRowContext(ctx, query, args...)
 
 	err = result.Scan(&integration.UpdatedAt)
@@ -204,6 +193,82 @@ func (r *IntegrationRepository) Update(ctx context.Context, integration *models.
 	return nil
 }
 
+// UpdateWithConfigs updates an integration together with the configs rewritten for its new config schema,
+// in one transaction so a failure leaves neither changed
+func (r *IntegrationRepository) UpdateWithConfigs(ctx context.Context, integration *models.Integration, configs []models.Config) error {
+	ctx, span := tracing.StartSpan(ctx, "IntegrationRepository.UpdateWithConfigs")
+	defer span.End()
+
+	tenantID, err := GetTenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.DB().BeginTxx(ctx, nil)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("failed to begin transaction")
+		return httperror.NewHTTPError(http.StatusInternalServerError, "failed to update integration")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query, args := buildIntegrationUpdate(tenantID, integration)
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&integration.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return httperror.NewHTTPErrorf(http.StatusNotFound, "integration %s does not exist", integration.ID)
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"integration_id": integration.ID,
+		}).Error("failed to update integration")
+		return httperror.NewHTTPError(http.StatusInternalServerError, "failed to update integration")
+	}
+
+	for i := range configs {
+		query, args := buildConfigUpdate(tenantID, &configs[i])
+		err = tx.QueryRowContext(ctx, query, args...).Scan(&configs[i].UpdatedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return httperror.NewHTTPErrorf(http.StatusNotFound, "config %s does not exist", configs[i].ID)
+		}
+		if err != nil {
+			r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+				"integration_id": integration.ID,
+				"config_id":      configs[i].ID,
+			}).Error("failed to update config")
+			return httperror.NewHTTPError(http.StatusInternalServerError, "failed to update integration configs")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"integration_id": integration.ID,
+		}).Error("failed to commit integration update")
+		return httperror.NewHTTPError(http.StatusInternalServerError, "failed to update integration")
+	}
+
+	r.logger.WithContext(ctx).WithFields(map[string]any{
+		"integration_id": integration.ID,
+		"configs":        len(configs),
+	}).Debugf("Updated %s with its configs", integrationsTable)
+	return nil
+}
+
+// buildIntegrationUpdate builds the statement that updates an integration and returns its updated_at
+func buildIntegrationUpdate(tenantID uuid.UUID, integration *models.Integration) (string, []any) {
+	ub := database.NewUpdateBuilder()
+	ub.Update(integrationsTable).
+		Set(
+			ub.Assign("name", integration.Name),
+			ub.Assign("description", integration.Description),
+			ub.Assign("config_schema", integration.ConfigSchema),
+			ub.Assign("rate_limits", integration.RateLimits),
+			ub.Assign("transport", integration.Transport),
+			ub.Assign("updated_at", sqlbuilder.Raw("NOW()")),
+		).
+		Where(ub.Equal("tenant_id", tenantID), ub.Equal("id", integration.ID))
+	ub.SQL("RETURNING updated_at")
+	return ub.Build()
+}
+
 // Delete deletes an integration by ID
 func (r *IntegrationRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	ctx, span := tracing.StartSpan(ctx, "IntegrationRepository.Delete")
@@ -14,6 +14,7 @@ type IntegrationRepo interface {
 	GetByName(ctx context.Context, name string) (*models.Integration, error)
 	List(ctx context.Context) ([]models.Integration, error)
 	Update(ctx context.Context, integration *models.Integration) error
+	UpdateWithConfigs(ctx context.Context, integration *models.Integration, configs []models.Config) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	DeleteByTenantID(ctx context.Context, tenantID uuid.UUID) (int64, error)
 }
@@ -40,6 +41,13 @@ type AuthFlowRepo interface {
 	DeleteByTenantID(ctx context.Context, tenantID uuid.UUID) (int64, error)
 }
 
+// AuthRefreshTokenRepo defines the interface for persisted refresh token operations
+type AuthRefreshTokenRepo interface {
+	Upsert(ctx context.Context, token *models.AuthRefreshToken) error
+	Get(ctx context.Context, authFlowID, configID uuid.UUID) (*models.AuthRefreshToken, error)
+	Delete(ctx context.Context, authFlowID, configID uuid.UUID) error
+}
+
 // PlanRepo defines the interface for plan repository operations
 type PlanRepo interface {
 	Create(ctx context.Context, plan *models.Plan) error
@@ -62,6 +70,10 @@ type PlanExecutionRepo interface {
 	UpdateStatus(ctx context.Context, id uuid.UUID, status models.ExecutionStatus) error
 	MarkStarted(ctx context.Context, id uuid.UUID) error
 	MarkCompleted(ctx context.Context, id uuid.UUID, status models.ExecutionStatus, errorMsg *string, errorType *models.ErrorType) error
+	CancelActive(ctx context.Context, id uuid.UUID, errorMsg string) (bool, error)
+	ClaimResume(ctx context.Context, id uuid.UUID) (bool, error)
+	ReleaseResume(ctx context.Context, id uuid.UUID) error
+	SaveCheckpoint(ctx context.Context, id uuid.UUID, checkpoint models.ExecutionCheckpoint) error
 	IncrementRetry(ctx context.Context, id uuid.UUID) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	DeleteByTenantID(ctx context.Context, tenantID uuid.UUID) (int64, error)
@@ -75,13 +87,48 @@ type PlanContextRepo interface {
 	Delete(ctx context.Context, planKey string, configID uuid.UUID) error
 }
 
+// PlanWatermarkRepo defines the interface for plan watermark repository operations
+type PlanWatermarkRepo interface {
+	Upsert(ctx context.Context, watermark *models.PlanWatermark) error
+	GetByPlanAndConfig(ctx context.Context, planKey string, configID uuid.UUID) (*models.PlanWatermark, error)
+	ListByPlan(ctx context.Context, planKey string) ([]models.PlanWatermark, error)
+	Delete(ctx context.Context, planKey string, configID uuid.UUID) error
+}
+
+// PlanPaginationCheckpointRepo defines the interface for pagination checkpoint repository operations
+type PlanPaginationCheckpointRepo interface {
+	Upsert(ctx context.Context, checkpoint *models.PlanPaginationCheckpoint) error
+	Get(ctx context.Context, planKey string, configID uuid.UUID, stepKey string) (*models.PlanPaginationCheckpoint, error)
+	Delete(ctx context.Context, planKey string, configID uuid.UUID, stepKey string) error
+}
+
 // PlanStatisticsRepo defines the interface for plan statistics repository operations
 type PlanStatisticsRepo interface {
 	GetOrCreate(ctx context.Context, planKey string, configID uuid.UUID) (*models.PlanStatistics, error)
 	GetByPlanAndConfig(ctx context.Context, planKey string, configID uuid.UUID) (*models.PlanStatistics, error)
-	RecordExecution(ctx context.Context, planKey string, configID uuid.UUID, success bool, executionTimeMs int) error
+	RecordExecution(ctx context.Context, planKey string, configID uuid.UUID, success bool, errorType *models.ErrorType, executionTimeMs int) (models.FailureStreak, error)
 	IncrementAPICalls(ctx context.Context, planKey string, configID uuid.UUID, count int) error
+	ClearNextRun(ctx context.Context, planKey string) error
 	ListByPlan(ctx context.Context, planKey string) ([]models.PlanStatistics, error)
 	Delete(ctx context.Context, planKey string, configID uuid.UUID) error
 	DeleteByTenantID(ctx context.Context, tenantID uuid.UUID) (int64, error)
 }
+
+// NotificationSubscriptionRepo defines the interface for notification subscription repository operations
+type NotificationSubscriptionRepo interface {
+	Create(ctx context.Context, subscription *models.NotificationSubscription) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.NotificationSubscription, error)
+	List(ctx context.Context) ([]models.NotificationSubscription, error)
+	ListEnabled(ctx context.Context, events []models.NotificationEvent) ([]models.NotificationSubscription, error)
+	Update(ctx context.Context, subscription *models.NotificationSubscription) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	DeleteByTenantID(ctx context.Context, tenantID uuid.UUID) (int64, error)
+}
+
+// NotificationDeliveryRepo defines the interface for notification delivery log operations
+type NotificationDeliveryRepo interface {
+	Create(ctx context.Context, delivery *models.NotificationDelivery) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.NotificationDelivery, error)
+	ListBySubscription(ctx context.Context, subscriptionID uuid.UUID, status models.NotificationDeliveryStatus, limit int) ([]models.NotificationDelivery, error)
+	Redeliver(ctx context.Context, id uuid.UUID) error
+}
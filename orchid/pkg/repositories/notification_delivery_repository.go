@@ -0,0 +1,182 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Gobusters/ectoerror/httperror"
+	"github.com/Gobusters/ectologger"
+	"github.com/google/uuid"
+	"github.com/huandu/go-sqlbuilder"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+	"github.com/Ramsey-B/stem/pkg/database"
+	"github.com/Ramsey-B/stem/pkg/tracing"
+)
+
+const notificationDeliveriesTable = "notification_deliveries"
+
+var notificationDeliveryStruct = database.NewStruct(new(models.NotificationDelivery))
+
+// NotificationDeliveryRepository handles database operations for the notification delivery log
+type NotificationDeliveryRepository struct {
+	*Repository
+}
+
+// NewNotificationDeliveryRepository creates a new notification delivery repository
+func NewNotificationDeliveryRepository(db database.DB, logger ectologger.Logger) *NotificationDeliveryRepository {
+	return &NotificationDeliveryRepository{
+		Repository: NewRepository(db, logger),
+	}
+}
+
+// Create creates a pending delivery, attempted by the notification dispatcher from its next_attempt_at
+func (r *NotificationDeliveryRepository) Create(ctx context.Context, delivery *models.NotificationDelivery) error {
+	ctx, span := tracing.StartSpan(ctx, "NotificationDeliveryRepository.Create")
+	defer span.End()
+
+	tenantID, err := GetTenantID(ctx)
+	if err != nil {
+		return err
+	}
+	delivery.TenantID = tenantID
+
+	if delivery.ID == uuid.Nil {
+		delivery.ID = uuid.New()
+	}
+	if delivery.Status == "" {
+		delivery.Status = models.NotificationDeliveryPending
+	}
+
+	ib := database.NewInsertBuilder()
+	ib.InsertInto(notificationDeliveriesTable).
+		Cols("id", "tenant_id", "subscription_id", "event", "execution_id", "payload", "status", "attempts",
+			"next_attempt_at", "created_at", "updated_at").
+		Values(delivery.ID, delivery.TenantID, delivery.SubscriptionID, delivery.Event, delivery.ExecutionID, delivery.Payload,
+			delivery.Status, delivery.Attempts, sqlbuilder.Raw("NOW()"), sqlbuilder.Raw("NOW()"), sqlbuilder.Raw("NOW()")).
+		Returning("next_attempt_at", "created_at", "updated_at")
+
+	query, args := ib.Build()
+	err = r.DB().QueryRowContext(ctx, query, args...).Scan(&delivery.NextAttemptAt, &delivery.CreatedAt, &delivery.UpdatedAt)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"delivery_id":     delivery.ID,
+			"subscription_id": delivery.SubscriptionID,
+		}).Error("failed to create notification delivery")
+		return httperror.NewHTTPError(http.StatusInternalServerError, "failed to create notification delivery")
+	}
+
+	r.logger.WithContext(ctx).WithFields(map[string]any{
+		"delivery_id":     delivery.ID,
+		"subscription_id": delivery.SubscriptionID,
+	}).Debugf("Created %s", notificationDeliveriesTable)
+	return nil
+}
+
+// GetByID retrieves a notification delivery by ID (tenant-scoped)
+func (r *NotificationDeliveryRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.NotificationDelivery, error) {
+	ctx, span := tracing.StartSpan(ctx, "NotificationDeliveryRepository.GetByID")
+	defer span.End()
+
+	tenantID, err := GetTenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sb := notificationDeliveryStruct.SelectFrom(notificationDeliveriesTable)
+	sb.Where(sb.Equal("tenant_id", tenantID), sb.Equal("id", id))
+
+	query, args := sb.Build()
+	var delivery models.NotificationDelivery
+	err = r.DB().GetContext(ctx, &delivery, query, args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, httperror.NewHTTPErrorf(http.StatusNotFound, "notification delivery %s does not exist", id)
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"delivery_id": id,
+		}).Error("failed to get notification delivery")
+		return nil, httperror.NewHTTPError(http.StatusInternalServerError, "failed to get notification delivery")
+	}
+
+	return &delivery, nil
+}
+
+// ListBySubscription retrieves the latest deliveries of a subscription, optionally of one status
+func (r *NotificationDeliveryRepository) ListBySubscription(ctx context.Context, subscriptionID uuid.UUID, status models.NotificationDeliveryStatus, limit int) ([]models.NotificationDelivery, error) {
+	ctx, span := tracing.StartSpan(ctx, "NotificationDeliveryRepository.ListBySubscription")
+	defer span.End()
+
+	tenantID, err := GetTenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sb := notificationDeliveryStruct.SelectFrom(notificationDeliveriesTable)
+	sb.Where(sb.Equal("tenant_id", tenantID), sb.Equal("subscription_id", subscriptionID))
+	if status != "" {
+		sb.Where(sb.Equal("status", status))
+	}
+	sb.OrderBy("created_at").Desc()
+	sb.Limit(limit)
+
+	query, args := sb.Build()
+	var deliveries []models.NotificationDelivery
+	err = r.DB().SelectContext(ctx, &deliveries, query, args...)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"subscription_id": subscriptionID,
+		}).Error("failed to list notification deliveries")
+		return nil, httperror.NewHTTPError(http.StatusInternalServerError, "failed to list notification deliveries")
+	}
+
+	r.logger.WithContext(ctx).WithFields(map[string]any{
+		"subscription_id": subscriptionID,
+	}).Debugf("Listed %d %s", len(deliveries), notificationDeliveriesTable)
+	return deliveries, nil
+}
+
+// Redeliver queues a delivery for a new round of attempts, whatever its status
+func (r *NotificationDeliveryRepository) Redeliver(ctx context.Context, id uuid.UUID) error {
+	ctx, span := tracing.StartSpan(ctx, "NotificationDeliveryRepository.Redeliver")
+	defer span.End()
+
+	tenantID, err := GetTenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	ub := database.NewUpdateBuilder()
+	ub.Update(notificationDeliveriesTable).
+		Set(
+			ub.Assign("status", models.NotificationDeliveryPending),
+			ub.Assign("attempts", 0),
+			ub.Assign("next_attempt_at", sqlbuilder.Raw("NOW()")),
+			ub.Assign("updated_at", sqlbuilder.Raw("NOW()")),
+		).
+		Where(ub.Equal("tenant_id", tenantID), ub.Equal("id", id))
+
+	query, args := ub.Build()
+	result, err := r.DB().ExecContext(ctx, query, args...)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"delivery_id": id,
+		}).Error("failed to redeliver notification")
+		return httperror.NewHTTPError(http.StatusInternalServerError, "failed to redeliver notification")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return httperror.NewHTTPError(http.StatusInternalServerError, "failed to redeliver notification")
+	}
+	if rows == 0 {
+		return httperror.NewHTTPErrorf(http.StatusNotFound, "notification delivery %s does not exist", id)
+	}
+
+	r.logger.WithContext(ctx).WithFields(map[string]any{
+		"delivery_id": id,
+	}).Infof("Queued redelivery of %s", notificationDeliveriesTable)
+	return nil
+}
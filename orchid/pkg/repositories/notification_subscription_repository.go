@@ -0,0 +1,272 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Gobusters/ectoerror/httperror"
+	"github.com/Gobusters/ectologger"
+	"github.com/google/uuid"
+	"github.com/huandu/go-sqlbuilder"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+	"github.com/Ramsey-B/stem/pkg/database"
+	"github.com/Ramsey-B/stem/pkg/tracing"
+)
+
+const notificationSubscriptionsTable = "notification_subscriptions"
+
+var notificationSubscriptionStruct = database.NewStruct(new(models.NotificationSubscription))
+
+// NotificationSubscriptionRepository handles database operations for notification subscriptions
+type NotificationSubscriptionRepository struct {
+	*Repository
+}
+
+// NewNotificationSubscriptionRepository creates a new notification subscription repository
+func NewNotificationSubscriptionRepository(db database.DB, logger ectologger.Logger) *NotificationSubscriptionRepository {
+	return &NotificationSubscriptionRepository{
+		Repository: NewRepository(db, logger),
+	}
+}
+
+// Create creates a new notification subscription
+func (r *NotificationSubscriptionRepository) Create(ctx context.Context, subscription *models.NotificationSubscription) error {
+	ctx, span := tracing.StartSpan(ctx, "NotificationSubscriptionRepository.Create")
+	defer span.End()
+
+	tenantID, err := GetTenantID(ctx)
+	if err != nil {
+		return err
+	}
+	subscription.TenantID = tenantID
+
+	if subscription.ID == uuid.Nil {
+		subscription.ID = uuid.New()
+	}
+
+	ib := database.NewInsertBuilder()
+	ib.InsertInto(notificationSubscriptionsTable).
+		Cols("id", "tenant_id", "name", "event", "plan_key", "config_id", "threshold", "url", "secret", "enabled",
+			"created_at", "updated_at").
+		Values(subscription.ID, subscription.TenantID, subscription.Name, subscription.Event, subscription.PlanKey,
+			subscription.ConfigID, subscription.Threshold, subscription.URL, subscription.Secret, subscription.Enabled,
+			sqlbuilder.Raw("NOW()"), sqlbuilder.Raw("NOW()")).
+		Returning("created_at", "updated_at")
+
+	query, args := ib.Build()
+	err = r.DB().QueryRowContext(ctx, query, args...).Scan(&subscription.CreatedAt, &subscription.UpdatedAt)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"subscription_id": subscription.ID,
+		}).Error("failed to create notification subscription")
+		return httperror.NewHTTPError(http.StatusInternalServerError, "failed to create notification subscription")
+	}
+
+	r.logger.WithContext(ctx).WithFields(map[string]any{
+		"subscription_id": subscription.ID,
+	}).Debugf("Created %s", notificationSubscriptionsTable)
+	return nil
+}
+
+// GetByID retrieves a notification subscription by ID (tenant-scoped)
+func (r *NotificationSubscriptionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.NotificationSubscription, error) {
+	ctx, span := tracing.StartSpan(ctx, "NotificationSubscriptionRepository.GetByID")
+	defer span.End()
+
+	tenantID, err := GetTenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sb := notificationSubscriptionStruct.SelectFrom(notificationSubscriptionsTable)
+	sb.Where(sb.Equal("tenant_id", tenantID), sb.Equal("id", id))
+
+	query, args := sb.Build()
+	var subscription models.NotificationSubscription
+	err = r.DB().GetContext(ctx, &subscription, query, args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, httperror.NewHTTPErrorf(http.StatusNotFound, "notification subscription %s does not exist", id)
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"subscription_id": id,
+		}).Error("failed to get notification subscription")
+		return nil, httperror.NewHTTPError(http.StatusInternalServerError, "failed to get notification subscription")
+	}
+
+	r.logger.WithContext(ctx).WithFields(map[string]any{
+		"subscription_id": id,
+	}).Debugf("Retrieved %s", notificationSubscriptionsTable)
+	return &subscription, nil
+}
+
+// List retrieves all notification subscriptions of the tenant
+func (r *NotificationSubscriptionRepository) List(ctx context.Context) ([]models.NotificationSubscription, error) {
+	ctx, span := tracing.StartSpan(ctx, "NotificationSubscriptionRepository.List")
+	defer span.End()
+
+	tenantID, err := GetTenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sb := notificationSubscriptionStruct.SelectFrom(notificationSubscriptionsTable)
+	sb.Where(sb.Equal("tenant_id", tenantID))
+	sb.OrderBy("name")
+
+	return r.list(ctx, sb)
+}
+
+// ListEnabled retrieves the enabled notification subscriptions of the tenant to any of events
+func (r *NotificationSubscriptionRepository) ListEnabled(ctx context.Context, events []models.NotificationEvent) ([]models.NotificationSubscription, error) {
+	ctx, span := tracing.StartSpan(ctx, "NotificationSubscriptionRepository.ListEnabled")
+	defer span.End()
+
+	if len(events) == 0 {
+		return nil, nil
+	}
+
+	tenantID, err := GetTenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]any, len(events))
+	for i, event := range events {
+		values[i] = event
+	}
+
+	sb := notificationSubscriptionStruct.SelectFrom(notificationSubscriptionsTable)
+	sb.Where(sb.Equal("tenant_id", tenantID), sb.Equal("enabled", true), sb.In("event", values...))
+
+	return r.list(ctx, sb)
+}
+
+// list runs a subscription select
+func (r *NotificationSubscriptionRepository) list(ctx context.Context, sb *database.SelectBuilder) ([]models.NotificationSubscription, error) {
+	query, args := sb.Build()
+	var subscriptions []models.NotificationSubscription
+	err := r.DB().SelectContext(ctx, &subscriptions, query, args...)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).Error("failed to list notification subscriptions")
+		return nil, httperror.NewHTTPError(http.StatusInternalServerError, "failed to list notification subscriptions")
+	}
+
+	r.logger.WithContext(ctx).WithFields(map[string]any{
+		"subscription_count": len(subscriptions),
+	}).Debugf("Listed %s", notificationSubscriptionsTable)
+	return subscriptions, nil
+}
+
+// Update updates an existing notification subscription
+func (r *NotificationSubscriptionRepository) Update(ctx context.Context, subscription *models.NotificationSubscription) error {
+	ctx, span := tracing.StartSpan(ctx, "NotificationSubscriptionRepository.Update")
+	defer span.End()
+
+	tenantID, err := GetTenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	ub := database.NewUpdateBuilder()
+	ub.Update(notificationSubscriptionsTable).
+		Set(
+			ub.Assign("name", subscription.Name),
+			ub.Assign("event", subscription.Event),
+			ub.Assign("plan_key", subscription.PlanKey),
+			ub.Assign("config_id", subscription.ConfigID),
+			ub.Assign("threshold", subscription.Threshold),
+			ub.Assign("url", subscription.URL),
+			ub.Assign("secret", subscription.Secret),
+			ub.Assign("enabled", subscription.Enabled),
+			ub.Assign("updated_at", sqlbuilder.Raw("NOW()")),
+		).
+		Where(ub.Equal("tenant_id", tenantID), ub.Equal("id", subscription.ID))
+	ub.SQL("RETURNING updated_at")
+
+	query, args := ub.Build()
+	err = r.DB().QueryRowContext(ctx, query, args...).Scan(&subscription.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return httperror.NewHTTPErrorf(http.StatusNotFound, "notification subscription %s does not exist", subscription.ID)
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"subscription_id": subscription.ID,
+		}).Error("failed to update notification subscription")
+		return httperror.NewHTTPError(http.StatusInternalServerError, "failed to update notification subscription")
+	}
+
+	r.logger.WithContext(ctx).WithFields(map[string]any{
+		"subscription_id": subscription.ID,
+	}).Debugf("Updated %s", notificationSubscriptionsTable)
+	return nil
+}
+
+// Delete deletes a notification subscription and its delivery log
+func (r *NotificationSubscriptionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, span := tracing.StartSpan(ctx, "NotificationSubscriptionRepository.Delete")
+	defer span.End()
+
+	tenantID, err := GetTenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	db := database.NewDeleteBuilder()
+	db.DeleteFrom(notificationSubscriptionsTable).
+		Where(db.Equal("tenant_id", tenantID), db.Equal("id", id))
+
+	query, args := db.Build()
+	result, err := r.DB().ExecContext(ctx, query, args...)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"subscription_id": id,
+		}).Error("failed to delete notification subscription")
+		return httperror.NewHTTPError(http.StatusInternalServerError, "failed to delete notification subscription")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"subscription_id": id,
+		}).Error("failed to delete notification subscription")
+		return httperror.NewHTTPError(http.StatusInternalServerError, "failed to delete notification subscription")
+	}
+	if rows == 0 {
+		return httperror.NewHTTPErrorf(http.StatusNotFound, "notification subscription %s does not exist", id)
+	}
+
+	r.logger.WithContext(ctx).WithFields(map[string]any{
+		"subscription_id": id,
+	}).Debugf("Deleted %s", notificationSubscriptionsTable)
+	return nil
+}
+
+// DeleteByTenantID deletes all notification subscriptions for a tenant (for testing cleanup)
+func (r *NotificationSubscriptionRepository) DeleteByTenantID(ctx context.Context, tenantID uuid.UUID) (int64, error) {
+	ctx, span := tracing.StartSpan(ctx, "NotificationSubscriptionRepository.DeleteByTenantID")
+	defer span.End()
+
+	db := database.NewDeleteBuilder()
+	db.DeleteFrom(notificationSubscriptionsTable).
+		Where(db.Equal("tenant_id", tenantID))
+
+	query, args := db.Build()
+	result, err := r.DB().ExecContext(ctx, query, args...)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"tenant_id": tenantID,
+		}).Error("failed to delete notification subscriptions by tenant")
+		return 0, err
+	}
+
+	rows, _ := result.RowsAffected()
+	r.logger.WithContext(ctx).WithFields(map[string]any{
+		"tenant_id": tenantID,
+		"count":     rows,
+	}).Info("Deleted notification subscriptions by tenant")
+	return rows, nil
+}
@@ -53,11 +53,11 @@ func (r *PlanExecutionRepository) Create(ctx context.Context, execution *models.
 
 	ib := database.NewInsertBuilder()
 	ib.InsertInto(planExecutionsTable).
-		Cols("id", "tenant_id", "plan_key", "config_id", "parent_execution_id",
+		Cols("id", "tenant_id", "plan_key", "config_id", "parent_execution_id", "resume",
 			"status", "step_path", "started_at", "completed_at", "error_message", "error_type",
 			"retry_count", "request_url", "request_method", "response_status_code",
 			"response_size_bytes", "created_at", "updated_at").
-		Values(execution.ID, execution.TenantID, execution.PlanKey, execution.ConfigID, execution.ParentExecutionID,
+		Values(execution.ID, execution.TenantID, execution.PlanKey, execution.ConfigID, execution.ParentExecutionID, execution.Resume,
 			execution.Status, execution.StepPath, execution.StartedAt, execution.CompletedAt, execution.ErrorMessage, execution.ErrorType,
 			execution.RetryCount, execution.RequestURL, execution.RequestMethod, execution.ResponseStatusCode,
 			execution.ResponseSizeBytes, sqlbuilder.Raw("NOW()"), sqlbuilder.Raw("NOW()")).
@@ -195,7 +195,7 @@ func (r *PlanExecutionRepository) ListChildren(ctx context.Context, parentID uui
 	}
 
 	sb := planExecutionStruct.SelectFrom(planExecutionsTable)
-	sb.Where(sb.Equal("tenant_id", tenantID), sb.Equal("parent_execution_id", parentID))
+	sb.Where(sb.Equal("tenant_id", tenantID), sb.Equal("parent_execution_id", parentID), sb.Equal("resume", false))
 	sb.OrderBy("created_at")
 
 	query, args := sb.Build()
@@ -361,6 +361,185 @@ func (r *PlanExecutionRepository) MarkCompleted(ctx context.Context, id uuid.UUI
 	return nil
 }
 
+// CancelActive marks an execution as cancelled unless it already completed.
+// It returns false when the execution is not pending or running (anymore).
+func (r *PlanExecutionRepository) CancelActive(ctx context.Context, id uuid.UUID, errorMsg string) (bool, error) {
+	ctx, span := tracing.StartSpan(ctx, "PlanExecutionRepository.CancelActive")
+	defer span.End()
+
+	tenantID, err := GetTenantID(ctx)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"execution_id": id,
+		}).Error("failed to get tenant ID")
+		return false, err
+	}
+
+	now := time.Now()
+	ub := database.NewUpdateBuilder()
+	ub.Update(planExecutionsTable).
+		Set(
+			ub.Assign("status", models.ExecutionStatusCancelled),
+			ub.Assign("completed_at", now),
+			ub.Assign("error_message", errorMsg),
+			ub.Assign("updated_at", sqlbuilder.Raw("NOW()")),
+		).
+		Where(
+			ub.Equal("tenant_id", tenantID),
+			ub.Equal("id", id),
+			ub.In("status", models.ExecutionStatusPending, models.ExecutionStatusRunning),
+		)
+
+	query, args := ub.Build()
+	result, err := r.DB().ExecContext(ctx, query, args...)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"execution_id": id,
+		}).Error("failed to cancel execution")
+		return false, httperror.NewHTTPError(http.StatusInternalServerError, "failed to cancel execution")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"execution_id": id,
+		}).Error("failed to cancel execution")
+		return false, httperror.NewHTTPError(http.StatusInternalServerError, "failed to cancel execution")
+	}
+
+	r.logger.WithContext(ctx).WithFields(map[string]any{
+		"execution_id": id,
+	}).Infof("Cancelled %s: %t", planExecutionsTable, rows > 0)
+	return rows > 0, nil
+}
+
+// ClaimResume marks a failed or aborted execution as resumed, unless it already was.
+// It returns false when another request claimed the resume first or the execution is not failed or aborted.
+func (r *PlanExecutionRepository) ClaimResume(ctx context.Context, id uuid.UUID) (bool, error) {
+	ctx, span := tracing.StartSpan(ctx, "PlanExecutionRepository.ClaimResume")
+	defer span.End()
+
+	tenantID, err := GetTenantID(ctx)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"execution_id": id,
+		}).Error("failed to get tenant ID")
+		return false, err
+	}
+
+	ub := database.NewUpdateBuilder()
+	ub.Update(planExecutionsTable).
+		Set(
+			ub.Assign("resumed_at", time.Now()),
+			ub.Assign("updated_at", sqlbuilder.Raw("NOW()")),
+		).
+		Where(
+			ub.Equal("tenant_id", tenantID),
+			ub.Equal("id", id),
+			ub.IsNull("resumed_at"),
+			ub.In("status", models.ExecutionStatusFailed, models.ExecutionStatusAborted),
+		)
+
+	query, args := ub.Build()
+	result, err := r.DB().ExecContext(ctx, query, args...)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"execution_id": id,
+		}).Error("failed to claim resume")
+		return false, httperror.NewHTTPError(http.StatusInternalServerError, "failed to claim resume")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"execution_id": id,
+		}).Error("failed to claim resume")
+		return false, httperror.NewHTTPError(http.StatusInternalServerError, "failed to claim resume")
+	}
+
+	return rows > 0, nil
+}
+
+// ReleaseResume clears the resume claim of an execution whose resume could not be queued
+func (r *PlanExecutionRepository) ReleaseResume(ctx context.Context, id uuid.UUID) error {
+	ctx, span := tracing.StartSpan(ctx, "PlanExecutionRepository.ReleaseResume")
+	defer span.End()
+
+	tenantID, err := GetTenantID(ctx)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"execution_id": id,
+		}).Error("failed to get tenant ID")
+		return err
+	}
+
+	ub := database.NewUpdateBuilder()
+	ub.Update(planExecutionsTable).
+		Set(
+			ub.Assign("resumed_at", nil),
+			ub.Assign("updated_at", sqlbuilder.Raw("NOW()")),
+		).
+		Where(ub.Equal("tenant_id", tenantID), ub.Equal("id", id))
+
+	query, args := ub.Build()
+	if _, err := r.DB().ExecContext(ctx, query, args...); err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"execution_id": id,
+		}).Error("failed to release resume")
+		return httperror.NewHTTPError(http.StatusInternalServerError, "failed to release resume")
+	}
+
+	return nil
+}
+
+// SaveCheckpoint stores the loop state of a running execution
+func (r *PlanExecutionRepository) SaveCheckpoint(ctx context.Context, id uuid.UUID, checkpoint models.ExecutionCheckpoint) error {
+	ctx, span := tracing.StartSpan(ctx, "PlanExecutionRepository.SaveCheckpoint")
+	defer span.End()
+
+	tenantID, err := GetTenantID(ctx)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"execution_id": id,
+		}).Error("failed to get tenant ID")
+		return err
+	}
+
+	ub := database.NewUpdateBuilder()
+	ub.Update(planExecutionsTable).
+		Set(
+			ub.Assign("checkpoint", database.JSONB[models.ExecutionCheckpoint]{Data: checkpoint}),
+			ub.Assign("updated_at", sqlbuilder.Raw("NOW()")),
+		).
+		Where(ub.Equal("tenant_id", tenantID), ub.Equal("id", id))
+
+	query, args := ub.Build()
+	result, err := r.DB().ExecContext(ctx, query, args...)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"execution_id": id,
+		}).Error("failed to save checkpoint")
+		return httperror.NewHTTPError(http.StatusInternalServerError, "failed to save checkpoint")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"execution_id": id,
+		}).Error("failed to save checkpoint")
+		return httperror.NewHTTPError(http.StatusInternalServerError, "failed to save checkpoint")
+	}
+	if rows == 0 {
+		return httperror.NewHTTPErrorf(http.StatusNotFound, "execution %s does not exist", id)
+	}
+
+	r.logger.WithContext(ctx).WithFields(map[string]any{
+		"execution_id": id,
+		"loop_count":   checkpoint.LoopCount,
+	}).Debugf("Saved checkpoint of %s", planExecutionsTable)
+	return nil
+}
+
 // IncrementRetry increments the retry count
 func (r *PlanExecutionRepository) IncrementRetry(ctx context.Context, id uuid.UUID) error {
 	ctx, span := tracing.StartSpan(ctx, "PlanExecutionRepository.IncrementRetry")
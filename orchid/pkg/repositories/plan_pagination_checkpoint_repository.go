@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Gobusters/ectoerror/httperror"
+	"github.com/Gobusters/ectologger"
+	"github.com/google/uuid"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+	"github.com/Ramsey-B/stem/pkg/database"
+	"github.com/Ramsey-B/stem/pkg/tracing"
+)
+
+const planPaginationCheckpointsTable = "plan_pagination_checkpoints"
+
+var planPaginationCheckpointStruct = database.NewStruct(new(models.PlanPaginationCheckpoint))
+
+// PlanPaginationCheckpointRepository handles database operations for pagination checkpoints
+type PlanPaginationCheckpointRepository struct {
+	*Repository
+}
+
+// NewPlanPaginationCheckpointRepository creates a new pagination checkpoint repository
+func NewPlanPaginationCheckpointRepository(db database.DB, logger ectologger.Logger) *PlanPaginationCheckpointRepository {
+	return &PlanPaginationCheckpointRepository{
+		Repository: NewRepository(db, logger),
+	}
+}
+
+// Upsert stores the position of a paginated step
+// Using raw SQL for upsert with ON CONFLICT
+func (r *PlanPaginationCheckpointRepository) Upsert(ctx context.Context, checkpoint *models.PlanPaginationCheckpoint) error {
+	ctx, span := tracing.StartSpan(ctx, "PlanPaginationCheckpointRepository.Upsert")
+	defer span.End()
+
+	tenantID, err := GetTenantID(ctx)
+	if err != nil {
+		return err
+	}
+	checkpoint.TenantID = tenantID
+
+	now := time.Now()
+
+	// Use parameterized timestamp instead of NOW() for Citus compatibility
+	query := `
+		INSERT INTO plan_pagination_checkpoints (tenant_id, plan_key, config_id, step_key, state, execution_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		ON CONFLICT (tenant_id, plan_key, config_id, step_key)
+		DO UPDATE SET state = $5, execution_id = $6, updated_at = $7
+		RETURNING created_at, updated_at`
+
+	err = r.DB().QueryRowContext(ctx, query,
+		checkpoint.TenantID,
+		checkpoint.PlanKey,
+		checkpoint.ConfigID,
+		checkpoint.StepKey,
+		checkpoint.State,
+		checkpoint.ExecutionID,
+		now,
+	).Scan(&checkpoint.CreatedAt, &checkpoint.UpdatedAt)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"plan_key":  checkpoint.PlanKey,
+			"config_id": checkpoint.ConfigID,
+			"step_key":  checkpoint.StepKey,
+		}).Error("failed to upsert pagination checkpoint")
+		return httperror.NewHTTPError(http.StatusInternalServerError, "failed to upsert pagination checkpoint")
+	}
+
+	r.logger.WithContext(ctx).WithFields(map[string]any{
+		"plan_key":  checkpoint.PlanKey,
+		"config_id": checkpoint.ConfigID,
+		"step_key":  checkpoint.StepKey,
+	}).Debugf("Upserted %s for plan=%s config=%s", planPaginationCheckpointsTable, checkpoint.PlanKey, checkpoint.ConfigID)
+	return nil
+}
+
+// Get retrieves the position of a paginated step
+func (r *PlanPaginationCheckpointRepository) Get(ctx context.Context, planKey string, configID uuid.UUID, stepKey string) (*models.PlanPaginationCheckpoint, error) {
+	ctx, span := tracing.StartSpan(ctx, "PlanPaginationCheckpointRepository.Get")
+	defer span.End()
+
+	tenantID, err := GetTenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sb := planPaginationCheckpointStruct.SelectFrom(planPaginationCheckpointsTable)
+	sb.Where(sb.Equal("tenant_id", tenantID), sb.Equal("plan_key", planKey), sb.Equal("config_id", configID), sb.Equal("step_key", stepKey))
+
+	query, args := sb.Build()
+	var checkpoint models.PlanPaginationCheckpoint
+	err = r.DB().GetContext(ctx, &checkpoint, query, args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, httperror.NewHTTPErrorf(http.StatusNotFound, "pagination checkpoint for step %s of plan %s with config %s does not exist", stepKey, planKey, configID)
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"plan_key":  planKey,
+			"config_id": configID,
+			"step_key":  stepKey,
+		}).Error("failed to get pagination checkpoint")
+		return nil, httperror.NewHTTPError(http.StatusInternalServerError, "failed to get pagination checkpoint")
+	}
+
+	return &checkpoint, nil
+}
+
+// Delete removes the position of a paginated step once its pagination finished (no-op when there is none)
+func (r *PlanPaginationCheckpointRepository) Delete(ctx context.Context, planKey string, configID uuid.UUID, stepKey string) error {
+	ctx, span := tracing.StartSpan(ctx, "PlanPaginationCheckpointRepository.Delete")
+	defer span.End()
+
+	tenantID, err := GetTenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	db := database.NewDeleteBuilder()
+	db.DeleteFrom(planPaginationCheckpointsTable).
+		Where(db.Equal("tenant_id", tenantID), db.Equal("plan_key", planKey), db.Equal("config_id", configID), db.Equal("step_key", stepKey))
+
+	query, args := db.Build()
+	if _, err := r.DB().ExecContext(ctx, query, args...); err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"plan_key":  planKey,
+			"config_id": configID,
+			"step_key":  stepKey,
+		}).Error("failed to delete pagination checkpoint")
+		return httperror.NewHTTPError(http.StatusInternalServerError, "failed to delete pagination checkpoint")
+	}
+
+	return nil
+}
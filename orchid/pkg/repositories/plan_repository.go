@@ -45,6 +45,9 @@ func (r *PlanRepository) Create(ctx context.Context, plan *models.Plan) error {
 	if plan.Key == "" {
 		plan.Key = uuid.New().String()
 	}
+	if plan.OverlapPolicy == "" {
+		plan.OverlapPolicy = models.DefaultOverlapPolicy
+	}
 
 	now := time.Now().UTC()
 
@@ -54,12 +57,13 @@ func (r *PlanRepository) Create(ctx context.Context, plan *models.Plan) error {
 	ib.InsertInto(plansTable).
 		Cols(
 			"key", "tenant_id", "integration_id", "name", "description", "plan_definition",
-			"enabled", "wait_seconds", "repeat_count", "created_at", "updated_at",
+			"enabled", "wait_seconds", "repeat_count", "schedule_cron", "schedule_timezone", "blackout_windows",
+			"overlap_policy", "created_at", "updated_at",
 		).
 		Values(
 			plan.Key, plan.TenantID, plan.IntegrationID, plan.Name, plan.Description, plan.PlanDefinition,
-			plan.Enabled, plan.WaitSeconds, plan.RepeatCount,
-			now, now,
+			plan.Enabled, plan.WaitSeconds, plan.RepeatCount, plan.ScheduleCron, plan.ScheduleTimezone, plan.BlackoutWindows,
+			plan.OverlapPolicy, now, now,
 		)
 	ib.SQL(`
 ON CONFLICT (tenant_id, key)
@@ -69,6 +73,10 @@ DO UPDATE SET
   enabled = EXCLUDED.enabled,
   wait_seconds = EXCLUDED.wait_seconds,
   repeat_count = EXCLUDED.repeat_count,
+  schedule_cron = EXCLUDED.schedule_cron,
+  schedule_timezone = EXCLUDED.schedule_timezone,
+  blackout_windows = EXCLUDED.blackout_windows,
+  overlap_policy = EXCLUDED.overlap_policy,
   updated_at = EXCLUDED.updated_at
 RETURNING key, created_at, updated_at`)
 
@@ -105,7 +113,8 @@ func (r *PlanRepository) GetByKey(ctx context.Context, key string) (*models.Plan
 		SELECT 
 			p.key, p.tenant_id, p.integration_id, i.name AS integration,
 			p.name, p.description, p.plan_definition, p.enabled,
-			p.wait_seconds, p.repeat_count, p.created_at, p.updated_at
+			p.wait_seconds, p.repeat_count, p.schedule_cron, p.schedule_timezone, p.blackout_windows,
+			p.overlap_policy, p.created_at, p.updated_at
 		FROM plans p
 		INNER JOIN integrations i ON p.tenant_id = i.tenant_id AND p.integration_id = i.id
 		WHERE p.tenant_id = $1 AND p.key = $2
@@ -147,7 +156,8 @@ func (r *PlanRepository) ListByIntegration(ctx context.Context, integrationID uu
 		SELECT 
 			p.key, p.tenant_id, p.integration_id, i.name AS integration,
 			p.name, p.description, p.plan_definition, p.enabled,
-			p.wait_seconds, p.repeat_count, p.created_at, p.updated_at
+			p.wait_seconds, p.repeat_count, p.schedule_cron, p.schedule_timezone, p.blackout_windows,
+			p.overlap_policy, p.created_at, p.updated_at
 		FROM plans p
 		INNER JOIN integrations i ON p.tenant_id = i.tenant_id AND p.integration_id = i.id
 		WHERE p.tenant_id = $1 AND p.integration_id = $2
@@ -183,7 +193,8 @@ func (r *PlanRepository) ListEnabled(ctx context.Context) ([]models.Plan, error)
 		SELECT 
 			p.key, p.tenant_id, p.integration_id, i.name AS integration,
 			p.name, p.description, p.plan_definition, p.enabled,
-			p.wait_seconds, p.repeat_count, p.created_at, p.updated_at
+			p.wait_seconds, p.repeat_count, p.schedule_cron, p.schedule_timezone, p.blackout_windows,
+			p.overlap_policy, p.created_at, p.updated_at
 		FROM plans p
 		INNER JOIN integrations i ON p.tenant_id = i.tenant_id AND p.integration_id = i.id
 		WHERE p.tenant_id = $1 AND p.enabled = true
@@ -224,6 +235,10 @@ func (r *PlanRepository) Update(ctx context.Context, plan *models.Plan) error {
 			ub.Assign("enabled", plan.Enabled),
 			ub.Assign("wait_seconds", plan.WaitSeconds),
 			ub.Assign("repeat_count", plan.RepeatCount),
+			ub.Assign("schedule_cron", plan.ScheduleCron),
+			ub.Assign("schedule_timezone", plan.ScheduleTimezone),
+			ub.Assign("blackout_windows", plan.BlackoutWindows),
+			ub.Assign("overlap_policy", plan.OverlapPolicy),
 			ub.Assign("updated_at", sqlbuilder.Raw("NOW()")),
 		).
 		Where(ub.Equal("tenant_id", tenantID), ub.Equal("key", plan.Key))
@@ -57,7 +57,7 @@ func (r *PlanStatisticsRepository) GetOrCreate(ctx context.Context, planKey stri
 		DO UPDATE SET updated_at = plan_statistics.updated_at
 		RETURNING id, tenant_id, plan_key, config_id, last_execution_at, last_success_at,
 				  last_failure_at, total_executions, total_successes, total_failures,
-				  total_api_calls, average_execution_time_ms, created_at, updated_at`
+				  total_api_calls, consecutive_failures, average_execution_time_ms, created_at, updated_at`
 
 	var stats models.PlanStatistics
 	err = r.DB().QueryRowContext(ctx, query,
@@ -78,6 +78,7 @@ func (r *PlanStatisticsRepository) GetOrCreate(ctx context.Context, planKey stri
 		&stats.TotalSuccesses,
 		&stats.TotalFailures,
 		&stats.TotalAPICalls,
+		&stats.ConsecutiveFailures,
 		&stats.AverageExecutionTimeMs,
 		&stats.CreatedAt,
 		&stats.UpdatedAt,
@@ -135,19 +136,24 @@ func (r *PlanStatisticsRepository) GetByPlanAndConfig(ctx context.Context, planK
 	return &stats, nil
 }
 
-// RecordExecution records an execution and updates statistics
+// RecordExecution records an execution and updates statistics.
+// It returns the failure streak of the plan/config: the failed executions in a row before this one
+// (a failure extends the streak, a success resets it) and the auth failures in a row including this one.
 // Using raw SQL for complex upsert with arithmetic
-func (r *PlanStatisticsRepository) RecordExecution(ctx context.Context, planKey string, configID uuid.UUID, success bool, executionTimeMs int) error {
+func (r *PlanStatisticsRepository) RecordExecution(ctx context.Context, planKey string, configID uuid.UUID, success bool, errorType *models.ErrorType, executionTimeMs int) (models.FailureStreak, error) {
 	ctx, span := tracing.StartSpan(ctx, "PlanStatisticsRepository.RecordExecution")
 	defer span.End()
 
 	tenantID, err := GetTenantID(ctx)
 	if err != nil {
-		return err
+		return models.FailureStreak{}, err
 	}
 
 	now := time.Now()
+	authFailure := !success && errorType != nil && *errorType == models.ErrorTypeAuth
 
+	// The upsert locks the row, so the streak it replaces is recorded in last_streak by the same update:
+	// concurrent executions are applied one after the other and each returns the streak it changed
 	// Use parameterized timestamp instead of NOW() for Citus compatibility
 	var query string
 	if success {
@@ -162,29 +168,43 @@ func (r *PlanStatisticsRepository) RecordExecution(ctx context.Context, planKey
 				last_success_at = $5,
 				total_executions = plan_statistics.total_executions + 1,
 				total_successes = plan_statistics.total_successes + 1,
+				last_streak = plan_statistics.consecutive_failures,
+				consecutive_failures = 0,
+				consecutive_auth_failures = 0,
 				average_execution_time_ms = (
 					COALESCE(plan_statistics.average_execution_time_ms, 0) * plan_statistics.total_executions + $6
 				) / (plan_statistics.total_executions + 1),
-				updated_at = $7`
+				updated_at = $7
+			RETURNING last_streak, consecutive_auth_failures`
 	} else {
 		query = `
 			INSERT INTO plan_statistics (id, tenant_id, plan_key, config_id,
 				last_execution_at, last_failure_at, total_executions, total_failures,
-				average_execution_time_ms, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, $5, $5, 1, 1, $6, $7, $7)
+				consecutive_failures, consecutive_auth_failures, average_execution_time_ms, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $5, 1, 1, 1, CASE WHEN $8 THEN 1 ELSE 0 END, $6, $7, $7)
 			ON CONFLICT (tenant_id, plan_key, config_id)
 			DO UPDATE SET
 				last_execution_at = $5,
 				last_failure_at = $5,
 				total_executions = plan_statistics.total_executions + 1,
 				total_failures = plan_statistics.total_failures + 1,
+				last_streak = plan_statistics.consecutive_failures,
+				consecutive_failures = plan_statistics.consecutive_failures + 1,
+				consecutive_auth_failures = CASE WHEN $8 THEN plan_statistics.consecutive_auth_failures + 1 ELSE 0 END,
 				average_execution_time_ms = (
 					COALESCE(plan_statistics.average_execution_time_ms, 0) * plan_statistics.total_executions + $6
 				) / (plan_statistics.total_executions + 1),
-				updated_at = $7`
+				updated_at = $7
+			RETURNING last_streak, consecutive_auth_failures`
 	}
 
-	_, err = r.DB().ExecContext(ctx, query, uuid.New(), tenantID, planKey, configID, now, executionTimeMs, now)
+	args := []any{uuid.New(), tenantID, planKey, configID, now, executionTimeMs, now}
+	if !success {
+		args = append(args, authFailure)
+	}
+
+	var streak models.FailureStreak
+	err = r.DB().QueryRowContext(ctx, query, args...).Scan(&streak.PreviousFailures, &streak.AuthFailures)
 	if err != nil {
 		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
 			"plan_key":          planKey,
@@ -192,7 +212,7 @@ func (r *PlanStatisticsRepository) RecordExecution(ctx context.Context, planKey
 			"success":           success,
 			"execution_time_ms": executionTimeMs,
 		}).Error("failed to record execution")
-		return httperror.NewHTTPError(http.StatusInternalServerError, "failed to record execution")
+		return models.FailureStreak{}, httperror.NewHTTPError(http.StatusInternalServerError, "failed to record execution")
 	}
 
 	r.logger.WithContext(ctx).WithFields(map[string]any{
@@ -200,7 +220,7 @@ func (r *PlanStatisticsRepository) RecordExecution(ctx context.Context, planKey
 		"config_id": configID,
 		"success":   success,
 	}).Debugf("Recorded execution for %s plan=%s config=%s success=%v", planStatisticsTable, planKey, configID, success)
-	return nil
+	return streak, nil
 }
 
 // ListByPlan retrieves all statistics for a plan
@@ -313,6 +333,33 @@ func (r *PlanStatisticsRepository) IncrementAPICalls(ctx context.Context, planKe
 	return nil
 }
 
+// ClearNextRun resets the next cron fire time of every config of a plan, so the scheduler
+// anchors a changed schedule afresh instead of firing at a time computed from the old one
+func (r *PlanStatisticsRepository) ClearNextRun(ctx context.Context, planKey string) error {
+	ctx, span := tracing.StartSpan(ctx, "PlanStatisticsRepository.ClearNextRun")
+	defer span.End()
+
+	tenantID, err := GetTenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Use parameterized timestamp instead of NOW() for Citus compatibility
+	query := `
+		UPDATE plan_statistics
+		SET next_run_at = NULL, updated_at = $3
+		WHERE tenant_id = $1 AND plan_key = $2 AND next_run_at IS NOT NULL`
+
+	if _, err := r.DB().ExecContext(ctx, query, tenantID, planKey, time.Now()); err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"plan_key": planKey,
+		}).Error("failed to clear next run")
+		return httperror.NewHTTPError(http.StatusInternalServerError, "failed to clear next run")
+	}
+
+	return nil
+}
+
 // DeleteByTenantID deletes all statistics for a tenant (for testing cleanup)
 func (r *PlanStatisticsRepository) DeleteByTenantID(ctx context.Context, tenantID uuid.UUID) (int64, error) {
 	ctx, span := tracing.StartSpan(ctx, "PlanStatisticsRepository.DeleteByTenantID")
@@ -0,0 +1,186 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Gobusters/ectoerror/httperror"
+	"github.com/Gobusters/ectologger"
+	"github.com/google/uuid"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+	"github.com/Ramsey-B/stem/pkg/database"
+	"github.com/Ramsey-B/stem/pkg/tracing"
+)
+
+const planWatermarksTable = "plan_watermarks"
+
+var planWatermarkStruct = database.NewStruct(new(models.PlanWatermark))
+
+// PlanWatermarkRepository handles database operations for plan watermarks
+type PlanWatermarkRepository struct {
+	*Repository
+}
+
+// NewPlanWatermarkRepository creates a new plan watermark repository
+func NewPlanWatermarkRepository(db database.DB, logger ectologger.Logger) *PlanWatermarkRepository {
+	return &PlanWatermarkRepository{
+		Repository: NewRepository(db, logger),
+	}
+}
+
+// Upsert sets the watermark of a plan/config combination, keeping the replaced value as previous_value
+// Using raw SQL for upsert with ON CONFLICT
+func (r *PlanWatermarkRepository) Upsert(ctx context.Context, watermark *models.PlanWatermark) error {
+	ctx, span := tracing.StartSpan(ctx, "PlanWatermarkRepository.Upsert")
+	defer span.End()
+
+	tenantID, err := GetTenantID(ctx)
+	if err != nil {
+		return err
+	}
+	watermark.TenantID = tenantID
+
+	now := time.Now()
+
+	// Use parameterized timestamp instead of NOW() for Citus compatibility
+	query := `
+		INSERT INTO plan_watermarks (tenant_id, plan_key, config_id, value, execution_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		ON CONFLICT (tenant_id, plan_key, config_id)
+		DO UPDATE SET previous_value = plan_watermarks.value, value = $4, execution_id = $5, updated_at = $6
+		RETURNING previous_value, created_at, updated_at`
+
+	err = r.DB().QueryRowContext(ctx, query,
+		watermark.TenantID,
+		watermark.PlanKey,
+		watermark.ConfigID,
+		watermark.Value,
+		watermark.ExecutionID,
+		now,
+	).Scan(&watermark.PreviousValue, &watermark.CreatedAt, &watermark.UpdatedAt)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"plan_key":  watermark.PlanKey,
+			"config_id": watermark.ConfigID,
+		}).Error("failed to upsert plan watermark")
+		return httperror.NewHTTPError(http.StatusInternalServerError, "failed to upsert plan watermark")
+	}
+
+	r.logger.WithContext(ctx).WithFields(map[string]any{
+		"plan_key":  watermark.PlanKey,
+		"config_id": watermark.ConfigID,
+		"value":     watermark.Value,
+	}).Infof("Upserted %s for plan=%s config=%s", planWatermarksTable, watermark.PlanKey, watermark.ConfigID)
+	return nil
+}
+
+// GetByPlanAndConfig retrieves the watermark of a plan/config combination
+func (r *PlanWatermarkRepository) GetByPlanAndConfig(ctx context.Context, planKey string, configID uuid.UUID) (*models.PlanWatermark, error) {
+	ctx, span := tracing.StartSpan(ctx, "PlanWatermarkRepository.GetByPlanAndConfig")
+	defer span.End()
+
+	tenantID, err := GetTenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sb := planWatermarkStruct.SelectFrom(planWatermarksTable)
+	sb.Where(sb.Equal("tenant_id", tenantID), sb.Equal("plan_key", planKey), sb.Equal("config_id", configID))
+
+	query, args := sb.Build()
+	var watermark models.PlanWatermark
+	err = r.DB().GetContext(ctx, &watermark, query, args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, httperror.NewHTTPErrorf(http.StatusNotFound, "watermark for plan %s with config %s does not exist", planKey, configID)
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"plan_key":  planKey,
+			"config_id": configID,
+		}).Error("failed to get plan watermark")
+		return nil, httperror.NewHTTPError(http.StatusInternalServerError, "failed to get plan watermark")
+	}
+
+	r.logger.WithContext(ctx).WithFields(map[string]any{
+		"plan_key":  planKey,
+		"config_id": configID,
+	}).Debugf("Retrieved %s for plan=%s config=%s", planWatermarksTable, planKey, configID)
+	return &watermark, nil
+}
+
+// ListByPlan retrieves the watermarks of every config of a plan
+func (r *PlanWatermarkRepository) ListByPlan(ctx context.Context, planKey string) ([]models.PlanWatermark, error) {
+	ctx, span := tracing.StartSpan(ctx, "PlanWatermarkRepository.ListByPlan")
+	defer span.End()
+
+	tenantID, err := GetTenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sb := planWatermarkStruct.SelectFrom(planWatermarksTable)
+	sb.Where(sb.Equal("tenant_id", tenantID), sb.Equal("plan_key", planKey))
+	sb.OrderBy("created_at")
+
+	query, args := sb.Build()
+	var watermarks []models.PlanWatermark
+	err = r.DB().SelectContext(ctx, &watermarks, query, args...)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"plan_key": planKey,
+		}).Error("failed to list watermarks")
+		return nil, httperror.NewHTTPError(http.StatusInternalServerError, "failed to list watermarks")
+	}
+
+	r.logger.WithContext(ctx).WithFields(map[string]any{
+		"plan_key": planKey,
+	}).Debugf("Listed %d watermarks for plan=%s", len(watermarks), planKey)
+	return watermarks, nil
+}
+
+// Delete resets the watermark of a plan/config combination
+func (r *PlanWatermarkRepository) Delete(ctx context.Context, planKey string, configID uuid.UUID) error {
+	ctx, span := tracing.StartSpan(ctx, "PlanWatermarkRepository.Delete")
+	defer span.End()
+
+	tenantID, err := GetTenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	db := database.NewDeleteBuilder()
+	db.DeleteFrom(planWatermarksTable).
+		Where(db.Equal("tenant_id", tenantID), db.Equal("plan_key", planKey), db.Equal("config_id", configID))
+
+	query, args := db.Build()
+	result, err := r.DB().ExecContext(ctx, query, args...)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"plan_key":  planKey,
+			"config_id": configID,
+		}).Error("failed to delete plan watermark")
+		return httperror.NewHTTPError(http.StatusInternalServerError, "failed to delete plan watermark")
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"plan_key":  planKey,
+			"config_id": configID,
+		}).Error("failed to delete plan watermark")
+		return httperror.NewHTTPError(http.StatusInternalServerError, "failed to delete plan watermark")
+	}
+	if rows == 0 {
+		return httperror.NewHTTPErrorf(http.StatusNotFound, "watermark for plan %s with config %s does not exist", planKey, configID)
+	}
+
+	r.logger.WithContext(ctx).WithFields(map[string]any{
+		"plan_key":  planKey,
+		"config_id": configID,
+	}).Infof("Deleted %s for plan=%s config=%s", planWatermarksTable, planKey, configID)
+	return nil
+}
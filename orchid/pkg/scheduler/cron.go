@@ -0,0 +1,270 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxCronSearchYears bounds the search for the next fire time so impossible
+// expressions such as "0 0 30 2 *" terminate
+const maxCronSearchYears = 5
+
+// cronDescriptors maps the supported shorthand descriptors to their five-field form
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var dayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// cronField describes the bounds and aliases of a single cron field
+type cronField struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+}
+
+var (
+	minuteField = cronField{name: "minute", min: 0, max: 59}
+	hourField   = cronField{name: "hour", min: 0, max: 23}
+	domField    = cronField{name: "day of month", min: 1, max: 31}
+	monthField  = cronField{name: "month", min: 1, max: 12, names: monthNames}
+	// Day of week accepts 7 as an alias for Sunday
+	dowField = cronField{name: "day of week", min: 0, max: 7, names: dayNames}
+)
+
+// CronSchedule is a parsed standard five-field cron expression
+// (minute hour day-of-month month day-of-week) bound to a timezone
+type CronSchedule struct {
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+
+	// When both day fields are restricted a day matches if either matches (Vixie cron semantics)
+	domRestricted bool
+	dowRestricted bool
+
+	// Schedules at fixed hours fire once in the hour repeated when clocks fall back; "*" fires in both
+	hourRestricted bool
+
+	location *time.Location
+}
+
+// ParseCron parses a cron expression in the given location.
+// A nil location is treated as UTC.
+func ParseCron(expr string, location *time.Location) (*CronSchedule, error) {
+	if location == nil {
+		location = time.UTC
+	}
+
+	expr = strings.TrimSpace(expr)
+	if expanded, ok := cronDescriptors[strings.ToLower(expr)]; ok {
+		expr = expanded
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields, got %d", expr, len(fields))
+	}
+
+	schedule := &CronSchedule{location: location}
+	var err error
+	if schedule.minute, err = parseCronField(fields[0], minuteField); err != nil {
+		return nil, err
+	}
+	if schedule.hour, err = parseCronField(fields[1], hourField); err != nil {
+		return nil, err
+	}
+	if schedule.dom, err = parseCronField(fields[2], domField); err != nil {
+		return nil, err
+	}
+	if schedule.month, err = parseCronField(fields[3], monthField); err != nil {
+		return nil, err
+	}
+	if schedule.dow, err = parseCronField(fields[4], dowField); err != nil {
+		return nil, err
+	}
+
+	// Fold Sunday=7 onto Sunday=0
+	if schedule.dow&(1<<7) != 0 {
+		schedule.dow |= 1
+		schedule.dow &^= 1 << 7
+	}
+
+	schedule.domRestricted = !isWildcard(fields[2])
+	schedule.dowRestricted = !isWildcard(fields[4])
+	schedule.hourRestricted = !isWildcard(fields[1])
+
+	return schedule, nil
+}
+
+// LoadLocation resolves an IANA timezone name, defaulting to UTC when empty
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+// Location returns the timezone the schedule is evaluated in
+func (s *CronSchedule) Location() *time.Location {
+	return s.location
+}
+
+// Next returns the first fire time strictly after the given time.
+// Fire times are derived from the calendar rather than from the previous run,
+// so a late or slow execution never shifts subsequent fire times.
+// Returns the zero time if the expression never fires within the search horizon.
+func (s *CronSchedule) Next(after time.Time) time.Time {
+	t := after.In(s.location)
+	// Start at the beginning of the next whole minute
+	t = t.Add(time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+
+	limit := t.Year() + maxCronSearchYears
+	for t.Year() <= limit {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = later(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.location))
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = later(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.location))
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 || (s.hourRestricted && repeatedHour(t)) {
+			// Advance in absolute time: wall clock hours are ambiguous or missing around DST changes
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+// later returns next, or the following minute when DST resolved next to a time not after t
+func later(t, next time.Time) time.Time {
+	if next.After(t) {
+		return next
+	}
+	return t.Add(time.Minute)
+}
+
+// repeatedHour reports whether t falls in the second occurrence of a wall clock hour (when clocks fall back)
+func repeatedHour(t time.Time) bool {
+	earlier := t.Add(-time.Hour)
+	return earlier.Hour() == t.Hour() && earlier.Day() == t.Day()
+}
+
+// dayMatches applies the day-of-month/day-of-week rules
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domRestricted && s.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+// parseCronField parses a comma-separated list of values, ranges and steps into a bitset
+func parseCronField(expr string, field cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		if part == "" {
+			return 0, fmt.Errorf("empty value in %s field %q", field.name, expr)
+		}
+
+		rangeExpr, step := part, 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			rangeExpr = part[:idx]
+			n, err := strconv.Atoi(part[idx+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %s field %q", field.name, part)
+			}
+			step = n
+		}
+
+		var start, end int
+		switch {
+		case rangeExpr == "*" || rangeExpr == "?":
+			start, end = field.min, field.max
+			if field.max == 7 {
+				// "*" for day of week should not double count Sunday
+				end = 6
+			}
+		case strings.Contains(rangeExpr, "-"):
+			bounds := strings.SplitN(rangeExpr, "-", 2)
+			var err error
+			if start, err = parseCronValue(bounds[0], field); err != nil {
+				return 0, err
+			}
+			if end, err = parseCronValue(bounds[1], field); err != nil {
+				return 0, err
+			}
+			if start > end {
+				return 0, fmt.Errorf("invalid range in %s field %q", field.name, part)
+			}
+		default:
+			var err error
+			if start, err = parseCronValue(rangeExpr, field); err != nil {
+				return 0, err
+			}
+			end = start
+			// "5/15" means every 15 starting at 5
+			if step > 1 {
+				end = field.max
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// parseCronValue parses a single numeric or named value and checks its bounds
+func parseCronValue(value string, field cronField) (int, error) {
+	if n, ok := field.names[strings.ToLower(value)]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q in %s field", value, field.name)
+	}
+	if n < field.min || n > field.max {
+		return 0, fmt.Errorf("value %d out of range [%d-%d] in %s field", n, field.min, field.max, field.name)
+	}
+	return n, nil
+}
+
+// isWildcard reports whether a day field places no restriction on the schedule.
+// Like Vixie cron, a field starting with "*" (including "*/2") counts as unrestricted.
+func isWildcard(expr string) bool {
+	return strings.HasPrefix(expr, "*") || expr == "?"
+}
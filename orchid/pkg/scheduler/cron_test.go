@@ -0,0 +1,109 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCronSchedule_Next_IsAnchoredToCalendar(t *testing.T) {
+	schedule, err := ParseCron("*/15 * * * *", time.UTC)
+	require.NoError(t, err)
+
+	// A run that finished late must not shift the following fire time
+	after := time.Date(2025, 3, 10, 9, 7, 42, 0, time.UTC)
+	require.Equal(t, time.Date(2025, 3, 10, 9, 15, 0, 0, time.UTC), schedule.Next(after))
+
+	onBoundary := time.Date(2025, 3, 10, 9, 15, 0, 0, time.UTC)
+	require.Equal(t, time.Date(2025, 3, 10, 9, 30, 0, 0, time.UTC), schedule.Next(onBoundary))
+}
+
+func TestCronSchedule_Next_UsesTimezone(t *testing.T) {
+	berlin, err := LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	schedule, err := ParseCron("0 6 * * mon-fri", berlin)
+	require.NoError(t, err)
+
+	// Friday evening UTC -> next fire is Monday 06:00 Berlin time
+	after := time.Date(2025, 1, 10, 20, 0, 0, 0, time.UTC)
+	next := schedule.Next(after)
+	require.Equal(t, time.Date(2025, 1, 13, 6, 0, 0, 0, berlin), next)
+	require.Equal(t, time.Date(2025, 1, 13, 5, 0, 0, 0, time.UTC), next.UTC())
+}
+
+func TestCronSchedule_Next_DaylightSavingTime(t *testing.T) {
+	newYork, err := LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	// Clocks fall back from 02:00 EDT to 01:00 EST on 2026-11-01
+	daily, err := ParseCron("0 3 * * *", newYork)
+	require.NoError(t, err)
+	next := daily.Next(time.Date(2026, 10, 31, 3, 0, 0, 0, newYork))
+	require.Equal(t, time.Date(2026, 11, 1, 3, 0, 0, 0, newYork), next)
+	require.Equal(t, time.Date(2026, 11, 1, 8, 0, 0, 0, time.UTC), next.UTC())
+
+	// The repeated hour fires once
+	repeated, err := ParseCron("30 1 * * *", newYork)
+	require.NoError(t, err)
+	first := repeated.Next(time.Date(2026, 11, 1, 0, 0, 0, 0, newYork))
+	require.Equal(t, time.Date(2026, 11, 1, 5, 30, 0, 0, time.UTC), first.UTC())
+	require.Equal(t, time.Date(2026, 11, 2, 6, 30, 0, 0, time.UTC), repeated.Next(first).UTC())
+
+	// Schedules for every hour fire in both
+	hourly, err := ParseCron("0 * * * *", newYork)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 11, 1, 6, 0, 0, 0, time.UTC), hourly.Next(time.Date(2026, 11, 1, 5, 0, 0, 0, time.UTC)).UTC())
+
+	// Clocks spring forward from 02:00 EST to 03:00 EDT on 2026-03-08, so 02:30 does not exist that day
+	skipped, err := ParseCron("30 2 * * *", newYork)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 3, 9, 2, 30, 0, 0, newYork), skipped.Next(time.Date(2026, 3, 8, 0, 0, 0, 0, newYork)))
+	require.Equal(t, time.Date(2026, 3, 8, 7, 0, 0, 0, time.UTC), hourly.Next(time.Date(2026, 3, 8, 6, 0, 0, 0, time.UTC)).UTC())
+}
+
+func TestCronSchedule_Next_DayOfMonthOrDayOfWeek(t *testing.T) {
+	schedule, err := ParseCron("0 0 1 * sun", time.UTC)
+	require.NoError(t, err)
+
+	// 2025-06-01 is a Sunday and the 1st; the next match is the following Sunday, not the next 1st
+	after := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, time.Date(2025, 6, 8, 0, 0, 0, 0, time.UTC), schedule.Next(after))
+}
+
+func TestParseCron_RejectsInvalidExpressions(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "5-1 * * * *", "*/0 * * * *", "* * * foo *"} {
+		_, err := ParseCron(expr, time.UTC)
+		require.Error(t, err, expr)
+	}
+
+	_, err := ParseCron("@daily", time.UTC)
+	require.NoError(t, err)
+}
+
+func TestInBlackout(t *testing.T) {
+	windows := []models.BlackoutWindow{
+		{Days: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "09:00", End: "17:00", Timezone: "Europe/Berlin"},
+		{Days: []string{"sat"}, Start: "22:00", End: "02:00"},
+	}
+
+	// Monday 10:00 Berlin (09:00 UTC in winter)
+	blackedOut, err := InBlackout(windows, time.UTC, time.Date(2025, 1, 13, 9, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.True(t, blackedOut)
+
+	// Monday 17:30 Berlin
+	blackedOut, err = InBlackout(windows, time.UTC, time.Date(2025, 1, 13, 16, 30, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.False(t, blackedOut)
+
+	// Sunday 01:00 UTC belongs to Saturday's window that wraps midnight
+	blackedOut, err = InBlackout(windows, time.UTC, time.Date(2025, 1, 12, 1, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.True(t, blackedOut)
+
+	_, err = InBlackout([]models.BlackoutWindow{{Start: "25:00", End: "01:00"}}, time.UTC, time.Now())
+	require.Error(t, err)
+}
@@ -2,10 +2,14 @@ package scheduler
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/Gobusters/ectologger"
+	"github.com/google/uuid"
 
+	"github.com/Ramsey-B/orchid/pkg/models"
 	"github.com/Ramsey-B/stem/pkg/database"
 	"github.com/Ramsey-B/stem/pkg/tracing"
 )
@@ -34,17 +38,21 @@ func NewSchedulerRepository(db database.DB, logger ectologger.Logger) *Scheduler
 // This query is complex:
 // 1. Finds all enabled plans
 // 2. Joins with configs (via integration_id) to find valid configs
-// 3. Left joins with plan_statistics to get last execution time
-// 4. Filters to only include plans that are due (last_execution + wait_seconds < now OR never executed)
+// 3. Left joins with plan_statistics to get last execution and next cron fire times
+// 4. Filters to only include plans that are due:
+//   - cron plans: next_run_at <= now, or next_run_at not yet computed
+//   - interval plans: last_execution + wait_seconds < now, or never executed
+//
+// Blackout windows are evaluated by the scheduler since they depend on per-window timezones.
 func (r *SchedulerRepositoryImpl) ListSchedulablePlans(ctx context.Context, limit int) ([]SchedulablePlan, error) {
 	ctx, span := tracing.StartSpan(ctx, "SchedulerRepository.ListSchedulablePlans")
 	defer span.End()
 
 	// This query:
 	// 1. Joins plans -> configs (via integration_id)
-	// 2. Left joins plan_statistics to get last_execution_at
+	// 2. Left joins plan_statistics to get last_execution_at and next_run_at
 	// 3. Filters for enabled plans and configs
-	// 4. Filters for plans that are due (never executed OR last_execution + wait_seconds < now)
+	// 4. Filters for plans that are due
 	query := `
 		SELECT 
 			p.tenant_id,
@@ -53,17 +61,30 @@ func (r *SchedulerRepositoryImpl) ListSchedulablePlans(ctx context.Context, limi
 			c.id AS config_id,
 			i.id AS integration_id,
 			COALESCE(p.wait_seconds, $1) AS wait_seconds,
-			ps.last_execution_at
+			ps.last_execution_at,
+			p.schedule_cron,
+			p.schedule_timezone,
+			p.blackout_windows,
+			ps.next_run_at
 		FROM plans p
 		INNER JOIN configs c ON p.tenant_id = c.tenant_id AND p.integration_id = c.integration_id AND c.enabled = true
 		INNER JOIN integrations i ON p.tenant_id = i.tenant_id AND p.integration_id = i.id
 		LEFT JOIN plan_statistics ps ON p.tenant_id = ps.tenant_id AND p.key = ps.plan_key AND c.id = ps.config_id
 		WHERE p.enabled = true
 		AND (
-			ps.last_execution_at IS NULL
-			OR ps.last_execution_at + (COALESCE(p.wait_seconds, $1) * INTERVAL '1 second') < NOW()
+			(
+				p.schedule_cron IS NOT NULL
+				AND (ps.next_run_at IS NULL OR ps.next_run_at <= NOW())
+			)
+			OR (
+				p.schedule_cron IS NULL
+				AND (
+					ps.last_execution_at IS NULL
+					OR ps.last_execution_at + (COALESCE(p.wait_seconds, $1) * INTERVAL '1 second') < NOW()
+				)
+			)
 		)
-		ORDER BY ps.last_execution_at ASC NULLS FIRST
+		ORDER BY COALESCE(ps.next_run_at, ps.last_execution_at) ASC NULLS FIRST
 		LIMIT $2
 	`
 
@@ -78,6 +99,7 @@ func (r *SchedulerRepositoryImpl) ListSchedulablePlans(ctx context.Context, limi
 	for rows.Next() {
 		var plan SchedulablePlan
 		var lastExec *time.Time
+		var blackoutWindows database.JSONB[[]models.BlackoutWindow]
 
 		err := rows.Scan(
 			&plan.TenantID,
@@ -87,6 +109,10 @@ func (r *SchedulerRepositoryImpl) ListSchedulablePlans(ctx context.Context, limi
 			&plan.IntegrationID,
 			&plan.WaitSeconds,
 			&lastExec,
+			&plan.ScheduleCron,
+			&plan.ScheduleTimezone,
+			&blackoutWindows,
+			&plan.NextRunAt,
 		)
 		if err != nil {
 			r.logger.WithContext(ctx).WithError(err).Error("Failed to scan schedulable plan")
@@ -94,6 +120,7 @@ func (r *SchedulerRepositoryImpl) ListSchedulablePlans(ctx context.Context, limi
 		}
 
 		plan.LastExecutionAt = lastExec
+		plan.BlackoutWindows = blackoutWindows.Data
 		plans = append(plans, plan)
 	}
 
@@ -105,3 +132,81 @@ func (r *SchedulerRepositoryImpl) ListSchedulablePlans(ctx context.Context, limi
 	r.logger.WithContext(ctx).Debugf("Found %d schedulable plans", len(plans))
 	return plans, nil
 }
+
+// ClaimNextRun advances next_run_at for a cron plan+config from the value the scheduler observed to next.
+// The update is conditional so that when several scheduler instances race for the same fire time only
+// one of them wins; the others get false and must not publish.
+func (r *SchedulerRepositoryImpl) ClaimNextRun(ctx context.Context, plan SchedulablePlan, next time.Time) (bool, error) {
+	ctx, span := tracing.StartSpan(ctx, "SchedulerRepository.ClaimNextRun")
+	defer span.End()
+
+	now := time.Now()
+
+	// Use parameterized timestamp instead of NOW() for Citus compatibility
+	query := `
+		INSERT INTO plan_statistics (id, tenant_id, plan_key, config_id, next_run_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		ON CONFLICT (tenant_id, plan_key, config_id)
+		DO UPDATE SET
+			next_run_at = EXCLUDED.next_run_at,
+			updated_at = EXCLUDED.updated_at
+		WHERE plan_statistics.next_run_at IS NOT DISTINCT FROM $7
+		RETURNING id`
+
+	var id uuid.UUID
+	err := r.db.QueryRowContext(ctx, query,
+		uuid.New(),
+		plan.TenantID,
+		plan.PlanKey,
+		plan.ConfigID,
+		next,
+		now,
+		plan.NextRunAt,
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"tenant_id": plan.TenantID,
+			"plan_key":  plan.PlanKey,
+			"config_id": plan.ConfigID,
+		}).Error("Failed to claim next run")
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ReleaseRun moves next_run_at of a cron plan+config back from next to the fire time the scheduler
+// observed (plan.NextRunAt). The update is conditional, so a fire time another scheduler has claimed since
+// is left alone.
+func (r *SchedulerRepositoryImpl) ReleaseRun(ctx context.Context, plan SchedulablePlan, next time.Time) error {
+	ctx, span := tracing.StartSpan(ctx, "SchedulerRepository.ReleaseRun")
+	defer span.End()
+
+	// Use parameterized timestamp instead of NOW() for Citus compatibility
+	query := `
+		UPDATE plan_statistics
+		SET next_run_at = $4, updated_at = $5
+		WHERE tenant_id = $1 AND plan_key = $2 AND config_id = $3 AND next_run_at = $6`
+
+	_, err := r.db.ExecContext(ctx, query,
+		plan.TenantID,
+		plan.PlanKey,
+		plan.ConfigID,
+		plan.NextRunAt,
+		time.Now(),
+		next,
+	)
+	if err != nil {
+		r.logger.WithContext(ctx).WithError(err).WithFields(map[string]any{
+			"tenant_id": plan.TenantID,
+			"plan_key":  plan.PlanKey,
+			"config_id": plan.ConfigID,
+		}).Error("Failed to release next run")
+		return err
+	}
+
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/Gobusters/ectologger"
 	"github.com/google/uuid"
 
+	"github.com/Ramsey-B/orchid/pkg/models"
 	"github.com/Ramsey-B/orchid/pkg/queue"
 	"github.com/Ramsey-B/orchid/pkg/redis"
 	appctx "github.com/Ramsey-B/stem/pkg/context"
@@ -21,6 +22,10 @@ var (
 
 	// ErrSchedulerAlreadyRunning is returned when trying to start an already running scheduler
 	ErrSchedulerAlreadyRunning = errors.New("scheduler already running")
+
+	// ErrPlanSkipped is returned when a due plan is intentionally not published
+	// (blackout window, first cron sighting, fire time claimed by another scheduler, or tenant at its cap)
+	ErrPlanSkipped = errors.New("plan skipped")
 )
 
 const (
@@ -46,6 +51,12 @@ type SchedulablePlan struct {
 	IntegrationID   uuid.UUID
 	WaitSeconds     int
 	LastExecutionAt *time.Time
+
+	// Cron scheduling; when ScheduleCron is set WaitSeconds is ignored
+	ScheduleCron     *string
+	ScheduleTimezone *string
+	BlackoutWindows  []models.BlackoutWindow
+	NextRunAt        *time.Time
 }
 
 // SchedulerRepository defines the interface for scheduler data access
@@ -53,6 +64,13 @@ type SchedulablePlan struct {
 type SchedulerRepository interface {
 	// ListSchedulablePlans returns all enabled plan+config combinations that are due for execution
 	ListSchedulablePlans(ctx context.Context, limit int) ([]SchedulablePlan, error)
+
+	// ClaimNextRun advances next_run_at for a cron plan+config, returning false if another scheduler already did
+	ClaimNextRun(ctx context.Context, plan SchedulablePlan, next time.Time) (bool, error)
+
+	// ReleaseRun moves next_run_at back from next to the fire time the scheduler claimed it from,
+	// unless it changed since
+	ReleaseRun(ctx context.Context, plan SchedulablePlan, next time.Time) error
 }
 
 // Config holds configuration for the scheduler
@@ -65,9 +83,6 @@ type Config struct {
 
 	// BatchSize is the maximum number of plans to schedule per poll
 	BatchSize int
-
-	// JobQueue is the Redis Streams queue name
-	JobQueue string
 }
 
 // DefaultConfig returns the default scheduler configuration
@@ -76,17 +91,16 @@ func DefaultConfig() Config {
 		PollInterval: DefaultPollInterval,
 		LockTTL:      DefaultLockTTL,
 		BatchSize:    DefaultBatchSize,
-		JobQueue:     "orchid:jobs",
 	}
 }
 
 // Scheduler polls for and schedules plan executions
 type Scheduler struct {
-	repo    SchedulerRepository
-	streams *redis.Streams
-	locker  *redis.Locker
-	config  Config
-	logger  ectologger.Logger
+	repo     SchedulerRepository
+	jobQueue *redis.JobQueue
+	locker   *redis.Locker
+	config   Config
+	logger   ectologger.Logger
 
 	// Coordination
 	stopCh   chan struct{}
@@ -98,7 +112,7 @@ type Scheduler struct {
 // NewScheduler creates a new scheduler
 func NewScheduler(
 	repo SchedulerRepository,
-	streams *redis.Streams,
+	jobQueue *redis.JobQueue,
 	locker *redis.Locker,
 	config Config,
 	logger ectologger.Logger,
@@ -113,13 +127,10 @@ func NewScheduler(
 	if config.BatchSize <= 0 {
 		config.BatchSize = DefaultBatchSize
 	}
-	if config.JobQueue == "" {
-		config.JobQueue = "orchid:jobs"
-	}
 
 	return &Scheduler{
 		repo:     repo,
-		streams:  streams,
+		jobQueue: jobQueue,
 		locker:   locker,
 		config:   config,
 		logger:   logger,
@@ -232,7 +243,7 @@ func (s *Scheduler) runSchedulingCycle(ctx context.Context) {
 	skipped := 0
 	for _, plan := range plans {
 		if err := s.schedulePlan(ctx, plan); err != nil {
-			if errors.Is(err, redis.ErrLockNotAcquired) {
+			if errors.Is(err, redis.ErrLockNotAcquired) || errors.Is(err, ErrPlanSkipped) {
 				skipped++
 				continue
 			}
@@ -267,6 +278,67 @@ func (s *Scheduler) schedulePlan(ctx context.Context, plan SchedulablePlan) erro
 	// Set tenant context for logging
 	ctx = appctx.SetTenantID(ctx, plan.TenantID.String())
 
+	// A tenant at its cap is skipped before the fire time is claimed, so the plan stays due
+	atCapacity, err := s.jobQueue.AtCapacity(ctx, plan.TenantID.String())
+	if err != nil {
+		return err
+	}
+	if atCapacity {
+		s.logger.WithContext(ctx).Infof("Deferring plan %s with config %s: tenant has %d jobs in flight",
+			plan.PlanKey, plan.ConfigID, s.jobQueue.MaxInFlight(plan.TenantID.String()))
+		return ErrPlanSkipped
+	}
+
+	now := time.Now()
+	scheduledAt := now
+
+	location, err := LoadLocation(stringValue(plan.ScheduleTimezone))
+	if err != nil {
+		return err
+	}
+
+	// The fire time claimed for this run; it is released when the job cannot be published,
+	// so the plan stays due instead of losing the run
+	var claimed *time.Time
+	if plan.ScheduleCron != nil {
+		schedule, err := ParseCron(*plan.ScheduleCron, location)
+		if err != nil {
+			return err
+		}
+
+		// Advance to the next calendar fire time after now. Missed fire times are coalesced
+		// into this run and the schedule never depends on when the previous run finished.
+		next := schedule.Next(now)
+		won, err := s.repo.ClaimNextRun(ctx, plan, next)
+		if err != nil {
+			return err
+		}
+		if !won {
+			return ErrPlanSkipped
+		}
+
+		if plan.NextRunAt == nil {
+			// First time this plan+config has been seen: anchor the schedule and wait for the first fire time
+			s.logger.WithContext(ctx).Debugf("Plan %s with config %s first fires at %s",
+				plan.PlanKey, plan.ConfigID, next)
+			return ErrPlanSkipped
+		}
+		scheduledAt = *plan.NextRunAt
+		claimed = &next
+	}
+
+	if len(plan.BlackoutWindows) > 0 {
+		blackedOut, err := InBlackout(plan.BlackoutWindows, location, now)
+		if err != nil {
+			return err
+		}
+		if blackedOut {
+			s.logger.WithContext(ctx).Infof("Skipping plan %s with config %s: inside blackout window",
+				plan.PlanKey, plan.ConfigID)
+			return ErrPlanSkipped
+		}
+	}
+
 	s.logger.WithContext(ctx).Debugf("Scheduling plan %s with config %s", plan.PlanKey, plan.ConfigID)
 
 	// Create the job
@@ -275,11 +347,19 @@ func (s *Scheduler) schedulePlan(ctx context.Context, plan SchedulablePlan) erro
 		Integration: plan.Integration,
 		PlanKey:     plan.PlanKey,
 		ConfigID:    plan.ConfigID.String(),
-		ScheduledAt: time.Now(),
+		ScheduledAt: scheduledAt,
 	}
 
 	// Publish to the queue
-	messageID, err := queue.PublishPlanExecution(ctx, s.streams, s.config.JobQueue, job)
+	messageID, err := queue.PublishPlanExecution(ctx, s.jobQueue, redis.LaneScheduled, job)
+	if err != nil {
+		s.releaseRun(ctx, plan, claimed)
+	}
+	if errors.Is(err, redis.ErrTenantAtCapacity) {
+		// Another publisher filled the tenant's cap since the check above
+		s.logger.WithContext(ctx).Warnf("Skipping plan %s with config %s: %v", plan.PlanKey, plan.ConfigID, err)
+		return ErrPlanSkipped
+	}
 	if err != nil {
 		s.logger.WithContext(ctx).WithError(err).Errorf("Failed to publish job for plan %s config %s",
 			plan.PlanKey, plan.ConfigID)
@@ -292,6 +372,26 @@ func (s *Scheduler) schedulePlan(ctx context.Context, plan SchedulablePlan) erro
 	return nil
 }
 
+// releaseRun gives back a claimed fire time whose job was not published, so the next poll retries it
+func (s *Scheduler) releaseRun(ctx context.Context, plan SchedulablePlan, claimed *time.Time) {
+	if claimed == nil {
+		return
+	}
+	// The publish may have failed on a cancelled context
+	if err := s.repo.ReleaseRun(context.WithoutCancel(ctx), plan, *claimed); err != nil {
+		s.logger.WithContext(ctx).WithError(err).Errorf("Failed to release fire time of plan %s config %s; it is skipped",
+			plan.PlanKey, plan.ConfigID)
+	}
+}
+
+// stringValue dereferences an optional string
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
 // lockKey generates a lock key for a plan+config combination
 func (s *Scheduler) lockKey(planKey string, configID uuid.UUID) string {
 	return LockKeyPrefix + planKey + ":" + configID.String()
@@ -0,0 +1,126 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+)
+
+// weekdayNames accepts both short and full day names
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+// blackoutWindow is a validated models.BlackoutWindow
+type blackoutWindow struct {
+	days     map[time.Weekday]bool // nil means every day
+	start    int                   // minutes since midnight
+	end      int                   // minutes since midnight
+	location *time.Location
+}
+
+// ValidateBlackoutWindows checks that every window has valid days, times and timezone
+func ValidateBlackoutWindows(windows []models.BlackoutWindow) error {
+	_, err := parseBlackoutWindows(windows, time.UTC)
+	return err
+}
+
+// InBlackout reports whether t falls inside any of the blackout windows.
+// Windows without a timezone are evaluated in defaultLocation.
+func InBlackout(windows []models.BlackoutWindow, defaultLocation *time.Location, t time.Time) (bool, error) {
+	parsed, err := parseBlackoutWindows(windows, defaultLocation)
+	if err != nil {
+		return false, err
+	}
+	for _, w := range parsed {
+		if w.contains(t) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// contains reports whether t is inside the window
+func (w blackoutWindow) contains(t time.Time) bool {
+	local := t.In(w.location)
+	minute := local.Hour()*60 + local.Minute()
+	today := local.Weekday()
+
+	switch {
+	case w.start == w.end:
+		// Whole day
+		return w.onDay(today)
+	case w.start < w.end:
+		return w.onDay(today) && minute >= w.start && minute < w.end
+	default:
+		// Wraps midnight: the late part belongs to today, the early part to yesterday's window
+		if minute >= w.start {
+			return w.onDay(today)
+		}
+		if minute < w.end {
+			return w.onDay((today + 6) % 7)
+		}
+		return false
+	}
+}
+
+// onDay reports whether the window applies on the given weekday
+func (w blackoutWindow) onDay(day time.Weekday) bool {
+	return w.days == nil || w.days[day]
+}
+
+// parseBlackoutWindows converts model windows into their evaluated form
+func parseBlackoutWindows(windows []models.BlackoutWindow, defaultLocation *time.Location) ([]blackoutWindow, error) {
+	if defaultLocation == nil {
+		defaultLocation = time.UTC
+	}
+
+	parsed := make([]blackoutWindow, 0, len(windows))
+	for i, window := range windows {
+		w := blackoutWindow{location: defaultLocation}
+
+		if window.Timezone != "" {
+			loc, err := LoadLocation(window.Timezone)
+			if err != nil {
+				return nil, fmt.Errorf("blackout_windows[%d]: %w", i, err)
+			}
+			w.location = loc
+		}
+
+		var err error
+		if w.start, err = parseClock(window.Start); err != nil {
+			return nil, fmt.Errorf("blackout_windows[%d].start: %w", i, err)
+		}
+		if w.end, err = parseClock(window.End); err != nil {
+			return nil, fmt.Errorf("blackout_windows[%d].end: %w", i, err)
+		}
+
+		if len(window.Days) > 0 {
+			w.days = make(map[time.Weekday]bool, len(window.Days))
+			for _, day := range window.Days {
+				weekday, ok := weekdayNames[strings.ToLower(strings.TrimSpace(day))]
+				if !ok {
+					return nil, fmt.Errorf("blackout_windows[%d].days: invalid day %q", i, day)
+				}
+				w.days[weekday] = true
+			}
+		}
+
+		parsed = append(parsed, w)
+	}
+	return parsed, nil
+}
+
+// parseClock parses an HH:MM time of day into minutes since midnight
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
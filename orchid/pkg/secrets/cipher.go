@@ -0,0 +1,283 @@
+package secrets
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const (
+	// EnvelopeKey marks an encrypted value inside config values: {"$encrypted": {...}}
+	EnvelopeKey = "$encrypted"
+
+	// envelopeVersion is the current envelope format version
+	envelopeVersion = 1
+)
+
+// ErrNoKeyProvider is returned when encrypted values are found but no key provider is configured
+var ErrNoKeyProvider = errors.New("secret encryption is not configured")
+
+// Envelope is an envelope-encrypted value.
+// The value is encrypted with a random per-value data key, and the data key is wrapped by the KeyProvider.
+type Envelope struct {
+	Version    int    `json:"v"`
+	KeyID      string `json:"kid"`
+	DataKey    string `json:"dk"` // Wrapped data key (base64)
+	Ciphertext string `json:"ct"` // AES-GCM nonce+ciphertext of the JSON-encoded value (base64)
+}
+
+// Cipher envelope-encrypts and decrypts secret config values
+type Cipher struct {
+	provider KeyProvider
+}
+
+// NewCipher creates a new cipher backed by a key provider
+func NewCipher(provider KeyProvider) *Cipher {
+	return &Cipher{provider: provider}
+}
+
+// EncryptValue encrypts any JSON value into an envelope wrapper
+func (c *Cipher) EncryptValue(ctx context.Context, value any) (map[string]any, error) {
+	if c == nil || c.provider == nil {
+		return nil, ErrNoKeyProvider
+	}
+
+	plaintext, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal secret value: %w", err)
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	ciphertext, err := seal(dataKey, plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt secret value: %w", err)
+	}
+
+	wrapped, err := c.provider.WrapKey(ctx, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	return map[string]any{
+		EnvelopeKey: map[string]any{
+			"v":   envelopeVersion,
+			"kid": c.provider.KeyID(),
+			"dk":  base64.StdEncoding.EncodeToString(wrapped),
+			"ct":  base64.StdEncoding.EncodeToString(ciphertext),
+		},
+	}, nil
+}
+
+// DecryptValue decrypts an envelope wrapper produced by EncryptValue
+func (c *Cipher) DecryptValue(ctx context.Context, wrapper map[string]any) (any, error) {
+	if c == nil || c.provider == nil {
+		return nil, ErrNoKeyProvider
+	}
+
+	envelope, err := parseEnvelope(wrapper)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(envelope.DataKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid wrapped data key: %w", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(envelope.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ciphertext: %w", err)
+	}
+
+	dataKey, err := c.provider.UnwrapKey(ctx, envelope.KeyID, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+
+	plaintext, err := open(dataKey, ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret value: %w", err)
+	}
+
+	var value any
+	if err := json.Unmarshal(plaintext, &value); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal secret value: %w", err)
+	}
+	return value, nil
+}
+
+// EncryptFields returns a copy of values with every secret path encrypted.
+// Values that are already encrypted are kept as-is.
+func (c *Cipher) EncryptFields(ctx context.Context, values map[string]any, paths []Path) (map[string]any, error) {
+	result := copyMap(values)
+	for _, path := range paths {
+		parent, key, ok := path.parent(result)
+		if !ok {
+			continue
+		}
+		value, exists := parent[key]
+		if !exists || value == nil || IsEncrypted(value) {
+			continue
+		}
+
+		encrypted, err := c.EncryptValue(ctx, value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt %s: %w", path, err)
+		}
+		parent[key] = encrypted
+	}
+	return result, nil
+}
+
+// DecryptValues returns a copy of values with every encrypted value decrypted,
+// along with the decrypted string values so callers can mask them in logs.
+// Values without envelopes are returned unchanged, so decrypting twice is harmless.
+func (c *Cipher) DecryptValues(ctx context.Context, values map[string]any) (map[string]any, []string, error) {
+	var plaintexts []string
+	decrypted, err := c.decryptAny(ctx, values, &plaintexts)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, _ := decrypted.(map[string]any)
+	return result, plaintexts, nil
+}
+
+// decryptAny walks a JSON value decrypting envelopes
+func (c *Cipher) decryptAny(ctx context.Context, value any, plaintexts *[]string) (any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		if IsEncrypted(v) {
+			plain, err := c.DecryptValue(ctx, v)
+			if err != nil {
+				return nil, err
+			}
+			collectStrings(plain, plaintexts)
+			return plain, nil
+		}
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			decrypted, err := c.decryptAny(ctx, item, plaintexts)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = decrypted
+		}
+		return result, nil
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			decrypted, err := c.decryptAny(ctx, item, plaintexts)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = decrypted
+		}
+		return result, nil
+	default:
+		return value, nil
+	}
+}
+
+// IsEncrypted reports whether a value is an envelope wrapper
+func IsEncrypted(value any) bool {
+	m, ok := value.(map[string]any)
+	if !ok || len(m) != 1 {
+		return false
+	}
+	_, ok = m[EnvelopeKey].(map[string]any)
+	return ok
+}
+
+// ContainsEncrypted reports whether any value inside a JSON value is an envelope wrapper
+func ContainsEncrypted(value any) bool {
+	switch v := value.(type) {
+	case map[string]any:
+		if IsEncrypted(v) {
+			return true
+		}
+		for _, item := range v {
+			if ContainsEncrypted(item) {
+				return true
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if ContainsEncrypted(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseEnvelope extracts the envelope from its wrapper
+func parseEnvelope(wrapper map[string]any) (*Envelope, error) {
+	raw, ok := wrapper[EnvelopeKey]
+	if !ok {
+		return nil, errors.New("value is not encrypted")
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid envelope: %w", err)
+	}
+	var envelope Envelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("invalid envelope: %w", err)
+	}
+	if envelope.Version != envelopeVersion {
+		return nil, fmt.Errorf("unsupported envelope version %d", envelope.Version)
+	}
+	return &envelope, nil
+}
+
+// collectStrings gathers the string leaves of a decrypted value
+func collectStrings(value any, out *[]string) {
+	switch v := value.(type) {
+	case string:
+		if v != "" {
+			*out = append(*out, v)
+		}
+	case map[string]any:
+		for _, item := range v {
+			collectStrings(item, out)
+		}
+	case []any:
+		for _, item := range v {
+			collectStrings(item, out)
+		}
+	}
+}
+
+// copyMap deep-copies a JSON object
+func copyMap(values map[string]any) map[string]any {
+	if values == nil {
+		return nil
+	}
+	result := make(map[string]any, len(values))
+	for key, value := range values {
+		result[key] = copyValue(value)
+	}
+	return result
+}
+
+// copyValue deep-copies a JSON value
+func copyValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		return copyMap(v)
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = copyValue(item)
+		}
+		return result
+	default:
+		return value
+	}
+}
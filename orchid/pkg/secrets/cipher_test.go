@@ -0,0 +1,119 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCipher(t *testing.T, keys ...[]byte) *Cipher {
+	t.Helper()
+	provider, err := NewLocalKeyProviderFromKeys(keys...)
+	require.NoError(t, err)
+	return NewCipher(provider)
+}
+
+func TestCipher_EncryptFieldsRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	cipher := newTestCipher(t, bytes.Repeat([]byte{1}, 32))
+
+	schema := map[string]any{
+		"name": "acme",
+		"schema": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"base_url": map[string]any{"type": "string"},
+				"api_key":  map[string]any{"type": "string", "secret": true},
+				"oauth": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"client_secret": map[string]any{"type": "string", "secret": true},
+					},
+				},
+			},
+		},
+	}
+	paths := SecretPaths(schema)
+	require.Equal(t, []Path{{"api_key"}, {"oauth", "client_secret"}}, paths)
+
+	values := map[string]any{
+		"base_url": "https://api.example.com",
+		"api_key":  "sk_live_123456",
+		"oauth":    map[string]any{"client_secret": "hunter2hunter2"},
+	}
+
+	encrypted, err := cipher.EncryptFields(ctx, values, paths)
+	require.NoError(t, err)
+	require.True(t, IsEncrypted(encrypted["api_key"]))
+	require.True(t, IsEncrypted(encrypted["oauth"].(map[string]any)["client_secret"]))
+	require.Equal(t, "https://api.example.com", encrypted["base_url"])
+	require.Equal(t, "sk_live_123456", values["api_key"], "input must not be mutated")
+
+	// Re-encrypting leaves existing envelopes untouched
+	again, err := cipher.EncryptFields(ctx, encrypted, paths)
+	require.NoError(t, err)
+	require.Equal(t, encrypted, again)
+
+	decrypted, plaintexts, err := cipher.DecryptValues(ctx, encrypted)
+	require.NoError(t, err)
+	require.Equal(t, values, decrypted)
+	require.ElementsMatch(t, []string{"sk_live_123456", "hunter2hunter2"}, plaintexts)
+
+	// Without a key provider, encrypted values cannot be read
+	_, _, err = (*Cipher)(nil).DecryptValues(ctx, encrypted)
+	require.ErrorIs(t, err, ErrNoKeyProvider)
+}
+
+func TestCipher_DecryptsWithRotatedKeys(t *testing.T) {
+	ctx := context.Background()
+	oldKey := bytes.Repeat([]byte{1}, 32)
+	newKey := bytes.Repeat([]byte{2}, 32)
+
+	encrypted, err := newTestCipher(t, oldKey).EncryptValue(ctx, "secret-value")
+	require.NoError(t, err)
+
+	value, err := newTestCipher(t, newKey, oldKey).DecryptValue(ctx, encrypted)
+	require.NoError(t, err)
+	require.Equal(t, "secret-value", value)
+
+	_, err = newTestCipher(t, newKey).DecryptValue(ctx, encrypted)
+	require.ErrorIs(t, err, ErrUnknownKey)
+}
+
+func TestRedactAndRestore(t *testing.T) {
+	ctx := context.Background()
+	cipher := newTestCipher(t, bytes.Repeat([]byte{1}, 32))
+	paths := []Path{{"api_key"}, {"password"}}
+
+	stored, err := cipher.EncryptFields(ctx, map[string]any{"api_key": "sk_live_123456", "region": "eu"}, paths)
+	require.NoError(t, err)
+
+	redacted := Redact(stored, paths)
+	require.Equal(t, map[string]any{"api_key": RedactedValue, "region": "eu"}, redacted)
+
+	// A client sending back the redacted placeholder keeps the stored secret
+	restored := RestoreRedacted(map[string]any{"api_key": RedactedValue, "region": "us"}, stored, paths)
+	require.Equal(t, stored["api_key"], restored["api_key"])
+	require.Equal(t, "us", restored["region"])
+
+	masker := NewMasker("sk_live_123456", "abc")
+	require.Equal(t, "https://api.example.com/v1?key=********&q=abc", masker.Mask("https://api.example.com/v1?key=sk_live_123456&q=abc"))
+
+	body := map[string]any{"token": "sk_live_123456", "items": []any{"Bearer sk_live_123456", float64(1)}}
+	require.Equal(t, map[string]any{"token": RedactedValue, "items": []any{"Bearer " + RedactedValue, float64(1)}}, masker.MaskValue(body))
+	require.Equal(t, "sk_live_123456", body["token"])
+}
+
+func TestSecretValues(t *testing.T) {
+	values := map[string]any{
+		"base_url": "https://api.example.com",
+		"api_key":  "sk_live_123456",
+		"oauth":    map[string]any{"client_id": "acme", "client_secret": "cs_987654"},
+		"password": map[string]any{EnvelopeKey: map[string]any{}},
+	}
+	paths := []Path{{"api_key"}, {"oauth", "client_secret"}, {"password"}, {"missing"}}
+
+	require.Equal(t, []string{"sk_live_123456", "cs_987654"}, SecretValues(values, paths))
+}
@@ -0,0 +1,173 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var (
+	// ErrUnknownKey is returned when a data key was wrapped with a key the provider does not hold
+	ErrUnknownKey = errors.New("unknown key-encryption key")
+
+	// ErrNoKeys is returned when a key provider is created without any keys
+	ErrNoKeys = errors.New("no key-encryption keys configured")
+)
+
+// KeyProvider wraps and unwraps per-value data keys with a key-encryption key.
+// Implementations can be backed by local key files or an external KMS.
+type KeyProvider interface {
+	// KeyID returns the identifier of the key used to wrap new data keys
+	KeyID() string
+
+	// WrapKey encrypts a data key with the key identified by KeyID
+	WrapKey(ctx context.Context, dataKey []byte) ([]byte, error)
+
+	// UnwrapKey decrypts a data key that was wrapped with the given key
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// Config holds key provider configuration
+type Config struct {
+	Provider string   // Key provider type (local)
+	KeyFiles []string // Key files for the local provider; the first wraps new data keys
+}
+
+// NewKeyProvider creates the key provider selected by cfg.
+// It returns nil when no keys are configured, which disables secret storage.
+func NewKeyProvider(cfg Config) (KeyProvider, error) {
+	switch cfg.Provider {
+	case "", "local":
+		if len(cfg.KeyFiles) == 0 {
+			return nil, nil
+		}
+		return NewLocalKeyProvider(cfg.KeyFiles...)
+	default:
+		return nil, fmt.Errorf("unsupported secrets key provider %q", cfg.Provider)
+	}
+}
+
+// LocalKeyProvider is a KeyProvider backed by 256-bit keys loaded from local files.
+// The first key wraps new data keys; all keys can unwrap, which allows rotation.
+type LocalKeyProvider struct {
+	activeID string
+	keys     map[string][]byte
+}
+
+// NewLocalKeyProvider loads key-encryption keys from key files.
+// Each file holds a 32-byte key, either raw or base64 encoded.
+func NewLocalKeyProvider(paths ...string) (*LocalKeyProvider, error) {
+	keys := make([][]byte, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file %s: %w", path, err)
+		}
+		key, err := decodeKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key file %s: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+	return NewLocalKeyProviderFromKeys(keys...)
+}
+
+// NewLocalKeyProviderFromKeys creates a local key provider from in-memory 32-byte keys
+func NewLocalKeyProviderFromKeys(keys ...[]byte) (*LocalKeyProvider, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	provider := &LocalKeyProvider{keys: make(map[string][]byte, len(keys))}
+	for i, key := range keys {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("key %d must be 32 bytes, got %d", i, len(key))
+		}
+		id := keyID(key)
+		provider.keys[id] = key
+		if i == 0 {
+			provider.activeID = id
+		}
+	}
+	return provider, nil
+}
+
+// KeyID returns the identifier of the active key
+func (p *LocalKeyProvider) KeyID() string {
+	return p.activeID
+}
+
+// WrapKey encrypts a data key with the active key using AES-GCM
+func (p *LocalKeyProvider) WrapKey(ctx context.Context, dataKey []byte) ([]byte, error) {
+	return seal(p.keys[p.activeID], dataKey)
+}
+
+// UnwrapKey decrypts a data key wrapped with the given key
+func (p *LocalKeyProvider) UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	key, ok := p.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+	return open(key, wrapped)
+}
+
+// keyID derives a stable, non-secret identifier for a key
+func keyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return "local:" + hex.EncodeToString(sum[:8])
+}
+
+// decodeKey accepts a raw 32-byte key or a base64 encoded one
+func decodeKey(data []byte) ([]byte, error) {
+	if len(data) == 32 {
+		return data, nil
+	}
+	trimmed := bytes.TrimSpace(data)
+	key, err := base64.StdEncoding.DecodeString(string(trimmed))
+	if err != nil {
+		return nil, errors.New("expected 32 raw bytes or base64")
+	}
+	return key, nil
+}
+
+// seal encrypts plaintext with AES-GCM, prefixing the random nonce
+func seal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// open decrypts data produced by seal
+func open(key, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,163 @@
+package secrets
+
+import (
+	"sort"
+	"strings"
+)
+
+// RedactedValue replaces secret values in API responses and logs
+const RedactedValue = "********"
+
+// Redact returns a copy of values with every encrypted value and secret path replaced by RedactedValue
+func Redact(values map[string]any, paths []Path) map[string]any {
+	result, _ := redactAny(copyMap(values)).(map[string]any)
+	for _, path := range paths {
+		parent, key, ok := path.parent(result)
+		if !ok {
+			continue
+		}
+		if value, exists := parent[key]; exists && value != nil {
+			parent[key] = RedactedValue
+		}
+	}
+	return result
+}
+
+// redactAny replaces envelopes anywhere inside a JSON value
+func redactAny(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		if IsEncrypted(v) {
+			return RedactedValue
+		}
+		for key, item := range v {
+			v[key] = redactAny(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = redactAny(item)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// RestoreRedacted returns a copy of incoming where secret fields still holding RedactedValue
+// take their value from existing. This lets clients send back a redacted config
+// without overwriting the stored secret.
+func RestoreRedacted(incoming, existing map[string]any, paths []Path) map[string]any {
+	result := copyMap(incoming)
+	for _, path := range paths {
+		parent, key, ok := path.parent(result)
+		if !ok || parent[key] != RedactedValue {
+			continue
+		}
+		existingParent, existingKey, ok := path.parent(existing)
+		if !ok {
+			delete(parent, key)
+			continue
+		}
+		if value, exists := existingParent[existingKey]; exists {
+			parent[key] = copyValue(value)
+		} else {
+			delete(parent, key)
+		}
+	}
+	return result
+}
+
+// minMaskLength avoids masking trivially short values (e.g. "1", "true") that would mangle logs
+const minMaskLength = 4
+
+// Masker replaces known secret strings with RedactedValue
+type Masker struct {
+	secrets []string
+}
+
+// NewMasker creates a masker for the given secret strings
+func NewMasker(secrets ...string) *Masker {
+	unique := make([]string, 0, len(secrets))
+	seen := make(map[string]bool, len(secrets))
+	for _, secret := range secrets {
+		if len(secret) < minMaskLength || seen[secret] {
+			continue
+		}
+		seen[secret] = true
+		unique = append(unique, secret)
+	}
+	// Longest first so a secret containing another is masked whole
+	sort.Slice(unique, func(i, j int) bool { return len(unique[i]) > len(unique[j]) })
+	return &Masker{secrets: unique}
+}
+
+// Mask replaces every secret in s with RedactedValue
+func (m *Masker) Mask(s string) string {
+	if m == nil {
+		return s
+	}
+	for _, secret := range m.secrets {
+		s = strings.ReplaceAll(s, secret, RedactedValue)
+	}
+	return s
+}
+
+// MaskValue returns a copy of a JSON value with every secret masked in its strings
+func (m *Masker) MaskValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		if v == nil {
+			return v
+		}
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			result[key] = m.MaskValue(item)
+		}
+		return result
+	case []any:
+		if v == nil {
+			return v
+		}
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = m.MaskValue(item)
+		}
+		return result
+	case string:
+		return m.Mask(v)
+	default:
+		return value
+	}
+}
+
+// sensitiveHeaderParts mark header names whose values are always redacted
+var sensitiveHeaderParts = []string{"authorization", "cookie", "token", "secret", "password", "api-key", "apikey", "signature"}
+
+// RedactHeaders returns a copy of headers with credential headers replaced by RedactedValue
+// and any known secrets masked in the remaining values
+func RedactHeaders(headers map[string]string, masker *Masker) map[string]string {
+	if headers == nil {
+		return nil
+	}
+	result := make(map[string]string, len(headers))
+	for name, value := range headers {
+		if isSensitiveHeader(name) {
+			result[name] = RedactedValue
+			continue
+		}
+		result[name] = masker.Mask(value)
+	}
+	return result
+}
+
+// isSensitiveHeader reports whether a header carries credentials
+func isSensitiveHeader(name string) bool {
+	lower := strings.ToLower(name)
+	for _, part := range sensitiveHeaderParts {
+		if strings.Contains(lower, part) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package secrets
+
+import (
+	"sort"
+	"strings"
+)
+
+// Path is the location of a field inside config values, e.g. ["oauth", "client_secret"]
+type Path []string
+
+// String returns the dotted form of the path
+func (p Path) String() string {
+	return strings.Join(p, ".")
+}
+
+// parent walks values to the object holding the last path segment
+func (p Path) parent(values map[string]any) (map[string]any, string, bool) {
+	if len(p) == 0 || values == nil {
+		return nil, "", false
+	}
+	current := values
+	for _, segment := range p[:len(p)-1] {
+		next, ok := current[segment].(map[string]any)
+		if !ok || IsEncrypted(next) {
+			return nil, "", false
+		}
+		current = next
+	}
+	return current, p[len(p)-1], true
+}
+
+// SecretPaths returns the paths of every property marked "secret": true in a config schema.
+// It accepts either an integration config schema ({"name": ..., "schema": {...}}) or a raw JSON schema.
+func SecretPaths(configSchema map[string]any) []Path {
+	if configSchema == nil {
+		return nil
+	}
+	schema := configSchema
+	if _, ok := schema["properties"]; !ok {
+		if inner, ok := schema["schema"].(map[string]any); ok {
+			schema = inner
+		}
+	}
+
+	var paths []Path
+	collectSecretPaths(schema, nil, &paths)
+	return paths
+}
+
+// collectSecretPaths recurses through object properties looking for secret markers
+func collectSecretPaths(schema map[string]any, prefix Path, paths *[]Path) {
+	properties, ok := schema["properties"].(map[string]any)
+	if !ok {
+		return
+	}
+
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property, ok := properties[name].(map[string]any)
+		if !ok {
+			continue
+		}
+		path := append(append(Path{}, prefix...), name)
+		if secret, _ := property["secret"].(bool); secret {
+			*paths = append(*paths, path)
+			continue
+		}
+		collectSecretPaths(property, path, paths)
+	}
+}
+
+// SecretValues returns the plaintext string values held at secret paths, so callers given
+// already-decrypted config values can still mask them. Encrypted values are skipped.
+func SecretValues(values map[string]any, paths []Path) []string {
+	var plaintexts []string
+	for _, path := range paths {
+		parent, key, ok := path.parent(values)
+		if !ok {
+			continue
+		}
+		if value, exists := parent[key]; exists && !IsEncrypted(value) {
+			collectStrings(value, &plaintexts)
+		}
+	}
+	return plaintexts
+}
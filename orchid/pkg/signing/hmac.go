@@ -0,0 +1,130 @@
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"github.com/Ramsey-B/orchid/pkg/expressions"
+	"github.com/Ramsey-B/orchid/pkg/models"
+)
+
+// hmacAlgorithms are the hash functions an hmac signer can use
+var hmacAlgorithms = map[string]func() hash.Hash{
+	"":       sha256.New,
+	"sha256": sha256.New,
+	"sha1":   sha1.New,
+	"sha512": sha512.New,
+}
+
+// hmacSigner signs a templated string with a shared secret and sends the signature in templated headers
+type hmacSigner struct {
+	secret       []byte
+	hash         func() hash.Hash
+	encoding     string
+	stringToSign string
+	headers      map[string]string
+	template     *expressions.Template
+	config       map[string]any
+}
+
+func validateHMAC(cfg *models.SignerConfig) error {
+	if cfg.Secret == "" {
+		return errors.New("hmac signer requires a secret")
+	}
+	if cfg.StringToSign == "" {
+		return errors.New("hmac signer requires a string_to_sign")
+	}
+	if len(cfg.Headers) == 0 {
+		return errors.New("hmac signer requires headers to send the signature in")
+	}
+	if _, ok := hmacAlgorithms[cfg.Algorithm]; !ok {
+		return fmt.Errorf("unsupported hmac algorithm %q (want sha256, sha1 or sha512)", cfg.Algorithm)
+	}
+	switch cfg.Encoding {
+	case "", "hex", "base64":
+	default:
+		return fmt.Errorf("unsupported signature encoding %q (want hex or base64)", cfg.Encoding)
+	}
+	return nil
+}
+
+func newHMACSigner(cfg *models.SignerConfig, r *resolver) (*hmacSigner, error) {
+	secret := r.string("secret", cfg.Secret)
+	if r.err != nil {
+		return nil, r.err
+	}
+	if secret == "" {
+		return nil, errors.New("hmac signer: secret resolved to an empty value")
+	}
+	return &hmacSigner{
+		secret:       []byte(secret),
+		hash:         hmacAlgorithms[cfg.Algorithm],
+		encoding:     cfg.Encoding,
+		stringToSign: cfg.StringToSign,
+		headers:      cfg.Headers,
+		template:     r.template,
+		config:       r.config(),
+	}, nil
+}
+
+// Sign renders the string to sign over the request, signs it and sets the signature headers
+func (s *hmacSigner) Sign(req *http.Request) error {
+	body, err := readBody(req)
+	if err != nil {
+		return err
+	}
+
+	t := now().UTC()
+	sum := sha256.Sum256(body)
+	data := map[string]any{
+		"config": s.config,
+		"request": map[string]any{
+			"method":       req.Method,
+			"url":          req.URL.String(),
+			"host":         req.URL.Host,
+			"path":         req.URL.EscapedPath(),
+			"query":        req.URL.RawQuery,
+			"body":         string(body),
+			"body_sha256":  hex.EncodeToString(sum[:]),
+			"content_type": req.Header.Get("Content-Type"),
+			"timestamp":    strconv.FormatInt(t.Unix(), 10),
+			"timestamp_ms": strconv.FormatInt(t.UnixMilli(), 10),
+			"date":         t.Format(http.TimeFormat),
+			"iso_date":     t.Format("2006-01-02T15:04:05Z"),
+			"nonce":        uuid.NewString(),
+		},
+	}
+
+	stringToSign, err := s.template.Render(s.stringToSign, data)
+	if err != nil {
+		return fmt.Errorf("failed to render string_to_sign: %w", err)
+	}
+
+	mac := hmac.New(s.hash, s.secret)
+	mac.Write([]byte(stringToSign))
+	if s.encoding == "base64" {
+		data["signature"] = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	} else {
+		data["signature"] = hex.EncodeToString(mac.Sum(nil))
+	}
+
+	for name, value := range s.headers {
+		rendered, err := s.template.Render(value, data)
+		if err != nil {
+			return fmt.Errorf("failed to render signature header %s: %w", name, err)
+		}
+		req.Header.Set(name, rendered)
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/Ramsey-B/orchid/pkg/models"
+)
+
+const (
+	// DefaultJWTExpiresIn is the default lifetime of a signed JWT in seconds
+	DefaultJWTExpiresIn = 300
+
+	// ClientAssertionType is the client_assertion_type of an OAuth private_key_jwt client assertion (RFC 7523)
+	ClientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+)
+
+// jwtSigner signs a fresh JWT for every request and sends it in a header or a form field
+type jwtSigner struct {
+	algorithm    string
+	rsaKey       *rsa.PrivateKey
+	ecKey        *ecdsa.PrivateKey
+	secret       []byte
+	keyID        string
+	claims       map[string]any
+	expiresIn    int64
+	header       string
+	headerFormat string
+	formField    string
+}
+
+func validateJWT(cfg *models.SignerConfig) error {
+	switch cfg.Algorithm {
+	case "", "RS256", "ES256":
+		if cfg.PrivateKey == "" {
+			return errors.New("jwt signer requires a private_key")
+		}
+	case "HS256":
+		if cfg.Secret == "" {
+			return errors.New("HS256 jwt signer requires a secret")
+		}
+	default:
+		return fmt.Errorf("unsupported jwt algorithm %q (want RS256, ES256 or HS256)", cfg.Algorithm)
+	}
+	if cfg.Header != "" && cfg.FormField != "" {
+		return errors.New("jwt signer can set a header or a form_field, not both")
+	}
+	if cfg.ExpiresIn < 0 {
+		return errors.New("jwt signer expires_in must be positive")
+	}
+	return nil
+}
+
+func newJWTSigner(cfg *models.SignerConfig, r *resolver) (*jwtSigner, error) {
+	s := &jwtSigner{
+		algorithm:    cfg.Algorithm,
+		keyID:        r.string("key_id", cfg.KeyID),
+		expiresIn:    int64(cfg.ExpiresIn),
+		header:       cfg.Header,
+		headerFormat: cfg.HeaderFormat,
+		formField:    cfg.FormField,
+		claims:       map[string]any{},
+	}
+	if s.algorithm == "" {
+		s.algorithm = "RS256"
+	}
+	if s.expiresIn == 0 {
+		s.expiresIn = DefaultJWTExpiresIn
+	}
+	if s.header == "" && s.formField == "" {
+		s.header = "Authorization"
+	}
+	if s.headerFormat == "" {
+		s.headerFormat = "Bearer {token}"
+	}
+
+	for claim, value := range map[string]string{
+		"iss":   r.string("issuer", cfg.Issuer),
+		"sub":   r.string("subject", cfg.Subject),
+		"aud":   r.string("audience", cfg.Audience),
+		"scope": r.string("scope", cfg.Scope),
+	} {
+		if value != "" {
+			s.claims[claim] = value
+		}
+	}
+	if extra, ok := r.value("claims", map[string]any(cfg.Claims)).(map[string]any); ok {
+		for claim, value := range extra {
+			s.claims[claim] = value
+		}
+	}
+
+	if s.algorithm == "HS256" {
+		s.secret = []byte(r.string("secret", cfg.Secret))
+		if r.err != nil {
+			return nil, r.err
+		}
+		if len(s.secret) == 0 {
+			return nil, errors.New("jwt signer: secret resolved to an empty value")
+		}
+		return s, nil
+	}
+
+	privateKey := r.string("private_key", cfg.PrivateKey)
+	if r.err != nil {
+		return nil, r.err
+	}
+	key, err := parsePrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("jwt signer: %w", err)
+	}
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if s.algorithm != "RS256" {
+			return nil, fmt.Errorf("jwt signer: %s requires an EC key, got an RSA key", s.algorithm)
+		}
+		s.rsaKey = k
+	case *ecdsa.PrivateKey:
+		if s.algorithm != "ES256" || k.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("jwt signer: %s cannot sign with an EC key on %s", s.algorithm, k.Curve.Params().Name)
+		}
+		s.ecKey = k
+	default:
+		return nil, fmt.Errorf("jwt signer: unsupported private key type %T", key)
+	}
+	return s, nil
+}
+
+// parsePrivateKey decodes a PEM encoded PKCS #8, PKCS #1 (RSA) or SEC 1 (EC) private key
+func parsePrivateKey(data string) (crypto.PrivateKey, error) {
+	// Keys pasted into JSON configs sometimes keep their newlines escaped
+	data = strings.ReplaceAll(strings.TrimSpace(data), `\n`, "\n")
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("private_key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported private key (%s)", block.Type)
+}
+
+// Sign signs a new JWT and sets it on the request
+func (s *jwtSigner) Sign(req *http.Request) error {
+	token, err := s.token()
+	if err != nil {
+		return err
+	}
+
+	if s.formField == "" {
+		req.Header.Set(s.header, strings.ReplaceAll(s.headerFormat, "{token}", token))
+		return nil
+	}
+
+	// The JWT is added to a form body, e.g. as the client_assertion of a token request
+	if contentType := req.Header.Get("Content-Type"); contentType != "" {
+		if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "application/x-www-form-urlencoded" {
+			return fmt.Errorf("jwt form_field requires a form body (body_type form), got %s", mediaType)
+		}
+	}
+	body, err := readBody(req)
+	if err != nil {
+		return err
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return fmt.Errorf("failed to parse form body: %w", err)
+	}
+	form.Set(s.formField, token)
+	if s.formField == "client_assertion" && form.Get("client_assertion_type") == "" {
+		form.Set("client_assertion_type", ClientAssertionType)
+	}
+	setBody(req, []byte(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return nil
+}
+
+// token builds and signs a JWT
+func (s *jwtSigner) token() (string, error) {
+	header := map[string]any{"alg": s.algorithm, "typ": "JWT"}
+	if s.keyID != "" {
+		header["kid"] = s.keyID
+	}
+
+	issuedAt := now().Unix()
+	claims := map[string]any{
+		"iat": issuedAt,
+		"exp": issuedAt + s.expiresIn,
+		"jti": uuid.NewString(),
+	}
+	for claim, value := range s.claims {
+		claims[claim] = value
+	}
+
+	encodedHeader, err := encodeSegment(header)
+	if err != nil {
+		return "", err
+	}
+	encodedClaims, err := encodeSegment(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := encodedHeader + "." + encodedClaims
+
+	digest := sha256.Sum256([]byte(signingInput))
+	var signature []byte
+	switch {
+	case s.rsaKey != nil:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, s.rsaKey, crypto.SHA256, digest[:])
+	case s.ecKey != nil:
+		signature, err = signES256(s.ecKey, digest[:])
+	default:
+		mac := hmac.New(sha256.New, s.secret)
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to sign jwt: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// signES256 signs a digest, encoding the signature as JWS requires: the fixed-size r and s, not ASN.1
+func signES256(key *ecdsa.PrivateKey, digest []byte) ([]byte, error) {
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest)
+	if err != nil {
+		return nil, err
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+	return signature, nil
+}
+
+// encodeSegment encodes a JWT header or claims set
+func encodeSegment(value map[string]any) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode jwt: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,133 @@
+// Package signing signs outgoing requests for APIs that authenticate every request with a signature
+// rather than (or on top of) a bearer token: AWS Signature Version 4, HMAC signatures and signed JWTs.
+package signing
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Ramsey-B/orchid/pkg/expressions"
+	"github.com/Ramsey-B/orchid/pkg/models"
+)
+
+// Signer signs a built request before it is sent. Signers may set headers and, for JWT form fields, replace the body.
+type Signer interface {
+	Sign(req *http.Request) error
+}
+
+// now returns the current time (replaced in tests)
+var now = time.Now
+
+// New creates the signer of an auth flow. Templated fields are resolved against the decrypted config.
+func New(cfg *models.SignerConfig, config map[string]any, evaluator *expressions.Evaluator) (Signer, error) {
+	if err := Validate(cfg); err != nil {
+		return nil, err
+	}
+
+	r := &resolver{template: expressions.NewTemplate(evaluator), data: map[string]any{"config": config}}
+	switch cfg.Type {
+	case models.SignerAWSSigV4:
+		return newSigV4Signer(cfg, r)
+	case models.SignerHMAC:
+		return newHMACSigner(cfg, r)
+	default:
+		return newJWTSigner(cfg, r)
+	}
+}
+
+// Validate checks a signer configuration. Credentials are usually templates, so they are only checked for presence.
+func Validate(cfg *models.SignerConfig) error {
+	if cfg == nil {
+		return nil
+	}
+
+	switch cfg.ApplyTo {
+	case "", models.SignerApplyToRequests, models.SignerApplyToTokenRequest:
+	default:
+		return fmt.Errorf("signer.apply_to must be %q or %q", models.SignerApplyToRequests, models.SignerApplyToTokenRequest)
+	}
+
+	switch cfg.Type {
+	case models.SignerAWSSigV4:
+		return validateSigV4(cfg)
+	case models.SignerHMAC:
+		return validateHMAC(cfg)
+	case models.SignerJWT:
+		return validateJWT(cfg)
+	default:
+		return fmt.Errorf("unsupported signer type %q (want %s, %s or %s)", cfg.Type,
+			models.SignerAWSSigV4, models.SignerHMAC, models.SignerJWT)
+	}
+}
+
+// resolver renders the templated fields of a signer configuration
+type resolver struct {
+	template *expressions.Template
+	data     map[string]any
+	err      error
+}
+
+// string renders a field, keeping the first error
+func (r *resolver) string(field, value string) string {
+	if r.err != nil || value == "" {
+		return value
+	}
+	rendered, err := r.template.Render(value, r.data)
+	if err != nil {
+		r.err = fmt.Errorf("signer.%s: %w", field, err)
+	}
+	return rendered
+}
+
+// config returns the config the fields are resolved against
+func (r *resolver) config() map[string]any {
+	config, _ := r.data["config"].(map[string]any)
+	return config
+}
+
+// value renders the strings nested in a value, keeping the first error
+func (r *resolver) value(field string, value any) any {
+	if r.err != nil || value == nil {
+		return value
+	}
+	rendered, err := r.template.RenderValue(value, r.data)
+	if err != nil {
+		r.err = fmt.Errorf("signer.%s: %w", field, err)
+	}
+	return rendered
+}
+
+// readBody returns the body of a request and restores it so the request can still be sent
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		defer body.Close()
+		return io.ReadAll(body)
+	}
+
+	data, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	req.Body.Close()
+	setBody(req, data)
+	return data, nil
+}
+
+// setBody replaces the body of a request
+func setBody(req *http.Request, data []byte) {
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	req.ContentLength = int64(len(data))
+}
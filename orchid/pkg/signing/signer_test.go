@@ -0,0 +1,242 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Ramsey-B/orchid/pkg/expressions"
+	"github.com/Ramsey-B/orchid/pkg/models"
+)
+
+func fixedNow(t *testing.T, at time.Time) {
+	now = func() time.Time { return at }
+	t.Cleanup(func() { now = time.Now })
+}
+
+func TestSigV4_TestSuiteVector(t *testing.T) {
+	// get-vanilla from the AWS Signature Version 4 test suite
+	fixedNow(t, time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	signer, err := New(&models.SignerConfig{
+		Type:      models.SignerAWSSigV4,
+		Region:    "us-east-1",
+		Service:   "service",
+		AccessKey: "{{ config.access_key }}",
+		SecretKey: "{{ config.secret_key }}",
+	}, map[string]any{
+		"access_key": "AKIDEXAMPLE",
+		"secret_key": "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}, expressions.NewEvaluator())
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	require.NoError(t, err)
+	require.NoError(t, signer.Sign(req))
+
+	require.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	require.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		req.Header.Get("Authorization"))
+}
+
+func TestSigV4_SignsBodyAndSessionToken(t *testing.T) {
+	signer, err := New(&models.SignerConfig{
+		Type: models.SignerAWSSigV4, Region: "eu-west-1", Service: "execute-api",
+		AccessKey: "AKID", SecretKey: "secret", SessionToken: "{{ config.session_token }}",
+	}, map[string]any{"session_token": "session"}, expressions.NewEvaluator())
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, "https://api.example.com/v1/items?b=2&a=1", strings.NewReader(`{"id":1}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	require.NoError(t, signer.Sign(req))
+
+	require.Equal(t, "session", req.Header.Get("X-Amz-Security-Token"))
+	require.Contains(t, req.Header.Get("Authorization"), "SignedHeaders=content-type;host;x-amz-date;x-amz-security-token,")
+	// The body can still be sent
+	body, _ := io.ReadAll(req.Body)
+	require.Equal(t, `{"id":1}`, string(body))
+}
+
+func TestHMAC_SignsRenderedString(t *testing.T) {
+	fixedNow(t, time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC))
+
+	signer, err := New(&models.SignerConfig{
+		Type:         models.SignerHMAC,
+		Secret:       "{{ config.api_secret }}",
+		StringToSign: "{{ request.method }}\n{{ request.path }}\n{{ request.timestamp }}\n{{ request.body_sha256 }}",
+		Headers: map[string]string{
+			"X-Signature": "v1={{ signature }}",
+			"X-Timestamp": "{{ request.timestamp }}",
+			"X-Key":       "{{ config.api_key }}",
+		},
+	}, map[string]any{"api_key": "key", "api_secret": "s3cret"}, expressions.NewEvaluator())
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, "https://api.example.com/orders?page=2", strings.NewReader(`{"a":1}`))
+	require.NoError(t, err)
+	require.NoError(t, signer.Sign(req))
+
+	bodyHash := sha256.Sum256([]byte(`{"a":1}`))
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte("POST\n/orders\n1710072000\n" + hex.EncodeToString(bodyHash[:])))
+
+	require.Equal(t, "v1="+hex.EncodeToString(mac.Sum(nil)), req.Header.Get("X-Signature"))
+	require.Equal(t, "1710072000", req.Header.Get("X-Timestamp"))
+	require.Equal(t, "key", req.Header.Get("X-Key"))
+}
+
+func TestJWT_RS256Header(t *testing.T) {
+	fixedNow(t, time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC))
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	signer, err := New(&models.SignerConfig{
+		Type:       models.SignerJWT,
+		PrivateKey: "{{ config.service_account.private_key }}",
+		KeyID:      "key-1",
+		Issuer:     "{{ config.service_account.client_email }}",
+		Audience:   "https://api.example.com/",
+		Claims:     map[string]any{"target_audience": "{{ config.audience }}"},
+	}, map[string]any{
+		"service_account": map[string]any{
+			"client_email": "sync@project.iam.gserviceaccount.com",
+			"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		},
+		"audience": "orchid",
+	}, expressions.NewEvaluator())
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, "https://api.example.com/users", nil)
+	require.NoError(t, err)
+	require.NoError(t, signer.Sign(req))
+
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	header, claims, signingInput, signature := decodeJWT(t, token)
+	require.Equal(t, "RS256", header["alg"])
+	require.Equal(t, "key-1", header["kid"])
+	require.Equal(t, "sync@project.iam.gserviceaccount.com", claims["iss"])
+	require.Equal(t, "https://api.example.com/", claims["aud"])
+	require.Equal(t, "orchid", claims["target_audience"])
+	require.Equal(t, float64(1710072000), claims["iat"])
+	require.Equal(t, float64(1710072000+DefaultJWTExpiresIn), claims["exp"])
+
+	digest := sha256.Sum256([]byte(signingInput))
+	require.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature))
+}
+
+func TestJWT_ES256ClientAssertion(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	signer, err := New(&models.SignerConfig{
+		Type:       models.SignerJWT,
+		Algorithm:  "ES256",
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})),
+		Issuer:     "client-id",
+		Subject:    "client-id",
+		Audience:   "https://login.example.com/token",
+		FormField:  "client_assertion",
+	}, nil, expressions.NewEvaluator())
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, "https://login.example.com/token", strings.NewReader("grant_type=client_credentials"))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	require.NoError(t, signer.Sign(req))
+
+	body, _ := io.ReadAll(req.Body)
+	form, err := url.ParseQuery(string(body))
+	require.NoError(t, err)
+	require.Equal(t, int64(len(body)), req.ContentLength)
+	require.Equal(t, "client_credentials", form.Get("grant_type"))
+	require.Equal(t, ClientAssertionType, form.Get("client_assertion_type"))
+
+	_, claims, signingInput, signature := decodeJWT(t, form.Get("client_assertion"))
+	require.Equal(t, "client-id", claims["sub"])
+	require.NotEmpty(t, claims["jti"])
+
+	digest := sha256.Sum256([]byte(signingInput))
+	require.Len(t, signature, 64)
+	r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+	require.True(t, ecdsa.Verify(&key.PublicKey, digest[:], r, s))
+
+	// A JSON body cannot carry a form field
+	req, _ = http.NewRequest(http.MethodPost, "https://login.example.com/token", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	require.Error(t, signer.Sign(req))
+}
+
+func TestNew_RejectsInvalidKeys(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalECPrivateKey(ecKey)
+	require.NoError(t, err)
+
+	_, err = New(&models.SignerConfig{Type: models.SignerJWT, PrivateKey: "not a key"}, nil, expressions.NewEvaluator())
+	require.Error(t, err)
+	// RS256 (the default) needs an RSA key
+	_, err = New(&models.SignerConfig{Type: models.SignerJWT,
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}))}, nil, expressions.NewEvaluator())
+	require.Error(t, err)
+	// Credentials that resolve to nothing
+	_, err = New(&models.SignerConfig{Type: models.SignerHMAC, Secret: "{{ config.missing }}", StringToSign: "x",
+		Headers: map[string]string{"X-Signature": "{{ signature }}"}}, map[string]any{}, expressions.NewEvaluator())
+	require.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Validate(nil))
+	require.NoError(t, Validate(&models.SignerConfig{Type: models.SignerAWSSigV4, Region: "us-east-1", Service: "s3",
+		AccessKey: "a", SecretKey: "b", ApplyTo: models.SignerApplyToRequests}))
+	require.NoError(t, Validate(&models.SignerConfig{Type: models.SignerJWT, Algorithm: "HS256", Secret: "s"}))
+
+	require.Error(t, Validate(&models.SignerConfig{Type: "oauth1"}))
+	require.Error(t, Validate(&models.SignerConfig{Type: models.SignerAWSSigV4, Region: "us-east-1"}))
+	require.Error(t, Validate(&models.SignerConfig{Type: models.SignerHMAC, Secret: "s", StringToSign: "x"}))
+	require.Error(t, Validate(&models.SignerConfig{Type: models.SignerHMAC, Secret: "s", StringToSign: "x",
+		Headers: map[string]string{"X-Signature": "{{ signature }}"}, Algorithm: "md5"}))
+	require.Error(t, Validate(&models.SignerConfig{Type: models.SignerJWT, Algorithm: "HS256"}))
+	require.Error(t, Validate(&models.SignerConfig{Type: models.SignerJWT, PrivateKey: "k", Header: "X-JWT", FormField: "assertion"}))
+	require.Error(t, Validate(&models.SignerConfig{Type: models.SignerJWT, PrivateKey: "k", ApplyTo: "responses"}))
+}
+
+// decodeJWT splits a JWT into its decoded header and claims, signing input and signature
+func decodeJWT(t *testing.T, token string) (map[string]any, map[string]any, string, []byte) {
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 3)
+
+	var header, claims map[string]any
+	data, err := base64.RawURLEncoding.DecodeString(parts[0])
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &header))
+	data, err = base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &claims))
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	return header, claims, parts[0] + "." + parts[1], signature
+}
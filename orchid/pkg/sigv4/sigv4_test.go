@@ -0,0 +1,34 @@
+package sigv4
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalEncoding(t *testing.T) {
+	require.Equal(t, "a-b_c.d~e%20f%2Bg%2F%E2%82%AC", URIEncode("a-b_c.d~e f+g/€"))
+	require.Equal(t, "/bucket/my%20file%2B1.txt", EscapePath("/bucket/my file+1.txt"))
+
+	// Sorted by key, then value
+	query := url.Values{"b": {"2", "1"}, "a": {"x y"}, "prefix": {"tenant/"}}
+	require.Equal(t, "a=x%20y&b=1&b=2&prefix=tenant%2F", CanonicalQuery(query))
+	require.Equal(t, []string{"2", "1"}, query["b"])
+}
+
+func TestAuthorization(t *testing.T) {
+	// get-vanilla from the AWS Signature Version 4 test suite
+	canonicalHeaders, signedHeaders := CanonicalHeaders(map[string]string{
+		"x-amz-date": "20150830T123600Z",
+		"host":       "example.amazonaws.com",
+	})
+	require.Equal(t, "host:example.amazonaws.com\nx-amz-date:20150830T123600Z\n", canonicalHeaders)
+	require.Equal(t, "host;x-amz-date", signedHeaders)
+
+	canonicalRequest := "GET\n/\n\n" + canonicalHeaders + "\n" + signedHeaders + "\n" + PayloadHash(nil)
+	require.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		Authorization(canonicalRequest, signedHeaders, "20150830T123600Z", "us-east-1", "service",
+			"AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"))
+}